@@ -17,9 +17,15 @@ import (
 	"github.com/HasanNugroho/coin-be/internal/modules/admin_dashboard"
 	"github.com/HasanNugroho/coin-be/internal/modules/allocation"
 	"github.com/HasanNugroho/coin-be/internal/modules/auth"
+	"github.com/HasanNugroho/coin-be/internal/modules/balance_snapshot"
+	"github.com/HasanNugroho/coin-be/internal/modules/budget"
 	"github.com/HasanNugroho/coin-be/internal/modules/category_template"
 	"github.com/HasanNugroho/coin-be/internal/modules/daily_summary"
 	"github.com/HasanNugroho/coin-be/internal/modules/dashboard"
+	"github.com/HasanNugroho/coin-be/internal/modules/envelope"
+	"github.com/HasanNugroho/coin-be/internal/modules/holiday"
+	"github.com/HasanNugroho/coin-be/internal/modules/income_source"
+	"github.com/HasanNugroho/coin-be/internal/modules/notification"
 	"github.com/HasanNugroho/coin-be/internal/modules/payroll"
 	"github.com/HasanNugroho/coin-be/internal/modules/platform"
 	"github.com/HasanNugroho/coin-be/internal/modules/pocket"
@@ -66,6 +72,7 @@ func main() {
 	}
 
 	// Register modules
+	notification.Register(builder)
 	auth.Register(builder)
 	user.Register(builder)
 	category_template.Register(builder)
@@ -74,9 +81,14 @@ func main() {
 	user_platform.Register(builder)
 	pocket_template.Register(builder)
 	pocket.Register(builder)
+	holiday.Register(builder)
 	allocation.Register(builder)
+	envelope.Register(builder)
+	budget.Register(builder)
 	transaction.Register(builder)
 	daily_summary.Register(builder)
+	balance_snapshot.Register(builder)
+	income_source.Register(builder)
 	payroll.Register(builder)
 	dashboard.Register(builder)
 	admin_dashboard.Register(builder)
@@ -130,6 +142,12 @@ func main() {
 	platformRoutes.Use(middleware.AuthMiddleware(jwtManager, db))
 	platform.RegisterRoutes(platformRoutes, platformController)
 
+	// Holiday routes (protected)
+	holidayController := appContainer.Get("holidayController").(*holiday.Controller)
+	holidayRoutes := api.Group("/v1/holidays")
+	holidayRoutes.Use(middleware.AuthMiddleware(jwtManager, db))
+	holiday.RegisterRoutes(holidayRoutes, holidayController)
+
 	// User Platform routes (protected)
 	userPlatformController := appContainer.Get("userPlatformController").(*user_platform.Controller)
 	userPlatformRoutes := api.Group("/v1/user-platforms")
@@ -154,12 +172,36 @@ func main() {
 	allocationRoutes.Use(middleware.AuthMiddleware(jwtManager, db))
 	allocation.RegisterRoutes(allocationRoutes, allocationController)
 
+	// Envelope routes (protected)
+	envelopeController := appContainer.Get("envelopeController").(*envelope.Controller)
+	envelopeRoutes := api.Group("/v1/envelopes")
+	envelopeRoutes.Use(middleware.AuthMiddleware(jwtManager, db))
+	envelope.RegisterRoutes(envelopeRoutes, envelopeController)
+
+	// Budget routes (protected)
+	budgetController := appContainer.Get("budgetController").(*budget.Controller)
+	budgetRoutes := api.Group("/v1/budgets")
+	budgetRoutes.Use(middleware.AuthMiddleware(jwtManager, db))
+	budget.RegisterRoutes(budgetRoutes, budgetController)
+
 	// Transaction routes (protected)
 	transactionController := appContainer.Get("transactionController").(*transaction.Controller)
 	transactionRoutes := api.Group("/v1/transactions")
 	transactionRoutes.Use(middleware.AuthMiddleware(jwtManager, db))
 	transaction.RegisterRoutes(transactionRoutes, transactionController)
 
+	// Income source routes (protected)
+	incomeSourceController := appContainer.Get("incomeSourceController").(*income_source.Controller)
+	incomeSourceRoutes := api.Group("/v1/income-sources")
+	incomeSourceRoutes.Use(middleware.AuthMiddleware(jwtManager, db))
+	income_source.RegisterRoutes(incomeSourceRoutes, incomeSourceController)
+
+	// Payroll routes (protected)
+	payrollController := appContainer.Get("payrollController").(*payroll.Controller)
+	payrollRoutes := api.Group("/v1/payroll")
+	payrollRoutes.Use(middleware.AuthMiddleware(jwtManager, db))
+	payroll.RegisterRoutes(payrollRoutes, payrollController)
+
 	// Dashboard routes (protected)
 	dashboardController := appContainer.Get("dashboardController").(*dashboard.Controller)
 	dashboardRoutes := api.Group("/v1/dashboard")
@@ -173,10 +215,11 @@ func main() {
 	adminDashboardRoutes.Use(middleware.AdminMiddleware())
 	admin_dashboard.RegisterRoutes(adminDashboardRoutes, adminDashboardController)
 
-	// Start dashboard cron job for daily summaries
+	// Start dashboard cron job for daily summaries and balance snapshots
 	dashboardService := appContainer.Get("dashboardService").(*dashboard.Service)
 	dailySummaryService := appContainer.Get("dailySummaryService").(*daily_summary.Service)
-	dashboardCronJob := dashboard.NewCronJob(dashboardService, dailySummaryService)
+	balanceSnapshotService := appContainer.Get("balanceSnapshotService").(*balance_snapshot.Service)
+	dashboardCronJob := dashboard.NewCronJob(dashboardService, dailySummaryService, balanceSnapshotService)
 	dashboardCronJob.Start()
 	defer dashboardCronJob.Stop()
 
@@ -186,12 +229,30 @@ func main() {
 	payrollCronJob.Start()
 	defer payrollCronJob.Stop()
 
+	// Start pocket cron job for savings goal milestone notifications
+	pocketService := appContainer.Get("pocketService").(*pocket.Service)
+	pocketCronJob := pocket.NewCronJob(pocketService)
+	pocketCronJob.Start()
+	defer pocketCronJob.Stop()
+
 	// Start allocation cron job for scheduled allocation execution
 	allocationService := appContainer.Get("allocationService").(*allocation.Service)
 	allocationCronJob := allocation.NewCronJob(allocationService)
 	allocationCronJob.Start()
 	defer allocationCronJob.Stop()
 
+	// Start envelope cron job for monthly rollover
+	envelopeService := appContainer.Get("envelopeService").(*envelope.Service)
+	envelopeCronJob := envelope.NewCronJob(envelopeService)
+	envelopeCronJob.Start()
+	defer envelopeCronJob.Stop()
+
+	// Start budget cron job for threshold alerts
+	budgetService := appContainer.Get("budgetService").(*budget.Service)
+	budgetCronJob := budget.NewCronJob(budgetService)
+	budgetCronJob.Start()
+	defer budgetCronJob.Stop()
+
 	log.Println("Server running on http://localhost:8080")
 	log.Println("Swagger docs available at http://localhost:8080/swagger/index.html")
 	r.Run(":8080")
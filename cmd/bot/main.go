@@ -12,8 +12,13 @@ import (
 	"github.com/HasanNugroho/coin-be/internal/core/config"
 	"github.com/HasanNugroho/coin-be/internal/core/database"
 	"github.com/HasanNugroho/coin-be/internal/core/utils"
+	"github.com/HasanNugroho/coin-be/internal/modules/allocation"
+	"github.com/HasanNugroho/coin-be/internal/modules/balance_snapshot"
 	"github.com/HasanNugroho/coin-be/internal/modules/daily_summary"
 	"github.com/HasanNugroho/coin-be/internal/modules/dashboard"
+	"github.com/HasanNugroho/coin-be/internal/modules/holiday"
+	"github.com/HasanNugroho/coin-be/internal/modules/income_source"
+	"github.com/HasanNugroho/coin-be/internal/modules/notification"
 	"github.com/HasanNugroho/coin-be/internal/modules/pocket"
 	"github.com/HasanNugroho/coin-be/internal/modules/transaction"
 	"github.com/HasanNugroho/coin-be/internal/modules/user"
@@ -40,12 +45,14 @@ func main() {
 	transactionRepo := transaction.NewRepository(db)
 	userPlatformRepo := user_platform.NewUserPlatformRepository(db)
 	userCategoryRepo := user_category.NewRepository(db)
+	incomeSourceRepo := income_source.NewRepository(db)
 
 	// Services
 	dailySummaryRepo := daily_summary.NewRepository(db)
 	dailySummarySvc := daily_summary.NewService(dailySummaryRepo)
-	dashboardSvc := dashboard.NewService(dashboard.NewRepository(db), dailySummaryRepo)
-	transactionSvc := transaction.NewService(transactionRepo, pocketRepo, userPlatformRepo, dailySummarySvc)
+	dashboardSvc := dashboard.NewService(dashboard.NewRepository(db), dailySummaryRepo, incomeSourceRepo)
+	balanceSnapshotSvc := balance_snapshot.NewService(balance_snapshot.NewRepository(db))
+	transactionSvc := transaction.NewService(transactionRepo, pocketRepo, userPlatformRepo, dailySummarySvc, balanceSnapshotSvc, db)
 
 	// Bot components
 	otpStore := otp.NewStore()
@@ -78,6 +85,12 @@ func main() {
 		return
 	}
 
+	// Income recorded through the bot fires income-triggered allocations as well
+	notificationSvc := notification.NewService(notification.NewRepository(db), mailer, b)
+	holidaySvc := holiday.NewService(holiday.NewRepository(db))
+	allocationSvc := allocation.NewService(allocation.NewRepository(db), pocketRepo, userPlatformRepo, userRepo, transactionRepo, userCategoryRepo, notificationSvc, holidaySvc, db)
+	transactionSvc.OnIncome(allocationSvc.HandleIncome)
+
 	handler := bot.NewHandler(telegramSvc, sessionStore)
 	handler.Register(b)
 
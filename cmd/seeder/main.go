@@ -47,5 +47,6 @@ func main() {
 	fmt.Println("\nDefault data has been set up:")
 	fmt.Println("- 12 default categories (4 income, 8 expense)")
 	fmt.Println("- 4 default allocations (Bills, Emergency Fund, Investment, Savings)")
+	fmt.Println("- Indonesian national holidays of 2026")
 	fmt.Println("\nYou can now start using the application!")
 }
@@ -11,6 +11,7 @@ import (
 
 	"github.com/HasanNugroho/coin-be/internal/bot/session"
 	"github.com/HasanNugroho/coin-be/internal/core/utils"
+	"github.com/HasanNugroho/coin-be/internal/modules/transaction"
 	"github.com/HasanNugroho/coin-be/internal/modules/user_category"
 	tele "gopkg.in/telebot.v4"
 )
@@ -184,11 +185,13 @@ func (h *Handler) handleSummary(ctx context.Context, c tele.Context, sess *sessi
 		return c.Send("❌ Gagal mengambil data ringkasan.")
 	}
 
-	msg := fmt.Sprintf("📊 *Ringkasan Keuangan*\n\n"+
+	msg := fmt.Sprintf("📊 *Ringkasan Keuangan*\n"+
+		"🗓 %s s/d %s\n\n"+
 		"💼 *Total Aset:* %s\n"+
 		"📈 *Pemasukan:* %s\n"+
 		"📉 *Pengeluaran:* %s\n"+
 		"💰 *Selisih:* %s",
+		summary.StartDate, summary.EndDate,
 		formatRupiah(summary.TotalNetWorth), formatRupiah(summary.PeriodIncome), formatRupiah(summary.PeriodExpense), formatRupiah(summary.PeriodNet))
 
 	return c.Send(msg, tele.ModeMarkdown)
@@ -219,9 +222,54 @@ func (h *Handler) handleNewTransaction(c tele.Context, txType string) error {
 
 	sess.State = "awaiting_tx_amount"
 	sess.TempData["tx_type"] = txType
+	// Template dari transaksi sebelumnya tidak boleh terbawa ke transaksi baru
+	delete(sess.TempData, "tx_template_id")
+
+	// Tawarkan template favorit dulu supaya user tidak perlu pilih pocket → platform → kategori
+	templates, err := h.svc.GetTopTemplates(context.Background(), sess.UserID, txType, templateLimit)
+	if err == nil && len(templates) > 0 {
+		return h.showTemplateSelection(c, typeLabel, templates)
+	}
+
 	return c.Send(fmt.Sprintf("Mencatat *%s*. Masukkan jumlahnya:", typeLabel), tele.ModeMarkdown, tele.RemoveKeyboard)
 }
 
+const templateLimit = 5
+
+func (h *Handler) showTemplateSelection(c tele.Context, typeLabel string, templates []*transaction.TransactionTemplate) error {
+	selector := &tele.ReplyMarkup{}
+	var rows []tele.Row
+
+	for _, t := range templates {
+		label := fmt.Sprintf("⚡ %s", t.Name)
+		if t.Amount != nil {
+			label = fmt.Sprintf("⚡ %s (%s)", t.Name, formatRupiah(*t.Amount))
+		}
+		rows = append(rows, selector.Row(selector.Data(label, "template", t.ID.Hex())))
+	}
+
+	rows = append(rows, selector.Row(selector.Data("✍️ Isi manual", "template_manual")))
+	selector.Inline(rows...)
+
+	c.Send(fmt.Sprintf("Mencatat *%s*.", typeLabel), tele.ModeMarkdown, tele.RemoveKeyboard)
+	return c.Send("Pilih template favorit atau isi manual:", selector)
+}
+
+func (h *Handler) submitTemplateTransaction(ctx context.Context, c tele.Context, sess *session.UserSession, amount float64) error {
+	templateID := sess.TempData["tx_template_id"]
+	delete(sess.TempData, "tx_template_id")
+
+	err := h.svc.CreateTransactionFromTemplate(ctx, sess.UserID, templateID, amount)
+	if err != nil {
+		c.Send("❌ Gagal menyimpan transaksi: " + err.Error())
+	} else {
+		c.Send("✅ Transaksi berhasil disimpan!")
+	}
+
+	h.sessions.ClearState(sess.TelegramID)
+	return nil
+}
+
 func (h *Handler) handleTXAmountInput(c tele.Context, sess *session.UserSession) error {
 	amountStr := strings.ReplaceAll(c.Text(), ".", "")
 	amountStr = strings.ReplaceAll(amountStr, ",", ".")
@@ -230,6 +278,10 @@ func (h *Handler) handleTXAmountInput(c tele.Context, sess *session.UserSession)
 		return c.Send("❌ Jumlah tidak valid. Masukkan angka yang benar (contoh: 50000):")
 	}
 
+	if sess.TempData["tx_template_id"] != "" {
+		return h.submitTemplateTransaction(context.Background(), c, sess, amount)
+	}
+
 	sess.TempData["tx_amount"] = amountStr
 	sess.TempData["pocket_page"] = "0"
 	sess.State = "awaiting_tx_pocket"
@@ -381,7 +433,36 @@ func (h *Handler) handleCallback(c tele.Context) error {
 		}
 	}
 
+	// Template dipilih
+	if strings.HasPrefix(data, "\ftemplate|") {
+		parts := strings.Split(data, "|")
+		if len(parts) > 1 {
+			sess.TempData["tx_template_id"] = parts[1]
+			c.Respond()
+			c.Delete()
+
+			template, err := h.svc.GetTemplate(ctx, sess.UserID, parts[1])
+			if err != nil {
+				h.sessions.ClearState(sess.TelegramID)
+				return c.Send("❌ Template tidak ditemukan.")
+			}
+
+			// Template dengan jumlah tetap langsung disimpan
+			if template.Amount != nil {
+				return h.submitTemplateTransaction(ctx, c, sess, 0)
+			}
+
+			sess.State = "awaiting_tx_amount"
+			return c.Send("Masukkan jumlahnya:")
+		}
+	}
+
 	switch data {
+	case "\ftemplate_manual":
+		delete(sess.TempData, "tx_template_id")
+		c.Respond()
+		c.Delete()
+		return c.Send("Masukkan jumlahnya:")
 	case "\fcategory_skip":
 		c.Respond()
 		c.Delete()
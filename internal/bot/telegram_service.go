@@ -101,7 +101,7 @@ func (s *TelegramService) VerifyOTP(ctx context.Context, email, code string, tel
 }
 
 func (s *TelegramService) GetSummary(ctx context.Context, userID primitive.ObjectID, timeRange string) (*dashboard.DashboardSummary, error) {
-	return s.dashboardSvc.GetDashboardSummary(ctx, userID.Hex(), dashboard.TimeRange(timeRange))
+	return s.dashboardSvc.GetDashboardSummary(ctx, userID.Hex(), &dashboard.DashboardQuery{TimeRange: dashboard.TimeRange(timeRange)})
 }
 
 func (s *TelegramService) GetPockets(ctx context.Context, userID primitive.ObjectID) ([]*pocket.Pocket, error) {
@@ -133,6 +133,24 @@ func (s *TelegramService) CreateTransaction(ctx context.Context, userID primitiv
 	return err
 }
 
+func (s *TelegramService) GetTopTemplates(ctx context.Context, userID primitive.ObjectID, txType string, limit int64) ([]*transaction.TransactionTemplate, error) {
+	return s.transactionSvc.ListTemplates(ctx, userID.Hex(), &txType, limit)
+}
+
+func (s *TelegramService) GetTemplate(ctx context.Context, userID primitive.ObjectID, templateID string) (*transaction.TransactionTemplate, error) {
+	return s.transactionSvc.GetTemplateByID(ctx, userID.Hex(), templateID)
+}
+
+func (s *TelegramService) CreateTransactionFromTemplate(ctx context.Context, userID primitive.ObjectID, templateID string, amount float64) error {
+	req := &dto.CreateTransactionFromTemplateRequest{}
+	if amount > 0 {
+		req.Amount = &amount
+	}
+
+	_, err := s.transactionSvc.CreateTransactionFromTemplate(ctx, userID.Hex(), templateID, req)
+	return err
+}
+
 func (s *TelegramService) ParseReceiptImage(ctx context.Context, imageData []byte) (*vision.ParsedReceipt, error) {
 	return s.visionParser.Parse(ctx, imageData)
 }
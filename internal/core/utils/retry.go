@@ -0,0 +1,57 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// MaxRetryAttempts is how many times a failed background run is retried before it is left for a manual re-run
+const MaxRetryAttempts = 5
+
+const (
+	retryBaseDelay = 5 * time.Minute
+	retryMaxDelay  = 6 * time.Hour
+)
+
+// IsTransientError reports whether err is a database failure worth retrying, such as a network error,
+// a timeout or a transaction conflict. Validation failures are not transient.
+func IsTransientError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) || mongo.IsNetworkError(err) || mongo.IsTimeout(err) {
+		return true
+	}
+
+	var labeled mongo.LabeledError
+	if errors.As(err, &labeled) {
+		return labeled.HasErrorLabel("TransientTransactionError") ||
+			labeled.HasErrorLabel("UnknownTransactionCommitResult") ||
+			labeled.HasErrorLabel("RetryableWriteError")
+	}
+
+	return false
+}
+
+// NextRetryAt returns when the next attempt of a run that has failed attempts times should happen,
+// doubling the delay each time, or nil when no retry is left
+func NextRetryAt(attempts int, now time.Time) *time.Time {
+	if attempts >= MaxRetryAttempts {
+		return nil
+	}
+
+	delay := retryBaseDelay
+	for i := 1; i < attempts && delay < retryMaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > retryMaxDelay {
+		delay = retryMaxDelay
+	}
+
+	next := now.Add(delay)
+	return &next
+}
@@ -0,0 +1,16 @@
+package utils
+
+import (
+	"log"
+	"time"
+)
+
+// GetJakartaLocation returns the Asia/Jakarta timezone location, UTC when it cannot be loaded
+func GetJakartaLocation() *time.Location {
+	loc, err := time.LoadLocation("Asia/Jakarta")
+	if err != nil {
+		log.Printf("Failed to load Asia/Jakarta timezone: %v, using UTC", err)
+		return time.UTC
+	}
+	return loc
+}
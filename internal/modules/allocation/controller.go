@@ -1,10 +1,13 @@
 package allocation
 
 import (
+	"fmt"
 	"net/http"
+	"strconv"
 
 	"github.com/HasanNugroho/coin-be/internal/core/utils"
 	"github.com/HasanNugroho/coin-be/internal/modules/allocation/dto"
+	"github.com/HasanNugroho/coin-be/internal/modules/holiday"
 	"github.com/gin-gonic/gin"
 )
 
@@ -97,9 +100,10 @@ func (c *Controller) GetAllocation(ctx *gin.Context) {
 
 // ListAllocations godoc
 // @Summary List all allocations
-// @Description Get all allocations for the authenticated user
+// @Description Get all allocations for the authenticated user, optionally only those of one plan
 // @Tags Allocations
 // @Produce json
+// @Param plan_id query string false "Plan ID"
 // @Success 200 {object} map[string]interface{} "Allocations retrieved successfully"
 // @Failure 401 {object} map[string]interface{} "Unauthorized"
 // @Security BearerAuth
@@ -112,7 +116,7 @@ func (c *Controller) ListAllocations(ctx *gin.Context) {
 		return
 	}
 
-	allocations, err := c.service.ListAllocations(ctx, userID.(string))
+	allocations, err := c.service.ListAllocations(ctx, userID.(string), ctx.Query("plan_id"))
 	if err != nil {
 		resp := utils.NewErrorResponse(http.StatusBadRequest, err.Error())
 		ctx.JSON(http.StatusBadRequest, resp)
@@ -206,7 +210,464 @@ func (c *Controller) DeleteAllocation(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, resp)
 }
 
+// ListExecutions godoc
+// @Summary List allocation executions
+// @Description Get the most recent runs of an allocation with their amount, resulting transaction, status and error
+// @Tags Allocations
+// @Produce json
+// @Param id path string true "Allocation ID"
+// @Param limit query int false "Number of executions (default 20, max 100)"
+// @Success 200 {object} map[string]interface{} "Allocation executions retrieved successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Security BearerAuth
+// @Router /v1/allocations/{id}/executions [get]
+func (c *Controller) ListExecutions(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		resp := utils.NewErrorResponse(http.StatusUnauthorized, "unauthorized")
+		ctx.JSON(http.StatusUnauthorized, resp)
+		return
+	}
+
+	limit, _ := strconv.ParseInt(ctx.DefaultQuery("limit", "20"), 10, 64)
+
+	executions, err := c.service.GetExecutions(ctx, userID.(string), ctx.Param("id"), limit)
+	if err != nil {
+		resp := utils.NewErrorResponse(http.StatusBadRequest, err.Error())
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	responses := make([]*dto.AllocationExecutionResponse, len(executions))
+	for i, execution := range executions {
+		responses[i] = c.mapExecutionToResponse(execution)
+	}
+
+	resp := utils.NewSuccessResponse("Allocation executions retrieved successfully", responses)
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// RerunExecution godoc
+// @Summary Re-run a failed allocation execution
+// @Description Run the failed execution of an allocation on a date again. A transfer that already went through settles the execution instead of moving the money twice.
+// @Tags Allocations
+// @Accept json
+// @Produce json
+// @Param id path string true "Allocation ID"
+// @Param request body dto.RerunExecutionRequest true "Execution date"
+// @Success 200 {object} map[string]interface{} "Allocation execution re-run"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Security BearerAuth
+// @Router /v1/allocations/{id}/executions/rerun [post]
+func (c *Controller) RerunExecution(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		resp := utils.NewErrorResponse(http.StatusUnauthorized, "unauthorized")
+		ctx.JSON(http.StatusUnauthorized, resp)
+		return
+	}
+
+	var req dto.RerunExecutionRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		resp := utils.NewErrorResponse(http.StatusBadRequest, err.Error())
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	if err := utils.ValidateRequest(&req); err != nil {
+		resp := utils.NewErrorResponse(http.StatusBadRequest, err.Error())
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	execution, err := c.service.RerunExecution(ctx, userID.(string), ctx.Param("id"), req.Date)
+	if err != nil {
+		resp := utils.NewErrorResponse(http.StatusBadRequest, err.Error())
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	resp := utils.NewSuccessResponse("Allocation execution re-run", c.mapExecutionToResponse(execution))
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// AdminRerunExecution godoc
+// @Summary Re-run a failed allocation execution (Admin only)
+// @Description Run any failed allocation execution again. A transfer that already went through settles the execution instead of moving the money twice.
+// @Tags Allocations
+// @Produce json
+// @Param execution_id path string true "Execution ID"
+// @Success 200 {object} map[string]interface{} "Allocation execution re-run"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Security BearerAuth
+// @Router /v1/allocations/admin/executions/{execution_id}/rerun [post]
+func (c *Controller) AdminRerunExecution(ctx *gin.Context) {
+	execution, err := c.service.AdminRerunExecution(ctx, ctx.Param("execution_id"))
+	if err != nil {
+		resp := utils.NewErrorResponse(http.StatusBadRequest, err.Error())
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	resp := utils.NewSuccessResponse("Allocation execution re-run", c.mapExecutionToResponse(execution))
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// PreviewAllocations godoc
+// @Summary Preview allocations
+// @Description Simulate a hypothetical income on a date and show which allocations would fire, in priority order, with their amounts, resulting balances and failures. Nothing is written.
+// @Tags Allocations
+// @Accept json
+// @Produce json
+// @Param request body dto.PreviewAllocationsRequest true "Hypothetical income"
+// @Success 200 {object} map[string]interface{} "Allocation preview retrieved successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Security BearerAuth
+// @Router /v1/allocations/preview [post]
+func (c *Controller) PreviewAllocations(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		resp := utils.NewErrorResponse(http.StatusUnauthorized, "unauthorized")
+		ctx.JSON(http.StatusUnauthorized, resp)
+		return
+	}
+
+	var req dto.PreviewAllocationsRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		resp := utils.NewErrorResponse(http.StatusBadRequest, err.Error())
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	if err := utils.ValidateRequest(&req); err != nil {
+		resp := utils.NewErrorResponse(http.StatusBadRequest, err.Error())
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	preview, err := c.service.PreviewAllocations(ctx, userID.(string), &req)
+	if err != nil {
+		resp := utils.NewErrorResponse(http.StatusBadRequest, err.Error())
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	resp := utils.NewSuccessResponse("Allocation preview retrieved successfully", c.mapPreviewToResponse(preview))
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// CreatePlan godoc
+// @Summary Create allocation plan
+// @Description Create an inactive allocation plan. The first plan also moves existing allocations into an active Default plan.
+// @Tags Allocation Plans
+// @Accept json
+// @Produce json
+// @Param request body dto.CreatePlanRequest true "Plan details"
+// @Success 201 {object} map[string]interface{} "Allocation plan created successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Security BearerAuth
+// @Router /v1/allocations/plans [post]
+func (c *Controller) CreatePlan(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		resp := utils.NewErrorResponse(http.StatusUnauthorized, "unauthorized")
+		ctx.JSON(http.StatusUnauthorized, resp)
+		return
+	}
+
+	var req dto.CreatePlanRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		resp := utils.NewErrorResponse(http.StatusBadRequest, err.Error())
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	if err := utils.ValidateRequest(&req); err != nil {
+		resp := utils.NewErrorResponse(http.StatusBadRequest, err.Error())
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	plan, err := c.service.CreatePlan(ctx, userID.(string), &req)
+	if err != nil {
+		resp := utils.NewErrorResponse(http.StatusBadRequest, err.Error())
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	resp := utils.NewSuccessResponse("Allocation plan created successfully", c.mapPlanToResponse(plan))
+	ctx.JSON(http.StatusCreated, resp)
+}
+
+// ListPlans godoc
+// @Summary List allocation plans
+// @Description Get all allocation plans of the authenticated user; exactly one is active
+// @Tags Allocation Plans
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Allocation plans retrieved successfully"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Security BearerAuth
+// @Router /v1/allocations/plans [get]
+func (c *Controller) ListPlans(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		resp := utils.NewErrorResponse(http.StatusUnauthorized, "unauthorized")
+		ctx.JSON(http.StatusUnauthorized, resp)
+		return
+	}
+
+	plans, err := c.service.ListPlans(ctx, userID.(string))
+	if err != nil {
+		resp := utils.NewErrorResponse(http.StatusBadRequest, err.Error())
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	responses := make([]*dto.AllocationPlanResponse, len(plans))
+	for i, plan := range plans {
+		responses[i] = c.mapPlanToResponse(plan)
+	}
+
+	resp := utils.NewSuccessResponse("Allocation plans retrieved successfully", responses)
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// GetPlan godoc
+// @Summary Get allocation plan by ID
+// @Description Get a specific allocation plan by ID
+// @Tags Allocation Plans
+// @Produce json
+// @Param plan_id path string true "Plan ID"
+// @Success 200 {object} map[string]interface{} "Allocation plan retrieved successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Security BearerAuth
+// @Router /v1/allocations/plans/{plan_id} [get]
+func (c *Controller) GetPlan(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		resp := utils.NewErrorResponse(http.StatusUnauthorized, "unauthorized")
+		ctx.JSON(http.StatusUnauthorized, resp)
+		return
+	}
+
+	plan, err := c.service.GetPlan(ctx, userID.(string), ctx.Param("plan_id"))
+	if err != nil {
+		resp := utils.NewErrorResponse(http.StatusBadRequest, err.Error())
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	resp := utils.NewSuccessResponse("Allocation plan retrieved successfully", c.mapPlanToResponse(plan))
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// UpdatePlan godoc
+// @Summary Update allocation plan
+// @Description Rename an allocation plan or change its description
+// @Tags Allocation Plans
+// @Accept json
+// @Produce json
+// @Param plan_id path string true "Plan ID"
+// @Param request body dto.UpdatePlanRequest true "Update details"
+// @Success 200 {object} map[string]interface{} "Allocation plan updated successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Security BearerAuth
+// @Router /v1/allocations/plans/{plan_id} [put]
+func (c *Controller) UpdatePlan(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		resp := utils.NewErrorResponse(http.StatusUnauthorized, "unauthorized")
+		ctx.JSON(http.StatusUnauthorized, resp)
+		return
+	}
+
+	var req dto.UpdatePlanRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		resp := utils.NewErrorResponse(http.StatusBadRequest, err.Error())
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	if err := utils.ValidateRequest(&req); err != nil {
+		resp := utils.NewErrorResponse(http.StatusBadRequest, err.Error())
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	plan, err := c.service.UpdatePlan(ctx, userID.(string), ctx.Param("plan_id"), &req)
+	if err != nil {
+		resp := utils.NewErrorResponse(http.StatusBadRequest, err.Error())
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	resp := utils.NewSuccessResponse("Allocation plan updated successfully", c.mapPlanToResponse(plan))
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// DeletePlan godoc
+// @Summary Delete allocation plan
+// @Description Delete an inactive allocation plan together with its allocations
+// @Tags Allocation Plans
+// @Produce json
+// @Param plan_id path string true "Plan ID"
+// @Success 200 {object} map[string]interface{} "Allocation plan deleted successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Security BearerAuth
+// @Router /v1/allocations/plans/{plan_id} [delete]
+func (c *Controller) DeletePlan(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		resp := utils.NewErrorResponse(http.StatusUnauthorized, "unauthorized")
+		ctx.JSON(http.StatusUnauthorized, resp)
+		return
+	}
+
+	if err := c.service.DeletePlan(ctx, userID.(string), ctx.Param("plan_id")); err != nil {
+		resp := utils.NewErrorResponse(http.StatusBadRequest, err.Error())
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	resp := utils.NewSuccessResponse("Allocation plan deleted successfully", nil)
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// ActivatePlan godoc
+// @Summary Activate allocation plan
+// @Description Switch to an allocation plan. Only the allocations of the active plan run.
+// @Tags Allocation Plans
+// @Produce json
+// @Param plan_id path string true "Plan ID"
+// @Success 200 {object} map[string]interface{} "Allocation plan activated successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Security BearerAuth
+// @Router /v1/allocations/plans/{plan_id}/activate [post]
+func (c *Controller) ActivatePlan(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		resp := utils.NewErrorResponse(http.StatusUnauthorized, "unauthorized")
+		ctx.JSON(http.StatusUnauthorized, resp)
+		return
+	}
+
+	plan, err := c.service.ActivatePlan(ctx, userID.(string), ctx.Param("plan_id"))
+	if err != nil {
+		resp := utils.NewErrorResponse(http.StatusBadRequest, err.Error())
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	resp := utils.NewSuccessResponse("Allocation plan activated successfully", c.mapPlanToResponse(plan))
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// ClonePlan godoc
+// @Summary Clone allocation plan
+// @Description Copy an allocation plan and its allocations into a new inactive plan
+// @Tags Allocation Plans
+// @Accept json
+// @Produce json
+// @Param plan_id path string true "Plan ID"
+// @Param request body dto.ClonePlanRequest true "Name of the new plan"
+// @Success 201 {object} map[string]interface{} "Allocation plan cloned successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Security BearerAuth
+// @Router /v1/allocations/plans/{plan_id}/clone [post]
+func (c *Controller) ClonePlan(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		resp := utils.NewErrorResponse(http.StatusUnauthorized, "unauthorized")
+		ctx.JSON(http.StatusUnauthorized, resp)
+		return
+	}
+
+	var req dto.ClonePlanRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		resp := utils.NewErrorResponse(http.StatusBadRequest, err.Error())
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	if err := utils.ValidateRequest(&req); err != nil {
+		resp := utils.NewErrorResponse(http.StatusBadRequest, err.Error())
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	plan, err := c.service.ClonePlan(ctx, userID.(string), ctx.Param("plan_id"), &req)
+	if err != nil {
+		resp := utils.NewErrorResponse(http.StatusBadRequest, err.Error())
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	resp := utils.NewSuccessResponse("Allocation plan cloned successfully", c.mapPlanToResponse(plan))
+	ctx.JSON(http.StatusCreated, resp)
+}
+
+// ComparePlans godoc
+// @Summary Compare allocation plans
+// @Description Preview the same hypothetical income under two plans and show each pocket's resulting balance side by side. Nothing is written.
+// @Tags Allocation Plans
+// @Accept json
+// @Produce json
+// @Param request body dto.ComparePlansRequest true "Plans and hypothetical income"
+// @Success 200 {object} map[string]interface{} "Allocation plan comparison retrieved successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Security BearerAuth
+// @Router /v1/allocations/plans/compare [post]
+func (c *Controller) ComparePlans(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		resp := utils.NewErrorResponse(http.StatusUnauthorized, "unauthorized")
+		ctx.JSON(http.StatusUnauthorized, resp)
+		return
+	}
+
+	var req dto.ComparePlansRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		resp := utils.NewErrorResponse(http.StatusBadRequest, err.Error())
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	if err := utils.ValidateRequest(&req); err != nil {
+		resp := utils.NewErrorResponse(http.StatusBadRequest, err.Error())
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	comparison, err := c.service.ComparePlans(ctx, userID.(string), &req)
+	if err != nil {
+		resp := utils.NewErrorResponse(http.StatusBadRequest, err.Error())
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	resp := utils.NewSuccessResponse("Allocation plan comparison retrieved successfully", c.mapComparisonToResponse(comparison))
+	ctx.JSON(http.StatusOK, resp)
+}
+
 func (c *Controller) mapToResponse(allocation *Allocation) *dto.AllocationResponse {
+	var planID *string
+	if allocation.PlanID != nil {
+		id := allocation.PlanID.Hex()
+		planID = &id
+	}
+
 	var pocketID *string
 	if allocation.PocketID != nil {
 		id := allocation.PocketID.Hex()
@@ -219,19 +680,49 @@ func (c *Controller) mapToResponse(allocation *Allocation) *dto.AllocationRespon
 		userPlatformID = &id
 	}
 
+	var baseTransactionID *string
+	if allocation.BaseTransactionID != nil {
+		id := allocation.BaseTransactionID.Hex()
+		baseTransactionID = &id
+	}
+
+	triggerType := allocation.TriggerType
+	if triggerType == "" {
+		triggerType = string(TriggerScheduled)
+	}
+
+	var incomeFilter *dto.IncomeFilterResponse
+	if allocation.IncomeFilter != nil {
+		incomeFilter = &dto.IncomeFilterResponse{MinAmount: allocation.IncomeFilter.MinAmount}
+		if allocation.IncomeFilter.UserPlatformID != nil {
+			id := allocation.IncomeFilter.UserPlatformID.Hex()
+			incomeFilter.UserPlatformID = &id
+		}
+		if allocation.IncomeFilter.CategoryID != nil {
+			id := allocation.IncomeFilter.CategoryID.Hex()
+			incomeFilter.CategoryID = &id
+		}
+	}
+
 	return &dto.AllocationResponse{
-		ID:             allocation.ID.Hex(),
-		UserID:         allocation.UserID.Hex(),
-		PocketID:       pocketID,
-		UserPlatformID: userPlatformID,
-		Priority:       allocation.Priority,
-		AllocationType: allocation.AllocationType,
-		Nominal:        allocation.Nominal,
-		IsActive:       allocation.IsActive,
-		ExecuteDay:     allocation.ExecuteDay,
-		CreatedAt:      allocation.CreatedAt,
-		UpdatedAt:      allocation.UpdatedAt,
-		DeletedAt:      allocation.DeletedAt,
+		ID:                allocation.ID.Hex(),
+		UserID:            allocation.UserID.Hex(),
+		PlanID:            planID,
+		PocketID:          pocketID,
+		UserPlatformID:    userPlatformID,
+		Priority:          allocation.Priority,
+		AllocationType:    allocation.AllocationType,
+		Nominal:           allocation.Nominal,
+		PercentageBase:    allocation.PercentageBase,
+		BaseTransactionID: baseTransactionID,
+		IsActive:          allocation.IsActive,
+		ExecuteDay:        allocation.ExecuteDay,
+		DayAdjustment:     holiday.NormalizeAdjustment(allocation.DayAdjustment),
+		TriggerType:       triggerType,
+		IncomeFilter:      incomeFilter,
+		CreatedAt:         allocation.CreatedAt,
+		UpdatedAt:         allocation.UpdatedAt,
+		DeletedAt:         allocation.DeletedAt,
 	}
 }
 
@@ -242,3 +733,121 @@ func (c *Controller) mapToResponseList(allocations []*Allocation) []*dto.Allocat
 	}
 	return responses
 }
+
+func (c *Controller) mapPreviewToResponse(preview *AllocationPreview) *dto.AllocationPreviewResponse {
+	items := make([]*dto.AllocationPreviewItemResponse, len(preview.Items))
+	for i, item := range preview.Items {
+		allocation := c.mapToResponse(item.Allocation)
+		items[i] = &dto.AllocationPreviewItemResponse{
+			AllocationID:   allocation.ID,
+			Priority:       allocation.Priority,
+			TriggerType:    allocation.TriggerType,
+			AllocationType: allocation.AllocationType,
+			Nominal:        allocation.Nominal,
+			PercentageBase: item.PercentageBase,
+			BaseAmount:     item.BaseAmount,
+			Amount:         item.Amount,
+			FundedAmount:   item.FundedAmount,
+			Shortfall:      item.Shortfall,
+			PocketID:       allocation.PocketID,
+			UserPlatformID: allocation.UserPlatformID,
+			Status:         item.Status,
+			Reason:         item.Reason,
+		}
+	}
+
+	balances := make([]*dto.PreviewBalanceResponse, len(preview.Balances))
+	for i, balance := range preview.Balances {
+		balances[i] = &dto.PreviewBalanceResponse{
+			EntityType: balance.EntityType,
+			ID:         balance.ID.Hex(),
+			Name:       balance.Name,
+			Before:     balance.Before,
+			After:      balance.After,
+		}
+	}
+
+	return &dto.AllocationPreviewResponse{
+		Date:                    preview.Date.Format("2006-01-02"),
+		IncomeAmount:            preview.IncomeAmount,
+		MainPocketBalanceBefore: preview.MainPocketBalanceBefore,
+		MainPocketBalanceAfter:  preview.MainPocketBalanceAfter,
+		TotalAllocated:          preview.TotalAllocated,
+		Items:                   items,
+		Balances:                balances,
+	}
+}
+
+func (c *Controller) mapExecutionToResponse(execution *AllocationExecution) *dto.AllocationExecutionResponse {
+	var incomeTransactionID *string
+	if execution.IncomeTransactionID != nil {
+		id := execution.IncomeTransactionID.Hex()
+		incomeTransactionID = &id
+	}
+
+	var transactionID *string
+	if execution.TransactionID != nil {
+		id := execution.TransactionID.Hex()
+		transactionID = &id
+	}
+
+	// Executions recorded before runs were dated fall back to their creation date
+	date := execution.CreatedAt.Format("2006-01-02")
+	if execution.Year != 0 {
+		date = fmt.Sprintf("%04d-%02d-%02d", execution.Year, execution.Month, execution.Day)
+	}
+
+	return &dto.AllocationExecutionResponse{
+		ID:                  execution.ID.Hex(),
+		AllocationID:        execution.AllocationID.Hex(),
+		Date:                date,
+		TriggerType:         execution.TriggerType,
+		IncomeTransactionID: incomeTransactionID,
+		AllocationType:      execution.AllocationType,
+		Nominal:             execution.Nominal,
+		PercentageBase:      execution.PercentageBase,
+		BaseAmount:          execution.BaseAmount,
+		RequestedAmount:     execution.RequestedAmount,
+		Amount:              execution.Amount,
+		Shortfall:           execution.Shortfall,
+		TransactionID:       transactionID,
+		Status:              execution.Status,
+		Error:               execution.Error,
+		Attempts:            execution.Attempts,
+		NextRetryAt:         execution.NextRetryAt,
+		CreatedAt:           execution.CreatedAt,
+	}
+}
+
+func (c *Controller) mapPlanToResponse(plan *AllocationPlan) *dto.AllocationPlanResponse {
+	return &dto.AllocationPlanResponse{
+		ID:          plan.ID.Hex(),
+		Name:        plan.Name,
+		Description: plan.Description,
+		IsActive:    plan.IsActive,
+		CreatedAt:   plan.CreatedAt,
+		UpdatedAt:   plan.UpdatedAt,
+	}
+}
+
+func (c *Controller) mapComparisonToResponse(comparison *PlanComparison) *dto.PlanComparisonResponse {
+	pockets := make([]*dto.PocketComparisonResponse, len(comparison.Pockets))
+	for i, pocket := range comparison.Pockets {
+		pockets[i] = &dto.PocketComparisonResponse{
+			PocketID:   pocket.PocketID.Hex(),
+			Name:       pocket.Name,
+			Before:     pocket.Before,
+			AfterA:     pocket.AfterA,
+			AfterB:     pocket.AfterB,
+			Difference: pocket.Difference,
+		}
+	}
+
+	return &dto.PlanComparisonResponse{
+		PlanA:    c.mapPlanToResponse(comparison.PlanA),
+		PlanB:    c.mapPlanToResponse(comparison.PlanB),
+		PreviewA: c.mapPreviewToResponse(comparison.PreviewA),
+		PreviewB: c.mapPreviewToResponse(comparison.PreviewB),
+		Pockets:  pockets,
+	}
+}
@@ -21,7 +21,7 @@ func NewCronJob(service *Service) *CronJob {
 }
 
 // Start begins the daily allocation processing cron job
-// Runs every day at 01:00 AM (Asia/Jakarta timezone)
+// Runs every day at 01:00 AM (Asia/Jakarta timezone), with transient failures retried every 5 minutes
 func (c *CronJob) Start() error {
 	_, err := c.cron.AddFunc("0 1 * * *", func() {
 		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
@@ -38,6 +38,19 @@ func (c *CronJob) Start() error {
 		return err
 	}
 
+	_, err = c.cron.AddFunc("*/5 * * * *", func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+		defer cancel()
+
+		if err := c.service.RetryFailedExecutions(ctx); err != nil {
+			log.Printf("Error retrying failed allocation executions: %v", err)
+		}
+	})
+
+	if err != nil {
+		return err
+	}
+
 	c.cron.Start()
 	log.Println("Allocation cron job started")
 	return nil
@@ -1,20 +1,72 @@
 package dto
 
 type CreateAllocationRequest struct {
-	PocketID       string  `json:"pocket_id" validate:"omitempty,len=24,hexadecimal"`
-	UserPlatformID string  `json:"user_platform_id" validate:"omitempty,len=24,hexadecimal"`
-	Priority       int     `json:"priority" validate:"required,min=1,max=3"`
-	AllocationType string  `json:"allocation_type" validate:"required,oneof=PERCENTAGE NOMINAL"`
-	Nominal        float64 `json:"nominal" validate:"required,gt=0"`
-	ExecuteDay     *int    `json:"execute_day" validate:"omitempty,min=1,max=31"`
+	PlanID            string               `json:"plan_id" validate:"omitempty,len=24,hexadecimal"` // defaults to the active plan
+	PocketID          string               `json:"pocket_id" validate:"omitempty,len=24,hexadecimal"`
+	UserPlatformID    string               `json:"user_platform_id" validate:"omitempty,len=24,hexadecimal"`
+	Priority          int                  `json:"priority" validate:"required,min=1,max=3"`
+	AllocationType    string               `json:"allocation_type" validate:"required,oneof=PERCENTAGE NOMINAL"`
+	Nominal           float64              `json:"nominal" validate:"required,gt=0"`
+	PercentageBase    string               `json:"percentage_base" validate:"omitempty,oneof=PAYROLL MAIN_POCKET_BALANCE INCOME_TRANSACTION"`
+	BaseTransactionID string               `json:"base_transaction_id" validate:"omitempty,len=24,hexadecimal"`
+	ExecuteDay        *int                 `json:"execute_day" validate:"omitempty,min=1,max=31"`
+	DayAdjustment     string               `json:"day_adjustment" validate:"omitempty,oneof=NONE PREVIOUS_BUSINESS_DAY NEXT_BUSINESS_DAY"`
+	TriggerType       string               `json:"trigger_type" validate:"omitempty,oneof=SCHEDULED ON_INCOME"`
+	IncomeFilter      *IncomeFilterRequest `json:"income_filter"`
 }
 
 type UpdateAllocationRequest struct {
-	PocketID       string   `json:"pocket_id" validate:"omitempty,len=24,hexadecimal"`
+	PocketID          string               `json:"pocket_id" validate:"omitempty,len=24,hexadecimal"`
+	UserPlatformID    string               `json:"user_platform_id" validate:"omitempty,len=24,hexadecimal"`
+	Priority          *int                 `json:"priority" validate:"omitempty,min=1,max=3"`
+	AllocationType    string               `json:"allocation_type" validate:"omitempty,oneof=PERCENTAGE NOMINAL"`
+	Nominal           *float64             `json:"nominal" validate:"omitempty,gt=0"`
+	PercentageBase    string               `json:"percentage_base" validate:"omitempty,oneof=PAYROLL MAIN_POCKET_BALANCE INCOME_TRANSACTION"`
+	BaseTransactionID string               `json:"base_transaction_id" validate:"omitempty,len=24,hexadecimal"`
+	IsActive          *bool                `json:"is_active"`
+	ExecuteDay        *int                 `json:"execute_day" validate:"omitempty,min=1,max=31"`
+	DayAdjustment     string               `json:"day_adjustment" validate:"omitempty,oneof=NONE PREVIOUS_BUSINESS_DAY NEXT_BUSINESS_DAY"`
+	TriggerType       string               `json:"trigger_type" validate:"omitempty,oneof=SCHEDULED ON_INCOME"`
+	IncomeFilter      *IncomeFilterRequest `json:"income_filter"`
+}
+
+// IncomeFilterRequest selects the income transactions that fire an ON_INCOME allocation
+type IncomeFilterRequest struct {
+	MinAmount      *float64 `json:"min_amount" validate:"omitempty,gte=0"`
 	UserPlatformID string   `json:"user_platform_id" validate:"omitempty,len=24,hexadecimal"`
-	Priority       *int     `json:"priority" validate:"omitempty,min=1,max=3"`
-	AllocationType string   `json:"allocation_type" validate:"omitempty,oneof=PERCENTAGE NOMINAL"`
-	Nominal        *float64 `json:"nominal" validate:"omitempty,gt=0"`
-	IsActive       *bool    `json:"is_active"`
-	ExecuteDay     *int     `json:"execute_day" validate:"omitempty,min=1,max=31"`
+	CategoryID     string   `json:"category_id" validate:"omitempty,len=24,hexadecimal"`
+}
+
+type PreviewAllocationsRequest struct {
+	IncomeAmount   float64 `json:"income_amount" validate:"required,gt=0"`
+	Date           string  `json:"date" validate:"required"` // YYYY-MM-DD
+	UserPlatformID string  `json:"user_platform_id" validate:"omitempty,len=24,hexadecimal"`
+	CategoryID     string  `json:"category_id" validate:"omitempty,len=24,hexadecimal"`
+}
+
+type CreatePlanRequest struct {
+	Name        string `json:"name" validate:"required,max=100"`
+	Description string `json:"description" validate:"omitempty,max=255"`
+}
+
+type UpdatePlanRequest struct {
+	Name        string  `json:"name" validate:"omitempty,max=100"`
+	Description *string `json:"description" validate:"omitempty,max=255"`
+}
+
+type ClonePlanRequest struct {
+	Name string `json:"name" validate:"required,max=100"`
+}
+
+type ComparePlansRequest struct {
+	PlanAID        string  `json:"plan_a_id" validate:"required,len=24,hexadecimal"`
+	PlanBID        string  `json:"plan_b_id" validate:"required,len=24,hexadecimal"`
+	IncomeAmount   float64 `json:"income_amount" validate:"required,gt=0"`
+	Date           string  `json:"date" validate:"required"` // YYYY-MM-DD
+	UserPlatformID string  `json:"user_platform_id" validate:"omitempty,len=24,hexadecimal"`
+	CategoryID     string  `json:"category_id" validate:"omitempty,len=24,hexadecimal"`
+}
+
+type RerunExecutionRequest struct {
+	Date string `json:"date" validate:"required"` // YYYY-MM-DD
 }
@@ -3,16 +3,110 @@ package dto
 import "time"
 
 type AllocationResponse struct {
-	ID             string     `json:"id"`
-	UserID         string     `json:"user_id"`
-	PocketID       *string    `json:"pocket_id,omitempty"`
-	UserPlatformID *string    `json:"user_platform_id,omitempty"`
-	Priority       int        `json:"priority"`
-	AllocationType string     `json:"allocation_type"`
-	Nominal        float64    `json:"nominal"`
-	IsActive       bool       `json:"is_active"`
-	ExecuteDay     *int       `json:"execute_day,omitempty"`
-	CreatedAt      time.Time  `json:"created_at"`
-	UpdatedAt      time.Time  `json:"updated_at"`
-	DeletedAt      *time.Time `json:"deleted_at,omitempty"`
+	ID                string                `json:"id"`
+	UserID            string                `json:"user_id"`
+	PlanID            *string               `json:"plan_id,omitempty"`
+	PocketID          *string               `json:"pocket_id,omitempty"`
+	UserPlatformID    *string               `json:"user_platform_id,omitempty"`
+	Priority          int                   `json:"priority"`
+	AllocationType    string                `json:"allocation_type"`
+	Nominal           float64               `json:"nominal"`
+	PercentageBase    string                `json:"percentage_base,omitempty"`
+	BaseTransactionID *string               `json:"base_transaction_id,omitempty"`
+	IsActive          bool                  `json:"is_active"`
+	ExecuteDay        *int                  `json:"execute_day,omitempty"`
+	DayAdjustment     string                `json:"day_adjustment"`
+	TriggerType       string                `json:"trigger_type"`
+	IncomeFilter      *IncomeFilterResponse `json:"income_filter,omitempty"`
+	CreatedAt         time.Time             `json:"created_at"`
+	UpdatedAt         time.Time             `json:"updated_at"`
+	DeletedAt         *time.Time            `json:"deleted_at,omitempty"`
+}
+
+type IncomeFilterResponse struct {
+	MinAmount      *float64 `json:"min_amount,omitempty"`
+	UserPlatformID *string  `json:"user_platform_id,omitempty"`
+	CategoryID     *string  `json:"category_id,omitempty"`
+}
+
+type AllocationPlanResponse struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	Description *string   `json:"description,omitempty"`
+	IsActive    bool      `json:"is_active"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type PlanComparisonResponse struct {
+	PlanA    *AllocationPlanResponse     `json:"plan_a"`
+	PlanB    *AllocationPlanResponse     `json:"plan_b"`
+	PreviewA *AllocationPreviewResponse  `json:"preview_a"`
+	PreviewB *AllocationPreviewResponse  `json:"preview_b"`
+	Pockets  []*PocketComparisonResponse `json:"pockets"`
+}
+
+type PocketComparisonResponse struct {
+	PocketID   string  `json:"pocket_id"`
+	Name       string  `json:"name"`
+	Before     float64 `json:"before"`
+	AfterA     float64 `json:"after_a"`
+	AfterB     float64 `json:"after_b"`
+	Difference float64 `json:"difference"`
+}
+
+type AllocationPreviewResponse struct {
+	Date                    string                           `json:"date"`
+	IncomeAmount            float64                          `json:"income_amount"`
+	MainPocketBalanceBefore float64                          `json:"main_pocket_balance_before"`
+	MainPocketBalanceAfter  float64                          `json:"main_pocket_balance_after"`
+	TotalAllocated          float64                          `json:"total_allocated"`
+	Items                   []*AllocationPreviewItemResponse `json:"items"`
+	Balances                []*PreviewBalanceResponse        `json:"balances"`
+}
+
+type AllocationPreviewItemResponse struct {
+	AllocationID   string   `json:"allocation_id"`
+	Priority       int      `json:"priority"`
+	TriggerType    string   `json:"trigger_type"`
+	AllocationType string   `json:"allocation_type"`
+	Nominal        float64  `json:"nominal"`
+	PercentageBase string   `json:"percentage_base,omitempty"`
+	BaseAmount     *float64 `json:"base_amount,omitempty"`
+	Amount         float64  `json:"amount"`
+	FundedAmount   float64  `json:"funded_amount"`
+	Shortfall      float64  `json:"shortfall"`
+	PocketID       *string  `json:"pocket_id,omitempty"`
+	UserPlatformID *string  `json:"user_platform_id,omitempty"`
+	Status         string   `json:"status"`
+	Reason         *string  `json:"reason,omitempty"`
+}
+
+type PreviewBalanceResponse struct {
+	EntityType string  `json:"entity_type"`
+	ID         string  `json:"id"`
+	Name       string  `json:"name"`
+	Before     float64 `json:"before"`
+	After      float64 `json:"after"`
+}
+
+type AllocationExecutionResponse struct {
+	ID                  string     `json:"id"`
+	AllocationID        string     `json:"allocation_id"`
+	Date                string     `json:"date"`
+	TriggerType         string     `json:"trigger_type"`
+	IncomeTransactionID *string    `json:"income_transaction_id,omitempty"`
+	AllocationType      string     `json:"allocation_type"`
+	Nominal             float64    `json:"nominal"`
+	PercentageBase      string     `json:"percentage_base,omitempty"`
+	BaseAmount          *float64   `json:"base_amount,omitempty"`
+	RequestedAmount     float64    `json:"requested_amount"`
+	Amount              float64    `json:"amount"`
+	Shortfall           float64    `json:"shortfall"`
+	TransactionID       *string    `json:"transaction_id,omitempty"`
+	Status              string     `json:"status"`
+	Error               *string    `json:"error,omitempty"`
+	Attempts            int        `json:"attempts"`
+	NextRetryAt         *time.Time `json:"next_retry_at,omitempty"`
+	CreatedAt           time.Time  `json:"created_at"`
 }
@@ -8,20 +8,42 @@ import (
 
 // Allocation represents a salary allocation rule
 type Allocation struct {
-	ID             primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
-	UserID         primitive.ObjectID  `bson:"user_id" json:"user_id"`
-	PocketID       *primitive.ObjectID `bson:"pocket_id,omitempty" json:"pocket_id,omitempty"`
-	UserPlatformID *primitive.ObjectID `bson:"user_platform_id,omitempty" json:"user_platform_id,omitempty"`
-	Priority       int                 `bson:"priority" json:"priority"` // 1=HIGH, 2=MEDIUM, 3=LOW
-	AllocationType string              `bson:"allocation_type" json:"allocation_type" enums:"PERCENTAGE,NOMINAL"`
-	Nominal        float64             `bson:"nominal" json:"nominal"` // percentage or amount
-	IsActive       bool                `bson:"is_active" json:"is_active"`
-	ExecuteDay     *int                `bson:"execute_day,omitempty" json:"execute_day,omitempty"` // 1-31, null for no scheduled execution
-	CreatedAt      time.Time           `bson:"created_at" json:"created_at"`
-	UpdatedAt      time.Time           `bson:"updated_at" json:"updated_at"`
-	DeletedAt      *time.Time          `bson:"deleted_at,omitempty" json:"deleted_at,omitempty"`
+	ID                primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	UserID            primitive.ObjectID  `bson:"user_id" json:"user_id"`
+	PlanID            *primitive.ObjectID `bson:"plan_id,omitempty" json:"plan_id,omitempty"` // null until the user's first plan is created
+	PocketID          *primitive.ObjectID `bson:"pocket_id,omitempty" json:"pocket_id,omitempty"`
+	UserPlatformID    *primitive.ObjectID `bson:"user_platform_id,omitempty" json:"user_platform_id,omitempty"`
+	Priority          int                 `bson:"priority" json:"priority"` // 1=HIGH, 2=MEDIUM, 3=LOW
+	AllocationType    string              `bson:"allocation_type" json:"allocation_type" enums:"PERCENTAGE,NOMINAL"`
+	Nominal           float64             `bson:"nominal" json:"nominal"` // percentage or amount
+	PercentageBase    string              `bson:"percentage_base,omitempty" json:"percentage_base,omitempty" enums:"PAYROLL,MAIN_POCKET_BALANCE,INCOME_TRANSACTION"`
+	BaseTransactionID *primitive.ObjectID `bson:"base_transaction_id,omitempty" json:"base_transaction_id,omitempty"` // income transaction used by the INCOME_TRANSACTION base
+	IsActive          bool                `bson:"is_active" json:"is_active"`
+	TriggerType       string              `bson:"trigger_type,omitempty" json:"trigger_type,omitempty" enums:"SCHEDULED,ON_INCOME"`
+	IncomeFilter      *IncomeFilter       `bson:"income_filter,omitempty" json:"income_filter,omitempty"`                                                        // only for ON_INCOME
+	ExecuteDay        *int                `bson:"execute_day,omitempty" json:"execute_day,omitempty"`                                                            // 1-31, null for no scheduled execution
+	DayAdjustment     string              `bson:"day_adjustment,omitempty" json:"day_adjustment,omitempty" enums:"NONE,PREVIOUS_BUSINESS_DAY,NEXT_BUSINESS_DAY"` // moves an execute day on a weekend or holiday
+	CreatedAt         time.Time           `bson:"created_at" json:"created_at"`
+	UpdatedAt         time.Time           `bson:"updated_at" json:"updated_at"`
+	DeletedAt         *time.Time          `bson:"deleted_at,omitempty" json:"deleted_at,omitempty"`
+}
+
+// AllocationPlan is a named set of allocation rules, such as one for normal months and one for bonus months.
+// Only the rules of the user's active plan run.
+type AllocationPlan struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID      primitive.ObjectID `bson:"user_id" json:"user_id"`
+	Name        string             `bson:"name" json:"name"`
+	Description *string            `bson:"description,omitempty" json:"description,omitempty"`
+	IsActive    bool               `bson:"is_active" json:"is_active"`
+	CreatedAt   time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt   time.Time          `bson:"updated_at" json:"updated_at"`
+	DeletedAt   *time.Time         `bson:"deleted_at,omitempty" json:"deleted_at,omitempty"`
 }
 
+// DefaultPlanName names the plan a user's existing allocations are moved into when plans are first used
+const DefaultPlanName = "Default"
+
 type AllocationType string
 
 const (
@@ -29,6 +51,184 @@ const (
 	TypeNominal    AllocationType = "NOMINAL"
 )
 
+// PercentageBase is the amount a PERCENTAGE allocation is taken from
+type PercentageBase string
+
+const (
+	// BasePayroll is the most recent payroll income credited to the main pocket
+	BasePayroll PercentageBase = "PAYROLL"
+	// BaseMainPocketBalance is the main pocket balance before the run starts
+	BaseMainPocketBalance PercentageBase = "MAIN_POCKET_BALANCE"
+	// BaseIncomeTransaction is the amount of a specific income transaction
+	BaseIncomeTransaction PercentageBase = "INCOME_TRANSACTION"
+)
+
+// IncomeFilter narrows which income transactions fire an ON_INCOME allocation. Empty fields match any income.
+type IncomeFilter struct {
+	MinAmount      *float64            `bson:"min_amount,omitempty" json:"min_amount,omitempty"`
+	UserPlatformID *primitive.ObjectID `bson:"user_platform_id,omitempty" json:"user_platform_id,omitempty"`
+	CategoryID     *primitive.ObjectID `bson:"category_id,omitempty" json:"category_id,omitempty"`
+}
+
+// Matches reports whether an income of the given amount, platform and category passes the filter
+func (f *IncomeFilter) Matches(amount float64, userPlatformID *primitive.ObjectID, categoryID *primitive.ObjectID) bool {
+	if f == nil {
+		return true
+	}
+	if f.MinAmount != nil && amount < *f.MinAmount {
+		return false
+	}
+	if f.UserPlatformID != nil && (userPlatformID == nil || *userPlatformID != *f.UserPlatformID) {
+		return false
+	}
+	if f.CategoryID != nil && (categoryID == nil || *categoryID != *f.CategoryID) {
+		return false
+	}
+	return true
+}
+
+// TriggerType decides what runs an allocation
+type TriggerType string
+
+const (
+	// TriggerScheduled runs on the allocation's execute_day
+	TriggerScheduled TriggerType = "SCHEDULED"
+	// TriggerOnIncome runs as soon as a matching income transaction is created
+	TriggerOnIncome TriggerType = "ON_INCOME"
+)
+
+// AllocationExecution records one run of an allocation, including how its amount was resolved.
+// A scheduled allocation runs at most once per day and an income-triggered one once per income.
+type AllocationExecution struct {
+	ID                  primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	AllocationID        primitive.ObjectID  `bson:"allocation_id" json:"allocation_id"`
+	UserID              primitive.ObjectID  `bson:"user_id" json:"user_id"`
+	Year                int                 `bson:"year" json:"year"`
+	Month               int                 `bson:"month" json:"month"`
+	Day                 int                 `bson:"day" json:"day"`
+	TriggerType         string              `bson:"trigger_type" json:"trigger_type"`
+	IncomeTransactionID *primitive.ObjectID `bson:"income_transaction_id" json:"income_transaction_id,omitempty"` // income that fired an ON_INCOME run
+	AllocationType      string              `bson:"allocation_type" json:"allocation_type"`
+	Nominal             float64             `bson:"nominal" json:"nominal"`
+	PercentageBase      string              `bson:"percentage_base,omitempty" json:"percentage_base,omitempty"`
+	BaseAmount          *float64            `bson:"base_amount,omitempty" json:"base_amount,omitempty"`
+	BaseTransactionID   *primitive.ObjectID `bson:"base_transaction_id,omitempty" json:"base_transaction_id,omitempty"`
+	RequestedAmount     float64             `bson:"requested_amount" json:"requested_amount"`
+	Amount              float64             `bson:"amount" json:"amount"`       // funded amount
+	Shortfall           float64             `bson:"shortfall" json:"shortfall"` // requested amount left unfunded
+	TransactionID       *primitive.ObjectID `bson:"transaction_id,omitempty" json:"transaction_id,omitempty"`
+	Status              string              `bson:"status" json:"status"` // PENDING, SUCCESS, PARTIAL, SKIPPED, FAILED
+	Error               *string             `bson:"error,omitempty" json:"error,omitempty"`
+	Attempts            int                 `bson:"attempts" json:"attempts"`
+	NextRetryAt         *time.Time          `bson:"next_retry_at,omitempty" json:"next_retry_at,omitempty"` // set while a transient failure waits for a retry
+	CreatedAt           time.Time           `bson:"created_at" json:"created_at"`
+}
+
+// ExecutionStatus constants
+const (
+	ExecutionPending = "PENDING"
+	ExecutionSuccess = "SUCCESS"
+	ExecutionPartial = "PARTIAL"
+	ExecutionSkipped = "SKIPPED"
+	ExecutionFailed  = "FAILED"
+)
+
+// ShortfallPolicy decides how the first priority level the main pocket cannot fully cover is funded.
+// Levels below it receive nothing.
+type ShortfallPolicy string
+
+const (
+	// PolicySkip funds the allocations of the level that fit in full and skips the rest
+	PolicySkip ShortfallPolicy = "SKIP"
+	// PolicyPartial funds the level in order; the first allocation that does not fit gets what is left
+	PolicyPartial ShortfallPolicy = "PARTIAL"
+	// PolicyProportional splits what is left across the level by requested amount
+	PolicyProportional ShortfallPolicy = "PROPORTIONAL"
+)
+
+// AllocationShortfall is the unfunded part of an allocation run. It is topped up from the next income.
+type AllocationShortfall struct {
+	ID                  primitive.ObjectID   `bson:"_id,omitempty" json:"id"`
+	UserID              primitive.ObjectID   `bson:"user_id" json:"user_id"`
+	AllocationID        primitive.ObjectID   `bson:"allocation_id" json:"allocation_id"`
+	ExecutionID         primitive.ObjectID   `bson:"execution_id" json:"execution_id"`
+	Priority            int                  `bson:"priority" json:"priority"`
+	Amount              float64              `bson:"amount" json:"amount"`
+	Remaining           float64              `bson:"remaining" json:"remaining"`
+	Status              string               `bson:"status" json:"status"` // OPEN, SETTLED, CANCELLED
+	TopUpTransactionIDs []primitive.ObjectID `bson:"top_up_transaction_ids" json:"top_up_transaction_ids"`
+	CreatedAt           time.Time            `bson:"created_at" json:"created_at"`
+	UpdatedAt           time.Time            `bson:"updated_at" json:"updated_at"`
+	SettledAt           *time.Time           `bson:"settled_at,omitempty" json:"settled_at,omitempty"`
+}
+
+// ShortfallStatus constants
+const (
+	ShortfallOpen      = "OPEN"
+	ShortfallSettled   = "SETTLED"
+	ShortfallCancelled = "CANCELLED"
+)
+
+// AllocationPreview is the simulated outcome of crediting a hypothetical income and running the allocations
+// that would fire for it. Nothing is written while building it.
+type AllocationPreview struct {
+	Date                    time.Time
+	IncomeAmount            float64
+	MainPocketBalanceBefore float64
+	MainPocketBalanceAfter  float64
+	TotalAllocated          float64
+	Items                   []*AllocationPreviewItem
+	Balances                []*PreviewBalance
+}
+
+// AllocationPreviewItem is one allocation that would fire, in execution order
+type AllocationPreviewItem struct {
+	Allocation     *Allocation
+	PercentageBase string
+	BaseAmount     *float64
+	Amount         float64 // requested amount
+	FundedAmount   float64
+	Shortfall      float64
+	Status         string // WOULD_EXECUTE, WOULD_PARTIAL, WOULD_SKIP, WOULD_FAIL
+	Reason         *string
+}
+
+// PreviewBalance is the balance of a pocket or user platform before and after the simulated run
+type PreviewBalance struct {
+	EntityType string // pocket, user_platform
+	ID         primitive.ObjectID
+	Name       string
+	Before     float64
+	After      float64
+}
+
+// PlanComparison is the simulated outcome of the same hypothetical income under two plans
+type PlanComparison struct {
+	PlanA    *AllocationPlan
+	PlanB    *AllocationPlan
+	PreviewA *AllocationPreview
+	PreviewB *AllocationPreview
+	Pockets  []*PocketComparison
+}
+
+// PocketComparison is a pocket's balance after the run under each plan
+type PocketComparison struct {
+	PocketID   primitive.ObjectID
+	Name       string
+	Before     float64
+	AfterA     float64
+	AfterB     float64
+	Difference float64 // AfterB - AfterA
+}
+
+// PreviewStatus constants
+const (
+	PreviewWouldExecute = "WOULD_EXECUTE"
+	PreviewWouldPartial = "WOULD_PARTIAL"
+	PreviewWouldSkip    = "WOULD_SKIP"
+	PreviewWouldFail    = "WOULD_FAIL"
+)
+
 type Priority int
 
 const (
@@ -46,6 +246,24 @@ func IsValidAllocationType(t string) bool {
 	}
 }
 
+func IsValidPercentageBase(b string) bool {
+	switch b {
+	case string(BasePayroll), string(BaseMainPocketBalance), string(BaseIncomeTransaction):
+		return true
+	default:
+		return false
+	}
+}
+
+func IsValidTriggerType(t string) bool {
+	switch t {
+	case string(TriggerScheduled), string(TriggerOnIncome):
+		return true
+	default:
+		return false
+	}
+}
+
 func IsValidPriority(p int) bool {
 	return p >= 1 && p <= 3
 }
@@ -1,10 +1,15 @@
 package allocation
 
 import (
+	"context"
+
 	"github.com/HasanNugroho/coin-be/internal/core/config"
+	"github.com/HasanNugroho/coin-be/internal/modules/holiday"
+	"github.com/HasanNugroho/coin-be/internal/modules/notification"
 	"github.com/HasanNugroho/coin-be/internal/modules/pocket"
 	"github.com/HasanNugroho/coin-be/internal/modules/transaction"
 	"github.com/HasanNugroho/coin-be/internal/modules/user"
+	"github.com/HasanNugroho/coin-be/internal/modules/user_category"
 	"github.com/HasanNugroho/coin-be/internal/modules/user_platform"
 	"github.com/sarulabs/di/v2"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -16,7 +21,13 @@ func Register(builder *di.Builder) {
 		Build: func(ctn di.Container) (interface{}, error) {
 			cfg := ctn.Get("config").(*config.Config)
 			client := ctn.Get("mongo").(*mongo.Client)
-			return NewRepository(client.Database(cfg.MongoDB)), nil
+			repo := NewRepository(client.Database(cfg.MongoDB))
+
+			if err := repo.EnsureIndexes(context.Background()); err != nil {
+				return nil, err
+			}
+
+			return repo, nil
 		},
 	})
 
@@ -31,7 +42,16 @@ func Register(builder *di.Builder) {
 			userPlatformRepo := ctn.Get("userPlatformRepository").(*user_platform.UserPlatformRepository)
 			userRepo := ctn.Get("userRepository").(*user.Repository)
 			transactionRepo := ctn.Get("transactionRepository").(*transaction.Repository)
-			return NewService(repo, pocketRepo, userPlatformRepo, userRepo, transactionRepo, db), nil
+			categoryRepo := ctn.Get("userCategoryRepository").(*user_category.Repository)
+			notificationService := ctn.Get("notificationService").(*notification.Service)
+			holidayService := ctn.Get("holidayService").(*holiday.Service)
+			service := NewService(repo, pocketRepo, userPlatformRepo, userRepo, transactionRepo, categoryRepo, notificationService, holidayService, db)
+
+			// Income-triggered allocations fire on every income created through the transaction service
+			transactionService := ctn.Get("transactionService").(*transaction.Service)
+			transactionService.OnIncome(service.HandleIncome)
+
+			return service, nil
 		},
 	})
 
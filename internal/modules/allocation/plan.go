@@ -0,0 +1,316 @@
+package allocation
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math"
+
+	"github.com/HasanNugroho/coin-be/internal/modules/allocation/dto"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// CreatePlan creates an inactive plan. A user's first plan starts a Default plan holding their existing
+// allocations, so the rules that ran before keep running until another plan is activated.
+func (s *Service) CreatePlan(ctx context.Context, userID string, req *dto.CreatePlanRequest) (*AllocationPlan, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, errors.New("invalid user id")
+	}
+
+	if _, err := s.ensurePlans(ctx, userObjID); err != nil {
+		return nil, err
+	}
+
+	plan := &AllocationPlan{
+		UserID:      userObjID,
+		Name:        req.Name,
+		Description: stringPtr(req.Description),
+	}
+
+	if err := s.repo.CreatePlan(ctx, plan); err != nil {
+		return nil, err
+	}
+
+	return plan, nil
+}
+
+// ListPlans returns the user's plans, oldest first
+func (s *Service) ListPlans(ctx context.Context, userID string) ([]*AllocationPlan, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, errors.New("invalid user id")
+	}
+
+	if _, err := s.ensurePlans(ctx, userObjID); err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetPlansByUserID(ctx, userObjID)
+}
+
+func (s *Service) GetPlan(ctx context.Context, userID string, planID string) (*AllocationPlan, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, errors.New("invalid user id")
+	}
+
+	return s.getPlan(ctx, userObjID, planID)
+}
+
+func (s *Service) UpdatePlan(ctx context.Context, userID string, planID string, req *dto.UpdatePlanRequest) (*AllocationPlan, error) {
+	plan, err := s.GetPlan(ctx, userID, planID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != "" {
+		plan.Name = req.Name
+	}
+	if req.Description != nil {
+		plan.Description = stringPtr(*req.Description)
+	}
+
+	if err := s.repo.UpdatePlan(ctx, plan.ID, plan); err != nil {
+		return nil, err
+	}
+
+	return plan, nil
+}
+
+// DeletePlan deletes an inactive plan together with its allocations
+func (s *Service) DeletePlan(ctx context.Context, userID string, planID string) error {
+	plan, err := s.GetPlan(ctx, userID, planID)
+	if err != nil {
+		return err
+	}
+
+	if plan.IsActive {
+		return errors.New("cannot delete the active plan")
+	}
+
+	return s.repo.DeletePlan(ctx, plan.ID)
+}
+
+// ActivatePlan switches the user to a plan. From then on only its allocations run.
+func (s *Service) ActivatePlan(ctx context.Context, userID string, planID string) (*AllocationPlan, error) {
+	plan, err := s.GetPlan(ctx, userID, planID)
+	if err != nil {
+		return nil, err
+	}
+
+	if plan.IsActive {
+		return plan, nil
+	}
+
+	if err := s.repo.ActivatePlan(ctx, plan.UserID, plan.ID); err != nil {
+		return nil, err
+	}
+
+	plan.IsActive = true
+	return plan, nil
+}
+
+// ClonePlan copies a plan and all of its allocations into a new inactive plan
+func (s *Service) ClonePlan(ctx context.Context, userID string, planID string, req *dto.ClonePlanRequest) (*AllocationPlan, error) {
+	source, err := s.GetPlan(ctx, userID, planID)
+	if err != nil {
+		return nil, err
+	}
+
+	allocations, err := s.repo.GetAllocationsByUserID(ctx, source.UserID, &source.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &AllocationPlan{
+		UserID:      source.UserID,
+		Name:        req.Name,
+		Description: source.Description,
+	}
+
+	if err := s.repo.CreatePlan(ctx, plan); err != nil {
+		return nil, err
+	}
+
+	for _, allocation := range allocations {
+		clone := *allocation
+		clone.PlanID = &plan.ID
+		if err := s.repo.CreateAllocation(ctx, &clone); err != nil {
+			log.Printf("failed to clone allocation %s into plan %s: %v", allocation.ID.Hex(), plan.ID.Hex(), err)
+			return nil, err
+		}
+	}
+
+	return plan, nil
+}
+
+// ComparePlans previews the same hypothetical income under two plans and lines up the resulting balance of
+// every pocket either plan touches
+func (s *Service) ComparePlans(ctx context.Context, userID string, req *dto.ComparePlansRequest) (*PlanComparison, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, errors.New("invalid user id")
+	}
+
+	planA, err := s.getPlan(ctx, userObjID, req.PlanAID)
+	if err != nil {
+		return nil, err
+	}
+
+	planB, err := s.getPlan(ctx, userObjID, req.PlanBID)
+	if err != nil {
+		return nil, err
+	}
+
+	previewReq := &dto.PreviewAllocationsRequest{
+		IncomeAmount:   req.IncomeAmount,
+		Date:           req.Date,
+		UserPlatformID: req.UserPlatformID,
+		CategoryID:     req.CategoryID,
+	}
+
+	previewA, err := s.previewPlan(ctx, planA, previewReq)
+	if err != nil {
+		return nil, err
+	}
+
+	previewB, err := s.previewPlan(ctx, planB, previewReq)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PlanComparison{
+		PlanA:    planA,
+		PlanB:    planB,
+		PreviewA: previewA,
+		PreviewB: previewB,
+		Pockets:  comparePockets(previewA, previewB),
+	}, nil
+}
+
+func (s *Service) previewPlan(ctx context.Context, plan *AllocationPlan, req *dto.PreviewAllocationsRequest) (*AllocationPreview, error) {
+	allocations, err := s.repo.GetActiveAllocationsByPlan(ctx, plan.UserID, &plan.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.previewAllocations(ctx, plan.UserID, allocations, req)
+}
+
+// comparePockets lines up pocket balances of two previews. A pocket only one plan touches keeps its balance
+// under the other.
+func comparePockets(a *AllocationPreview, b *AllocationPreview) []*PocketComparison {
+	pockets := make([]*PocketComparison, 0)
+	byID := make(map[primitive.ObjectID]*PocketComparison)
+
+	collect := func(preview *AllocationPreview) {
+		for _, balance := range preview.Balances {
+			if balance.EntityType != "pocket" {
+				continue
+			}
+			if _, ok := byID[balance.ID]; ok {
+				continue
+			}
+			entry := &PocketComparison{
+				PocketID: balance.ID,
+				Name:     balance.Name,
+				Before:   balance.Before,
+				AfterA:   balance.Before,
+				AfterB:   balance.Before,
+			}
+			byID[balance.ID] = entry
+			pockets = append(pockets, entry)
+		}
+	}
+	collect(a)
+	collect(b)
+
+	for _, balance := range a.Balances {
+		if entry, ok := byID[balance.ID]; ok && balance.EntityType == "pocket" {
+			entry.AfterA = balance.After
+		}
+	}
+	for _, balance := range b.Balances {
+		if entry, ok := byID[balance.ID]; ok && balance.EntityType == "pocket" {
+			entry.AfterB = balance.After
+		}
+	}
+
+	for _, entry := range pockets {
+		entry.Difference = math.Round((entry.AfterB-entry.AfterA)*100) / 100
+	}
+
+	return pockets
+}
+
+// getPlan returns one of the user's plans
+func (s *Service) getPlan(ctx context.Context, userID primitive.ObjectID, planID string) (*AllocationPlan, error) {
+	planObjID, err := primitive.ObjectIDFromHex(planID)
+	if err != nil {
+		return nil, errors.New("invalid plan id")
+	}
+
+	plan, err := s.repo.GetPlanByID(ctx, planObjID)
+	if err != nil {
+		return nil, err
+	}
+
+	if plan.UserID != userID {
+		return nil, errors.New("unauthorized")
+	}
+
+	return plan, nil
+}
+
+// activePlanID returns the id of the user's active plan, or nil for a user who has no plans yet and whose
+// allocations all run
+func (s *Service) activePlanID(ctx context.Context, userID primitive.ObjectID) (*primitive.ObjectID, error) {
+	plan, err := s.repo.GetActivePlan(ctx, userID)
+	if err != nil || plan == nil {
+		return nil, err
+	}
+	return &plan.ID, nil
+}
+
+// ensurePlans returns the user's active plan. A user who has no plans yet gets an active Default plan holding
+// their existing allocations.
+func (s *Service) ensurePlans(ctx context.Context, userID primitive.ObjectID) (*AllocationPlan, error) {
+	plan, err := s.repo.GetActivePlan(ctx, userID)
+	if err != nil || plan != nil {
+		return plan, err
+	}
+
+	plans, err := s.repo.GetPlansByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(plans) > 0 {
+		// Plans exist but none is active; fall back to the oldest
+		if err := s.repo.ActivatePlan(ctx, userID, plans[0].ID); err != nil {
+			return nil, err
+		}
+		plans[0].IsActive = true
+		return plans[0], nil
+	}
+
+	plan = &AllocationPlan{
+		UserID:   userID,
+		Name:     DefaultPlanName,
+		IsActive: true,
+	}
+	if err := s.repo.CreatePlan(ctx, plan); err != nil {
+		// Another request created the plan first
+		if active, getErr := s.repo.GetActivePlan(ctx, userID); getErr == nil && active != nil {
+			return active, nil
+		}
+		return nil, err
+	}
+
+	if err := s.repo.AssignUnplannedAllocations(ctx, userID, plan.ID); err != nil {
+		return nil, err
+	}
+
+	return plan, nil
+}
@@ -0,0 +1,323 @@
+package allocation
+
+import (
+	"context"
+	"errors"
+	"math"
+	"time"
+
+	"github.com/HasanNugroho/coin-be/internal/core/utils"
+	"github.com/HasanNugroho/coin-be/internal/modules/allocation/dto"
+	"github.com/HasanNugroho/coin-be/internal/modules/holiday"
+	"github.com/HasanNugroho/coin-be/internal/modules/pocket"
+	"github.com/HasanNugroho/coin-be/internal/modules/user_platform"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// PreviewAllocations simulates crediting a hypothetical income on the given date and running every allocation
+// of the active plan that would fire for it, in priority order. Scheduled allocations fire when their
+// execute_day falls on the date, income-triggered ones when the income passes their filter. Funding follows
+// the same priority waterfall and shortfall policy as a real run. Nothing is written.
+func (s *Service) PreviewAllocations(ctx context.Context, userID string, req *dto.PreviewAllocationsRequest) (*AllocationPreview, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, errors.New("invalid user id")
+	}
+
+	planID, err := s.activePlanID(ctx, userObjID)
+	if err != nil {
+		return nil, err
+	}
+
+	allocations, err := s.repo.GetActiveAllocationsByPlan(ctx, userObjID, planID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.previewAllocations(ctx, userObjID, allocations, req)
+}
+
+// previewAllocations simulates the hypothetical income of req against the given allocations
+func (s *Service) previewAllocations(ctx context.Context, userObjID primitive.ObjectID, allocations []*Allocation, req *dto.PreviewAllocationsRequest) (*AllocationPreview, error) {
+	if req.IncomeAmount <= 0 {
+		return nil, errors.New("income amount must be greater than 0")
+	}
+
+	date, err := time.Parse("2006-01-02", req.Date)
+	if err != nil {
+		return nil, errors.New("invalid date format, expected YYYY-MM-DD")
+	}
+
+	var incomeUserPlatformID *primitive.ObjectID
+	if req.UserPlatformID != "" {
+		id, err := primitive.ObjectIDFromHex(req.UserPlatformID)
+		if err != nil {
+			return nil, errors.New("invalid user platform id")
+		}
+		incomeUserPlatformID = &id
+	}
+
+	var incomeCategoryID *primitive.ObjectID
+	if req.CategoryID != "" {
+		id, err := primitive.ObjectIDFromHex(req.CategoryID)
+		if err != nil {
+			return nil, errors.New("invalid category id")
+		}
+		incomeCategoryID = &id
+	}
+
+	mainPocket, err := s.getMainPocket(ctx, userObjID)
+	if err != nil {
+		return nil, err
+	}
+
+	var defaultUserPlatformID *primitive.ObjectID
+	if profile, err := s.userRepo.GetUserProfileByUserID(ctx, userObjID); err == nil {
+		defaultUserPlatformID = profile.DefaultUserPlatformID
+	}
+
+	balances := newPreviewBalances()
+	mainBalance := balances.pocket(mainPocket)
+
+	preview := &AllocationPreview{
+		Date:                    date,
+		IncomeAmount:            req.IncomeAmount,
+		MainPocketBalanceBefore: mainBalance.Before,
+		Items:                   make([]*AllocationPreviewItem, 0),
+	}
+
+	// Credit the hypothetical income the same way payroll or a manual income would
+	mainBalance.After += req.IncomeAmount
+	incomePlatformID := defaultUserPlatformID
+	if incomeUserPlatformID != nil {
+		incomePlatformID = incomeUserPlatformID
+	}
+	if incomePlatformID != nil {
+		if platform, err := s.userPlatformRepo.GetUserPlatformByID(ctx, *incomePlatformID); err == nil && platform.UserID == userObjID {
+			balances.userPlatform(platform).After += req.IncomeAmount
+		}
+	}
+
+	// Percentages of the main pocket are taken from the balance before the run starts
+	mainBalanceAtStart := mainBalance.After
+	cal, err := s.holidayService.CalendarAround(ctx, date)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]*previewEntry, 0)
+	funding := make([]*waterfallItem, 0)
+
+	for _, allocation := range allocations {
+		if !firesOn(allocation, date, cal, req.IncomeAmount, incomeUserPlatformID, incomeCategoryID) {
+			continue
+		}
+
+		sourceUserPlatformID := defaultUserPlatformID
+		if allocation.TriggerType == string(TriggerOnIncome) && incomeUserPlatformID != nil {
+			sourceUserPlatformID = incomeUserPlatformID
+		}
+
+		entry := &previewEntry{item: &AllocationPreviewItem{Allocation: allocation}}
+		err := s.loadPreviewEntry(ctx, entry, req.IncomeAmount, mainBalanceAtStart, sourceUserPlatformID)
+		entries = append(entries, entry)
+		funding = append(funding, &waterfallItem{allocation: allocation, requested: entry.item.Amount, err: err})
+	}
+
+	planFunding(funding, mainBalanceAtStart, s.getShortfallPolicy(ctx, userObjID))
+
+	for i, entry := range entries {
+		item := entry.item
+		preview.Items = append(preview.Items, item)
+
+		if err := funding[i].err; err != nil {
+			reason := err.Error()
+			item.Status = PreviewWouldFail
+			item.Reason = &reason
+			continue
+		}
+
+		item.FundedAmount = funding[i].funded
+		item.Shortfall = math.Round((item.Amount-item.FundedAmount)*100) / 100
+
+		switch {
+		case item.Shortfall <= 0:
+			item.Status = PreviewWouldExecute
+		case item.FundedAmount > 0:
+			item.Status = PreviewWouldPartial
+		default:
+			item.Status = PreviewWouldSkip
+		}
+		if item.Shortfall > 0 {
+			reason := "insufficient main pocket balance"
+			item.Reason = &reason
+		}
+
+		preview.TotalAllocated += item.FundedAmount
+		mainBalance.After -= item.FundedAmount
+		balances.userPlatform(entry.source).After -= item.FundedAmount
+		if entry.targetPocket != nil {
+			balances.pocket(entry.targetPocket).After += item.FundedAmount
+		}
+		if entry.targetPlatform != nil {
+			balances.userPlatform(entry.targetPlatform).After += item.FundedAmount
+		}
+	}
+
+	preview.MainPocketBalanceAfter = mainBalance.After
+	preview.Balances = balances.list()
+
+	return preview, nil
+}
+
+// previewEntry is an allocation of the preview with the entities its money would move between
+type previewEntry struct {
+	item           *AllocationPreviewItem
+	source         *user_platform.UserPlatform
+	targetPocket   *pocket.Pocket
+	targetPlatform *user_platform.UserPlatform
+}
+
+// loadPreviewEntry resolves the requested amount of one allocation and loads its source and targets, failing for
+// the same reasons a real execution would
+func (s *Service) loadPreviewEntry(ctx context.Context, entry *previewEntry, incomeAmount float64, mainBalanceAtStart float64, sourceUserPlatformID *primitive.ObjectID) error {
+	allocation := entry.item.Allocation
+
+	if err := s.previewAmount(ctx, entry.item, incomeAmount, mainBalanceAtStart); err != nil {
+		return err
+	}
+
+	if sourceUserPlatformID == nil {
+		return errors.New("no default user platform configured")
+	}
+
+	sourcePlatform, err := s.userPlatformRepo.GetUserPlatformByID(ctx, *sourceUserPlatformID)
+	if err != nil {
+		return errors.New("default user platform not found")
+	}
+
+	if !sourcePlatform.IsActive || sourcePlatform.UserID != allocation.UserID {
+		return errors.New("default user platform is not active")
+	}
+	entry.source = sourcePlatform
+
+	if _, _, err := s.resolveTargets(ctx, allocation); err != nil {
+		return err
+	}
+
+	if allocation.PocketID != nil {
+		if entry.targetPocket, err = s.pocketRepo.GetPocketByID(ctx, *allocation.PocketID); err != nil {
+			return errors.New("target pocket is invalid")
+		}
+	}
+
+	if allocation.UserPlatformID != nil {
+		if entry.targetPlatform, err = s.userPlatformRepo.GetUserPlatformByID(ctx, *allocation.UserPlatformID); err != nil {
+			return errors.New("target user platform is invalid")
+		}
+	}
+
+	return nil
+}
+
+// previewAmount mirrors resolveAmount with the hypothetical income standing in for the payroll and for the
+// income that fires ON_INCOME allocations
+func (s *Service) previewAmount(ctx context.Context, item *AllocationPreviewItem, incomeAmount float64, mainBalanceAtStart float64) error {
+	allocation := item.Allocation
+
+	if allocation.AllocationType != string(TypePercentage) {
+		item.Amount = allocation.Nominal
+		return nil
+	}
+
+	baseType := allocation.PercentageBase
+	if baseType == "" {
+		baseType = string(BasePayroll)
+	}
+
+	base := incomeAmount
+	switch {
+	case allocation.TriggerType == string(TriggerOnIncome):
+		baseType = string(BaseIncomeTransaction)
+	case baseType == string(BaseMainPocketBalance):
+		base = mainBalanceAtStart
+	case baseType == string(BaseIncomeTransaction):
+		if allocation.BaseTransactionID == nil {
+			return errors.New("no base transaction configured")
+		}
+		tx, err := s.getIncomeTransaction(ctx, allocation.UserID, *allocation.BaseTransactionID)
+		if err != nil {
+			return err
+		}
+		base = tx.Amount
+	}
+
+	item.PercentageBase = baseType
+	item.BaseAmount = &base
+	item.Amount = math.Round(base*allocation.Nominal) / 100
+
+	if item.Amount <= 0 {
+		return errors.New("computed allocation amount is zero")
+	}
+
+	return nil
+}
+
+// firesOn reports whether an allocation would run for an income of the given amount, platform and category
+// credited on the given date
+func firesOn(allocation *Allocation, date time.Time, cal *holiday.Calendar, amount float64, userPlatformID *primitive.ObjectID, categoryID *primitive.ObjectID) bool {
+	if allocation.TriggerType == string(TriggerOnIncome) {
+		return allocation.IncomeFilter.Matches(amount, userPlatformID, categoryID)
+	}
+
+	if allocation.ExecuteDay == nil {
+		return false
+	}
+
+	// Days beyond the end of a short month run on its last day, and weekends and holidays move by the adjustment
+	return cal.FallsOn(*allocation.ExecuteDay, allocation.DayAdjustment, date)
+}
+
+// previewBalances tracks simulated balances in the order the entities were first touched
+type previewBalances struct {
+	entries map[string]*PreviewBalance
+	order   []*PreviewBalance
+}
+
+func newPreviewBalances() *previewBalances {
+	return &previewBalances{entries: make(map[string]*PreviewBalance)}
+}
+
+func (b *previewBalances) get(entityType string, id primitive.ObjectID, name string, balance primitive.Decimal128) *PreviewBalance {
+	key := entityType + "_" + id.Hex()
+	if entry, ok := b.entries[key]; ok {
+		return entry
+	}
+
+	amount := utils.Decimal128ToFloat64(balance)
+	entry := &PreviewBalance{EntityType: entityType, ID: id, Name: name, Before: amount, After: amount}
+	b.entries[key] = entry
+	b.order = append(b.order, entry)
+	return entry
+}
+
+func (b *previewBalances) pocket(p *pocket.Pocket) *PreviewBalance {
+	return b.get("pocket", p.ID, p.Name, p.Balance)
+}
+
+func (b *previewBalances) userPlatform(up *user_platform.UserPlatform) *PreviewBalance {
+	name := ""
+	if up.AliasName != nil {
+		name = *up.AliasName
+	}
+	return b.get("user_platform", up.ID, name, up.Balance)
+}
+
+// mainPocket returns the main pocket, which is always the first entry
+func (b *previewBalances) mainPocket() *PreviewBalance {
+	return b.order[0]
+}
+
+func (b *previewBalances) list() []*PreviewBalance {
+	return b.order
+}
@@ -3,8 +3,11 @@ package allocation
 import (
 	"context"
 	"errors"
+	"math"
 	"time"
 
+	"github.com/HasanNugroho/coin-be/internal/modules/holiday"
+	"github.com/HasanNugroho/coin-be/internal/modules/transaction"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -12,12 +15,20 @@ import (
 )
 
 type Repository struct {
-	allocations *mongo.Collection
+	allocations  *mongo.Collection
+	plans        *mongo.Collection
+	executions   *mongo.Collection
+	shortfalls   *mongo.Collection
+	transactions *mongo.Collection
 }
 
 func NewRepository(db *mongo.Database) *Repository {
 	return &Repository{
-		allocations: db.Collection("allocations"),
+		allocations:  db.Collection("allocations"),
+		plans:        db.Collection("allocation_plans"),
+		executions:   db.Collection("allocation_executions"),
+		shortfalls:   db.Collection("allocation_shortfalls"),
+		transactions: db.Collection("transactions"),
 	}
 }
 
@@ -41,8 +52,14 @@ func (r *Repository) GetAllocationByID(ctx context.Context, id primitive.ObjectI
 	return &allocation, nil
 }
 
-func (r *Repository) GetAllocationsByUserID(ctx context.Context, userID primitive.ObjectID) ([]*Allocation, error) {
-	cursor, err := r.allocations.Find(ctx, bson.M{"user_id": userID, "deleted_at": nil})
+// GetAllocationsByUserID returns the user's allocations, only those of planID when it is given
+func (r *Repository) GetAllocationsByUserID(ctx context.Context, userID primitive.ObjectID, planID *primitive.ObjectID) ([]*Allocation, error) {
+	filter := bson.M{"user_id": userID, "deleted_at": nil}
+	if planID != nil {
+		filter["plan_id"] = *planID
+	}
+
+	cursor, err := r.allocations.Find(ctx, filter)
 	if err != nil {
 		return nil, err
 	}
@@ -55,10 +72,13 @@ func (r *Repository) GetAllocationsByUserID(ctx context.Context, userID primitiv
 	return allocations, nil
 }
 
-func (r *Repository) GetActiveAllocationsByUserID(ctx context.Context, userID primitive.ObjectID) ([]*Allocation, error) {
+// GetActiveAllocationsByPlan returns the active allocations of one of the user's plans in priority order.
+// A nil planID selects the allocations of a user who has no plans yet.
+func (r *Repository) GetActiveAllocationsByPlan(ctx context.Context, userID primitive.ObjectID, planID *primitive.ObjectID) ([]*Allocation, error) {
 	opts := options.Find().SetSort(bson.D{{Key: "priority", Value: 1}})
 	cursor, err := r.allocations.Find(ctx, bson.M{
 		"user_id":    userID,
+		"plan_id":    planID,
 		"is_active":  true,
 		"deleted_at": nil,
 	}, opts)
@@ -111,26 +131,33 @@ func (r *Repository) DeleteAllocation(ctx context.Context, id primitive.ObjectID
 	return nil
 }
 
-// GetAllocationsByExecuteDayWithOverflow fetches allocations for a specific day, including overflow allocations
-// that should execute on the last day of the month if their execute_day exceeds the month's length
-func (r *Repository) GetAllocationsByExecuteDayWithOverflow(ctx context.Context, executeDay int, lastDayOfMonth int) ([]map[string]interface{}, error) {
-	// Build match conditions
-	matchConditions := []bson.D{
-		{{Key: "execute_day", Value: executeDay}},
-	}
+// GetAllocationsDueOn fetches scheduled allocations whose execute day falls on a date, given the execute days
+// that fall on it under each day adjustment policy
+func (r *Repository) GetAllocationsDueOn(ctx context.Context, dueDays map[string][]int) ([]map[string]interface{}, error) {
+	matchConditions := bson.A{}
+	for adjustment, days := range dueDays {
+		// Allocations created before adjustments were added have none and are not moved
+		adjustments := bson.A{adjustment}
+		if adjustment == holiday.AdjustNone {
+			adjustments = append(adjustments, "", nil)
+		}
 
-	// If today is the last day of the month, also include allocations scheduled for days beyond this month
-	if executeDay == lastDayOfMonth && lastDayOfMonth < 31 {
 		matchConditions = append(matchConditions, bson.D{
-			{Key: "execute_day", Value: bson.D{{Key: "$gt", Value: lastDayOfMonth}}},
+			{Key: "day_adjustment", Value: bson.D{{Key: "$in", Value: adjustments}}},
+			{Key: "execute_day", Value: bson.D{{Key: "$in", Value: days}}},
 		})
 	}
 
+	if len(matchConditions) == 0 {
+		return nil, nil
+	}
+
 	pipeline := mongo.Pipeline{
 		{{Key: "$match", Value: bson.D{
 			{Key: "$or", Value: matchConditions},
 			{Key: "is_active", Value: true},
 			{Key: "deleted_at", Value: nil},
+			{Key: "trigger_type", Value: bson.D{{Key: "$ne", Value: string(TriggerOnIncome)}}},
 		}}},
 		{{Key: "$lookup", Value: bson.D{
 			{Key: "from", Value: "users"},
@@ -156,16 +183,20 @@ func (r *Repository) GetAllocationsByExecuteDayWithOverflow(ctx context.Context,
 			{Key: "user.is_active", Value: true},
 			{Key: "user_profile.is_active", Value: true},
 		}}},
-		// Normalize execute_day for overflow allocations
-		{{Key: "$addFields", Value: bson.D{
-			{Key: "execute_day", Value: bson.D{
-				{Key: "$cond", Value: bson.A{
-					bson.D{{Key: "$gt", Value: bson.A{"$execute_day", lastDayOfMonth}}},
-					lastDayOfMonth,
-					"$execute_day",
-				}},
+		// Only rules of the user's active plan run, or every rule of a user who has no plans yet
+		{{Key: "$lookup", Value: bson.D{
+			{Key: "from", Value: "allocation_plans"},
+			{Key: "localField", Value: "plan_id"},
+			{Key: "foreignField", Value: "_id"},
+			{Key: "as", Value: "plan"},
+		}}},
+		{{Key: "$match", Value: bson.D{
+			{Key: "$or", Value: bson.A{
+				bson.D{{Key: "plan_id", Value: nil}},
+				bson.D{{Key: "plan.is_active", Value: true}, {Key: "plan.deleted_at", Value: nil}},
 			}},
 		}}},
+		{{Key: "$unset", Value: "plan"}},
 	}
 
 	cursor, err := r.allocations.Aggregate(ctx, pipeline)
@@ -196,9 +227,10 @@ func (r *Repository) GetAllocationsByExecuteDay(ctx context.Context, executeDay
 	}
 
 	cursor, err := r.allocations.Find(ctx, bson.M{
-		"$or":        matchConditions,
-		"is_active":  true,
-		"deleted_at": nil,
+		"$or":          matchConditions,
+		"is_active":    true,
+		"deleted_at":   nil,
+		"trigger_type": bson.M{"$ne": string(TriggerOnIncome)},
 	})
 	if err != nil {
 		return nil, err
@@ -219,3 +251,453 @@ func (r *Repository) GetAllocationsByExecuteDay(ctx context.Context, executeDay
 
 	return allocations, nil
 }
+
+// GetIncomeTriggeredAllocations returns the active ON_INCOME allocations of one of the user's plans in priority
+// order. A nil planID selects the allocations of a user who has no plans yet.
+func (r *Repository) GetIncomeTriggeredAllocations(ctx context.Context, userID primitive.ObjectID, planID *primitive.ObjectID) ([]*Allocation, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "priority", Value: 1}, {Key: "created_at", Value: 1}})
+	cursor, err := r.allocations.Find(ctx, bson.M{
+		"user_id":      userID,
+		"plan_id":      planID,
+		"trigger_type": string(TriggerOnIncome),
+		"is_active":    true,
+		"deleted_at":   nil,
+	}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var allocations []*Allocation
+	if err = cursor.All(ctx, &allocations); err != nil {
+		return nil, err
+	}
+	return allocations, nil
+}
+
+// SumActivePercentages totals the percentages of the active PERCENTAGE allocations in one of the user's plans
+// with the same trigger type, leaving out excludeID. Scheduled and income-triggered allocations take from
+// different amounts, so each group is capped at 100 on its own.
+func (r *Repository) SumActivePercentages(ctx context.Context, userID primitive.ObjectID, planID *primitive.ObjectID, triggerType TriggerType, excludeID *primitive.ObjectID) (float64, error) {
+	match := bson.M{
+		"user_id":         userID,
+		"plan_id":         planID,
+		"allocation_type": string(TypePercentage),
+		"is_active":       true,
+		"deleted_at":      nil,
+	}
+	if triggerType == TriggerOnIncome {
+		match["trigger_type"] = string(TriggerOnIncome)
+	} else {
+		match["trigger_type"] = bson.M{"$ne": string(TriggerOnIncome)}
+	}
+	if excludeID != nil {
+		match["_id"] = bson.M{"$ne": *excludeID}
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$group", Value: bson.M{
+			"_id":   nil,
+			"total": bson.M{"$sum": "$nominal"},
+		}}},
+	}
+
+	cursor, err := r.allocations.Aggregate(ctx, pipeline)
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var results []struct {
+		Total float64 `bson:"total"`
+	}
+	if err = cursor.All(ctx, &results); err != nil {
+		return 0, err
+	}
+
+	if len(results) == 0 {
+		return 0, nil
+	}
+	return results[0].Total, nil
+}
+
+func (r *Repository) CreatePlan(ctx context.Context, plan *AllocationPlan) error {
+	plan.ID = primitive.NewObjectID()
+	plan.CreatedAt = time.Now()
+	plan.UpdatedAt = time.Now()
+	_, err := r.plans.InsertOne(ctx, plan)
+	return err
+}
+
+func (r *Repository) GetPlanByID(ctx context.Context, id primitive.ObjectID) (*AllocationPlan, error) {
+	var plan AllocationPlan
+	err := r.plans.FindOne(ctx, bson.M{"_id": id, "deleted_at": nil}).Decode(&plan)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.New("allocation plan not found")
+		}
+		return nil, err
+	}
+	return &plan, nil
+}
+
+func (r *Repository) GetPlansByUserID(ctx context.Context, userID primitive.ObjectID) ([]*AllocationPlan, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+	cursor, err := r.plans.Find(ctx, bson.M{"user_id": userID, "deleted_at": nil}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var plans []*AllocationPlan
+	if err = cursor.All(ctx, &plans); err != nil {
+		return nil, err
+	}
+	return plans, nil
+}
+
+// GetActivePlan returns the user's active plan, or nil when the user has no plans yet
+func (r *Repository) GetActivePlan(ctx context.Context, userID primitive.ObjectID) (*AllocationPlan, error) {
+	var plan AllocationPlan
+	err := r.plans.FindOne(ctx, bson.M{"user_id": userID, "is_active": true, "deleted_at": nil}).Decode(&plan)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &plan, nil
+}
+
+func (r *Repository) UpdatePlan(ctx context.Context, id primitive.ObjectID, plan *AllocationPlan) error {
+	plan.UpdatedAt = time.Now()
+	result, err := r.plans.UpdateOne(
+		ctx,
+		bson.M{"_id": id, "deleted_at": nil},
+		bson.M{"$set": plan},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errors.New("allocation plan not found")
+	}
+	return nil
+}
+
+// DeletePlan soft deletes a plan together with its allocations
+func (r *Repository) DeletePlan(ctx context.Context, id primitive.ObjectID) error {
+	now := time.Now()
+	update := bson.M{
+		"$set": bson.M{
+			"deleted_at": now,
+			"updated_at": now,
+		},
+	}
+
+	result, err := r.plans.UpdateOne(ctx, bson.M{"_id": id, "deleted_at": nil}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errors.New("allocation plan not found")
+	}
+
+	_, err = r.allocations.UpdateMany(ctx, bson.M{"plan_id": id, "deleted_at": nil}, update)
+	return err
+}
+
+// ActivatePlan makes planID the user's only active plan. Both writes run in one database transaction so the
+// user never ends up with no active plan or with two.
+func (r *Repository) ActivatePlan(ctx context.Context, userID primitive.ObjectID, planID primitive.ObjectID) error {
+	session, err := r.plans.Database().Client().StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sessionCtx mongo.SessionContext) (interface{}, error) {
+		now := time.Now()
+		if _, err := r.plans.UpdateMany(sessionCtx,
+			bson.M{"user_id": userID, "is_active": true, "_id": bson.M{"$ne": planID}},
+			bson.M{"$set": bson.M{"is_active": false, "updated_at": now}},
+		); err != nil {
+			return nil, err
+		}
+
+		result, err := r.plans.UpdateOne(sessionCtx,
+			bson.M{"_id": planID, "user_id": userID, "deleted_at": nil},
+			bson.M{"$set": bson.M{"is_active": true, "updated_at": now}},
+		)
+		if err != nil {
+			return nil, err
+		}
+		if result.MatchedCount == 0 {
+			return nil, errors.New("allocation plan not found")
+		}
+		return nil, nil
+	})
+	return err
+}
+
+// AssignUnplannedAllocations moves the user's allocations that belong to no plan into planID
+func (r *Repository) AssignUnplannedAllocations(ctx context.Context, userID primitive.ObjectID, planID primitive.ObjectID) error {
+	_, err := r.allocations.UpdateMany(ctx,
+		bson.M{"user_id": userID, "plan_id": nil, "deleted_at": nil},
+		bson.M{"$set": bson.M{"plan_id": planID, "updated_at": time.Now()}},
+	)
+	return err
+}
+
+// GetLatestPayrollIncome returns the most recent payroll income credited to the user since the given time,
+// or nil when there is none
+func (r *Repository) GetLatestPayrollIncome(ctx context.Context, userID primitive.ObjectID, since time.Time) (*transaction.Transaction, error) {
+	filter := bson.M{
+		"user_id":    userID,
+		"type":       string(transaction.TypeIncome),
+		"ref":        bson.M{"$regex": "^payroll_"},
+		"date":       bson.M{"$gte": since},
+		"deleted_at": nil,
+	}
+	opts := options.FindOne().SetSort(bson.M{"date": -1})
+
+	var tx transaction.Transaction
+	err := r.transactions.FindOne(ctx, filter, opts).Decode(&tx)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &tx, nil
+}
+
+// CreateExecution claims a run of an allocation. It returns false when the same run (allocation, day and
+// triggering income) has already been recorded.
+func (r *Repository) CreateExecution(ctx context.Context, execution *AllocationExecution) (bool, error) {
+	execution.ID = primitive.NewObjectID()
+	execution.CreatedAt = time.Now()
+	_, err := r.executions.InsertOne(ctx, execution)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+func (r *Repository) UpdateExecution(ctx context.Context, execution *AllocationExecution) error {
+	_, err := r.executions.UpdateOne(ctx, bson.M{"_id": execution.ID}, bson.M{"$set": execution})
+	return err
+}
+
+func (r *Repository) GetExecutionByID(ctx context.Context, id primitive.ObjectID) (*AllocationExecution, error) {
+	var execution AllocationExecution
+	err := r.executions.FindOne(ctx, bson.M{"_id": id}).Decode(&execution)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.New("allocation execution not found")
+		}
+		return nil, err
+	}
+	return &execution, nil
+}
+
+// GetFailedExecutionOnDate returns the latest failed run of an allocation on the given day, or nil when there is none
+func (r *Repository) GetFailedExecutionOnDate(ctx context.Context, allocationID primitive.ObjectID, year, month, day int) (*AllocationExecution, error) {
+	opts := options.FindOne().SetSort(bson.D{{Key: "created_at", Value: -1}})
+
+	var execution AllocationExecution
+	err := r.executions.FindOne(ctx, bson.M{
+		"allocation_id": allocationID,
+		"year":          year,
+		"month":         month,
+		"day":           day,
+		"status":        ExecutionFailed,
+	}, opts).Decode(&execution)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &execution, nil
+}
+
+// GetDueRetries returns failed executions whose next retry is due
+func (r *Repository) GetDueRetries(ctx context.Context, now time.Time, limit int64) ([]*AllocationExecution, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "next_retry_at", Value: 1}}).SetLimit(limit)
+	cursor, err := r.executions.Find(ctx, bson.M{
+		"status":        ExecutionFailed,
+		"next_retry_at": bson.M{"$lte": now},
+	}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var executions []*AllocationExecution
+	if err = cursor.All(ctx, &executions); err != nil {
+		return nil, err
+	}
+	return executions, nil
+}
+
+// ClaimFailedExecution moves a failed execution back to PENDING for a re-run and counts the attempt.
+// It returns nil when the execution is no longer failed, so a run is never re-run twice at once.
+func (r *Repository) ClaimFailedExecution(ctx context.Context, id primitive.ObjectID) (*AllocationExecution, error) {
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var execution AllocationExecution
+	err := r.executions.FindOneAndUpdate(ctx,
+		bson.M{"_id": id, "status": ExecutionFailed},
+		bson.M{
+			"$set":   bson.M{"status": ExecutionPending},
+			"$unset": bson.M{"error": "", "next_retry_at": ""},
+			"$inc":   bson.M{"attempts": 1},
+		},
+		opts,
+	).Decode(&execution)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &execution, nil
+}
+
+func (r *Repository) GetExecutionsByAllocationID(ctx context.Context, allocationID primitive.ObjectID, limit int64) ([]*AllocationExecution, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}).SetLimit(limit)
+	cursor, err := r.executions.Find(ctx, bson.M{"allocation_id": allocationID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var executions []*AllocationExecution
+	if err = cursor.All(ctx, &executions); err != nil {
+		return nil, err
+	}
+	return executions, nil
+}
+
+func (r *Repository) CreateShortfall(ctx context.Context, shortfall *AllocationShortfall) error {
+	shortfall.ID = primitive.NewObjectID()
+	shortfall.Status = ShortfallOpen
+	shortfall.TopUpTransactionIDs = []primitive.ObjectID{}
+	shortfall.CreatedAt = time.Now()
+	shortfall.UpdatedAt = time.Now()
+	_, err := r.shortfalls.InsertOne(ctx, shortfall)
+	return err
+}
+
+// GetOpenShortfalls returns the user's open shortfalls, highest priority and oldest first
+func (r *Repository) GetOpenShortfalls(ctx context.Context, userID primitive.ObjectID) ([]*AllocationShortfall, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "priority", Value: 1}, {Key: "created_at", Value: 1}})
+	cursor, err := r.shortfalls.Find(ctx, bson.M{"user_id": userID, "status": ShortfallOpen}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var shortfalls []*AllocationShortfall
+	if err = cursor.All(ctx, &shortfalls); err != nil {
+		return nil, err
+	}
+	return shortfalls, nil
+}
+
+// ReduceShortfall records a top-up of amount. It only applies while the shortfall still has the remaining
+// amount it was read with, so concurrent top-ups cannot fund it twice; it returns false otherwise.
+func (r *Repository) ReduceShortfall(ctx context.Context, shortfall *AllocationShortfall, amount float64, transactionID primitive.ObjectID) (bool, error) {
+	now := time.Now()
+	remaining := math.Round((shortfall.Remaining-amount)*100) / 100
+
+	set := bson.M{"remaining": remaining, "updated_at": now}
+	if remaining <= 0 {
+		set["status"] = ShortfallSettled
+		set["settled_at"] = now
+	}
+
+	result, err := r.shortfalls.UpdateOne(ctx,
+		bson.M{"_id": shortfall.ID, "status": ShortfallOpen, "remaining": shortfall.Remaining},
+		bson.M{"$set": set, "$push": bson.M{"top_up_transaction_ids": transactionID}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount > 0, nil
+}
+
+func (r *Repository) CancelShortfall(ctx context.Context, id primitive.ObjectID) error {
+	_, err := r.shortfalls.UpdateOne(ctx,
+		bson.M{"_id": id, "status": ShortfallOpen},
+		bson.M{"$set": bson.M{"status": ShortfallCancelled, "updated_at": time.Now()}},
+	)
+	return err
+}
+
+func (r *Repository) EnsureIndexes(ctx context.Context) error {
+	indexes := []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				{Key: "allocation_id", Value: 1},
+				{Key: "created_at", Value: -1},
+			},
+			Options: options.Index().
+				SetName("idx_allocation_executions_allocation_created"),
+		},
+		{
+			// One run per allocation per day, or per triggering income for ON_INCOME allocations.
+			// Executions recorded before runs were dated have no year and are left out.
+			Keys: bson.D{
+				{Key: "allocation_id", Value: 1},
+				{Key: "year", Value: 1},
+				{Key: "month", Value: 1},
+				{Key: "day", Value: 1},
+				{Key: "income_transaction_id", Value: 1},
+			},
+			Options: options.Index().
+				SetName("idx_allocation_executions_run_unique").
+				SetUnique(true).
+				SetPartialFilterExpression(bson.M{"year": bson.M{"$exists": true}}),
+		},
+		{
+			Keys: bson.D{
+				{Key: "status", Value: 1},
+				{Key: "next_retry_at", Value: 1},
+			},
+			Options: options.Index().
+				SetName("idx_allocation_executions_retry"),
+		},
+	}
+
+	if _, err := r.executions.Indexes().CreateMany(ctx, indexes); err != nil {
+		return err
+	}
+
+	// At most one active plan per user
+	if _, err := r.plans.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "user_id", Value: 1}},
+		Options: options.Index().
+			SetName("idx_allocation_plans_user_active_unique").
+			SetUnique(true).
+			SetPartialFilterExpression(bson.M{"is_active": true}),
+	}); err != nil {
+		return err
+	}
+
+	_, err := r.shortfalls.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "user_id", Value: 1},
+			{Key: "status", Value: 1},
+			{Key: "priority", Value: 1},
+			{Key: "created_at", Value: 1},
+		},
+		Options: options.Index().SetName("idx_allocation_shortfalls_user_status"),
+	})
+	return err
+}
@@ -0,0 +1,136 @@
+package allocation
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// RetryFailedExecutions re-runs failed executions whose retry is due
+func (s *Service) RetryFailedExecutions(ctx context.Context) error {
+	executions, err := s.repo.GetDueRetries(ctx, time.Now(), 100)
+	if err != nil {
+		return err
+	}
+
+	for _, execution := range executions {
+		if _, err := s.rerunExecution(ctx, execution.ID); err != nil {
+			log.Printf("failed to retry allocation execution %s: %v", execution.ID.Hex(), err)
+		}
+	}
+
+	if len(executions) > 0 {
+		log.Printf("retried %d failed allocation executions", len(executions))
+	}
+	return nil
+}
+
+// RerunExecution re-runs the user's failed execution of an allocation on the given date (YYYY-MM-DD)
+func (s *Service) RerunExecution(ctx context.Context, userID string, allocationID string, date string) (*AllocationExecution, error) {
+	allocation, err := s.GetAllocationByID(ctx, userID, allocationID)
+	if err != nil {
+		return nil, err
+	}
+
+	day, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return nil, errors.New("invalid date format, expected YYYY-MM-DD")
+	}
+
+	execution, err := s.repo.GetFailedExecutionOnDate(ctx, allocation.ID, day.Year(), int(day.Month()), day.Day())
+	if err != nil {
+		return nil, err
+	}
+	if execution == nil {
+		return nil, errors.New("no failed execution on this date")
+	}
+
+	return s.rerunExecution(ctx, execution.ID)
+}
+
+// AdminRerunExecution re-runs any failed execution by its id
+func (s *Service) AdminRerunExecution(ctx context.Context, executionID string) (*AllocationExecution, error) {
+	executionObjID, err := primitive.ObjectIDFromHex(executionID)
+	if err != nil {
+		return nil, errors.New("invalid execution id")
+	}
+
+	if _, err := s.repo.GetExecutionByID(ctx, executionObjID); err != nil {
+		return nil, err
+	}
+
+	return s.rerunExecution(ctx, executionObjID)
+}
+
+// rerunExecution claims a failed execution and runs it again through the waterfall. A transfer already recorded
+// under the execution's ref, for example when the commit result was unknown, settles the execution instead of
+// moving the money a second time.
+func (s *Service) rerunExecution(ctx context.Context, executionID primitive.ObjectID) (*AllocationExecution, error) {
+	execution, err := s.repo.ClaimFailedExecution(ctx, executionID)
+	if err != nil {
+		return nil, err
+	}
+	if execution == nil {
+		return nil, errors.New("execution is not failed or is already being re-run")
+	}
+
+	existing, err := s.transactionRepo.GetTransactionByRef(ctx, execution.UserID, executionRef(execution))
+	if err != nil {
+		s.finishExecution(ctx, execution, err)
+		return execution, nil
+	}
+	if existing != nil {
+		execution.TransactionID = &existing.ID
+		execution.Amount = existing.Amount
+		s.finishExecution(ctx, execution, nil)
+		return execution, nil
+	}
+
+	allocation, err := s.repo.GetAllocationByID(ctx, execution.AllocationID)
+	if err == nil && !allocation.IsActive {
+		err = errors.New("allocation is not active")
+	}
+	if err != nil {
+		s.finishExecution(ctx, execution, err)
+		return execution, nil
+	}
+
+	sourceUserPlatformID, err := s.rerunSource(ctx, allocation, execution)
+	if err != nil {
+		s.finishExecution(ctx, execution, err)
+		return execution, nil
+	}
+
+	var summary waterfallSummary
+	items := []*waterfallItem{{allocation: allocation, execution: execution}}
+	s.runWaterfall(ctx, execution.UserID, items, sourceUserPlatformID, make(map[string]*resolvedBase), "Allocation re-run", &summary)
+
+	return execution, nil
+}
+
+// rerunSource returns the platform a re-run pays from. Income-triggered runs take their base and source from
+// the income that fired them, as the original run did; scheduled runs use the default user platform.
+func (s *Service) rerunSource(ctx context.Context, allocation *Allocation, execution *AllocationExecution) (primitive.ObjectID, error) {
+	if execution.TriggerType == string(TriggerOnIncome) && execution.IncomeTransactionID != nil {
+		allocation.PercentageBase = string(BaseIncomeTransaction)
+		allocation.BaseTransactionID = execution.IncomeTransactionID
+
+		income, err := s.getIncomeTransaction(ctx, allocation.UserID, *execution.IncomeTransactionID)
+		if err != nil {
+			return primitive.NilObjectID, err
+		}
+		if income.UserPlatformToID != nil {
+			return *income.UserPlatformToID, nil
+		}
+	}
+
+	profile, err := s.userRepo.GetUserProfileByUserID(ctx, allocation.UserID)
+	if err != nil || profile.DefaultUserPlatformID == nil {
+		return primitive.NilObjectID, errors.New("no default user platform configured")
+	}
+
+	return *profile.DefaultUserPlatformID, nil
+}
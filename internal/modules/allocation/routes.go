@@ -1,6 +1,7 @@
 package allocation
 
 import (
+	"github.com/HasanNugroho/coin-be/internal/core/middleware"
 	"github.com/gin-gonic/gin"
 )
 
@@ -10,8 +11,26 @@ func RegisterRoutes(r *gin.RouterGroup, controller *Controller) {
 	{
 		protected.POST("", controller.CreateAllocation)
 		protected.GET("", controller.ListAllocations)
+		protected.POST("/preview", controller.PreviewAllocations)
+		protected.POST("/plans", controller.CreatePlan)
+		protected.GET("/plans", controller.ListPlans)
+		protected.POST("/plans/compare", controller.ComparePlans)
+		protected.GET("/plans/:plan_id", controller.GetPlan)
+		protected.PUT("/plans/:plan_id", controller.UpdatePlan)
+		protected.DELETE("/plans/:plan_id", controller.DeletePlan)
+		protected.POST("/plans/:plan_id/activate", controller.ActivatePlan)
+		protected.POST("/plans/:plan_id/clone", controller.ClonePlan)
 		protected.GET("/:id", controller.GetAllocation)
+		protected.GET("/:id/executions", controller.ListExecutions)
+		protected.POST("/:id/executions/rerun", controller.RerunExecution)
 		protected.PUT("/:id", controller.UpdateAllocation)
 		protected.DELETE("/:id", controller.DeleteAllocation)
 	}
+
+	// Admin routes
+	admin := r.Group("admin")
+	admin.Use(middleware.AdminMiddleware())
+	{
+		admin.POST("/executions/:execution_id/rerun", controller.AdminRerunExecution)
+	}
 }
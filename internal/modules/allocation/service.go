@@ -3,36 +3,50 @@ package allocation
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log"
+	"math"
 	"time"
 
 	"github.com/HasanNugroho/coin-be/internal/core/utils"
 	"github.com/HasanNugroho/coin-be/internal/modules/allocation/dto"
+	"github.com/HasanNugroho/coin-be/internal/modules/holiday"
+	"github.com/HasanNugroho/coin-be/internal/modules/notification"
 	"github.com/HasanNugroho/coin-be/internal/modules/pocket"
 	"github.com/HasanNugroho/coin-be/internal/modules/transaction"
 	"github.com/HasanNugroho/coin-be/internal/modules/user"
+	"github.com/HasanNugroho/coin-be/internal/modules/user_category"
 	"github.com/HasanNugroho/coin-be/internal/modules/user_platform"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
+// payrollLookbackDays is how far back the PAYROLL base looks for the payroll income just credited
+const payrollLookbackDays = 31
+
 type Service struct {
-	repo             *Repository
-	pocketRepo       *pocket.Repository
-	userPlatformRepo *user_platform.UserPlatformRepository
-	userRepo         *user.Repository
-	transactionRepo  *transaction.Repository
-	db               *mongo.Database
+	repo                *Repository
+	pocketRepo          *pocket.Repository
+	userPlatformRepo    *user_platform.UserPlatformRepository
+	userRepo            *user.Repository
+	transactionRepo     *transaction.Repository
+	categoryRepo        *user_category.Repository
+	notificationService *notification.Service
+	holidayService      *holiday.Service
+	db                  *mongo.Database
 }
 
-func NewService(r *Repository, pr *pocket.Repository, upr *user_platform.UserPlatformRepository, ur *user.Repository, tr *transaction.Repository, db *mongo.Database) *Service {
+func NewService(r *Repository, pr *pocket.Repository, upr *user_platform.UserPlatformRepository, ur *user.Repository, tr *transaction.Repository, cr *user_category.Repository, ns *notification.Service, hs *holiday.Service, db *mongo.Database) *Service {
 	return &Service{
-		repo:             r,
-		pocketRepo:       pr,
-		userPlatformRepo: upr,
-		userRepo:         ur,
-		transactionRepo:  tr,
-		db:               db,
+		repo:                r,
+		pocketRepo:          pr,
+		userPlatformRepo:    upr,
+		userRepo:            ur,
+		transactionRepo:     tr,
+		categoryRepo:        cr,
+		notificationService: ns,
+		holidayService:      hs,
+		db:                  db,
 	}
 }
 
@@ -104,11 +118,6 @@ func (s *Service) CreateAllocation(ctx context.Context, userID string, req *dto.
 		userPlatformID = &userPlatformObjID
 	}
 
-	// Validate nominal based on allocation type
-	if req.AllocationType == string(TypePercentage) && req.Nominal > 100 {
-		return nil, errors.New("percentage cannot exceed 100")
-	}
-
 	allocation := &Allocation{
 		UserID:         userObjID,
 		PocketID:       pocketID,
@@ -116,8 +125,42 @@ func (s *Service) CreateAllocation(ctx context.Context, userID string, req *dto.
 		Priority:       req.Priority,
 		AllocationType: req.AllocationType,
 		Nominal:        req.Nominal,
+		PercentageBase: req.PercentageBase,
 		IsActive:       true,
 		ExecuteDay:     req.ExecuteDay,
+		DayAdjustment:  req.DayAdjustment,
+		TriggerType:    req.TriggerType,
+	}
+
+	// New allocations join the given plan, or the active one
+	if req.PlanID != "" {
+		plan, err := s.getPlan(ctx, userObjID, req.PlanID)
+		if err != nil {
+			return nil, err
+		}
+		allocation.PlanID = &plan.ID
+	} else {
+		plan, err := s.ensurePlans(ctx, userObjID)
+		if err != nil {
+			return nil, err
+		}
+		allocation.PlanID = &plan.ID
+	}
+
+	if err := s.applyTrigger(ctx, allocation, req.IncomeFilter); err != nil {
+		return nil, err
+	}
+
+	if req.BaseTransactionID != "" {
+		baseTransactionID, err := primitive.ObjectIDFromHex(req.BaseTransactionID)
+		if err != nil {
+			return nil, errors.New("invalid base transaction id")
+		}
+		allocation.BaseTransactionID = &baseTransactionID
+	}
+
+	if err := s.validatePercentage(ctx, allocation); err != nil {
+		return nil, err
 	}
 
 	err = s.repo.CreateAllocation(ctx, allocation)
@@ -151,22 +194,38 @@ func (s *Service) GetAllocationByID(ctx context.Context, userID string, allocati
 	return allocation, nil
 }
 
-func (s *Service) ListAllocations(ctx context.Context, userID string) ([]*Allocation, error) {
+// ListAllocations returns the user's allocations, only those of planID when it is given
+func (s *Service) ListAllocations(ctx context.Context, userID string, planID string) ([]*Allocation, error) {
 	userObjID, err := primitive.ObjectIDFromHex(userID)
 	if err != nil {
 		return nil, errors.New("invalid user id")
 	}
 
-	return s.repo.GetAllocationsByUserID(ctx, userObjID)
+	if planID == "" {
+		return s.repo.GetAllocationsByUserID(ctx, userObjID, nil)
+	}
+
+	plan, err := s.getPlan(ctx, userObjID, planID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetAllocationsByUserID(ctx, userObjID, &plan.ID)
 }
 
+// GetActiveAllocations returns the active allocations of the user's active plan
 func (s *Service) GetActiveAllocations(ctx context.Context, userID string) ([]*Allocation, error) {
 	userObjID, err := primitive.ObjectIDFromHex(userID)
 	if err != nil {
 		return nil, errors.New("invalid user id")
 	}
 
-	return s.repo.GetActiveAllocationsByUserID(ctx, userObjID)
+	planID, err := s.activePlanID(ctx, userObjID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetActiveAllocationsByPlan(ctx, userObjID, planID)
 }
 
 func (s *Service) UpdateAllocation(ctx context.Context, userID string, allocationID string, req *dto.UpdateAllocationRequest) (*Allocation, error) {
@@ -242,12 +301,21 @@ func (s *Service) UpdateAllocation(ctx context.Context, userID string, allocatio
 	}
 
 	if req.Nominal != nil {
-		if allocation.AllocationType == string(TypePercentage) && *req.Nominal > 100 {
-			return nil, errors.New("percentage cannot exceed 100")
-		}
 		allocation.Nominal = *req.Nominal
 	}
 
+	if req.PercentageBase != "" {
+		allocation.PercentageBase = req.PercentageBase
+	}
+
+	if req.BaseTransactionID != "" {
+		baseTransactionID, err := primitive.ObjectIDFromHex(req.BaseTransactionID)
+		if err != nil {
+			return nil, errors.New("invalid base transaction id")
+		}
+		allocation.BaseTransactionID = &baseTransactionID
+	}
+
 	if req.IsActive != nil {
 		allocation.IsActive = *req.IsActive
 	}
@@ -256,6 +324,22 @@ func (s *Service) UpdateAllocation(ctx context.Context, userID string, allocatio
 		allocation.ExecuteDay = req.ExecuteDay
 	}
 
+	if req.DayAdjustment != "" {
+		allocation.DayAdjustment = req.DayAdjustment
+	}
+
+	if req.TriggerType != "" {
+		allocation.TriggerType = req.TriggerType
+	}
+
+	if err := s.applyTrigger(ctx, allocation, req.IncomeFilter); err != nil {
+		return nil, err
+	}
+
+	if err := s.validatePercentage(ctx, allocation); err != nil {
+		return nil, err
+	}
+
 	err = s.repo.UpdateAllocation(ctx, allocationObjID, allocation)
 	if err != nil {
 		return nil, err
@@ -264,6 +348,20 @@ func (s *Service) UpdateAllocation(ctx context.Context, userID string, allocatio
 	return allocation, nil
 }
 
+// GetExecutions returns the most recent runs of an allocation, newest first
+func (s *Service) GetExecutions(ctx context.Context, userID string, allocationID string, limit int64) ([]*AllocationExecution, error) {
+	allocation, err := s.GetAllocationByID(ctx, userID, allocationID)
+	if err != nil {
+		return nil, err
+	}
+
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+
+	return s.repo.GetExecutionsByAllocationID(ctx, allocation.ID, limit)
+}
+
 func (s *Service) DeleteAllocation(ctx context.Context, userID string, allocationID string) error {
 	userObjID, err := primitive.ObjectIDFromHex(userID)
 	if err != nil {
@@ -288,18 +386,30 @@ func (s *Service) DeleteAllocation(ctx context.Context, userID string, allocatio
 }
 
 // ProcessDailyAllocations processes all allocations scheduled for execution on the current day
-// Automatically handles month-end logic: allocations scheduled for days 29-31 will execute
-// on the last day of shorter months (e.g., day 31 executes on Feb 28/29, Apr 30, etc.)
+// Allocations scheduled for days 29-31 execute on the last day of shorter months (e.g., day 31 executes on
+// Feb 28/29, Apr 30, etc.), and execute days on a weekend or holiday move by the allocation's day adjustment
 func (s *Service) ProcessDailyAllocations(ctx context.Context) error {
 	jakartaLoc := getJakartaLocation()
 	now := time.Now().In(jakartaLoc)
 	currentDay := now.Day()
-	lastDayOfMonth := getLastDayOfMonth(now)
 
-	log.Printf("Processing allocations for day %d (last day of month: %d)", currentDay, lastDayOfMonth)
+	cal, err := s.holidayService.CalendarAround(ctx, now)
+	if err != nil {
+		log.Printf("failed to load holiday calendar for allocations: %v", err)
+		return err
+	}
 
-	// Fetch allocations with automatic overflow handling
-	allocationsData, err := s.repo.GetAllocationsByExecuteDayWithOverflow(ctx, currentDay, lastDayOfMonth)
+	// Execute days that fall on today under each adjustment policy
+	dueDays := make(map[string][]int)
+	for _, adjustment := range holiday.Adjustments {
+		for day := range cal.DueMonthDays(now, adjustment) {
+			dueDays[adjustment] = append(dueDays[adjustment], day)
+		}
+	}
+
+	log.Printf("Processing allocations for %s", now.Format("2006-01-02"))
+
+	allocationsData, err := s.repo.GetAllocationsDueOn(ctx, dueDays)
 	if err != nil {
 		log.Printf("failed to fetch allocations for execution on day %d: %v", currentDay, err)
 		return err
@@ -314,152 +424,457 @@ func (s *Service) ProcessDailyAllocations(ctx context.Context) error {
 	return s.processAllocations(ctx, allocationsData, currentDay)
 }
 
-// processAllocations handles the execution of a batch of allocations
+// processAllocations handles the execution of a batch of allocations. Each user's allocations are funded
+// together as a priority waterfall out of the main pocket.
 func (s *Service) processAllocations(ctx context.Context, allocationsData []map[string]interface{}, executeDay int) error {
 	if len(allocationsData) == 0 {
 		log.Printf("no allocations scheduled for execution on day %d", executeDay)
 		return nil
 	}
 
-	successCount := 0
-	failureCount := 0
-
+	userOrder := make([]primitive.ObjectID, 0)
+	byUser := make(map[primitive.ObjectID][]map[string]interface{})
 	for _, allocData := range allocationsData {
-		allocationID := allocData["_id"].(primitive.ObjectID)
 		userID := allocData["user_id"].(primitive.ObjectID)
-
-		err := s.processAllocationExecution(ctx, userID, allocationID, allocData)
-		if err != nil {
-			log.Printf("failed to process allocation %s for user %s: %v", allocationID.Hex(), userID.Hex(), err)
-			failureCount++
-		} else {
-			log.Printf("successfully processed allocation %s for user %s", allocationID.Hex(), userID.Hex())
-			successCount++
+		if _, ok := byUser[userID]; !ok {
+			userOrder = append(userOrder, userID)
 		}
+		byUser[userID] = append(byUser[userID], allocData)
+	}
+
+	var summary waterfallSummary
+	bases := make(map[string]*resolvedBase)
+
+	for _, userID := range userOrder {
+		s.processUserAllocations(ctx, userID, byUser[userID], bases, &summary)
 	}
 
-	log.Printf("allocation processing complete for day %d: %d success, %d failures", executeDay, successCount, failureCount)
+	log.Printf("allocation processing complete for day %d: %d funded, %d partial, %d skipped, %d failures, %d already executed",
+		executeDay, summary.funded, summary.partial, summary.skipped, summary.failed, summary.alreadyExecuted)
 	return nil
 }
 
-// processAllocationExecution executes a single allocation within a database transaction
-func (s *Service) processAllocationExecution(ctx context.Context, userID primitive.ObjectID, allocationID primitive.ObjectID, allocData map[string]interface{}) error {
-	allocation, err := s.repo.GetAllocationByID(ctx, allocationID)
-	if err != nil {
-		return err
+// processUserAllocations claims today's run of each scheduled allocation of one user and funds them as a
+// waterfall from the user's default platform
+func (s *Service) processUserAllocations(ctx context.Context, userID primitive.ObjectID, rows []map[string]interface{}, bases map[string]*resolvedBase, summary *waterfallSummary) {
+	items := make([]*waterfallItem, 0, len(rows))
+
+	for _, allocData := range rows {
+		allocationID := allocData["_id"].(primitive.ObjectID)
+
+		execution, err := s.startExecution(ctx, allocationID, userID, TriggerScheduled, nil)
+		if err != nil {
+			log.Printf("failed to process allocation %s for user %s: %v", allocationID.Hex(), userID.Hex(), err)
+			summary.failed++
+			continue
+		}
+		if execution == nil {
+			log.Printf("allocation %s already executed today", allocationID.Hex())
+			summary.alreadyExecuted++
+			continue
+		}
+
+		allocation, err := s.repo.GetAllocationByID(ctx, allocationID)
+		if err == nil && !allocation.IsActive {
+			err = errors.New("allocation is not active")
+		}
+		if err != nil {
+			s.finishExecution(ctx, execution, err)
+			summary.failed++
+			continue
+		}
+
+		items = append(items, &waterfallItem{allocation: allocation, execution: execution})
 	}
 
-	if !allocation.IsActive {
-		return errors.New("allocation is not active")
+	if len(items) == 0 {
+		return
 	}
 
-	userProfileInterface := allocData["user_profile"]
+	sourceUserPlatformID, err := defaultUserPlatformFromProfile(rows[0])
+	if err != nil {
+		for _, item := range items {
+			s.finishExecution(ctx, item.execution, err)
+			summary.failed++
+		}
+		return
+	}
+
+	s.runWaterfall(ctx, userID, items, sourceUserPlatformID, bases, "Scheduled allocation execution", summary)
+}
 
-	userProfileMap := userProfileInterface.(map[string]interface{})
+// defaultUserPlatformFromProfile reads the default user platform from the profile joined to a scheduled allocation
+func defaultUserPlatformFromProfile(allocData map[string]interface{}) (primitive.ObjectID, error) {
+	userProfileMap, ok := allocData["user_profile"].(map[string]interface{})
+	if !ok {
+		return primitive.NilObjectID, errors.New("no default user platform configured")
+	}
 
 	defaultUserPlatformID := userProfileMap["default_user_platform_id"]
 	if defaultUserPlatformID == nil {
-		return errors.New("no default user platform configured")
+		return primitive.NilObjectID, errors.New("no default user platform configured")
 	}
 
 	defaultUserPlatformObjID, ok := defaultUserPlatformID.(primitive.ObjectID)
 	if !ok {
-		return errors.New("invalid default user platform id")
+		return primitive.NilObjectID, errors.New("invalid default user platform id")
+	}
+
+	return defaultUserPlatformObjID, nil
+}
+
+// startExecution claims a run of an allocation for today, or for the triggering income, before any money
+// moves. It returns nil when the run has already been claimed.
+func (s *Service) startExecution(ctx context.Context, allocationID primitive.ObjectID, userID primitive.ObjectID, triggerType TriggerType, incomeTransactionID *primitive.ObjectID) (*AllocationExecution, error) {
+	now := time.Now().In(getJakartaLocation())
+	execution := &AllocationExecution{
+		AllocationID:        allocationID,
+		UserID:              userID,
+		Year:                now.Year(),
+		Month:               int(now.Month()),
+		Day:                 now.Day(),
+		TriggerType:         string(triggerType),
+		IncomeTransactionID: incomeTransactionID,
+		Status:              ExecutionPending,
+		Attempts:            1,
+	}
+
+	created, err := s.repo.CreateExecution(ctx, execution)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record allocation execution: %w", err)
+	}
+	if !created {
+		return nil, nil
+	}
+
+	return execution, nil
+}
+
+// finishExecution stores the outcome of a claimed run. Runs that moved less than requested are PARTIAL, or
+// SKIPPED when nothing was moved. Transient failures are queued for a retry with backoff; the user is told
+// about a failure once no retry is left.
+func (s *Service) finishExecution(ctx context.Context, execution *AllocationExecution, err error) {
+	execution.NextRetryAt = nil
+
+	switch {
+	case err != nil:
+		errMsg := err.Error()
+		execution.Status = ExecutionFailed
+		execution.Error = &errMsg
+		if utils.IsTransientError(err) {
+			execution.NextRetryAt = utils.NextRetryAt(execution.Attempts, time.Now())
+		}
+	case execution.Shortfall > 0 && execution.Amount > 0:
+		execution.Status = ExecutionPartial
+	case execution.Shortfall > 0:
+		execution.Status = ExecutionSkipped
+	default:
+		execution.Status = ExecutionSuccess
+	}
+
+	if updateErr := s.repo.UpdateExecution(ctx, execution); updateErr != nil {
+		log.Printf("failed to record execution of allocation %s: %v", execution.AllocationID.Hex(), updateErr)
+	}
+
+	if err != nil && execution.NextRetryAt == nil {
+		s.notifyExecutionFailed(ctx, execution)
 	}
+}
+
+func (s *Service) notifyExecutionFailed(ctx context.Context, execution *AllocationExecution) {
+	label := "alokasi"
+	switch execution.AllocationType {
+	case string(TypePercentage):
+		label = fmt.Sprintf("alokasi %.0f%%", execution.Nominal)
+	case string(TypeNominal):
+		label = "alokasi " + notification.FormatRupiah(execution.Nominal)
+	}
+
+	subject := "Finlet - Alokasi gagal dijalankan"
+	body := fmt.Sprintf("Eksekusi %s pada %02d/%02d/%d gagal: %s. Tidak ada dana yang dipindahkan.",
+		label, execution.Day, execution.Month, execution.Year, *execution.Error)
 
-	defaultUserPlatform, err := s.userPlatformRepo.GetUserPlatformByID(ctx, defaultUserPlatformObjID)
+	if err := s.notificationService.Notify(ctx, execution.UserID, subject, body); err != nil {
+		log.Printf("failed to send allocation failure notification for allocation %s: %v", execution.AllocationID.Hex(), err)
+	}
+}
+
+// HandleIncome runs when an income transaction is created. Income credited to the main pocket first tops up
+// open shortfalls, then funds the user's matching ON_INCOME allocations as a priority waterfall. Percentages
+// are taken from the income amount, and the money moves out of the platform the income was credited to.
+func (s *Service) HandleIncome(ctx context.Context, income *transaction.Transaction) {
+	mainPocket, err := s.getMainPocket(ctx, income.UserID)
 	if err != nil {
-		return errors.New("default user platform not found")
+		log.Printf("skipping income allocations for user %s: %v", income.UserID.Hex(), err)
+		return
+	}
+	if income.PocketToID == nil || *income.PocketToID != mainPocket.ID {
+		// Only income credited to the main pocket is allocated
+		return
 	}
 
-	if !defaultUserPlatform.IsActive {
-		return errors.New("default user platform is not active")
+	sourceUserPlatformID := income.UserPlatformToID
+	if sourceUserPlatformID == nil {
+		profile, err := s.userRepo.GetUserProfileByUserID(ctx, income.UserID)
+		if err != nil || profile.DefaultUserPlatformID == nil {
+			log.Printf("skipping income allocations for user %s: no default user platform configured", income.UserID.Hex())
+			return
+		}
+		sourceUserPlatformID = profile.DefaultUserPlatformID
 	}
 
-	mainPocket, err := s.getMainPocket(ctx, userID)
+	s.topUpShortfalls(ctx, income, mainPocket, *sourceUserPlatformID)
+
+	planID, err := s.activePlanID(ctx, income.UserID)
 	if err != nil {
-		return err
+		log.Printf("failed to fetch active allocation plan for user %s: %v", income.UserID.Hex(), err)
+		return
 	}
 
-	session, err := s.db.Client().StartSession()
+	allocations, err := s.repo.GetIncomeTriggeredAllocations(ctx, income.UserID, planID)
 	if err != nil {
-		return err
+		log.Printf("failed to fetch income-triggered allocations for user %s: %v", income.UserID.Hex(), err)
+		return
 	}
-	defer session.EndSession(ctx)
 
-	err = mongo.WithSession(ctx, session, func(sessionCtx mongo.SessionContext) error {
-		if err := session.StartTransaction(); err != nil {
-			return err
+	items := make([]*waterfallItem, 0, len(allocations))
+	for _, allocation := range allocations {
+		if !allocation.IncomeFilter.Matches(income.Amount, income.UserPlatformToID, income.CategoryID) {
+			continue
+		}
+
+		// The triggering income is the base for this run only; it is not saved on the allocation
+		allocation.PercentageBase = string(BaseIncomeTransaction)
+		allocation.BaseTransactionID = &income.ID
+
+		execution, err := s.startExecution(ctx, allocation.ID, income.UserID, TriggerOnIncome, &income.ID)
+		if err != nil {
+			log.Printf("failed to process income-triggered allocation %s for income %s: %v", allocation.ID.Hex(), income.ID.Hex(), err)
+			continue
 		}
+		if execution == nil {
+			log.Printf("income-triggered allocation %s already executed for income %s", allocation.ID.Hex(), income.ID.Hex())
+			continue
+		}
+
+		items = append(items, &waterfallItem{allocation: allocation, execution: execution})
+	}
+
+	if len(items) == 0 {
+		return
+	}
+
+	var summary waterfallSummary
+	s.runWaterfall(ctx, income.UserID, items, *sourceUserPlatformID, make(map[string]*resolvedBase), "Income allocation execution", &summary)
 
-		var targetPocketID *primitive.ObjectID
-		var targetUserPlatformID *primitive.ObjectID
+	log.Printf("income allocations for income %s complete: %d funded, %d partial, %d skipped, %d failures",
+		income.ID.Hex(), summary.funded, summary.partial, summary.skipped, summary.failed)
+}
+
+// resolvedBase is the amount a percentage allocation is taken from
+type resolvedBase struct {
+	amount        float64
+	transactionID *primitive.ObjectID
+}
+
+// resolveAmount returns the amount to transfer for an allocation and fills the execution with how it was computed.
+// Bases are cached per run so every percentage is taken from the same amount, not from a main pocket
+// balance already reduced by earlier transfers.
+func (s *Service) resolveAmount(ctx context.Context, allocation *Allocation, mainPocket *pocket.Pocket, bases map[string]*resolvedBase, execution *AllocationExecution) (float64, error) {
+	if allocation.AllocationType != string(TypePercentage) {
+		execution.Amount = allocation.Nominal
+		return allocation.Nominal, nil
+	}
+
+	baseType := allocation.PercentageBase
+	if baseType == "" {
+		baseType = string(BasePayroll)
+	}
 
-		if allocation.PocketID != nil {
-			pocket, err := s.pocketRepo.GetPocketByID(sessionCtx, *allocation.PocketID)
-			if err != nil || !pocket.IsActive || pocket.UserID != userID {
-				session.AbortTransaction(sessionCtx)
-				return errors.New("target pocket is invalid")
-			}
-			targetPocketID = allocation.PocketID
+	key := allocation.UserID.Hex() + "_" + baseType
+	if baseType == string(BaseIncomeTransaction) && allocation.BaseTransactionID != nil {
+		key += "_" + allocation.BaseTransactionID.Hex()
+	}
+
+	base, ok := bases[key]
+	if !ok {
+		var err error
+		base, err = s.resolveBase(ctx, allocation, PercentageBase(baseType), mainPocket)
+		if err != nil {
+			return 0, err
 		}
+		bases[key] = base
+	}
+
+	// Rounded to cents: base * nominal / 100
+	amount := math.Round(base.amount*allocation.Nominal) / 100
 
-		if allocation.UserPlatformID != nil {
-			userPlatform, err := s.userPlatformRepo.GetUserPlatformByID(sessionCtx, *allocation.UserPlatformID)
-			if err != nil || !userPlatform.IsActive || userPlatform.UserID != userID {
-				session.AbortTransaction(sessionCtx)
-				return errors.New("target user platform is invalid")
-			}
-			targetUserPlatformID = allocation.UserPlatformID
+	execution.PercentageBase = baseType
+	execution.BaseAmount = &base.amount
+	execution.BaseTransactionID = base.transactionID
+	execution.Amount = amount
+
+	if amount <= 0 {
+		return 0, errors.New("computed allocation amount is zero")
+	}
+
+	return amount, nil
+}
+
+func (s *Service) resolveBase(ctx context.Context, allocation *Allocation, baseType PercentageBase, mainPocket *pocket.Pocket) (*resolvedBase, error) {
+	switch baseType {
+	case BaseMainPocketBalance:
+		return &resolvedBase{amount: utils.Decimal128ToFloat64(mainPocket.Balance)}, nil
+	case BaseIncomeTransaction:
+		if allocation.BaseTransactionID == nil {
+			return nil, errors.New("no base transaction configured")
+		}
+		tx, err := s.getIncomeTransaction(ctx, allocation.UserID, *allocation.BaseTransactionID)
+		if err != nil {
+			return nil, err
 		}
+		return &resolvedBase{amount: tx.Amount, transactionID: &tx.ID}, nil
+	default:
+		tx, err := s.repo.GetLatestPayrollIncome(ctx, allocation.UserID, time.Now().AddDate(0, 0, -payrollLookbackDays))
+		if err != nil {
+			return nil, err
+		}
+		if tx == nil {
+			return nil, fmt.Errorf("no payroll income credited in the last %d days", payrollLookbackDays)
+		}
+		return &resolvedBase{amount: tx.Amount, transactionID: &tx.ID}, nil
+	}
+}
+
+// validatePercentage checks the base of a PERCENTAGE allocation and that the user's active percentages
+// do not exceed 100 in total. NOMINAL allocations have their base cleared.
+func (s *Service) validatePercentage(ctx context.Context, allocation *Allocation) error {
+	if allocation.AllocationType != string(TypePercentage) {
+		allocation.PercentageBase = ""
+		allocation.BaseTransactionID = nil
+		return nil
+	}
+
+	if allocation.Nominal > 100 {
+		return errors.New("percentage cannot exceed 100")
+	}
+
+	if allocation.TriggerType == string(TriggerOnIncome) {
+		// The income that fires the allocation is its base
+		allocation.PercentageBase = string(BaseIncomeTransaction)
+	} else if allocation.PercentageBase == "" {
+		allocation.PercentageBase = string(BasePayroll)
+	}
+	if !IsValidPercentageBase(allocation.PercentageBase) {
+		return errors.New("invalid percentage base")
+	}
 
-		if targetPocketID == nil && targetUserPlatformID == nil {
-			session.AbortTransaction(sessionCtx)
-			return errors.New("no valid target for allocation")
+	// ON_INCOME allocations resolve their base transaction when they fire
+	if allocation.PercentageBase == string(BaseIncomeTransaction) && allocation.TriggerType != string(TriggerOnIncome) {
+		if allocation.BaseTransactionID == nil {
+			return errors.New("base_transaction_id is required for INCOME_TRANSACTION base")
 		}
+		if _, err := s.getIncomeTransaction(ctx, allocation.UserID, *allocation.BaseTransactionID); err != nil {
+			return err
+		}
+	} else {
+		allocation.BaseTransactionID = nil
+	}
+
+	if !allocation.IsActive {
+		return nil
+	}
+
+	var excludeID *primitive.ObjectID
+	if !allocation.ID.IsZero() {
+		excludeID = &allocation.ID
+	}
+
+	total, err := s.repo.SumActivePercentages(ctx, allocation.UserID, allocation.PlanID, TriggerType(allocation.TriggerType), excludeID)
+	if err != nil {
+		return err
+	}
+	if total+allocation.Nominal > 100 {
+		return fmt.Errorf("total percentage of active allocations cannot exceed 100 (%.2f already allocated)", total)
+	}
+
+	return nil
+}
+
+// applyTrigger validates how the allocation is run. ON_INCOME allocations have no execute day and keep
+// their income filter, which is replaced when filterReq is given; SCHEDULED allocations have no filter.
+func (s *Service) applyTrigger(ctx context.Context, allocation *Allocation, filterReq *dto.IncomeFilterRequest) error {
+	if allocation.TriggerType == "" {
+		allocation.TriggerType = string(TriggerScheduled)
+	}
+	if !IsValidTriggerType(allocation.TriggerType) {
+		return errors.New("invalid trigger type")
+	}
+
+	if allocation.TriggerType != string(TriggerOnIncome) {
+		allocation.IncomeFilter = nil
+		return nil
+	}
+
+	allocation.ExecuteDay = nil
+	if filterReq == nil {
+		return nil
+	}
 
-		allocAmount := allocation.Nominal
+	filter := &IncomeFilter{MinAmount: filterReq.MinAmount}
 
-		allocTx := &transaction.Transaction{
-			UserID:             userID,
-			Type:               string(transaction.TypeTransfer),
-			Amount:             allocAmount,
-			PocketFromID:       &mainPocket.ID,
-			PocketToID:         targetPocketID,
-			UserPlatformFromID: &defaultUserPlatform.ID,
-			UserPlatformToID:   targetUserPlatformID,
-			Date:               time.Now(),
-			Note:               stringPtr("Scheduled allocation execution"),
-			Ref:                stringPtr("alloc_exec_" + allocationID.Hex()),
+	if filterReq.UserPlatformID != "" {
+		userPlatformObjID, err := primitive.ObjectIDFromHex(filterReq.UserPlatformID)
+		if err != nil {
+			return errors.New("invalid income filter user platform id")
+		}
+
+		userPlatform, err := s.userPlatformRepo.GetUserPlatformByID(ctx, userPlatformObjID)
+		if err != nil {
+			return errors.New("income filter user platform not found")
 		}
 
-		if err := s.transactionRepo.CreateTransaction(sessionCtx, allocTx); err != nil {
-			session.AbortTransaction(sessionCtx)
-			return errors.New("failed to create allocation transaction: " + err.Error())
+		if userPlatform.UserID != allocation.UserID {
+			return errors.New("unauthorized: income filter user platform does not belong to user")
 		}
 
-		balanceUpdates := make([]balanceUpdate, 0, 4)
-		balanceUpdates = append(balanceUpdates,
-			balanceUpdate{entityType: "pocket", id: mainPocket.ID, delta: -allocAmount},
-			balanceUpdate{entityType: "userPlatform", id: defaultUserPlatform.ID, delta: -allocAmount},
-		)
+		filter.UserPlatformID = &userPlatformObjID
+	}
 
-		if targetPocketID != nil {
-			balanceUpdates = append(balanceUpdates, balanceUpdate{entityType: "pocket", id: *targetPocketID, delta: allocAmount})
+	if filterReq.CategoryID != "" {
+		categoryObjID, err := primitive.ObjectIDFromHex(filterReq.CategoryID)
+		if err != nil {
+			return errors.New("invalid income filter category id")
 		}
-		if targetUserPlatformID != nil {
-			balanceUpdates = append(balanceUpdates, balanceUpdate{entityType: "userPlatform", id: *targetUserPlatformID, delta: allocAmount})
+
+		category, err := s.categoryRepo.FindByID(ctx, categoryObjID, allocation.UserID)
+		if err != nil {
+			return errors.New("income filter category not found")
 		}
 
-		if err := s.applyBalanceUpdates(sessionCtx, balanceUpdates); err != nil {
-			session.AbortTransaction(sessionCtx)
-			return errors.New("failed to update balances: " + err.Error())
+		if category.TransactionType != nil && *category.TransactionType != user_category.TransactionIncome {
+			return errors.New("income filter category must be an income category")
 		}
 
-		return session.CommitTransaction(sessionCtx)
-	})
+		filter.CategoryID = &categoryObjID
+	}
 
-	return err
+	allocation.IncomeFilter = filter
+	return nil
+}
+
+func (s *Service) getIncomeTransaction(ctx context.Context, userID primitive.ObjectID, transactionID primitive.ObjectID) (*transaction.Transaction, error) {
+	tx, err := s.transactionRepo.GetTransactionByID(ctx, transactionID)
+	if err != nil {
+		return nil, errors.New("base transaction not found")
+	}
+	if tx.UserID != userID {
+		return nil, errors.New("unauthorized: base transaction does not belong to user")
+	}
+	if tx.Type != string(transaction.TypeIncome) {
+		return nil, errors.New("base transaction must be an income transaction")
+	}
+	return tx, nil
 }
 
 // balanceUpdate tracks a balance change for batch processing
@@ -530,8 +945,3 @@ func stringPtr(s string) *string {
 	}
 	return &s
 }
-
-// getLastDayOfMonth returns the last day of the given month
-func getLastDayOfMonth(t time.Time) int {
-	return time.Date(t.Year(), t.Month()+1, 0, 0, 0, 0, 0, t.Location()).Day()
-}
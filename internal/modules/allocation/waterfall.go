@@ -0,0 +1,369 @@
+package allocation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/HasanNugroho/coin-be/internal/core/utils"
+	"github.com/HasanNugroho/coin-be/internal/modules/notification"
+	"github.com/HasanNugroho/coin-be/internal/modules/pocket"
+	"github.com/HasanNugroho/coin-be/internal/modules/transaction"
+	"github.com/HasanNugroho/coin-be/internal/modules/user_platform"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// waterfallItem is one allocation of a waterfall run with its claimed execution
+type waterfallItem struct {
+	allocation *Allocation
+	execution  *AllocationExecution
+	requested  float64
+	funded     float64
+	err        error
+}
+
+// waterfallSummary counts the outcomes of allocation runs for logging
+type waterfallSummary struct {
+	funded          int
+	partial         int
+	skipped         int
+	failed          int
+	alreadyExecuted int
+}
+
+// runWaterfall funds one user's allocations out of the main pocket in priority order. Amounts are resolved
+// up front, every priority level is funded in full while the balance lasts and the first level that cannot
+// be covered is funded by the user's shortfall policy. Unfunded amounts are recorded as shortfalls.
+func (s *Service) runWaterfall(ctx context.Context, userID primitive.ObjectID, items []*waterfallItem, sourceUserPlatformID primitive.ObjectID, bases map[string]*resolvedBase, note string, summary *waterfallSummary) {
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].allocation.Priority < items[j].allocation.Priority
+	})
+
+	mainPocket, sourcePlatform, err := s.getFundingSources(ctx, userID, sourceUserPlatformID)
+	if err != nil {
+		for _, item := range items {
+			s.finishExecution(ctx, item.execution, err)
+			summary.failed++
+		}
+		return
+	}
+
+	for _, item := range items {
+		item.execution.AllocationType = item.allocation.AllocationType
+		item.execution.Nominal = item.allocation.Nominal
+
+		item.requested, item.err = s.resolveAmount(ctx, item.allocation, mainPocket, bases, item.execution)
+		if item.err == nil {
+			_, _, item.err = s.resolveTargets(ctx, item.allocation)
+		}
+	}
+
+	planFunding(items, utils.Decimal128ToFloat64(mainPocket.Balance), s.getShortfallPolicy(ctx, userID))
+
+	shortfallCount := 0
+	shortfallTotal := 0.0
+
+	for _, item := range items {
+		execution := item.execution
+
+		if item.err == nil && item.funded > 0 {
+			txID, err := s.transfer(ctx, item.allocation, mainPocket.ID, sourcePlatform.ID, item.funded, note, executionRef(execution), nil)
+			if err != nil {
+				item.err = err
+			} else {
+				execution.TransactionID = &txID
+			}
+		}
+
+		if item.err != nil {
+			s.finishExecution(ctx, execution, item.err)
+			summary.failed++
+			continue
+		}
+
+		execution.RequestedAmount = item.requested
+		execution.Amount = item.funded
+		execution.Shortfall = math.Round((item.requested-item.funded)*100) / 100
+
+		if execution.Shortfall > 0 {
+			s.recordShortfall(ctx, item.allocation, execution)
+			shortfallCount++
+			shortfallTotal += execution.Shortfall
+		}
+
+		s.finishExecution(ctx, execution, nil)
+
+		switch execution.Status {
+		case ExecutionPartial:
+			summary.partial++
+		case ExecutionSkipped:
+			summary.skipped++
+		default:
+			summary.funded++
+		}
+	}
+
+	if shortfallCount > 0 {
+		s.notifyShortfall(ctx, userID, shortfallCount, shortfallTotal)
+	}
+}
+
+// planFunding sets the funded amount of items already sorted by priority. Levels are funded in full while
+// available lasts; the first level that cannot be covered is funded by the policy and every level below it
+// gets nothing. Items with an error take no part.
+func planFunding(items []*waterfallItem, available float64, policy ShortfallPolicy) {
+	available = math.Max(available, 0)
+
+	for start := 0; start < len(items); {
+		end := start
+		for end < len(items) && items[end].allocation.Priority == items[start].allocation.Priority {
+			end++
+		}
+
+		level := make([]*waterfallItem, 0, end-start)
+		need := 0.0
+		for _, item := range items[start:end] {
+			if item.err == nil {
+				level = append(level, item)
+				need += item.requested
+			}
+		}
+		start = end
+
+		if need <= available {
+			for _, item := range level {
+				item.funded = item.requested
+			}
+			available -= need
+			continue
+		}
+
+		switch policy {
+		case PolicyPartial:
+			for _, item := range level {
+				item.funded = math.Min(item.requested, available)
+				available -= item.funded
+			}
+		case PolicyProportional:
+			ratio := available / need
+			for _, item := range level {
+				// Rounded down to cents so the level never takes more than what is left
+				item.funded = math.Floor(item.requested*ratio*100) / 100
+			}
+		default:
+			for _, item := range level {
+				if item.requested <= available {
+					item.funded = item.requested
+					available -= item.requested
+				}
+			}
+		}
+
+		return
+	}
+}
+
+// getShortfallPolicy returns the user's shortfall policy, SKIP when none is set
+func (s *Service) getShortfallPolicy(ctx context.Context, userID primitive.ObjectID) ShortfallPolicy {
+	profile, err := s.userRepo.GetUserProfileByUserID(ctx, userID)
+	if err != nil || profile.AllocationShortfall == "" {
+		return PolicySkip
+	}
+	return ShortfallPolicy(profile.AllocationShortfall)
+}
+
+// getFundingSources returns the main pocket and the user platform allocations are paid from
+func (s *Service) getFundingSources(ctx context.Context, userID primitive.ObjectID, sourceUserPlatformID primitive.ObjectID) (*pocket.Pocket, *user_platform.UserPlatform, error) {
+	sourcePlatform, err := s.userPlatformRepo.GetUserPlatformByID(ctx, sourceUserPlatformID)
+	if err != nil {
+		return nil, nil, errors.New("default user platform not found")
+	}
+
+	if !sourcePlatform.IsActive || sourcePlatform.UserID != userID {
+		return nil, nil, errors.New("default user platform is not active")
+	}
+
+	mainPocket, err := s.getMainPocket(ctx, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return mainPocket, sourcePlatform, nil
+}
+
+// resolveTargets checks that the allocation targets still exist, are active and belong to the user
+func (s *Service) resolveTargets(ctx context.Context, allocation *Allocation) (*primitive.ObjectID, *primitive.ObjectID, error) {
+	if allocation.PocketID != nil {
+		pocket, err := s.pocketRepo.GetPocketByID(ctx, *allocation.PocketID)
+		if err != nil || !pocket.IsActive || pocket.UserID != allocation.UserID {
+			return nil, nil, errors.New("target pocket is invalid")
+		}
+	}
+
+	if allocation.UserPlatformID != nil {
+		userPlatform, err := s.userPlatformRepo.GetUserPlatformByID(ctx, *allocation.UserPlatformID)
+		if err != nil || !userPlatform.IsActive || userPlatform.UserID != allocation.UserID {
+			return nil, nil, errors.New("target user platform is invalid")
+		}
+	}
+
+	if allocation.PocketID == nil && allocation.UserPlatformID == nil {
+		return nil, nil, errors.New("no valid target for allocation")
+	}
+
+	return allocation.PocketID, allocation.UserPlatformID, nil
+}
+
+// transfer moves amount from the main pocket and source platform to the allocation targets within a database
+// transaction. within, when given, runs inside the same transaction once the transfer is recorded.
+func (s *Service) transfer(ctx context.Context, allocation *Allocation, mainPocketID primitive.ObjectID, sourceUserPlatformID primitive.ObjectID, amount float64, note string, ref string, within func(sessionCtx mongo.SessionContext, transactionID primitive.ObjectID) error) (primitive.ObjectID, error) {
+	var allocTxID primitive.ObjectID
+	session, err := s.db.Client().StartSession()
+	if err != nil {
+		return allocTxID, err
+	}
+	defer session.EndSession(ctx)
+
+	err = mongo.WithSession(ctx, session, func(sessionCtx mongo.SessionContext) error {
+		if err := session.StartTransaction(); err != nil {
+			return err
+		}
+
+		targetPocketID, targetUserPlatformID, err := s.resolveTargets(sessionCtx, allocation)
+		if err != nil {
+			session.AbortTransaction(sessionCtx)
+			return err
+		}
+
+		allocTx := &transaction.Transaction{
+			UserID:             allocation.UserID,
+			Type:               string(transaction.TypeTransfer),
+			Amount:             amount,
+			PocketFromID:       &mainPocketID,
+			PocketToID:         targetPocketID,
+			UserPlatformFromID: &sourceUserPlatformID,
+			UserPlatformToID:   targetUserPlatformID,
+			Date:               time.Now(),
+			Note:               stringPtr(note),
+			Ref:                stringPtr(ref),
+		}
+
+		if err := s.transactionRepo.CreateTransaction(sessionCtx, allocTx); err != nil {
+			session.AbortTransaction(sessionCtx)
+			return fmt.Errorf("failed to create allocation transaction: %w", err)
+		}
+
+		balanceUpdates := make([]balanceUpdate, 0, 4)
+		balanceUpdates = append(balanceUpdates,
+			balanceUpdate{entityType: "pocket", id: mainPocketID, delta: -amount},
+			balanceUpdate{entityType: "userPlatform", id: sourceUserPlatformID, delta: -amount},
+		)
+
+		if targetPocketID != nil {
+			balanceUpdates = append(balanceUpdates, balanceUpdate{entityType: "pocket", id: *targetPocketID, delta: amount})
+		}
+		if targetUserPlatformID != nil {
+			balanceUpdates = append(balanceUpdates, balanceUpdate{entityType: "userPlatform", id: *targetUserPlatformID, delta: amount})
+		}
+
+		if err := s.applyBalanceUpdates(sessionCtx, balanceUpdates); err != nil {
+			session.AbortTransaction(sessionCtx)
+			return fmt.Errorf("failed to update balances: %w", err)
+		}
+
+		if within != nil {
+			if err := within(sessionCtx, allocTx.ID); err != nil {
+				session.AbortTransaction(sessionCtx)
+				return err
+			}
+		}
+
+		allocTxID = allocTx.ID
+		return session.CommitTransaction(sessionCtx)
+	})
+
+	return allocTxID, err
+}
+
+// executionRef is the ref of the transfer made by an execution, used to detect a transfer that went through
+// although its run was recorded as failed
+func executionRef(execution *AllocationExecution) string {
+	return "alloc_exec_" + execution.AllocationID.Hex() + "_" + execution.ID.Hex()
+}
+
+func (s *Service) recordShortfall(ctx context.Context, allocation *Allocation, execution *AllocationExecution) {
+	shortfall := &AllocationShortfall{
+		UserID:       allocation.UserID,
+		AllocationID: allocation.ID,
+		ExecutionID:  execution.ID,
+		Priority:     allocation.Priority,
+		Amount:       execution.Shortfall,
+		Remaining:    execution.Shortfall,
+	}
+
+	if err := s.repo.CreateShortfall(ctx, shortfall); err != nil {
+		log.Printf("failed to record shortfall of allocation %s: %v", allocation.ID.Hex(), err)
+	}
+}
+
+// topUpShortfalls funds open shortfalls from a new income, highest priority and oldest first, up to the
+// income amount and the main pocket balance. Shortfalls of deleted or inactive allocations are cancelled.
+func (s *Service) topUpShortfalls(ctx context.Context, income *transaction.Transaction, mainPocket *pocket.Pocket, sourceUserPlatformID primitive.ObjectID) {
+	shortfalls, err := s.repo.GetOpenShortfalls(ctx, income.UserID)
+	if err != nil {
+		log.Printf("failed to fetch open shortfalls for user %s: %v", income.UserID.Hex(), err)
+		return
+	}
+
+	available := math.Min(income.Amount, utils.Decimal128ToFloat64(mainPocket.Balance))
+
+	for _, shortfall := range shortfalls {
+		if available < 0.01 {
+			break
+		}
+
+		allocation, err := s.repo.GetAllocationByID(ctx, shortfall.AllocationID)
+		if err != nil || !allocation.IsActive {
+			if err := s.repo.CancelShortfall(ctx, shortfall.ID); err != nil {
+				log.Printf("failed to cancel shortfall %s: %v", shortfall.ID.Hex(), err)
+			}
+			continue
+		}
+
+		amount := math.Min(shortfall.Remaining, available)
+		ref := "alloc_topup_" + shortfall.ID.Hex()
+		_, err = s.transfer(ctx, allocation, mainPocket.ID, sourceUserPlatformID, amount, "Allocation shortfall top-up", ref,
+			func(sessionCtx mongo.SessionContext, transactionID primitive.ObjectID) error {
+				reduced, err := s.repo.ReduceShortfall(sessionCtx, shortfall, amount, transactionID)
+				if err != nil {
+					return err
+				}
+				if !reduced {
+					return errors.New("shortfall was already topped up")
+				}
+				return nil
+			})
+		if err != nil {
+			log.Printf("failed to top up shortfall %s of allocation %s: %v", shortfall.ID.Hex(), allocation.ID.Hex(), err)
+			continue
+		}
+
+		available -= amount
+		log.Printf("topped up shortfall %s of allocation %s with %.2f from income %s", shortfall.ID.Hex(), allocation.ID.Hex(), amount, income.ID.Hex())
+	}
+}
+
+func (s *Service) notifyShortfall(ctx context.Context, userID primitive.ObjectID, count int, total float64) {
+	subject := "Finlet - Saldo tidak cukup untuk semua alokasi"
+	body := fmt.Sprintf("Saldo kantong utama tidak cukup untuk %d alokasi, kurang %s. Kekurangan ini akan dipenuhi otomatis dari pemasukan berikutnya.",
+		count, notification.FormatRupiah(total))
+
+	if err := s.notificationService.Notify(ctx, userID, subject, body); err != nil {
+		log.Printf("failed to send allocation shortfall notification to user %s: %v", userID.Hex(), err)
+	}
+}
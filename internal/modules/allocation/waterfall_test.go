@@ -0,0 +1,95 @@
+package allocation
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPlanFunding(t *testing.T) {
+	type request struct {
+		priority  int
+		requested float64
+		invalid   bool
+	}
+
+	// 100 covers the first level, leaving 50 for a second level that asks for 160 and a third that gets nothing
+	shortLevel := []request{{1, 100, false}, {2, 80, false}, {2, 30, false}, {2, 50, false}, {3, 10, false}}
+
+	tests := []struct {
+		name      string
+		requests  []request
+		available float64
+		policy    ShortfallPolicy
+		funded    []float64
+	}{
+		{
+			name:      "every level covered",
+			requests:  []request{{1, 100, false}, {1, 50, false}, {2, 30, false}},
+			available: 200,
+			policy:    PolicySkip,
+			funded:    []float64{100, 50, 30},
+		},
+		{
+			name:      "SKIP funds what fits in full",
+			requests:  shortLevel,
+			available: 150,
+			policy:    PolicySkip,
+			funded:    []float64{100, 0, 30, 0, 0},
+		},
+		{
+			name:      "PARTIAL funds in order until the balance runs out",
+			requests:  shortLevel,
+			available: 150,
+			policy:    PolicyPartial,
+			funded:    []float64{100, 50, 0, 0, 0},
+		},
+		{
+			name:      "PROPORTIONAL splits the balance rounded down to cents",
+			requests:  shortLevel,
+			available: 150,
+			policy:    PolicyProportional,
+			funded:    []float64{100, 25, 9.37, 15.62, 0},
+		},
+		{
+			name:      "PROPORTIONAL never takes more than the balance",
+			requests:  []request{{1, 100, false}, {1, 100, false}, {1, 100, false}},
+			available: 100,
+			policy:    PolicyProportional,
+			funded:    []float64{33.33, 33.33, 33.33},
+		},
+		{
+			name:      "negative balance funds nothing",
+			requests:  []request{{1, 100, false}, {2, 50, false}},
+			available: -20,
+			policy:    PolicyPartial,
+			funded:    []float64{0, 0},
+		},
+		{
+			name:      "allocations with an error take no part",
+			requests:  []request{{1, 100, true}, {1, 50, false}, {2, 10, false}},
+			available: 60,
+			policy:    PolicySkip,
+			funded:    []float64{0, 50, 10},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			items := make([]*waterfallItem, len(tt.requests))
+			for i, r := range tt.requests {
+				items[i] = &waterfallItem{allocation: &Allocation{Priority: r.priority}, requested: r.requested}
+				if r.invalid {
+					items[i].err = errors.New("target pocket is invalid")
+				}
+			}
+
+			planFunding(items, tt.available, tt.policy)
+
+			for i, item := range items {
+				if item.funded != tt.funded[i] {
+					t.Errorf("allocation %d funded %v, want %v", i, item.funded, tt.funded[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package balance_snapshot
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// BalanceSnapshot stores the closing balance of a pocket or user platform at the end of a day (UTC).
+type BalanceSnapshot struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID     primitive.ObjectID `bson:"user_id" json:"user_id"`
+	EntityType string             `bson:"entity_type" json:"entity_type" enums:"pocket,user_platform"`
+	EntityID   primitive.ObjectID `bson:"entity_id" json:"entity_id"`
+	Date       time.Time          `bson:"date" json:"date"`
+	Balance    float64            `bson:"balance" json:"balance"`
+	CreatedAt  time.Time          `bson:"created_at" json:"created_at"`
+}
+
+type BalanceHistoryPoint struct {
+	Date    string  `json:"date"`
+	Balance float64 `json:"balance"`
+}
+
+type EntityType string
+
+const (
+	EntityTypePocket       EntityType = "pocket"
+	EntityTypeUserPlatform EntityType = "user_platform"
+)
+
+// transactionFields returns the transaction fields referencing the entity as source and destination
+func (t EntityType) transactionFields() (string, string) {
+	if t == EntityTypeUserPlatform {
+		return "user_platform_from_id", "user_platform_to_id"
+	}
+	return "pocket_from_id", "pocket_to_id"
+}
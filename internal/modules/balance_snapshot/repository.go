@@ -0,0 +1,244 @@
+package balance_snapshot
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/HasanNugroho/coin-be/internal/core/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type Repository struct {
+	snapshots     *mongo.Collection
+	transactions  *mongo.Collection
+	pockets       *mongo.Collection
+	userPlatforms *mongo.Collection
+}
+
+func NewRepository(db *mongo.Database) *Repository {
+	return &Repository{
+		snapshots:     db.Collection("balance_snapshots"),
+		transactions:  db.Collection("transactions"),
+		pockets:       db.Collection("pockets"),
+		userPlatforms: db.Collection("user_platforms"),
+	}
+}
+
+// balanceEntity is the minimal projection of a pocket or user platform needed to build snapshots
+type balanceEntity struct {
+	ID      primitive.ObjectID   `bson:"_id"`
+	UserID  primitive.ObjectID   `bson:"user_id"`
+	Balance primitive.Decimal128 `bson:"balance"`
+}
+
+// transactionFlow is the minimal projection of a transaction needed to walk balances back in time
+type transactionFlow struct {
+	Amount             float64             `bson:"amount"`
+	Date               time.Time           `bson:"date"`
+	PocketFromID       *primitive.ObjectID `bson:"pocket_from_id,omitempty"`
+	PocketToID         *primitive.ObjectID `bson:"pocket_to_id,omitempty"`
+	UserPlatformFromID *primitive.ObjectID `bson:"user_platform_from_id,omitempty"`
+	UserPlatformToID   *primitive.ObjectID `bson:"user_platform_to_id,omitempty"`
+}
+
+func (r *Repository) entityCollection(entityType EntityType) *mongo.Collection {
+	if entityType == EntityTypeUserPlatform {
+		return r.userPlatforms
+	}
+	return r.pockets
+}
+
+func (r *Repository) GetSnapshots(ctx context.Context, entityType EntityType, entityID primitive.ObjectID, startDate, endDate time.Time) ([]*BalanceSnapshot, error) {
+	filter := bson.M{
+		"entity_type": entityType,
+		"entity_id":   entityID,
+		"date": bson.M{
+			"$gte": startDate,
+			"$lt":  endDate,
+		},
+	}
+
+	opts := options.Find().SetSort(bson.M{"date": 1})
+	cursor, err := r.snapshots.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var snapshots []*BalanceSnapshot
+	if err = cursor.All(ctx, &snapshots); err != nil {
+		return nil, err
+	}
+	return snapshots, nil
+}
+
+func (r *Repository) UpsertSnapshots(ctx context.Context, snapshots []*BalanceSnapshot) error {
+	if len(snapshots) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	models := make([]mongo.WriteModel, 0, len(snapshots))
+	for _, snapshot := range snapshots {
+		filter := bson.M{
+			"entity_type": snapshot.EntityType,
+			"entity_id":   snapshot.EntityID,
+			"date":        snapshot.Date,
+		}
+		update := bson.M{
+			"$set": bson.M{
+				"user_id": snapshot.UserID,
+				"balance": snapshot.Balance,
+			},
+			"$setOnInsert": bson.M{
+				"created_at": now,
+			},
+		}
+		models = append(models, mongo.NewUpdateOneModel().SetFilter(filter).SetUpdate(update).SetUpsert(true))
+	}
+
+	_, err := r.snapshots.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+	return err
+}
+
+// DeleteSnapshotsFrom removes the snapshots of the given entities from startDate onwards
+func (r *Repository) DeleteSnapshotsFrom(ctx context.Context, entityIDs []primitive.ObjectID, startDate time.Time) error {
+	if len(entityIDs) == 0 {
+		return nil
+	}
+
+	filter := bson.M{
+		"entity_id": bson.M{"$in": entityIDs},
+		"date":      bson.M{"$gte": startDate},
+	}
+	_, err := r.snapshots.DeleteMany(ctx, filter)
+	return err
+}
+
+func (r *Repository) GetEntity(ctx context.Context, entityType EntityType, entityID primitive.ObjectID) (*balanceEntity, error) {
+	var entity balanceEntity
+	err := r.entityCollection(entityType).FindOne(ctx, bson.M{"_id": entityID, "deleted_at": nil}).Decode(&entity)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.New(string(entityType) + " not found")
+		}
+		return nil, err
+	}
+	return &entity, nil
+}
+
+func (r *Repository) GetAllEntities(ctx context.Context, entityType EntityType) ([]*balanceEntity, error) {
+	opts := options.Find().SetProjection(bson.M{"_id": 1, "user_id": 1, "balance": 1})
+	cursor, err := r.entityCollection(entityType).Find(ctx, bson.M{"deleted_at": nil}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var entities []*balanceEntity
+	if err = cursor.All(ctx, &entities); err != nil {
+		return nil, err
+	}
+	return entities, nil
+}
+
+// GetDailyNetFlows returns the net balance change of an entity per UTC day (keyed by YYYY-MM-DD) from startDate onwards
+func (r *Repository) GetDailyNetFlows(ctx context.Context, entityType EntityType, entityID primitive.ObjectID, startDate time.Time) (map[string]float64, error) {
+	fromField, toField := entityType.transactionFields()
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{
+			"deleted_at": nil,
+			"date":       bson.M{"$gte": startDate.UTC()},
+			"$or": []bson.M{
+				{fromField: entityID},
+				{toField: entityID},
+			},
+		}}},
+		{{Key: "$project", Value: bson.M{
+			"day": bson.M{"$dateToString": bson.M{"format": "%Y-%m-%d", "date": "$date"}},
+			"delta": bson.M{"$subtract": bson.A{
+				bson.M{"$cond": bson.A{bson.M{"$eq": bson.A{"$" + toField, entityID}}, "$amount", 0}},
+				bson.M{"$cond": bson.A{bson.M{"$eq": bson.A{"$" + fromField, entityID}}, "$amount", 0}},
+			}},
+		}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":   "$day",
+			"delta": bson.M{"$sum": "$delta"},
+		}}},
+	}
+
+	cursor, err := r.transactions.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var results []struct {
+		Day   string  `bson:"_id"`
+		Delta float64 `bson:"delta"`
+	}
+	if err = cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+
+	flows := make(map[string]float64, len(results))
+	for _, res := range results {
+		flows[res.Day] = res.Delta
+	}
+	return flows, nil
+}
+
+// GetTransactionFlowsSince returns every active transaction dated at or after startDate
+func (r *Repository) GetTransactionFlowsSince(ctx context.Context, startDate time.Time) ([]*transactionFlow, error) {
+	filter := bson.M{
+		"deleted_at": nil,
+		"date":       bson.M{"$gte": startDate.UTC()},
+	}
+	opts := options.Find().SetProjection(bson.M{
+		"amount":                1,
+		"date":                  1,
+		"pocket_from_id":        1,
+		"pocket_to_id":          1,
+		"user_platform_from_id": 1,
+		"user_platform_to_id":   1,
+	})
+
+	cursor, err := r.transactions.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var flows []*transactionFlow
+	if err = cursor.All(ctx, &flows); err != nil {
+		return nil, err
+	}
+	return flows, nil
+}
+
+func (r *Repository) EnsureIndexes(ctx context.Context) error {
+	indexes := []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				{Key: "entity_type", Value: 1},
+				{Key: "entity_id", Value: 1},
+				{Key: "date", Value: 1},
+			},
+			Options: options.Index().
+				SetName("idx_balance_snapshots_entity_date").
+				SetUnique(true),
+		},
+	}
+
+	_, err := r.snapshots.Indexes().CreateMany(ctx, indexes)
+	return err
+}
+
+func (e *balanceEntity) balance() float64 {
+	return utils.Decimal128ToFloat64(e.Balance)
+}
@@ -0,0 +1,222 @@
+package balance_snapshot
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	dateLayout         = "2006-01-02"
+	defaultHistoryDays = 30
+	maxHistoryDays     = 366
+)
+
+type Service struct {
+	repo *Repository
+}
+
+func NewService(r *Repository) *Service {
+	return &Service{
+		repo: r,
+	}
+}
+
+// GenerateSnapshotsForDate writes the closing balance of every pocket and user platform for the given day.
+// The closing balance is derived from the current balance minus every transaction dated after that day.
+func (s *Service) GenerateSnapshotsForDate(ctx context.Context, date time.Time) error {
+	day := startOfDay(date)
+
+	flows, err := s.repo.GetTransactionFlowsSince(ctx, day.AddDate(0, 0, 1))
+	if err != nil {
+		return err
+	}
+
+	deltas := map[EntityType]map[primitive.ObjectID]float64{
+		EntityTypePocket:       {},
+		EntityTypeUserPlatform: {},
+	}
+	for _, flow := range flows {
+		if flow.PocketFromID != nil {
+			deltas[EntityTypePocket][*flow.PocketFromID] -= flow.Amount
+		}
+		if flow.PocketToID != nil {
+			deltas[EntityTypePocket][*flow.PocketToID] += flow.Amount
+		}
+		if flow.UserPlatformFromID != nil {
+			deltas[EntityTypeUserPlatform][*flow.UserPlatformFromID] -= flow.Amount
+		}
+		if flow.UserPlatformToID != nil {
+			deltas[EntityTypeUserPlatform][*flow.UserPlatformToID] += flow.Amount
+		}
+	}
+
+	for _, entityType := range []EntityType{EntityTypePocket, EntityTypeUserPlatform} {
+		entities, err := s.repo.GetAllEntities(ctx, entityType)
+		if err != nil {
+			return err
+		}
+
+		snapshots := make([]*BalanceSnapshot, 0, len(entities))
+		for _, entity := range entities {
+			snapshots = append(snapshots, &BalanceSnapshot{
+				UserID:     entity.UserID,
+				EntityType: string(entityType),
+				EntityID:   entity.ID,
+				Date:       day,
+				Balance:    entity.balance() - deltas[entityType][entity.ID],
+			})
+		}
+
+		if err := s.repo.UpsertSnapshots(ctx, snapshots); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetBalanceHistory returns the daily closing balances of an entity between startDate and endDate (inclusive).
+// Cached snapshots are used when the range is fully covered, otherwise balances are rebuilt from transactions
+// and the missing snapshots are stored for subsequent requests. Today's point is always the live balance.
+func (s *Service) GetBalanceHistory(ctx context.Context, userID string, entityType EntityType, entityID string, startDate, endDate *time.Time) ([]BalanceHistoryPoint, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, errors.New("invalid user id")
+	}
+
+	entityObjID, err := primitive.ObjectIDFromHex(entityID)
+	if err != nil {
+		return nil, errors.New("invalid " + string(entityType) + " id")
+	}
+
+	today := startOfDay(time.Now())
+	end := today
+	if endDate != nil && startOfDay(*endDate).Before(today) {
+		end = startOfDay(*endDate)
+	}
+	start := end.AddDate(0, 0, -(defaultHistoryDays - 1))
+	if startDate != nil {
+		start = startOfDay(*startDate)
+	}
+
+	if start.After(end) {
+		return nil, errors.New("start date must be before end date")
+	}
+	if int(end.Sub(start).Hours()/24)+1 > maxHistoryDays {
+		return nil, errors.New("date range cannot exceed 366 days")
+	}
+
+	entity, err := s.repo.GetEntity(ctx, entityType, entityObjID)
+	if err != nil {
+		return nil, err
+	}
+
+	if entity.UserID != userObjID {
+		return nil, errors.New("unauthorized")
+	}
+
+	snapshots, err := s.repo.GetSnapshots(ctx, entityType, entityObjID, start, end.AddDate(0, 0, 1))
+	if err != nil {
+		return nil, err
+	}
+
+	cached := make(map[string]float64, len(snapshots))
+	for _, snapshot := range snapshots {
+		cached[snapshot.Date.Format(dateLayout)] = snapshot.Balance
+	}
+
+	complete := true
+	for d := start; !d.After(end) && d.Before(today); d = d.AddDate(0, 0, 1) {
+		if _, ok := cached[d.Format(dateLayout)]; !ok {
+			complete = false
+			break
+		}
+	}
+
+	if complete {
+		points := make([]BalanceHistoryPoint, 0, len(cached)+1)
+		for d := start; !d.After(end); d = d.AddDate(0, 0, 1) {
+			key := d.Format(dateLayout)
+			balance := cached[key]
+			if d.Equal(today) {
+				balance = entity.balance()
+			}
+			points = append(points, BalanceHistoryPoint{Date: key, Balance: balance})
+		}
+		return points, nil
+	}
+
+	flows, err := s.repo.GetDailyNetFlows(ctx, entityType, entityObjID, start.AddDate(0, 0, 1))
+	if err != nil {
+		return nil, err
+	}
+
+	// Walk back from the live balance, undoing every day that closes after the end of the range
+	endKey := end.Format(dateLayout)
+	running := entity.balance()
+	for day, delta := range flows {
+		if day > endKey {
+			running -= delta
+		}
+	}
+
+	points := make([]BalanceHistoryPoint, int(end.Sub(start).Hours()/24)+1)
+	var missing []*BalanceSnapshot
+	for i, d := len(points)-1, end; i >= 0; i, d = i-1, d.AddDate(0, 0, -1) {
+		key := d.Format(dateLayout)
+		points[i] = BalanceHistoryPoint{Date: key, Balance: running}
+
+		if _, ok := cached[key]; !ok && d.Before(today) {
+			missing = append(missing, &BalanceSnapshot{
+				UserID:     entity.UserID,
+				EntityType: string(entityType),
+				EntityID:   entity.ID,
+				Date:       d,
+				Balance:    running,
+			})
+		}
+
+		running -= flows[key]
+	}
+
+	if err := s.repo.UpsertSnapshots(ctx, missing); err != nil {
+		log.Printf("failed to cache balance snapshots for %s %s: %v", entityType, entityID, err)
+	}
+
+	return points, nil
+}
+
+// InvalidateFrom drops the cached snapshots of the given pockets and user platforms from date onwards.
+// It must be called whenever a back-dated transaction changes their history.
+func (s *Service) InvalidateFrom(ctx context.Context, date time.Time, entityIDs ...*primitive.ObjectID) error {
+	ids := make([]primitive.ObjectID, 0, len(entityIDs))
+	for _, id := range entityIDs {
+		if id != nil {
+			ids = append(ids, *id)
+		}
+	}
+
+	return s.repo.DeleteSnapshotsFrom(ctx, ids, startOfDay(date))
+}
+
+func startOfDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// ParseDate parses an optional YYYY-MM-DD query value, returning nil when it is empty
+func ParseDate(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	date, err := time.Parse(dateLayout, value)
+	if err != nil {
+		return nil, errors.New("invalid date format, use YYYY-MM-DD")
+	}
+	return &date, nil
+}
@@ -0,0 +1,591 @@
+package budget
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/HasanNugroho/coin-be/internal/core/utils"
+	"github.com/HasanNugroho/coin-be/internal/modules/budget/dto"
+	"github.com/gin-gonic/gin"
+)
+
+type Controller struct {
+	service *Service
+}
+
+func NewController(s *Service) *Controller {
+	return &Controller{service: s}
+}
+
+// CreateBudget godoc
+// @Summary Create budget
+// @Description Set a monthly, weekly or payday-cycle spending limit on a category. Spending of its child categories counts toward the limit.
+// @Tags Budgets
+// @Accept json
+// @Produce json
+// @Param request body dto.CreateBudgetRequest true "Budget details"
+// @Success 201 {object} map[string]interface{} "Budget created successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Security BearerAuth
+// @Router /v1/budgets [post]
+func (c *Controller) CreateBudget(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		resp := utils.NewErrorResponse(http.StatusUnauthorized, "unauthorized")
+		ctx.JSON(http.StatusUnauthorized, resp)
+		return
+	}
+
+	var req dto.CreateBudgetRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		resp := utils.NewErrorResponse(http.StatusBadRequest, err.Error())
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	if err := utils.ValidateRequest(&req); err != nil {
+		resp := utils.NewErrorResponse(http.StatusBadRequest, err.Error())
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	budget, err := c.service.CreateBudget(ctx, userID.(string), &req)
+	if err != nil {
+		resp := utils.NewErrorResponse(http.StatusBadRequest, err.Error())
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	resp := utils.NewSuccessResponse("Budget created successfully", c.mapToResponse(budget))
+	ctx.JSON(http.StatusCreated, resp)
+}
+
+// ListBudgets godoc
+// @Summary List budgets
+// @Description Get all budgets of the authenticated user with spent and remaining amounts of the current period
+// @Tags Budgets
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Budgets retrieved successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Security BearerAuth
+// @Router /v1/budgets [get]
+func (c *Controller) ListBudgets(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		resp := utils.NewErrorResponse(http.StatusUnauthorized, "unauthorized")
+		ctx.JSON(http.StatusUnauthorized, resp)
+		return
+	}
+
+	statuses, err := c.service.ListBudgetStatuses(ctx, userID.(string))
+	if err != nil {
+		resp := utils.NewErrorResponse(http.StatusBadRequest, err.Error())
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	responses := make([]*dto.BudgetStatusResponse, len(statuses))
+	for i, status := range statuses {
+		responses[i] = c.mapStatusToResponse(status)
+	}
+
+	resp := utils.NewSuccessResponse("Budgets retrieved successfully", responses)
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// GetBudget godoc
+// @Summary Get budget by ID
+// @Description Get a budget with spent and remaining amounts of the current period
+// @Tags Budgets
+// @Produce json
+// @Param id path string true "Budget ID"
+// @Success 200 {object} map[string]interface{} "Budget retrieved successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 404 {object} map[string]interface{} "Not found"
+// @Security BearerAuth
+// @Router /v1/budgets/{id} [get]
+func (c *Controller) GetBudget(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		resp := utils.NewErrorResponse(http.StatusUnauthorized, "unauthorized")
+		ctx.JSON(http.StatusUnauthorized, resp)
+		return
+	}
+
+	status, err := c.service.GetBudgetStatus(ctx, userID.(string), ctx.Param("id"))
+	if err != nil {
+		resp := utils.NewErrorResponse(http.StatusNotFound, err.Error())
+		ctx.JSON(http.StatusNotFound, resp)
+		return
+	}
+
+	resp := utils.NewSuccessResponse("Budget retrieved successfully", c.mapStatusToResponse(status))
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// UpdateBudget godoc
+// @Summary Update budget
+// @Description Update the limit, period type, alert thresholds or active state of a budget
+// @Tags Budgets
+// @Accept json
+// @Produce json
+// @Param id path string true "Budget ID"
+// @Param request body dto.UpdateBudgetRequest true "Budget update details"
+// @Success 200 {object} map[string]interface{} "Budget updated successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Security BearerAuth
+// @Router /v1/budgets/{id} [put]
+func (c *Controller) UpdateBudget(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		resp := utils.NewErrorResponse(http.StatusUnauthorized, "unauthorized")
+		ctx.JSON(http.StatusUnauthorized, resp)
+		return
+	}
+
+	var req dto.UpdateBudgetRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		resp := utils.NewErrorResponse(http.StatusBadRequest, err.Error())
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	if err := utils.ValidateRequest(&req); err != nil {
+		resp := utils.NewErrorResponse(http.StatusBadRequest, err.Error())
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	budget, err := c.service.UpdateBudget(ctx, userID.(string), ctx.Param("id"), &req)
+	if err != nil {
+		resp := utils.NewErrorResponse(http.StatusBadRequest, err.Error())
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	resp := utils.NewSuccessResponse("Budget updated successfully", c.mapToResponse(budget))
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// DeleteBudget godoc
+// @Summary Delete budget
+// @Description Delete a budget
+// @Tags Budgets
+// @Produce json
+// @Param id path string true "Budget ID"
+// @Success 200 {object} map[string]interface{} "Budget deleted successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Security BearerAuth
+// @Router /v1/budgets/{id} [delete]
+func (c *Controller) DeleteBudget(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		resp := utils.NewErrorResponse(http.StatusUnauthorized, "unauthorized")
+		ctx.JSON(http.StatusUnauthorized, resp)
+		return
+	}
+
+	if err := c.service.DeleteBudget(ctx, userID.(string), ctx.Param("id")); err != nil {
+		resp := utils.NewErrorResponse(http.StatusBadRequest, err.Error())
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	resp := utils.NewSuccessResponse("Budget deleted successfully", nil)
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// ListAlerts godoc
+// @Summary List budget alerts
+// @Description Get the threshold alerts sent for a budget, most recent period first
+// @Tags Budgets
+// @Produce json
+// @Param id path string true "Budget ID"
+// @Param limit query int false "Number of alerts (default: 20, max: 100)"
+// @Success 200 {object} map[string]interface{} "Budget alerts retrieved successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Security BearerAuth
+// @Router /v1/budgets/{id}/alerts [get]
+func (c *Controller) ListAlerts(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		resp := utils.NewErrorResponse(http.StatusUnauthorized, "unauthorized")
+		ctx.JSON(http.StatusUnauthorized, resp)
+		return
+	}
+
+	limit, _ := strconv.ParseInt(ctx.DefaultQuery("limit", "20"), 10, 64)
+
+	alerts, err := c.service.ListAlerts(ctx, userID.(string), ctx.Param("id"), limit)
+	if err != nil {
+		resp := utils.NewErrorResponse(http.StatusBadRequest, err.Error())
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	responses := make([]*dto.BudgetAlertResponse, len(alerts))
+	for i, alert := range alerts {
+		responses[i] = &dto.BudgetAlertResponse{
+			ID:          alert.ID.Hex(),
+			BudgetID:    alert.BudgetID.Hex(),
+			CategoryID:  alert.CategoryID.Hex(),
+			PeriodStart: alert.PeriodStart,
+			PeriodEnd:   alert.PeriodEnd,
+			Threshold:   alert.Threshold,
+			Limit:       alert.Limit,
+			Spent:       alert.Spent,
+			Percentage:  alert.Percentage,
+			CreatedAt:   alert.CreatedAt,
+		}
+	}
+
+	resp := utils.NewSuccessResponse("Budget alerts retrieved successfully", responses)
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// GetReport godoc
+// @Summary Get budget vs actual report
+// @Description Compare budgeted amounts with actual spending per category for the period containing the given date and the three periods before it
+// @Tags Budgets
+// @Produce json
+// @Param period_type query string false "Period type (monthly, weekly, payday), defaults to monthly"
+// @Param date query string false "Any date within the period (YYYY-MM-DD), defaults to today"
+// @Success 200 {object} map[string]interface{} "Budget report retrieved successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Security BearerAuth
+// @Router /v1/budgets/report [get]
+func (c *Controller) GetReport(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		resp := utils.NewErrorResponse(http.StatusUnauthorized, "unauthorized")
+		ctx.JSON(http.StatusUnauthorized, resp)
+		return
+	}
+
+	report, err := c.service.GetBudgetReport(ctx, userID.(string), ctx.Query("period_type"), ctx.Query("date"))
+	if err != nil {
+		resp := utils.NewErrorResponse(http.StatusBadRequest, err.Error())
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	resp := utils.NewSuccessResponse("Budget report retrieved successfully", c.mapReportToResponse(report))
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// ExportReport godoc
+// @Summary Export budget vs actual report
+// @Description Download the budget vs actual report as CSV, one row per category and period
+// @Tags Budgets
+// @Produce text/csv
+// @Param period_type query string false "Period type (monthly, weekly, payday), defaults to monthly"
+// @Param date query string false "Any date within the period (YYYY-MM-DD), defaults to today"
+// @Success 200 {file} file "CSV file"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Security BearerAuth
+// @Router /v1/budgets/report/export [get]
+func (c *Controller) ExportReport(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		resp := utils.NewErrorResponse(http.StatusUnauthorized, "unauthorized")
+		ctx.JSON(http.StatusUnauthorized, resp)
+		return
+	}
+
+	report, err := c.service.GetBudgetReport(ctx, userID.(string), ctx.Query("period_type"), ctx.Query("date"))
+	if err != nil {
+		resp := utils.NewErrorResponse(http.StatusBadRequest, err.Error())
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	filename := fmt.Sprintf("budget-report-%s-%s.csv", report.PeriodType, report.PeriodStart.Format("2006-01-02"))
+	ctx.Header("Content-Type", "text/csv")
+	ctx.Header("Content-Disposition", "attachment; filename="+filename)
+
+	writer := csv.NewWriter(ctx.Writer)
+	writer.Write([]string{"category", "period_start", "period_end", "budgeted", "actual", "variance", "variance_percentage"})
+	for _, item := range report.Items {
+		for _, period := range item.Periods {
+			writer.Write(reportCSVRow(item.CategoryName, period))
+		}
+	}
+	for _, total := range report.Totals {
+		writer.Write(reportCSVRow("TOTAL", total))
+	}
+	writer.Flush()
+}
+
+func reportCSVRow(category string, period *BudgetReportPeriod) []string {
+	return []string{
+		category,
+		period.PeriodStart.Format("2006-01-02"),
+		period.PeriodEnd.AddDate(0, 0, -1).Format("2006-01-02"),
+		strconv.FormatFloat(period.Budgeted, 'f', 2, 64),
+		strconv.FormatFloat(period.Actual, 'f', 2, 64),
+		strconv.FormatFloat(period.Variance, 'f', 2, 64),
+		strconv.FormatFloat(period.VariancePercentage, 'f', 2, 64),
+	}
+}
+
+// GetZeroBasedSummary godoc
+// @Summary Get zero-based budgeting summary
+// @Description Get the income received by the main pocket in a month, how it has been assigned to pockets and categories, and the amount still to be assigned
+// @Tags Budgets
+// @Produce json
+// @Param period query string false "Financial month (YYYY-MM, the month it starts in), defaults to the current one"
+// @Success 200 {object} map[string]interface{} "Zero-based summary retrieved successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Security BearerAuth
+// @Router /v1/budgets/zero-based [get]
+func (c *Controller) GetZeroBasedSummary(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		resp := utils.NewErrorResponse(http.StatusUnauthorized, "unauthorized")
+		ctx.JSON(http.StatusUnauthorized, resp)
+		return
+	}
+
+	summary, err := c.service.GetZeroBasedSummary(ctx, userID.(string), ctx.Query("period"))
+	if err != nil {
+		resp := utils.NewErrorResponse(http.StatusBadRequest, err.Error())
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	resp := utils.NewSuccessResponse("Zero-based summary retrieved successfully", c.mapZeroBasedToResponse(summary))
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// AssignCategory godoc
+// @Summary Assign income to a category
+// @Description Set the amount of a month's income assigned to a category. An amount of zero removes the assignment.
+// @Tags Budgets
+// @Accept json
+// @Produce json
+// @Param category_id path string true "Category ID"
+// @Param request body dto.AssignCategoryRequest true "Assignment details"
+// @Success 200 {object} map[string]interface{} "Category assigned successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Security BearerAuth
+// @Router /v1/budgets/zero-based/categories/{category_id} [put]
+func (c *Controller) AssignCategory(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		resp := utils.NewErrorResponse(http.StatusUnauthorized, "unauthorized")
+		ctx.JSON(http.StatusUnauthorized, resp)
+		return
+	}
+
+	var req dto.AssignCategoryRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		resp := utils.NewErrorResponse(http.StatusBadRequest, err.Error())
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	if err := utils.ValidateRequest(&req); err != nil {
+		resp := utils.NewErrorResponse(http.StatusBadRequest, err.Error())
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	summary, err := c.service.AssignCategory(ctx, userID.(string), ctx.Param("category_id"), &req)
+	if err != nil {
+		resp := utils.NewErrorResponse(http.StatusBadRequest, err.Error())
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	resp := utils.NewSuccessResponse("Category assigned successfully", c.mapZeroBasedToResponse(summary))
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// AssignLikeLastMonth godoc
+// @Summary Assign like last month
+// @Description Replay last month's assignments on the current month: pockets are topped up with transfers from the main pocket and category assignments are raised to last month's amounts
+// @Tags Budgets
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Last month's assignments replayed successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Security BearerAuth
+// @Router /v1/budgets/zero-based/assign-last-month [post]
+func (c *Controller) AssignLikeLastMonth(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		resp := utils.NewErrorResponse(http.StatusUnauthorized, "unauthorized")
+		ctx.JSON(http.StatusUnauthorized, resp)
+		return
+	}
+
+	summary, err := c.service.AssignLikeLastMonth(ctx, userID.(string))
+	if err != nil {
+		resp := utils.NewErrorResponse(http.StatusBadRequest, err.Error())
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	resp := utils.NewSuccessResponse("Last month's assignments replayed successfully", c.mapZeroBasedToResponse(summary))
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// CloseZeroBasedPeriod godoc
+// @Summary Close zero-based month
+// @Description Lock the assignments of a month. Refused while the amount to be assigned is not zero.
+// @Tags Budgets
+// @Produce json
+// @Param period query string false "Financial month (YYYY-MM, the month it starts in), defaults to the current one"
+// @Success 200 {object} map[string]interface{} "Period closed successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Security BearerAuth
+// @Router /v1/budgets/zero-based/close [post]
+func (c *Controller) CloseZeroBasedPeriod(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		resp := utils.NewErrorResponse(http.StatusUnauthorized, "unauthorized")
+		ctx.JSON(http.StatusUnauthorized, resp)
+		return
+	}
+
+	summary, err := c.service.CloseZeroBasedPeriod(ctx, userID.(string), ctx.Query("period"))
+	if err != nil {
+		resp := utils.NewErrorResponse(http.StatusBadRequest, err.Error())
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	resp := utils.NewSuccessResponse("Period closed successfully", c.mapZeroBasedToResponse(summary))
+	ctx.JSON(http.StatusOK, resp)
+}
+
+func (c *Controller) mapToResponse(budget *Budget) *dto.BudgetResponse {
+	return &dto.BudgetResponse{
+		ID:         budget.ID.Hex(),
+		UserID:     budget.UserID.Hex(),
+		CategoryID: budget.CategoryID.Hex(),
+		Amount:     budget.Amount,
+		PeriodType: budget.PeriodType,
+		Thresholds: budget.Thresholds,
+		IsActive:   budget.IsActive,
+		CreatedAt:  budget.CreatedAt,
+		UpdatedAt:  budget.UpdatedAt,
+		DeletedAt:  budget.DeletedAt,
+	}
+}
+
+func (c *Controller) mapStatusToResponse(status *BudgetStatus) *dto.BudgetStatusResponse {
+	return &dto.BudgetStatusResponse{
+		ID:           status.Budget.ID.Hex(),
+		CategoryID:   status.Budget.CategoryID.Hex(),
+		CategoryName: status.CategoryName,
+		Amount:       status.Budget.Amount,
+		PeriodType:   status.Budget.PeriodType,
+		Thresholds:   status.Budget.Thresholds,
+		IsActive:     status.Budget.IsActive,
+		PeriodStart:  status.PeriodStart,
+		PeriodEnd:    status.PeriodEnd,
+		Spent:        status.Spent,
+		Remaining:    status.Remaining,
+		Percentage:   status.Percentage,
+		IsOverBudget: status.Spent > status.Budget.Amount,
+	}
+}
+
+func (c *Controller) mapReportToResponse(report *BudgetReport) *dto.BudgetReportResponse {
+	resp := &dto.BudgetReportResponse{
+		PeriodType:    report.PeriodType,
+		PeriodStart:   report.PeriodStart,
+		PeriodEnd:     report.PeriodEnd,
+		Items:         make([]*dto.BudgetReportItemResponse, len(report.Items)),
+		Total:         mapReportPeriod(report.Totals[0]),
+		PreviousTotal: mapReportPeriods(report.Totals[1:]),
+	}
+
+	for i, item := range report.Items {
+		resp.Items[i] = &dto.BudgetReportItemResponse{
+			BudgetID:       item.Budget.ID.Hex(),
+			CategoryID:     item.Budget.CategoryID.Hex(),
+			CategoryName:   item.CategoryName,
+			Current:        mapReportPeriod(item.Periods[0]),
+			Previous:       mapReportPeriods(item.Periods[1:]),
+			OvershootCount: item.OvershootCount,
+		}
+	}
+
+	return resp
+}
+
+func mapReportPeriods(periods []*BudgetReportPeriod) []*dto.BudgetReportPeriodResponse {
+	responses := make([]*dto.BudgetReportPeriodResponse, len(periods))
+	for i, period := range periods {
+		responses[i] = mapReportPeriod(period)
+	}
+	return responses
+}
+
+func mapReportPeriod(period *BudgetReportPeriod) *dto.BudgetReportPeriodResponse {
+	return &dto.BudgetReportPeriodResponse{
+		PeriodStart:        period.PeriodStart,
+		PeriodEnd:          period.PeriodEnd,
+		Budgeted:           period.Budgeted,
+		Actual:             period.Actual,
+		Variance:           period.Variance,
+		VariancePercentage: period.VariancePercentage,
+	}
+}
+
+func (c *Controller) mapZeroBasedToResponse(summary *ZeroBasedSummary) *dto.ZeroBasedSummaryResponse {
+	resp := &dto.ZeroBasedSummaryResponse{
+		Period:               summary.Period,
+		PeriodStart:          summary.PeriodStart,
+		PeriodEnd:            summary.PeriodEnd,
+		Income:               summary.Income,
+		Pockets:              make([]*dto.ZeroBasedPocketAssignmentResponse, len(summary.Pockets)),
+		Categories:           make([]*dto.ZeroBasedCategoryAssignmentResponse, len(summary.Categories)),
+		AssignedToPockets:    summary.AssignedToPockets,
+		AssignedToCategories: summary.AssignedToCategories,
+		ToBeAssigned:         summary.ToBeAssigned,
+		IsClosed:             summary.IsClosed,
+		ClosedAt:             summary.ClosedAt,
+	}
+
+	for i, assignment := range summary.Pockets {
+		resp.Pockets[i] = &dto.ZeroBasedPocketAssignmentResponse{
+			PocketID:   assignment.PocketID.Hex(),
+			PocketName: assignment.PocketName,
+			Amount:     assignment.Amount,
+		}
+	}
+
+	for i, assignment := range summary.Categories {
+		resp.Categories[i] = &dto.ZeroBasedCategoryAssignmentResponse{
+			CategoryID:   assignment.CategoryID.Hex(),
+			CategoryName: assignment.CategoryName,
+			Amount:       assignment.Amount,
+		}
+	}
+
+	for _, assignment := range summary.SkippedPockets {
+		resp.SkippedPockets = append(resp.SkippedPockets, &dto.ZeroBasedPocketAssignmentResponse{
+			PocketID:   assignment.PocketID.Hex(),
+			PocketName: assignment.PocketName,
+			Amount:     assignment.Amount,
+		})
+	}
+
+	return resp
+}
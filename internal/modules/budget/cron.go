@@ -0,0 +1,49 @@
+package budget
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/HasanNugroho/coin-be/internal/core/utils"
+	"github.com/robfig/cron/v3"
+)
+
+type CronJob struct {
+	service *Service
+	cron    *cron.Cron
+}
+
+func NewCronJob(service *Service) *CronJob {
+	return &CronJob{
+		service: service,
+		cron:    cron.New(cron.WithLocation(utils.GetJakartaLocation())),
+	}
+}
+
+// Start begins the budget threshold check
+// Runs every 15 minutes (Asia/Jakarta timezone)
+func (c *CronJob) Start() error {
+	_, err := c.cron.AddFunc("*/15 * * * *", func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+		defer cancel()
+
+		if err := c.service.CheckBudgetAlerts(ctx); err != nil {
+			log.Printf("Error checking budget alerts: %v", err)
+		}
+	})
+
+	if err != nil {
+		return err
+	}
+
+	c.cron.Start()
+	log.Println("Budget cron job started")
+	return nil
+}
+
+// Stop stops the cron job
+func (c *CronJob) Stop() {
+	c.cron.Stop()
+	log.Println("Budget cron job stopped")
+}
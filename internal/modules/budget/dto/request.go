@@ -0,0 +1,20 @@
+package dto
+
+type CreateBudgetRequest struct {
+	CategoryID string  `json:"category_id" validate:"required,len=24,hexadecimal"`
+	Amount     float64 `json:"amount" validate:"required,gt=0"`
+	PeriodType string  `json:"period_type" validate:"required,oneof=monthly weekly payday"`
+	Thresholds []int   `json:"thresholds" validate:"omitempty,max=10,dive,gt=0,lte=500"`
+}
+
+type UpdateBudgetRequest struct {
+	Amount     *float64 `json:"amount" validate:"omitempty,gt=0"`
+	PeriodType string   `json:"period_type" validate:"omitempty,oneof=monthly weekly payday"`
+	Thresholds []int    `json:"thresholds" validate:"omitempty,max=10,dive,gt=0,lte=500"`
+	IsActive   *bool    `json:"is_active"`
+}
+
+type AssignCategoryRequest struct {
+	Period string   `json:"period" validate:"omitempty,len=7"`
+	Amount *float64 `json:"amount" validate:"required,gte=0"`
+}
@@ -0,0 +1,99 @@
+package dto
+
+import "time"
+
+type BudgetResponse struct {
+	ID         string     `json:"id"`
+	UserID     string     `json:"user_id"`
+	CategoryID string     `json:"category_id"`
+	Amount     float64    `json:"amount"`
+	PeriodType string     `json:"period_type"`
+	Thresholds []int      `json:"thresholds"`
+	IsActive   bool       `json:"is_active"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+	DeletedAt  *time.Time `json:"deleted_at,omitempty"`
+}
+
+type BudgetStatusResponse struct {
+	ID           string    `json:"id"`
+	CategoryID   string    `json:"category_id"`
+	CategoryName string    `json:"category_name"`
+	Amount       float64   `json:"amount"`
+	PeriodType   string    `json:"period_type"`
+	Thresholds   []int     `json:"thresholds"`
+	IsActive     bool      `json:"is_active"`
+	PeriodStart  time.Time `json:"period_start"`
+	PeriodEnd    time.Time `json:"period_end"`
+	Spent        float64   `json:"spent"`
+	Remaining    float64   `json:"remaining"`
+	Percentage   float64   `json:"percentage"`
+	IsOverBudget bool      `json:"is_over_budget"`
+}
+
+type BudgetAlertResponse struct {
+	ID          string    `json:"id"`
+	BudgetID    string    `json:"budget_id"`
+	CategoryID  string    `json:"category_id"`
+	PeriodStart time.Time `json:"period_start"`
+	PeriodEnd   time.Time `json:"period_end"`
+	Threshold   int       `json:"threshold"`
+	Limit       float64   `json:"limit"`
+	Spent       float64   `json:"spent"`
+	Percentage  float64   `json:"percentage"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type BudgetReportPeriodResponse struct {
+	PeriodStart        time.Time `json:"period_start"`
+	PeriodEnd          time.Time `json:"period_end"`
+	Budgeted           float64   `json:"budgeted"`
+	Actual             float64   `json:"actual"`
+	Variance           float64   `json:"variance"`
+	VariancePercentage float64   `json:"variance_percentage"`
+}
+
+type BudgetReportItemResponse struct {
+	BudgetID       string                        `json:"budget_id"`
+	CategoryID     string                        `json:"category_id"`
+	CategoryName   string                        `json:"category_name"`
+	Current        *BudgetReportPeriodResponse   `json:"current"`
+	Previous       []*BudgetReportPeriodResponse `json:"previous"`
+	OvershootCount int                           `json:"overshoot_count"`
+}
+
+type BudgetReportResponse struct {
+	PeriodType    string                        `json:"period_type"`
+	PeriodStart   time.Time                     `json:"period_start"`
+	PeriodEnd     time.Time                     `json:"period_end"`
+	Items         []*BudgetReportItemResponse   `json:"items"`
+	Total         *BudgetReportPeriodResponse   `json:"total"`
+	PreviousTotal []*BudgetReportPeriodResponse `json:"previous_total"`
+}
+
+type ZeroBasedPocketAssignmentResponse struct {
+	PocketID   string  `json:"pocket_id"`
+	PocketName string  `json:"pocket_name"`
+	Amount     float64 `json:"amount"`
+}
+
+type ZeroBasedCategoryAssignmentResponse struct {
+	CategoryID   string  `json:"category_id"`
+	CategoryName string  `json:"category_name"`
+	Amount       float64 `json:"amount"`
+}
+
+type ZeroBasedSummaryResponse struct {
+	Period               string                                 `json:"period"`
+	PeriodStart          time.Time                              `json:"period_start"`
+	PeriodEnd            time.Time                              `json:"period_end"`
+	Income               float64                                `json:"income"`
+	Pockets              []*ZeroBasedPocketAssignmentResponse   `json:"pockets"`
+	Categories           []*ZeroBasedCategoryAssignmentResponse `json:"categories"`
+	AssignedToPockets    float64                                `json:"assigned_to_pockets"`
+	AssignedToCategories float64                                `json:"assigned_to_categories"`
+	ToBeAssigned         float64                                `json:"to_be_assigned"`
+	IsClosed             bool                                   `json:"is_closed"`
+	ClosedAt             *time.Time                             `json:"closed_at,omitempty"`
+	SkippedPockets       []*ZeroBasedPocketAssignmentResponse   `json:"skipped_pockets,omitempty"`
+}
@@ -0,0 +1,140 @@
+package budget
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type Budget struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID     primitive.ObjectID `bson:"user_id" json:"user_id"`
+	CategoryID primitive.ObjectID `bson:"category_id" json:"category_id"`
+	Amount     float64            `bson:"amount" json:"amount"`
+	PeriodType string             `bson:"period_type" json:"period_type" enums:"monthly,weekly,payday"`
+	Thresholds []int              `bson:"thresholds" json:"thresholds"`
+	IsActive   bool               `bson:"is_active" json:"is_active"`
+	CreatedAt  time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt  time.Time          `bson:"updated_at" json:"updated_at"`
+	DeletedAt  *time.Time         `bson:"deleted_at,omitempty" json:"deleted_at,omitempty"`
+}
+
+// BudgetAlert records a threshold crossed by a budget within one period, so each alert is only sent once
+type BudgetAlert struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	BudgetID    primitive.ObjectID `bson:"budget_id" json:"budget_id"`
+	UserID      primitive.ObjectID `bson:"user_id" json:"user_id"`
+	CategoryID  primitive.ObjectID `bson:"category_id" json:"category_id"`
+	PeriodStart time.Time          `bson:"period_start" json:"period_start"`
+	PeriodEnd   time.Time          `bson:"period_end" json:"period_end"`
+	Threshold   int                `bson:"threshold" json:"threshold"`
+	Limit       float64            `bson:"limit" json:"limit"`
+	Spent       float64            `bson:"spent" json:"spent"`
+	Percentage  float64            `bson:"percentage" json:"percentage"`
+	CreatedAt   time.Time          `bson:"created_at" json:"created_at"`
+}
+
+type PeriodType string
+
+const (
+	PeriodMonthly PeriodType = "monthly"
+	PeriodWeekly  PeriodType = "weekly"
+	PeriodPayday  PeriodType = "payday"
+)
+
+var DefaultThresholds = []int{80, 100}
+
+// BudgetStatus is the spending of a budget within one period, including its child categories
+type BudgetStatus struct {
+	Budget       *Budget
+	CategoryName string
+	PeriodStart  time.Time
+	PeriodEnd    time.Time
+	Spent        float64
+	Remaining    float64
+	Percentage   float64
+}
+
+// BudgetReportPeriod compares the budgeted amount with the actual spending of one period.
+// A negative variance means the budget was overshot.
+type BudgetReportPeriod struct {
+	PeriodStart        time.Time
+	PeriodEnd          time.Time
+	Budgeted           float64
+	Actual             float64
+	Variance           float64
+	VariancePercentage float64
+}
+
+type BudgetReportItem struct {
+	Budget         *Budget
+	CategoryName   string
+	Periods        []*BudgetReportPeriod
+	OvershootCount int
+}
+
+// BudgetReport holds the requested period first in every Periods slice, followed by the previous ones
+type BudgetReport struct {
+	PeriodType  string
+	PeriodStart time.Time
+	PeriodEnd   time.Time
+	Items       []*BudgetReportItem
+	Totals      []*BudgetReportPeriod
+}
+
+// ZeroBasedAssignment earmarks part of a month's income for a spending category
+type ZeroBasedAssignment struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID     primitive.ObjectID `bson:"user_id" json:"user_id"`
+	CategoryID primitive.ObjectID `bson:"category_id" json:"category_id"`
+	Period     string             `bson:"period" json:"period"`
+	Amount     float64            `bson:"amount" json:"amount"`
+	CreatedAt  time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt  time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// ZeroBasedPeriod is the closing record of a zero-based month; once stored its assignments are locked
+type ZeroBasedPeriod struct {
+	ID                   primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID               primitive.ObjectID `bson:"user_id" json:"user_id"`
+	Period               string             `bson:"period" json:"period"`
+	PeriodStart          time.Time          `bson:"period_start" json:"period_start"`
+	PeriodEnd            time.Time          `bson:"period_end" json:"period_end"`
+	Income               float64            `bson:"income" json:"income"`
+	AssignedToPockets    float64            `bson:"assigned_to_pockets" json:"assigned_to_pockets"`
+	AssignedToCategories float64            `bson:"assigned_to_categories" json:"assigned_to_categories"`
+	ClosedAt             time.Time          `bson:"closed_at" json:"closed_at"`
+}
+
+// zeroBasedRefPrefix marks the transfers created by "assign like last month"
+const zeroBasedRefPrefix = "zero_based_"
+
+// ZeroBasedPocketAssignment is the net amount moved from the main pocket to another pocket within a month
+type ZeroBasedPocketAssignment struct {
+	PocketID   primitive.ObjectID
+	PocketName string
+	Amount     float64
+}
+
+type ZeroBasedCategoryAssignment struct {
+	CategoryID   primitive.ObjectID
+	CategoryName string
+	Amount       float64
+}
+
+// ZeroBasedSummary shows how a month's income landing in the main pocket has been assigned.
+// ToBeAssigned must reach zero before the month can be closed.
+type ZeroBasedSummary struct {
+	Period               string
+	PeriodStart          time.Time
+	PeriodEnd            time.Time
+	Income               float64
+	Pockets              []*ZeroBasedPocketAssignment
+	Categories           []*ZeroBasedCategoryAssignment
+	AssignedToPockets    float64
+	AssignedToCategories float64
+	ToBeAssigned         float64
+	IsClosed             bool
+	ClosedAt             *time.Time
+	SkippedPockets       []*ZeroBasedPocketAssignment // top-ups of last month's pockets that can no longer be assigned to
+}
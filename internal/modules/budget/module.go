@@ -0,0 +1,61 @@
+package budget
+
+import (
+	"context"
+
+	"github.com/HasanNugroho/coin-be/internal/core/config"
+	"github.com/HasanNugroho/coin-be/internal/modules/daily_summary"
+	"github.com/HasanNugroho/coin-be/internal/modules/dashboard"
+	"github.com/HasanNugroho/coin-be/internal/modules/income_source"
+	"github.com/HasanNugroho/coin-be/internal/modules/notification"
+	"github.com/HasanNugroho/coin-be/internal/modules/pocket"
+	"github.com/HasanNugroho/coin-be/internal/modules/transaction"
+	"github.com/HasanNugroho/coin-be/internal/modules/user"
+	"github.com/HasanNugroho/coin-be/internal/modules/user_category"
+	"github.com/sarulabs/di/v2"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func Register(builder *di.Builder) {
+	builder.Add(di.Def{
+		Name: "budgetRepository",
+		Build: func(ctn di.Container) (interface{}, error) {
+			cfg := ctn.Get("config").(*config.Config)
+			client := ctn.Get("mongo").(*mongo.Client)
+			repo := NewRepository(client.Database(cfg.MongoDB))
+
+			if err := repo.EnsureIndexes(context.Background()); err != nil {
+				return nil, err
+			}
+
+			return repo, nil
+		},
+	})
+
+	builder.Add(di.Def{
+		Name: "budgetService",
+		Build: func(ctn di.Container) (interface{}, error) {
+			cfg := ctn.Get("config").(*config.Config)
+			client := ctn.Get("mongo").(*mongo.Client)
+			db := client.Database(cfg.MongoDB)
+			repo := ctn.Get("budgetRepository").(*Repository)
+			categoryRepo := ctn.Get("userCategoryRepository").(*user_category.Repository)
+			userRepo := ctn.Get("userRepository").(*user.Repository)
+			incomeSourceRepo := ctn.Get("incomeSourceRepository").(*income_source.Repository)
+			dailySummaryRepo := ctn.Get("dailySummaryRepository").(*daily_summary.Repository)
+			dashboardRepo := ctn.Get("dashboardRepository").(*dashboard.Repository)
+			pocketRepo := ctn.Get("pocketRepository").(*pocket.Repository)
+			transactionRepo := ctn.Get("transactionRepository").(*transaction.Repository)
+			notificationService := ctn.Get("notificationService").(*notification.Service)
+			return NewService(repo, categoryRepo, userRepo, incomeSourceRepo, dailySummaryRepo, dashboardRepo, pocketRepo, transactionRepo, notificationService, db), nil
+		},
+	})
+
+	builder.Add(di.Def{
+		Name: "budgetController",
+		Build: func(ctn di.Container) (interface{}, error) {
+			service := ctn.Get("budgetService").(*Service)
+			return NewController(service), nil
+		},
+	})
+}
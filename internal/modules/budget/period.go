@@ -0,0 +1,105 @@
+package budget
+
+import (
+	"time"
+
+	"github.com/HasanNugroho/coin-be/internal/modules/daily_summary"
+	"github.com/HasanNugroho/coin-be/internal/modules/user_category"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// periodBounds returns the [start, end) range of the budget period containing reference.
+// Days follow the UTC calendar used by daily summaries. Weekly periods start on Monday and
+// payday periods start on the user's salary day, clamped to the length of shorter months.
+func periodBounds(periodType PeriodType, reference time.Time, salaryDay int) (time.Time, time.Time) {
+	day := startOfDay(reference)
+
+	switch periodType {
+	case PeriodWeekly:
+		offset := (int(day.Weekday()) + 6) % 7
+		start := day.AddDate(0, 0, -offset)
+		return start, start.AddDate(0, 0, 7)
+	case PeriodPayday:
+		start := daily_summary.FinancialMonthStart(day, salaryDay)
+		return start, daily_summary.AddFinancialMonths(start, salaryDay, 1)
+	default:
+		start := time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, time.UTC)
+		return start, start.AddDate(0, 1, 0)
+	}
+}
+
+func startOfDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// categoryTree indexes the categories of a user so a budget can roll up the spending of its children
+type categoryTree struct {
+	names    map[primitive.ObjectID]string
+	children map[primitive.ObjectID][]primitive.ObjectID
+}
+
+func newCategoryTree(categories []*user_category.UserCategory) *categoryTree {
+	tree := &categoryTree{
+		names:    make(map[primitive.ObjectID]string, len(categories)),
+		children: make(map[primitive.ObjectID][]primitive.ObjectID),
+	}
+	for _, category := range categories {
+		tree.names[category.ID] = category.Name
+		if category.ParentID != nil {
+			tree.children[*category.ParentID] = append(tree.children[*category.ParentID], category.ID)
+		}
+	}
+	return tree
+}
+
+// withDescendants returns the category and every category nested below it
+func (t *categoryTree) withDescendants(rootID primitive.ObjectID) map[primitive.ObjectID]bool {
+	ids := map[primitive.ObjectID]bool{rootID: true}
+	queue := []primitive.ObjectID{rootID}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		for _, child := range t.children[id] {
+			if !ids[child] {
+				ids[child] = true
+				queue = append(queue, child)
+			}
+		}
+	}
+	return ids
+}
+
+// spending holds the expenses of a user per category: closed days come from daily summaries
+// and today's transactions from the live delta, the same split the dashboard uses
+type spending struct {
+	summaries []*daily_summary.DailySummary
+	live      []daily_summary.CategoryBreakdown
+	today     time.Time
+}
+
+// total sums the expenses of the given categories dated within [start, end)
+func (sp *spending) total(categoryIDs map[primitive.ObjectID]bool, start, end time.Time) float64 {
+	var total float64
+	for _, summary := range sp.summaries {
+		if summary.Date.Before(start) || !summary.Date.Before(end) {
+			continue
+		}
+		total += sumExpenses(summary.CategoryBreakdown, categoryIDs)
+	}
+
+	if sp.today.Before(end) && !sp.today.Before(start) {
+		total += sumExpenses(sp.live, categoryIDs)
+	}
+	return total
+}
+
+func sumExpenses(breakdown []daily_summary.CategoryBreakdown, categoryIDs map[primitive.ObjectID]bool) float64 {
+	var total float64
+	for _, item := range breakdown {
+		if item.Type == "expense" && item.CategoryID != nil && categoryIDs[*item.CategoryID] {
+			total += item.Amount
+		}
+	}
+	return total
+}
@@ -0,0 +1,335 @@
+package budget
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type Repository struct {
+	budgets              *mongo.Collection
+	alerts               *mongo.Collection
+	zeroBasedAssignments *mongo.Collection
+	zeroBasedPeriods     *mongo.Collection
+	transactions         *mongo.Collection
+}
+
+func NewRepository(db *mongo.Database) *Repository {
+	return &Repository{
+		budgets:              db.Collection("budgets"),
+		alerts:               db.Collection("budget_alerts"),
+		zeroBasedAssignments: db.Collection("zero_based_assignments"),
+		zeroBasedPeriods:     db.Collection("zero_based_periods"),
+		transactions:         db.Collection("transactions"),
+	}
+}
+
+func (r *Repository) CreateBudget(ctx context.Context, budget *Budget) error {
+	budget.ID = primitive.NewObjectID()
+	budget.CreatedAt = time.Now()
+	budget.UpdatedAt = time.Now()
+	_, err := r.budgets.InsertOne(ctx, budget)
+	return err
+}
+
+func (r *Repository) GetBudgetByID(ctx context.Context, id primitive.ObjectID) (*Budget, error) {
+	var budget Budget
+	err := r.budgets.FindOne(ctx, bson.M{"_id": id, "deleted_at": nil}).Decode(&budget)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.New("budget not found")
+		}
+		return nil, err
+	}
+	return &budget, nil
+}
+
+// GetBudgetByCategory returns the budget of a category for the given period type, or nil when there is none
+func (r *Repository) GetBudgetByCategory(ctx context.Context, userID, categoryID primitive.ObjectID, periodType string) (*Budget, error) {
+	var budget Budget
+	filter := bson.M{
+		"user_id":     userID,
+		"category_id": categoryID,
+		"period_type": periodType,
+		"deleted_at":  nil,
+	}
+	err := r.budgets.FindOne(ctx, filter).Decode(&budget)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &budget, nil
+}
+
+func (r *Repository) GetBudgetsByUserID(ctx context.Context, userID primitive.ObjectID) ([]*Budget, error) {
+	opts := options.Find().SetSort(bson.M{"created_at": 1})
+	cursor, err := r.budgets.Find(ctx, bson.M{"user_id": userID, "deleted_at": nil}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var budgets []*Budget
+	if err = cursor.All(ctx, &budgets); err != nil {
+		return nil, err
+	}
+	return budgets, nil
+}
+
+func (r *Repository) GetActiveBudgets(ctx context.Context) ([]*Budget, error) {
+	opts := options.Find().SetSort(bson.M{"user_id": 1})
+	cursor, err := r.budgets.Find(ctx, bson.M{"is_active": true, "deleted_at": nil}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var budgets []*Budget
+	if err = cursor.All(ctx, &budgets); err != nil {
+		return nil, err
+	}
+	return budgets, nil
+}
+
+func (r *Repository) UpdateBudget(ctx context.Context, id primitive.ObjectID, budget *Budget) error {
+	budget.UpdatedAt = time.Now()
+	result, err := r.budgets.UpdateOne(ctx, bson.M{"_id": id, "deleted_at": nil}, bson.M{"$set": budget})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errors.New("budget not found")
+	}
+	return nil
+}
+
+func (r *Repository) DeleteBudget(ctx context.Context, id primitive.ObjectID) error {
+	now := time.Now()
+	result, err := r.budgets.UpdateOne(
+		ctx,
+		bson.M{"_id": id, "deleted_at": nil},
+		bson.M{
+			"$set": bson.M{
+				"deleted_at": now,
+				"updated_at": now,
+			},
+		},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errors.New("budget not found")
+	}
+	return nil
+}
+
+// CreateAlert stores an alert and reports whether it is new.
+// An alert already recorded for the same budget, period and threshold is left untouched.
+func (r *Repository) CreateAlert(ctx context.Context, alert *BudgetAlert) (bool, error) {
+	alert.ID = primitive.NewObjectID()
+	alert.CreatedAt = time.Now()
+	_, err := r.alerts.InsertOne(ctx, alert)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+func (r *Repository) GetAlertsByBudgetID(ctx context.Context, budgetID primitive.ObjectID, limit int64) ([]*BudgetAlert, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "period_start", Value: -1}, {Key: "threshold", Value: -1}}).SetLimit(limit)
+	cursor, err := r.alerts.Find(ctx, bson.M{"budget_id": budgetID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var alerts []*BudgetAlert
+	if err = cursor.All(ctx, &alerts); err != nil {
+		return nil, err
+	}
+	return alerts, nil
+}
+
+// SetZeroBasedAssignment stores the amount assigned to a category for a month, removing it when the amount is zero
+func (r *Repository) SetZeroBasedAssignment(ctx context.Context, userID, categoryID primitive.ObjectID, period string, amount float64) error {
+	filter := bson.M{"user_id": userID, "category_id": categoryID, "period": period}
+	if amount == 0 {
+		_, err := r.zeroBasedAssignments.DeleteOne(ctx, filter)
+		return err
+	}
+
+	now := time.Now()
+	update := bson.M{
+		"$set": bson.M{
+			"amount":     amount,
+			"updated_at": now,
+		},
+		"$setOnInsert": bson.M{
+			"_id":        primitive.NewObjectID(),
+			"created_at": now,
+		},
+	}
+	_, err := r.zeroBasedAssignments.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	return err
+}
+
+func (r *Repository) GetZeroBasedAssignments(ctx context.Context, userID primitive.ObjectID, period string) ([]*ZeroBasedAssignment, error) {
+	opts := options.Find().SetSort(bson.M{"created_at": 1})
+	cursor, err := r.zeroBasedAssignments.Find(ctx, bson.M{"user_id": userID, "period": period}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var assignments []*ZeroBasedAssignment
+	if err = cursor.All(ctx, &assignments); err != nil {
+		return nil, err
+	}
+	return assignments, nil
+}
+
+func (r *Repository) CreateZeroBasedPeriod(ctx context.Context, period *ZeroBasedPeriod) error {
+	period.ID = primitive.NewObjectID()
+	period.ClosedAt = time.Now()
+	_, err := r.zeroBasedPeriods.InsertOne(ctx, period)
+	if mongo.IsDuplicateKeyError(err) {
+		return errors.New("period is already closed")
+	}
+	return err
+}
+
+// GetZeroBasedPeriod returns the closing record of a month, or nil when it is still open
+func (r *Repository) GetZeroBasedPeriod(ctx context.Context, userID primitive.ObjectID, period string) (*ZeroBasedPeriod, error) {
+	var record ZeroBasedPeriod
+	err := r.zeroBasedPeriods.FindOne(ctx, bson.M{"user_id": userID, "period": period}).Decode(&record)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &record, nil
+}
+
+// GetMainPocketFlows returns the income received by the main pocket within [startDate, endDate) and
+// the net amount transferred from it to every other pocket (transfers back to the main pocket count negative)
+func (r *Repository) GetMainPocketFlows(ctx context.Context, mainPocketID primitive.ObjectID, startDate, endDate time.Time) (float64, map[primitive.ObjectID]float64, error) {
+	filter := bson.M{
+		"deleted_at": nil,
+		"date":       bson.M{"$gte": startDate, "$lt": endDate},
+		"$or": []bson.M{
+			{"pocket_to_id": mainPocketID},
+			{"pocket_from_id": mainPocketID},
+		},
+	}
+	opts := options.Find().SetProjection(bson.M{"type": 1, "amount": 1, "pocket_from_id": 1, "pocket_to_id": 1})
+
+	cursor, err := r.transactions.Find(ctx, filter, opts)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var transactions []struct {
+		Type         string              `bson:"type"`
+		Amount       float64             `bson:"amount"`
+		PocketFromID *primitive.ObjectID `bson:"pocket_from_id"`
+		PocketToID   *primitive.ObjectID `bson:"pocket_to_id"`
+	}
+	if err = cursor.All(ctx, &transactions); err != nil {
+		return 0, nil, err
+	}
+
+	var income float64
+	pockets := make(map[primitive.ObjectID]float64)
+	for _, tx := range transactions {
+		switch {
+		case tx.Type == "income" && tx.PocketToID != nil && *tx.PocketToID == mainPocketID:
+			income += tx.Amount
+		case tx.Type != "transfer":
+			continue
+		case tx.PocketFromID != nil && *tx.PocketFromID == mainPocketID && tx.PocketToID != nil:
+			pockets[*tx.PocketToID] += tx.Amount
+		case tx.PocketToID != nil && *tx.PocketToID == mainPocketID && tx.PocketFromID != nil:
+			pockets[*tx.PocketFromID] -= tx.Amount
+		}
+	}
+	return income, pockets, nil
+}
+
+func (r *Repository) EnsureIndexes(ctx context.Context) error {
+	budgetIndexes := []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				{Key: "user_id", Value: 1},
+				{Key: "category_id", Value: 1},
+			},
+			Options: options.Index().
+				SetName("idx_budgets_user_category"),
+		},
+	}
+
+	if _, err := r.budgets.Indexes().CreateMany(ctx, budgetIndexes); err != nil {
+		return err
+	}
+
+	alertIndexes := []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				{Key: "budget_id", Value: 1},
+				{Key: "period_start", Value: 1},
+				{Key: "threshold", Value: 1},
+			},
+			Options: options.Index().
+				SetName("idx_budget_alerts_budget_period_threshold").
+				SetUnique(true),
+		},
+	}
+
+	if _, err := r.alerts.Indexes().CreateMany(ctx, alertIndexes); err != nil {
+		return err
+	}
+
+	assignmentIndexes := []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				{Key: "user_id", Value: 1},
+				{Key: "period", Value: 1},
+				{Key: "category_id", Value: 1},
+			},
+			Options: options.Index().
+				SetName("idx_zero_based_assignments_user_period_category").
+				SetUnique(true),
+		},
+	}
+
+	if _, err := r.zeroBasedAssignments.Indexes().CreateMany(ctx, assignmentIndexes); err != nil {
+		return err
+	}
+
+	periodIndexes := []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				{Key: "user_id", Value: 1},
+				{Key: "period", Value: 1},
+			},
+			Options: options.Index().
+				SetName("idx_zero_based_periods_user_period").
+				SetUnique(true),
+		},
+	}
+
+	_, err := r.zeroBasedPeriods.Indexes().CreateMany(ctx, periodIndexes)
+	return err
+}
@@ -0,0 +1,24 @@
+package budget
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterRoutes(r *gin.RouterGroup, controller *Controller) {
+	// User routes
+	protected := r.Group("")
+	{
+		protected.POST("", controller.CreateBudget)
+		protected.GET("", controller.ListBudgets)
+		protected.GET("/report", controller.GetReport)
+		protected.GET("/report/export", controller.ExportReport)
+		protected.GET("/zero-based", controller.GetZeroBasedSummary)
+		protected.PUT("/zero-based/categories/:category_id", controller.AssignCategory)
+		protected.POST("/zero-based/assign-last-month", controller.AssignLikeLastMonth)
+		protected.POST("/zero-based/close", controller.CloseZeroBasedPeriod)
+		protected.GET("/:id", controller.GetBudget)
+		protected.GET("/:id/alerts", controller.ListAlerts)
+		protected.PUT("/:id", controller.UpdateBudget)
+		protected.DELETE("/:id", controller.DeleteBudget)
+	}
+}
@@ -0,0 +1,503 @@
+package budget
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/HasanNugroho/coin-be/internal/modules/budget/dto"
+	"github.com/HasanNugroho/coin-be/internal/modules/daily_summary"
+	"github.com/HasanNugroho/coin-be/internal/modules/dashboard"
+	"github.com/HasanNugroho/coin-be/internal/modules/income_source"
+	"github.com/HasanNugroho/coin-be/internal/modules/notification"
+	"github.com/HasanNugroho/coin-be/internal/modules/pocket"
+	"github.com/HasanNugroho/coin-be/internal/modules/transaction"
+	"github.com/HasanNugroho/coin-be/internal/modules/user"
+	"github.com/HasanNugroho/coin-be/internal/modules/user_category"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// reportPeriodCount is the requested period plus the three before it
+const reportPeriodCount = 4
+
+type Service struct {
+	repo                *Repository
+	categoryRepo        *user_category.Repository
+	userRepo            *user.Repository
+	incomeSourceRepo    *income_source.Repository
+	dailySummaryRepo    *daily_summary.Repository
+	dashboardRepo       *dashboard.Repository
+	pocketRepo          *pocket.Repository
+	transactionRepo     *transaction.Repository
+	notificationService *notification.Service
+	db                  *mongo.Database
+}
+
+func NewService(r *Repository, cr *user_category.Repository, ur *user.Repository, isr *income_source.Repository, dsr *daily_summary.Repository, dr *dashboard.Repository, pr *pocket.Repository, tr *transaction.Repository, ns *notification.Service, db *mongo.Database) *Service {
+	return &Service{
+		repo:                r,
+		categoryRepo:        cr,
+		userRepo:            ur,
+		incomeSourceRepo:    isr,
+		dailySummaryRepo:    dsr,
+		dashboardRepo:       dr,
+		pocketRepo:          pr,
+		transactionRepo:     tr,
+		notificationService: ns,
+		db:                  db,
+	}
+}
+
+func (s *Service) CreateBudget(ctx context.Context, userID string, req *dto.CreateBudgetRequest) (*Budget, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, errors.New("invalid user id")
+	}
+
+	categoryObjID, err := primitive.ObjectIDFromHex(req.CategoryID)
+	if err != nil {
+		return nil, errors.New("invalid category id")
+	}
+
+	category, err := s.categoryRepo.FindByID(ctx, categoryObjID, userObjID)
+	if err != nil {
+		return nil, errors.New("category not found")
+	}
+
+	if category.TransactionType != nil && *category.TransactionType == user_category.TransactionIncome {
+		return nil, errors.New("budgets can only be set on expense categories")
+	}
+
+	existing, err := s.repo.GetBudgetByCategory(ctx, userObjID, categoryObjID, req.PeriodType)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, errors.New("category already has a " + req.PeriodType + " budget")
+	}
+
+	budget := &Budget{
+		UserID:     userObjID,
+		CategoryID: categoryObjID,
+		Amount:     req.Amount,
+		PeriodType: req.PeriodType,
+		Thresholds: normalizeThresholds(req.Thresholds),
+		IsActive:   true,
+	}
+
+	if err := s.repo.CreateBudget(ctx, budget); err != nil {
+		return nil, err
+	}
+
+	return budget, nil
+}
+
+func (s *Service) GetBudgetByID(ctx context.Context, userID string, budgetID string) (*Budget, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, errors.New("invalid user id")
+	}
+
+	budgetObjID, err := primitive.ObjectIDFromHex(budgetID)
+	if err != nil {
+		return nil, errors.New("invalid budget id")
+	}
+
+	budget, err := s.repo.GetBudgetByID(ctx, budgetObjID)
+	if err != nil {
+		return nil, err
+	}
+
+	if budget.UserID != userObjID {
+		return nil, errors.New("unauthorized")
+	}
+
+	return budget, nil
+}
+
+// GetBudgetStatus returns the spending of a budget in its current period
+func (s *Service) GetBudgetStatus(ctx context.Context, userID string, budgetID string) (*BudgetStatus, error) {
+	budget, err := s.GetBudgetByID(ctx, userID, budgetID)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses, err := s.buildStatuses(ctx, budget.UserID, []*Budget{budget}, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	return statuses[0], nil
+}
+
+// ListBudgetStatuses returns every budget of the user with its spending in the current period
+func (s *Service) ListBudgetStatuses(ctx context.Context, userID string) ([]*BudgetStatus, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, errors.New("invalid user id")
+	}
+
+	budgets, err := s.repo.GetBudgetsByUserID(ctx, userObjID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.buildStatuses(ctx, userObjID, budgets, time.Now())
+}
+
+func (s *Service) UpdateBudget(ctx context.Context, userID string, budgetID string, req *dto.UpdateBudgetRequest) (*Budget, error) {
+	budget, err := s.GetBudgetByID(ctx, userID, budgetID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.PeriodType != "" && req.PeriodType != budget.PeriodType {
+		existing, err := s.repo.GetBudgetByCategory(ctx, budget.UserID, budget.CategoryID, req.PeriodType)
+		if err != nil {
+			return nil, err
+		}
+		if existing != nil {
+			return nil, errors.New("category already has a " + req.PeriodType + " budget")
+		}
+		budget.PeriodType = req.PeriodType
+	}
+
+	if req.Amount != nil {
+		budget.Amount = *req.Amount
+	}
+
+	if req.Thresholds != nil {
+		budget.Thresholds = normalizeThresholds(req.Thresholds)
+	}
+
+	if req.IsActive != nil {
+		budget.IsActive = *req.IsActive
+	}
+
+	if err := s.repo.UpdateBudget(ctx, budget.ID, budget); err != nil {
+		return nil, err
+	}
+
+	return budget, nil
+}
+
+func (s *Service) DeleteBudget(ctx context.Context, userID string, budgetID string) error {
+	budget, err := s.GetBudgetByID(ctx, userID, budgetID)
+	if err != nil {
+		return err
+	}
+
+	return s.repo.DeleteBudget(ctx, budget.ID)
+}
+
+func (s *Service) ListAlerts(ctx context.Context, userID string, budgetID string, limit int64) ([]*BudgetAlert, error) {
+	budget, err := s.GetBudgetByID(ctx, userID, budgetID)
+	if err != nil {
+		return nil, err
+	}
+
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+
+	return s.repo.GetAlertsByBudgetID(ctx, budget.ID, limit)
+}
+
+// CheckBudgetAlerts notifies users whose budgets crossed a new threshold in the current period.
+// Every crossed threshold is recorded once; a single message is sent for the highest one.
+func (s *Service) CheckBudgetAlerts(ctx context.Context) error {
+	budgets, err := s.repo.GetActiveBudgets(ctx)
+	if err != nil {
+		return err
+	}
+
+	byUser := make(map[primitive.ObjectID][]*Budget)
+	for _, budget := range budgets {
+		byUser[budget.UserID] = append(byUser[budget.UserID], budget)
+	}
+
+	now := time.Now()
+	for userID, userBudgets := range byUser {
+		statuses, err := s.buildStatuses(ctx, userID, userBudgets, now)
+		if err != nil {
+			log.Printf("failed to compute budgets for user %s: %v", userID.Hex(), err)
+			continue
+		}
+
+		for _, status := range statuses {
+			s.alertStatus(ctx, status)
+		}
+	}
+
+	return nil
+}
+
+func (s *Service) alertStatus(ctx context.Context, status *BudgetStatus) {
+	budget := status.Budget
+
+	highest := 0
+	for _, threshold := range budget.Thresholds {
+		if status.Percentage < float64(threshold) {
+			continue
+		}
+
+		created, err := s.repo.CreateAlert(ctx, &BudgetAlert{
+			BudgetID:    budget.ID,
+			UserID:      budget.UserID,
+			CategoryID:  budget.CategoryID,
+			PeriodStart: status.PeriodStart,
+			PeriodEnd:   status.PeriodEnd,
+			Threshold:   threshold,
+			Limit:       budget.Amount,
+			Spent:       status.Spent,
+			Percentage:  status.Percentage,
+		})
+		if err != nil {
+			log.Printf("failed to record budget alert for budget %s: %v", budget.ID.Hex(), err)
+			continue
+		}
+		if created && threshold > highest {
+			highest = threshold
+		}
+	}
+
+	if highest == 0 {
+		return
+	}
+
+	subject := fmt.Sprintf("Finlet - Anggaran %s terpakai %d%%", status.CategoryName, highest)
+	if highest >= 100 {
+		subject = fmt.Sprintf("Finlet - Anggaran %s terlampaui", status.CategoryName)
+	}
+	body := fmt.Sprintf("Pengeluaran kategori %s periode %s - %s sudah mencapai %s dari anggaran %s (%.0f%%). Sisa anggaran: %s.",
+		status.CategoryName,
+		status.PeriodStart.Format("02 Jan 2006"),
+		status.PeriodEnd.AddDate(0, 0, -1).Format("02 Jan 2006"),
+		notification.FormatRupiah(status.Spent),
+		notification.FormatRupiah(budget.Amount),
+		status.Percentage,
+		notification.FormatRupiah(status.Remaining))
+
+	if err := s.notificationService.Notify(ctx, budget.UserID, subject, body); err != nil {
+		log.Printf("failed to send budget alert for budget %s: %v", budget.ID.Hex(), err)
+	}
+}
+
+// GetBudgetReport compares budgets of one period type with the actual spending of the period containing date
+// and of the previous periods. Past periods are measured against the current budget amount.
+func (s *Service) GetBudgetReport(ctx context.Context, userID string, periodType string, date string) (*BudgetReport, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, errors.New("invalid user id")
+	}
+
+	switch PeriodType(periodType) {
+	case "":
+		periodType = string(PeriodMonthly)
+	case PeriodMonthly, PeriodWeekly, PeriodPayday:
+	default:
+		return nil, errors.New("invalid period type, use monthly, weekly or payday")
+	}
+
+	reference := time.Now()
+	if date != "" {
+		reference, err = time.Parse("2006-01-02", date)
+		if err != nil {
+			return nil, errors.New("invalid date format, use YYYY-MM-DD")
+		}
+	}
+
+	budgets, err := s.repo.GetBudgetsByUserID(ctx, userObjID)
+	if err != nil {
+		return nil, err
+	}
+
+	var matching []*Budget
+	for _, budget := range budgets {
+		if budget.PeriodType == periodType {
+			matching = append(matching, budget)
+		}
+	}
+
+	salaryDay := s.getSalaryDay(ctx, userObjID, matching)
+
+	bounds := make([][2]time.Time, reportPeriodCount)
+	for i := range bounds {
+		start, end := periodBounds(PeriodType(periodType), reference, salaryDay)
+		bounds[i] = [2]time.Time{start, end}
+		reference = start.AddDate(0, 0, -1)
+	}
+
+	report := &BudgetReport{
+		PeriodType:  periodType,
+		PeriodStart: bounds[0][0],
+		PeriodEnd:   bounds[0][1],
+		Items:       make([]*BudgetReportItem, 0, len(matching)),
+		Totals:      make([]*BudgetReportPeriod, reportPeriodCount),
+	}
+	for i, bound := range bounds {
+		report.Totals[i] = &BudgetReportPeriod{PeriodStart: bound[0], PeriodEnd: bound[1]}
+	}
+
+	if len(matching) == 0 {
+		return report, nil
+	}
+
+	categories, err := s.categoryRepo.FindAllByUserID(ctx, userObjID)
+	if err != nil {
+		return nil, err
+	}
+	tree := newCategoryTree(categories)
+
+	spent, err := s.loadSpending(ctx, userObjID, bounds[reportPeriodCount-1][0])
+	if err != nil {
+		return nil, err
+	}
+
+	for _, budget := range matching {
+		categoryIDs := tree.withDescendants(budget.CategoryID)
+		item := &BudgetReportItem{
+			Budget:       budget,
+			CategoryName: tree.names[budget.CategoryID],
+			Periods:      make([]*BudgetReportPeriod, reportPeriodCount),
+		}
+
+		for i, bound := range bounds {
+			period := newReportPeriod(bound[0], bound[1], budget.Amount, spent.total(categoryIDs, bound[0], bound[1]))
+			item.Periods[i] = period
+			if period.Variance < 0 {
+				item.OvershootCount++
+			}
+
+			report.Totals[i].Budgeted += period.Budgeted
+			report.Totals[i].Actual += period.Actual
+		}
+
+		report.Items = append(report.Items, item)
+	}
+
+	for i, total := range report.Totals {
+		report.Totals[i] = newReportPeriod(total.PeriodStart, total.PeriodEnd, total.Budgeted, total.Actual)
+	}
+
+	return report, nil
+}
+
+func newReportPeriod(start, end time.Time, budgeted, actual float64) *BudgetReportPeriod {
+	period := &BudgetReportPeriod{
+		PeriodStart: start,
+		PeriodEnd:   end,
+		Budgeted:    budgeted,
+		Actual:      actual,
+		Variance:    budgeted - actual,
+	}
+	if budgeted > 0 {
+		period.VariancePercentage = period.Variance / budgeted * 100
+	}
+	return period
+}
+
+// buildStatuses computes the spending of budgets owned by one user in the period containing reference
+func (s *Service) buildStatuses(ctx context.Context, userID primitive.ObjectID, budgets []*Budget, reference time.Time) ([]*BudgetStatus, error) {
+	statuses := make([]*BudgetStatus, 0, len(budgets))
+	if len(budgets) == 0 {
+		return statuses, nil
+	}
+
+	categories, err := s.categoryRepo.FindAllByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	tree := newCategoryTree(categories)
+
+	salaryDay := s.getSalaryDay(ctx, userID, budgets)
+
+	earliest := reference
+	for _, budget := range budgets {
+		start, end := periodBounds(PeriodType(budget.PeriodType), reference, salaryDay)
+		statuses = append(statuses, &BudgetStatus{
+			Budget:       budget,
+			CategoryName: tree.names[budget.CategoryID],
+			PeriodStart:  start,
+			PeriodEnd:    end,
+		})
+		if start.Before(earliest) {
+			earliest = start
+		}
+	}
+
+	spent, err := s.loadSpending(ctx, userID, earliest)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, status := range statuses {
+		status.Spent = spent.total(tree.withDescendants(status.Budget.CategoryID), status.PeriodStart, status.PeriodEnd)
+		status.Remaining = status.Budget.Amount - status.Spent
+		if status.Budget.Amount > 0 {
+			status.Percentage = status.Spent / status.Budget.Amount * 100
+		}
+	}
+
+	return statuses, nil
+}
+
+// loadSpending reads the daily summaries from since up to yesterday plus the live delta of today
+func (s *Service) loadSpending(ctx context.Context, userID primitive.ObjectID, since time.Time) (*spending, error) {
+	today := startOfDay(time.Now())
+
+	summaries, err := s.dailySummaryRepo.GetDailySummariesByDateRange(ctx, userID, startOfDay(since), today)
+	if err != nil {
+		return nil, err
+	}
+
+	_, _, live, err := s.dashboardRepo.GetLiveDeltaSummary(ctx, userID, today)
+	if err != nil {
+		return nil, err
+	}
+
+	return &spending{
+		summaries: summaries,
+		live:      live,
+		today:     today,
+	}, nil
+}
+
+// getSalaryDay returns the day the user's financial months start on when one of the budgets follows the payday
+// cycle
+func (s *Service) getSalaryDay(ctx context.Context, userID primitive.ObjectID, budgets []*Budget) int {
+	for _, budget := range budgets {
+		if PeriodType(budget.PeriodType) != PeriodPayday {
+			continue
+		}
+
+		day, err := s.incomeSourceRepo.GetFinancialMonthStartDay(ctx, userID)
+		if err != nil {
+			return 1
+		}
+		return day
+	}
+	return 1
+}
+
+// normalizeThresholds sorts and deduplicates alert thresholds, falling back to the defaults
+func normalizeThresholds(values []int) []int {
+	if len(values) == 0 {
+		return append([]int(nil), DefaultThresholds...)
+	}
+
+	seen := make(map[int]bool, len(values))
+	thresholds := make([]int, 0, len(values))
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			thresholds = append(thresholds, value)
+		}
+	}
+	sort.Ints(thresholds)
+	return thresholds
+}
@@ -0,0 +1,415 @@
+package budget
+
+import (
+	"context"
+	"errors"
+	"math"
+	"time"
+
+	"github.com/HasanNugroho/coin-be/internal/core/utils"
+	"github.com/HasanNugroho/coin-be/internal/modules/budget/dto"
+	"github.com/HasanNugroho/coin-be/internal/modules/daily_summary"
+	"github.com/HasanNugroho/coin-be/internal/modules/notification"
+	"github.com/HasanNugroho/coin-be/internal/modules/pocket"
+	"github.com/HasanNugroho/coin-be/internal/modules/transaction"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const zeroBasedPeriodLayout = "2006-01"
+
+// GetZeroBasedSummary shows how the income of a financial month (YYYY-MM, the month it starts in, defaults to the
+// current one) has been assigned
+func (s *Service) GetZeroBasedSummary(ctx context.Context, userID string, period string) (*ZeroBasedSummary, error) {
+	userObjID, err := s.requireZeroBased(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.buildZeroBasedSummary(ctx, userObjID, period)
+}
+
+// AssignCategory sets the amount of a month's income earmarked for a category. An amount of zero removes it.
+func (s *Service) AssignCategory(ctx context.Context, userID string, categoryID string, req *dto.AssignCategoryRequest) (*ZeroBasedSummary, error) {
+	userObjID, err := s.requireZeroBased(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	categoryObjID, err := primitive.ObjectIDFromHex(categoryID)
+	if err != nil {
+		return nil, errors.New("invalid category id")
+	}
+
+	if _, err := s.categoryRepo.FindByID(ctx, categoryObjID, userObjID); err != nil {
+		return nil, errors.New("category not found")
+	}
+
+	period, _, _, err := s.zeroBasedPeriodBounds(ctx, userObjID, req.Period)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.ensurePeriodOpen(ctx, userObjID, period); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.SetZeroBasedAssignment(ctx, userObjID, categoryObjID, period, *req.Amount); err != nil {
+		return nil, err
+	}
+
+	return s.buildZeroBasedSummary(ctx, userObjID, period)
+}
+
+// AssignLikeLastMonth replays the previous month's assignments on the current one: pockets are topped up
+// with transfers from the main pocket and categories are raised to last month's amounts.
+// Assignments already made this month count toward the replay, so repeating it does nothing. Pockets that were
+// deactivated or became the main pocket since are left out and returned as skipped.
+func (s *Service) AssignLikeLastMonth(ctx context.Context, userID string) (*ZeroBasedSummary, error) {
+	userObjID, err := s.requireZeroBased(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := s.buildZeroBasedSummary(ctx, userObjID, "")
+	if err != nil {
+		return nil, err
+	}
+
+	if current.IsClosed {
+		return nil, errors.New("period is already closed")
+	}
+
+	// The label is the calendar month the financial month starts in, so the previous one is the month before it
+	currentMonth, err := time.Parse(zeroBasedPeriodLayout, current.Period)
+	if err != nil {
+		return nil, err
+	}
+	previous, err := s.buildZeroBasedSummary(ctx, userObjID, currentMonth.AddDate(0, -1, 0).Format(zeroBasedPeriodLayout))
+	if err != nil {
+		return nil, err
+	}
+
+	currentPockets := make(map[primitive.ObjectID]float64, len(current.Pockets))
+	for _, assignment := range current.Pockets {
+		currentPockets[assignment.PocketID] = assignment.Amount
+	}
+	currentCategories := make(map[primitive.ObjectID]float64, len(current.Categories))
+	for _, assignment := range current.Categories {
+		currentCategories[assignment.CategoryID] = assignment.Amount
+	}
+
+	// Only active pockets of the user other than the main one can still be topped up
+	pockets, err := s.pocketRepo.GetPocketsByUserIDDropdown(ctx, userObjID)
+	if err != nil {
+		return nil, err
+	}
+	assignable := make(map[primitive.ObjectID]bool, len(pockets))
+	for _, p := range pockets {
+		assignable[p.ID] = p.Type != string(pocket.TypeMain)
+	}
+
+	transfers := make(map[primitive.ObjectID]float64)
+	var skipped []*ZeroBasedPocketAssignment
+	var needed float64
+	for _, assignment := range previous.Pockets {
+		topUp := assignment.Amount - currentPockets[assignment.PocketID]
+		if topUp <= 0 {
+			continue
+		}
+		if !assignable[assignment.PocketID] {
+			skipped = append(skipped, &ZeroBasedPocketAssignment{
+				PocketID:   assignment.PocketID,
+				PocketName: assignment.PocketName,
+				Amount:     topUp,
+			})
+			continue
+		}
+		transfers[assignment.PocketID] = topUp
+		needed += topUp
+	}
+	categories := make(map[primitive.ObjectID]float64)
+	for _, assignment := range previous.Categories {
+		if assignment.Amount > currentCategories[assignment.CategoryID] {
+			categories[assignment.CategoryID] = assignment.Amount
+			needed += assignment.Amount - currentCategories[assignment.CategoryID]
+		}
+	}
+
+	if needed == 0 {
+		current.SkippedPockets = skipped
+		return current, nil
+	}
+	if needed > current.ToBeAssigned {
+		return nil, errors.New("not enough to be assigned to repeat last month's assignments, " + notification.FormatRupiah(needed) + " needed")
+	}
+
+	if len(transfers) > 0 {
+		if err := s.transferFromMainPocket(ctx, userObjID, current.Period, transfers); err != nil {
+			return nil, err
+		}
+	}
+
+	for categoryID, amount := range categories {
+		if err := s.repo.SetZeroBasedAssignment(ctx, userObjID, categoryID, current.Period, amount); err != nil {
+			return nil, err
+		}
+	}
+
+	summary, err := s.buildZeroBasedSummary(ctx, userObjID, current.Period)
+	if err != nil {
+		return nil, err
+	}
+	summary.SkippedPockets = skipped
+	return summary, nil
+}
+
+// CloseZeroBasedPeriod locks the assignments of a month. It is refused while anything is left to be assigned.
+func (s *Service) CloseZeroBasedPeriod(ctx context.Context, userID string, period string) (*ZeroBasedSummary, error) {
+	userObjID, err := s.requireZeroBased(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	summary, err := s.buildZeroBasedSummary(ctx, userObjID, period)
+	if err != nil {
+		return nil, err
+	}
+
+	if summary.IsClosed {
+		return nil, errors.New("period is already closed")
+	}
+
+	if math.Abs(summary.ToBeAssigned) >= 0.01 {
+		return nil, errors.New("cannot close period while " + notification.FormatRupiah(summary.ToBeAssigned) + " is still to be assigned")
+	}
+
+	record := &ZeroBasedPeriod{
+		UserID:               userObjID,
+		Period:               summary.Period,
+		PeriodStart:          summary.PeriodStart,
+		PeriodEnd:            summary.PeriodEnd,
+		Income:               summary.Income,
+		AssignedToPockets:    summary.AssignedToPockets,
+		AssignedToCategories: summary.AssignedToCategories,
+	}
+
+	if err := s.repo.CreateZeroBasedPeriod(ctx, record); err != nil {
+		return nil, err
+	}
+
+	summary.IsClosed = true
+	summary.ClosedAt = &record.ClosedAt
+	return summary, nil
+}
+
+func (s *Service) buildZeroBasedSummary(ctx context.Context, userID primitive.ObjectID, period string) (*ZeroBasedSummary, error) {
+	period, start, end, err := s.zeroBasedPeriodBounds(ctx, userID, period)
+	if err != nil {
+		return nil, err
+	}
+
+	mainPocket, err := s.pocketRepo.GetMainPocketByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if mainPocket == nil {
+		return nil, errors.New("main pocket not found")
+	}
+
+	income, pocketFlows, err := s.repo.GetMainPocketFlows(ctx, mainPocket.ID, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	assignments, err := s.repo.GetZeroBasedAssignments(ctx, userID, period)
+	if err != nil {
+		return nil, err
+	}
+
+	record, err := s.repo.GetZeroBasedPeriod(ctx, userID, period)
+	if err != nil {
+		return nil, err
+	}
+
+	pockets, err := s.pocketRepo.GetPocketsByUserIDDropdown(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	pocketNames := make(map[primitive.ObjectID]string, len(pockets))
+	for _, p := range pockets {
+		pocketNames[p.ID] = p.Name
+	}
+
+	categories, err := s.categoryRepo.FindAllByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	tree := newCategoryTree(categories)
+
+	summary := &ZeroBasedSummary{
+		Period:      period,
+		PeriodStart: start,
+		PeriodEnd:   end,
+		Income:      income,
+		Pockets:     make([]*ZeroBasedPocketAssignment, 0, len(pocketFlows)),
+		Categories:  make([]*ZeroBasedCategoryAssignment, 0, len(assignments)),
+	}
+
+	for pocketID, amount := range pocketFlows {
+		if amount == 0 {
+			continue
+		}
+		summary.Pockets = append(summary.Pockets, &ZeroBasedPocketAssignment{
+			PocketID:   pocketID,
+			PocketName: pocketNames[pocketID],
+			Amount:     amount,
+		})
+		summary.AssignedToPockets += amount
+	}
+
+	for _, assignment := range assignments {
+		summary.Categories = append(summary.Categories, &ZeroBasedCategoryAssignment{
+			CategoryID:   assignment.CategoryID,
+			CategoryName: tree.names[assignment.CategoryID],
+			Amount:       assignment.Amount,
+		})
+		summary.AssignedToCategories += assignment.Amount
+	}
+
+	summary.ToBeAssigned = summary.Income - summary.AssignedToPockets - summary.AssignedToCategories
+
+	if record != nil {
+		summary.IsClosed = true
+		summary.ClosedAt = &record.ClosedAt
+	}
+
+	return summary, nil
+}
+
+// transferFromMainPocket moves the given amounts from the main pocket to each pocket in one transaction
+func (s *Service) transferFromMainPocket(ctx context.Context, userID primitive.ObjectID, period string, amounts map[primitive.ObjectID]float64) error {
+	mainPocket, err := s.pocketRepo.GetMainPocketByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if mainPocket == nil {
+		return errors.New("main pocket not found")
+	}
+
+	var total float64
+	for _, amount := range amounts {
+		total += amount
+	}
+	if utils.Decimal128ToFloat64(mainPocket.Balance) < total {
+		return errors.New("insufficient main pocket balance")
+	}
+
+	session, err := s.db.Client().StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	return mongo.WithSession(ctx, session, func(sessionCtx mongo.SessionContext) error {
+		if err := session.StartTransaction(); err != nil {
+			return err
+		}
+
+		for pocketID, amount := range amounts {
+			target, err := s.pocketRepo.GetPocketByID(sessionCtx, pocketID)
+			if err != nil {
+				session.AbortTransaction(sessionCtx)
+				return err
+			}
+			if target.UserID != userID || !target.IsActive || target.Type == string(pocket.TypeMain) {
+				session.AbortTransaction(sessionCtx)
+				return errors.New("pocket " + target.Name + " can no longer be assigned to")
+			}
+
+			note := "Assign like last month " + period
+			ref := zeroBasedRefPrefix + period + "_" + pocketID.Hex()
+			tx := &transaction.Transaction{
+				UserID:       userID,
+				Type:         string(transaction.TypeTransfer),
+				Amount:       amount,
+				PocketFromID: &mainPocket.ID,
+				PocketToID:   &pocketID,
+				Date:         time.Now(),
+				Note:         &note,
+				Ref:          &ref,
+			}
+
+			if err := s.transactionRepo.CreateTransaction(sessionCtx, tx); err != nil {
+				session.AbortTransaction(sessionCtx)
+				return errors.New("failed to create transfer transaction: " + err.Error())
+			}
+
+			target.Balance = utils.AddDecimal128(target.Balance, amount)
+			if err := s.pocketRepo.UpdatePocket(sessionCtx, target.ID, target); err != nil {
+				session.AbortTransaction(sessionCtx)
+				return errors.New("failed to update balances: " + err.Error())
+			}
+
+			mainPocket.Balance = utils.AddDecimal128(mainPocket.Balance, -amount)
+		}
+
+		if err := s.pocketRepo.UpdatePocket(sessionCtx, mainPocket.ID, mainPocket); err != nil {
+			session.AbortTransaction(sessionCtx)
+			return errors.New("failed to update balances: " + err.Error())
+		}
+
+		return session.CommitTransaction(sessionCtx)
+	})
+}
+
+// requireZeroBased returns the user id when the user opted into zero-based budgeting
+func (s *Service) requireZeroBased(ctx context.Context, userID string) (primitive.ObjectID, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return primitive.NilObjectID, errors.New("invalid user id")
+	}
+
+	profile, err := s.userRepo.GetUserProfileByUserID(ctx, userObjID)
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+
+	if !profile.ZeroBasedBudgeting {
+		return primitive.NilObjectID, errors.New("zero-based budgeting is not enabled")
+	}
+
+	return userObjID, nil
+}
+
+func (s *Service) ensurePeriodOpen(ctx context.Context, userID primitive.ObjectID, period string) error {
+	record, err := s.repo.GetZeroBasedPeriod(ctx, userID, period)
+	if err != nil {
+		return err
+	}
+	if record != nil {
+		return errors.New("period is already closed")
+	}
+	return nil
+}
+
+// zeroBasedPeriodBounds resolves a financial month of the user, labelled YYYY-MM by the month it starts in and
+// defaulting to the current one
+func (s *Service) zeroBasedPeriodBounds(ctx context.Context, userID primitive.ObjectID, period string) (string, time.Time, time.Time, error) {
+	startDay, err := s.incomeSourceRepo.GetFinancialMonthStartDay(ctx, userID)
+	if err != nil {
+		return "", time.Time{}, time.Time{}, err
+	}
+
+	start := daily_summary.FinancialMonthStart(startOfDay(time.Now()), startDay)
+	if period != "" {
+		month, err := time.Parse(zeroBasedPeriodLayout, period)
+		if err != nil {
+			return "", time.Time{}, time.Time{}, errors.New("invalid period format, use YYYY-MM")
+		}
+		start = daily_summary.AddFinancialMonths(month, startDay, 0)
+	}
+
+	end := daily_summary.AddFinancialMonths(start, startDay, 1)
+	return start.Format(zeroBasedPeriodLayout), start, end, nil
+}
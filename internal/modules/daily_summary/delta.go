@@ -0,0 +1,157 @@
+package daily_summary
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// TransactionEntry is the part of a transaction that summaries total
+type TransactionEntry struct {
+	UserID             primitive.ObjectID
+	Type               string
+	Amount             float64
+	Date               time.Time
+	CategoryID         *primitive.ObjectID
+	PocketFromID       *primitive.ObjectID
+	PocketToID         *primitive.ObjectID
+	UserPlatformFromID *primitive.ObjectID
+	UserPlatformToID   *primitive.ObjectID
+}
+
+// deltaEpsilon is the amount under which a total or breakdown left by subtracting deltas counts as nothing
+const deltaEpsilon = 0.005
+
+// delta returns what the entry adds to a summary, negated when sign is negative. Income counts towards the
+// pocket and platform it went to, expense towards the ones it came from.
+func (e *TransactionEntry) delta(sign float64) *rollupSource {
+	amount := e.Amount * sign
+	doc := &rollupSource{
+		CategoryBreakdown: []CategoryBreakdown{{CategoryID: e.CategoryID, Type: e.Type, Amount: amount}},
+	}
+
+	pocketID, platformID := e.PocketFromID, e.UserPlatformFromID
+	if e.Type == "income" {
+		doc.TotalIncome = amount
+		pocketID, platformID = e.PocketToID, e.UserPlatformToID
+	} else {
+		doc.TotalExpense = amount
+	}
+
+	if pocketID != nil {
+		doc.PocketBreakdown = []PocketBreakdown{{PocketID: pocketID, Type: e.Type, Amount: amount}}
+	}
+	if platformID != nil {
+		doc.PlatformBreakdown = []PlatformBreakdown{{PlatformID: platformID, Type: e.Type, Amount: amount}}
+	}
+	return doc
+}
+
+// ApplyTransactionDelta adds a transaction to the daily summary of its day and to the rollups of its week, month
+// and year, or takes it out again when sign is negative. Transfers are not summarised. Given a session context
+// the writes are part of the caller's database transaction.
+func (r *Repository) ApplyTransactionDelta(ctx context.Context, entry *TransactionEntry, sign float64) error {
+	if entry.Type != "income" && entry.Type != "expense" {
+		return nil
+	}
+
+	// Days are bucketed in UTC, like the transactions the summaries are generated from
+	day := PeriodStart(GranularityDaily, entry.Date.UTC())
+	delta := entry.delta(sign)
+
+	if err := r.applySummaryDelta(ctx, GranularityDaily, entry.UserID, day, delta); err != nil {
+		return err
+	}
+
+	for _, granularity := range rollupGranularities {
+		if err := r.applySummaryDelta(ctx, granularity, entry.UserID, PeriodStart(granularity, day), delta); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applySummaryDelta merges a delta into the summary of a granularity starting at start, dropping the summary when
+// nothing is left in it
+func (r *Repository) applySummaryDelta(ctx context.Context, granularity string, userID primitive.ObjectID, start time.Time, delta *rollupSource) error {
+	collection, dateField := r.dailySummaries, "date"
+	if granularity != GranularityDaily {
+		collection, dateField = r.rollupCollection(granularity), "period_start"
+	}
+	filter := bson.M{"user_id": userID, dateField: start}
+
+	data := newRollupData()
+
+	var existing rollupSource
+	err := collection.FindOne(ctx, filter).Decode(&existing)
+	if err == nil {
+		data.add(&existing)
+	} else if err != mongo.ErrNoDocuments {
+		return err
+	}
+
+	data.add(delta)
+
+	if data.prune() {
+		_, err := collection.DeleteOne(ctx, filter)
+		return err
+	}
+
+	summary := data.summary()
+	var replacement interface{}
+	if granularity == GranularityDaily {
+		replacement = &DailySummary{
+			UserID:            userID,
+			Date:              start,
+			TotalIncome:       summary.TotalIncome,
+			TotalExpense:      summary.TotalExpense,
+			CategoryBreakdown: summary.CategoryBreakdown,
+			PocketBreakdown:   summary.PocketBreakdown,
+			PlatformBreakdown: summary.PlatformBreakdown,
+			CreatedAt:         time.Now(),
+		}
+	} else {
+		summary.UserID = userID
+		summary.PeriodStart = start
+		summary.PeriodEnd = PeriodEnd(granularity, start)
+		summary.UpdatedAt = time.Now()
+		replacement = summary
+	}
+
+	_, err = collection.ReplaceOne(ctx, filter, replacement, options.Replace().SetUpsert(true))
+	return err
+}
+
+// prune drops the breakdowns that subtracting has brought to nothing and reports whether the data is empty
+func (d *rollupData) prune() bool {
+	if math.Abs(d.totalIncome) < deltaEpsilon {
+		d.totalIncome = 0
+	}
+	if math.Abs(d.totalExpense) < deltaEpsilon {
+		d.totalExpense = 0
+	}
+
+	for key, cat := range d.categories {
+		if math.Abs(cat.Amount) < deltaEpsilon {
+			delete(d.categories, key)
+		}
+	}
+	for key, p := range d.pockets {
+		if math.Abs(p.Amount) < deltaEpsilon {
+			delete(d.pockets, key)
+		}
+	}
+	for key, pl := range d.platforms {
+		if math.Abs(pl.Amount) < deltaEpsilon {
+			delete(d.platforms, key)
+		}
+	}
+
+	return d.totalIncome == 0 && d.totalExpense == 0 &&
+		len(d.categories) == 0 && len(d.pockets) == 0 && len(d.platforms) == 0
+}
@@ -0,0 +1,91 @@
+package daily_summary
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// FinancialMonthStart returns the first day of the financial month containing the day of t. Financial months
+// start on startDay, clamped to the length of shorter months, so a start day of 1 gives calendar months.
+func FinancialMonthStart(t time.Time, startDay int) time.Time {
+	start := dayInMonth(t.Year(), t.Month(), startDay, t.Location())
+	if t.Day() < start.Day() {
+		start = dayInMonth(t.Year(), t.Month()-1, startDay, t.Location())
+	}
+	return start
+}
+
+// AddFinancialMonths returns the start of the financial month n months after the one starting at start
+func AddFinancialMonths(start time.Time, startDay int, n int) time.Time {
+	return dayInMonth(start.Year(), start.Month()+time.Month(n), startDay, start.Location())
+}
+
+// dayInMonth returns the given day of a month, or its last day when the month is shorter
+func dayInMonth(year int, month time.Month, day int, loc *time.Location) time.Time {
+	firstOfMonth := time.Date(year, month, 1, 0, 0, 0, 0, loc)
+	lastDay := firstOfMonth.AddDate(0, 1, -1).Day()
+	if day < 1 {
+		day = 1
+	}
+	if day > lastDay {
+		day = lastDay
+	}
+	return time.Date(firstOfMonth.Year(), firstOfMonth.Month(), day, 0, 0, 0, 0, loc)
+}
+
+// GetPeriodTotals totals the summaries of consecutive periods, the i-th one running from bounds[i] to
+// bounds[i+1] (exclusive). Each period is read from the coarsest rollups up to coarsest that fit inside it, so
+// periods such as financial months that do not line up with calendar ones are totalled exactly.
+func (r *Repository) GetPeriodTotals(ctx context.Context, userID primitive.ObjectID, bounds []time.Time, coarsest string) ([]*PeriodSummary, error) {
+	if len(bounds) < 2 {
+		return []*PeriodSummary{}, nil
+	}
+
+	starts := make([]time.Time, len(bounds))
+	cover := make(map[string][]time.Time)
+	for i, bound := range bounds {
+		starts[i] = PeriodStart(GranularityDaily, bound)
+		if i == 0 {
+			continue
+		}
+		for granularity, periodStarts := range CoverRange(starts[i-1], starts[i], coarsest) {
+			cover[granularity] = append(cover[granularity], periodStarts...)
+		}
+	}
+
+	summaries, err := r.findCover(ctx, userID, cover)
+	if err != nil {
+		return nil, err
+	}
+
+	periods := make([]*rollupData, len(bounds)-1)
+	for i := range periods {
+		periods[i] = newRollupData()
+	}
+	for _, summary := range summaries {
+		// The period holding the summary is the last one starting on or before it
+		i := sort.Search(len(starts), func(i int) bool { return starts[i].After(summary.PeriodStart) }) - 1
+		if i < 0 || i >= len(periods) {
+			continue
+		}
+		periods[i].add(&rollupSource{
+			TotalIncome:       summary.TotalIncome,
+			TotalExpense:      summary.TotalExpense,
+			CategoryBreakdown: summary.CategoryBreakdown,
+			PocketBreakdown:   summary.PocketBreakdown,
+			PlatformBreakdown: summary.PlatformBreakdown,
+		})
+	}
+
+	totals := make([]*PeriodSummary, len(periods))
+	for i, data := range periods {
+		totals[i] = data.summary()
+		totals[i].UserID = userID
+		totals[i].PeriodStart = starts[i]
+		totals[i].PeriodEnd = starts[i+1]
+	}
+	return totals, nil
+}
@@ -0,0 +1,65 @@
+package daily_summary
+
+import (
+	"testing"
+	"time"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestFinancialMonthStart(t *testing.T) {
+	tests := []struct {
+		name     string
+		t        time.Time
+		startDay int
+		want     time.Time
+	}{
+		{"calendar month", date(2026, time.March, 15), 1, date(2026, time.March, 1)},
+		{"before the start day", date(2026, time.March, 24), 25, date(2026, time.February, 25)},
+		{"on the start day", date(2026, time.March, 25), 25, date(2026, time.March, 25)},
+		{"across the new year", date(2026, time.January, 10), 15, date(2025, time.December, 15)},
+		{"31st clamped to the end of February", date(2026, time.March, 1), 31, date(2026, time.February, 28)},
+		{"on the clamped end of February", date(2026, time.February, 28), 31, date(2026, time.February, 28)},
+		{"30th clamped to February 29 of a leap year", date(2024, time.February, 29), 30, date(2024, time.February, 29)},
+		{"day before February 29", date(2024, time.February, 28), 30, date(2024, time.January, 30)},
+		{"31st clamped to the end of April", date(2026, time.May, 30), 31, date(2026, time.April, 30)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FinancialMonthStart(tt.t, tt.startDay); !got.Equal(tt.want) {
+				t.Errorf("FinancialMonthStart(%s, %d) = %s, want %s", tt.t.Format("2006-01-02"), tt.startDay, got.Format("2006-01-02"), tt.want.Format("2006-01-02"))
+			}
+		})
+	}
+}
+
+func TestAddFinancialMonths(t *testing.T) {
+	tests := []struct {
+		name     string
+		start    time.Time
+		startDay int
+		n        int
+		want     time.Time
+	}{
+		{"31st into February", date(2026, time.January, 31), 31, 1, date(2026, time.February, 28)},
+		{"31st does not drift after February", date(2026, time.January, 31), 31, 2, date(2026, time.March, 31)},
+		{"from a clamped start", date(2026, time.February, 28), 31, 1, date(2026, time.March, 31)},
+		{"30th into a leap February", date(2024, time.January, 30), 30, 1, date(2024, time.February, 29)},
+		{"into the next year", date(2026, time.December, 25), 25, 1, date(2027, time.January, 25)},
+		{"back a month", date(2026, time.December, 25), 25, -1, date(2026, time.November, 25)},
+		{"back into a short month", date(2026, time.March, 31), 31, -1, date(2026, time.February, 28)},
+		{"start day of a month label", date(2026, time.September, 1), 25, 0, date(2026, time.September, 25)},
+		{"start day below 1", date(2026, time.March, 10), 0, 0, date(2026, time.March, 1)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := AddFinancialMonths(tt.start, tt.startDay, tt.n); !got.Equal(tt.want) {
+				t.Errorf("AddFinancialMonths(%s, %d, %d) = %s, want %s", tt.start.Format("2006-01-02"), tt.startDay, tt.n, got.Format("2006-01-02"), tt.want.Format("2006-01-02"))
+			}
+		})
+	}
+}
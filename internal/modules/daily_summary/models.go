@@ -38,3 +38,25 @@ type PlatformBreakdown struct {
 	Type         string              `bson:"type" json:"type"`
 	Amount       float64             `bson:"amount" json:"amount"`
 }
+
+// PeriodSummary rolls up the daily summaries of a week (Monday to Sunday), a calendar month or a calendar year
+type PeriodSummary struct {
+	ID                primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	UserID            primitive.ObjectID  `bson:"user_id" json:"user_id"`
+	PeriodStart       time.Time           `bson:"period_start" json:"period_start"`
+	PeriodEnd         time.Time           `bson:"period_end" json:"period_end"` // exclusive
+	TotalIncome       float64             `bson:"total_income" json:"total_income"`
+	TotalExpense      float64             `bson:"total_expense" json:"total_expense"`
+	CategoryBreakdown []CategoryBreakdown `bson:"category_breakdown" json:"category_breakdown"`
+	PocketBreakdown   []PocketBreakdown   `bson:"pocket_breakdown" json:"pocket_breakdown"`
+	PlatformBreakdown []PlatformBreakdown `bson:"platform_breakdown" json:"platform_breakdown"`
+	UpdatedAt         time.Time           `bson:"updated_at" json:"updated_at"`
+}
+
+// Summary granularities, from the finest to the coarsest
+const (
+	GranularityDaily   = "daily"
+	GranularityWeekly  = "weekly"
+	GranularityMonthly = "monthly"
+	GranularityYearly  = "yearly"
+)
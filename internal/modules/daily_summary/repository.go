@@ -11,16 +11,22 @@ import (
 )
 
 type Repository struct {
-	dailySummaries *mongo.Collection
-	transactions   *mongo.Collection
-	userCategories *mongo.Collection
+	dailySummaries   *mongo.Collection
+	weeklySummaries  *mongo.Collection
+	monthlySummaries *mongo.Collection
+	yearlySummaries  *mongo.Collection
+	transactions     *mongo.Collection
+	userCategories   *mongo.Collection
 }
 
 func NewRepository(db *mongo.Database) *Repository {
 	return &Repository{
-		dailySummaries: db.Collection("daily_summaries"),
-		transactions:   db.Collection("transactions"),
-		userCategories: db.Collection("user_categories"),
+		dailySummaries:   db.Collection("daily_summaries"),
+		weeklySummaries:  db.Collection("weekly_summaries"),
+		monthlySummaries: db.Collection("monthly_summaries"),
+		yearlySummaries:  db.Collection("yearly_summaries"),
+		transactions:     db.Collection("transactions"),
+		userCategories:   db.Collection("user_categories"),
 	}
 }
 
@@ -54,6 +60,32 @@ func (r *Repository) GetDailySummariesByDateRange(ctx context.Context, userID pr
 	return summaries, nil
 }
 
+// GetDailyTotalsByDateRange returns the daily income and expense totals in a date range, without breakdowns
+func (r *Repository) GetDailyTotalsByDateRange(ctx context.Context, userID primitive.ObjectID, startDate, endDate time.Time) ([]*DailySummary, error) {
+	filter := bson.M{
+		"user_id": userID,
+		"date": bson.M{
+			"$gte": startDate,
+			"$lt":  endDate,
+		},
+	}
+
+	opts := options.Find().
+		SetSort(bson.M{"date": 1}).
+		SetProjection(bson.M{"date": 1, "total_income": 1, "total_expense": 1})
+	cursor, err := r.dailySummaries.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var summaries []*DailySummary
+	if err = cursor.All(ctx, &summaries); err != nil {
+		return nil, err
+	}
+	return summaries, nil
+}
+
 func (r *Repository) DeleteDailySummariesByDateRange(ctx context.Context, startDate time.Time) error {
 	filter := bson.M{
 		"date": bson.M{
@@ -0,0 +1,413 @@
+package daily_summary
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// rollupGranularities are the granularities kept in rollup collections, each built from the one before it
+var rollupGranularities = []string{GranularityWeekly, GranularityMonthly, GranularityYearly}
+
+// PeriodStart returns the start of the period of a granularity that contains the day of t, at midnight UTC
+// like daily summaries
+func PeriodStart(granularity string, t time.Time) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+
+	switch granularity {
+	case GranularityWeekly:
+		return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	case GranularityMonthly:
+		return time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, time.UTC)
+	case GranularityYearly:
+		return time.Date(day.Year(), time.January, 1, 0, 0, 0, 0, time.UTC)
+	default:
+		return day
+	}
+}
+
+// PeriodEnd returns the exclusive end of the period of a granularity that starts at start
+func PeriodEnd(granularity string, start time.Time) time.Time {
+	switch granularity {
+	case GranularityWeekly:
+		return start.AddDate(0, 0, 7)
+	case GranularityMonthly:
+		return start.AddDate(0, 1, 0)
+	case GranularityYearly:
+		return start.AddDate(1, 0, 0)
+	default:
+		return start.AddDate(0, 0, 1)
+	}
+}
+
+// CoverRange splits the days from start to end (exclusive) into as few summaries as possible: whole periods no
+// coarser than coarsest where they fit and single days for the rest. It returns the period starts by granularity.
+func CoverRange(start, end time.Time, coarsest string) map[string][]time.Time {
+	allowed := make([]string, 0, len(rollupGranularities))
+	for i := len(rollupGranularities) - 1; i >= 0; i-- {
+		if len(allowed) > 0 || rollupGranularities[i] == coarsest {
+			allowed = append(allowed, rollupGranularities[i])
+		}
+	}
+
+	cover := make(map[string][]time.Time)
+	last := PeriodStart(GranularityDaily, end)
+	for cursor := PeriodStart(GranularityDaily, start); cursor.Before(last); {
+		granularity := GranularityDaily
+		for _, candidate := range allowed {
+			// A week running into the next month would blur monthly totals, so it is only used when weeks are the
+			// coarsest summaries asked for
+			if candidate == GranularityWeekly && coarsest != GranularityWeekly &&
+				!PeriodStart(GranularityMonthly, cursor).Equal(PeriodStart(GranularityMonthly, PeriodEnd(candidate, cursor).AddDate(0, 0, -1))) {
+				continue
+			}
+			if PeriodStart(candidate, cursor).Equal(cursor) && !PeriodEnd(candidate, cursor).After(last) {
+				granularity = candidate
+				break
+			}
+		}
+
+		cover[granularity] = append(cover[granularity], cursor)
+		cursor = PeriodEnd(granularity, cursor)
+	}
+
+	return cover
+}
+
+func (r *Repository) rollupCollection(granularity string) *mongo.Collection {
+	switch granularity {
+	case GranularityWeekly:
+		return r.weeklySummaries
+	case GranularityMonthly:
+		return r.monthlySummaries
+	default:
+		return r.yearlySummaries
+	}
+}
+
+// GetCoveringSummaries returns summaries that together cover the days from start to end (exclusive), using the
+// coarsest rollups up to coarsest that fit and daily summaries for the remaining days, in date order
+func (r *Repository) GetCoveringSummaries(ctx context.Context, userID primitive.ObjectID, start, end time.Time, coarsest string) ([]*PeriodSummary, error) {
+	summaries, err := r.findCover(ctx, userID, CoverRange(start, end, coarsest))
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(summaries, func(i, j int) bool {
+		return summaries[i].PeriodStart.Before(summaries[j].PeriodStart)
+	})
+	return summaries, nil
+}
+
+// findCover reads the summaries of a cover, daily summaries read as single day periods
+func (r *Repository) findCover(ctx context.Context, userID primitive.ObjectID, cover map[string][]time.Time) ([]*PeriodSummary, error) {
+	summaries := make([]*PeriodSummary, 0)
+
+	for granularity, starts := range cover {
+		if granularity == GranularityDaily {
+			cursor, err := r.dailySummaries.Find(ctx, bson.M{
+				"user_id": userID,
+				"date":    bson.M{"$in": starts},
+			})
+			if err != nil {
+				return nil, err
+			}
+
+			var dailies []*DailySummary
+			if err = cursor.All(ctx, &dailies); err != nil {
+				return nil, err
+			}
+
+			for _, daily := range dailies {
+				summaries = append(summaries, &PeriodSummary{
+					UserID:            daily.UserID,
+					PeriodStart:       daily.Date,
+					PeriodEnd:         PeriodEnd(GranularityDaily, daily.Date),
+					TotalIncome:       daily.TotalIncome,
+					TotalExpense:      daily.TotalExpense,
+					CategoryBreakdown: daily.CategoryBreakdown,
+					PocketBreakdown:   daily.PocketBreakdown,
+					PlatformBreakdown: daily.PlatformBreakdown,
+				})
+			}
+			continue
+		}
+
+		cursor, err := r.rollupCollection(granularity).Find(ctx, bson.M{
+			"user_id":      userID,
+			"period_start": bson.M{"$in": starts},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		var rollups []*PeriodSummary
+		if err = cursor.All(ctx, &rollups); err != nil {
+			return nil, err
+		}
+		summaries = append(summaries, rollups...)
+	}
+
+	return summaries, nil
+}
+
+// GetFirstSummaryDate returns the date of the user's earliest daily summary, or nil when they have none
+func (r *Repository) GetFirstSummaryDate(ctx context.Context, userID primitive.ObjectID) (*time.Time, error) {
+	var first DailySummary
+	opts := options.FindOne().SetSort(bson.M{"date": 1}).SetProjection(bson.M{"date": 1})
+	err := r.dailySummaries.FindOne(ctx, bson.M{"user_id": userID}, opts).Decode(&first)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &first.Date, nil
+}
+
+// HasRollups reports whether any rollup has been built yet
+func (r *Repository) HasRollups(ctx context.Context) (bool, error) {
+	count, err := r.monthlySummaries.CountDocuments(ctx, bson.M{}, options.Count().SetLimit(1))
+	return count > 0, err
+}
+
+// RebuildRollups recomputes the weekly, monthly and yearly rollups of the periods touching the days from start
+// to end (exclusive) for the given users, or for every user with summaries in those periods when userIDs is
+// nil. Weeks and months are rolled up from daily summaries and years from months.
+func (r *Repository) RebuildRollups(ctx context.Context, userIDs []primitive.ObjectID, start, end time.Time) error {
+	if !start.Before(end) {
+		return nil
+	}
+
+	for _, granularity := range rollupGranularities {
+		if err := r.rebuildRollup(ctx, granularity, userIDs, start, end); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rollupSource is a daily summary or a monthly rollup read to build a coarser rollup
+type rollupSource struct {
+	UserID            primitive.ObjectID  `bson:"user_id"`
+	Date              time.Time           `bson:"date"`
+	PeriodStart       time.Time           `bson:"period_start"`
+	TotalIncome       float64             `bson:"total_income"`
+	TotalExpense      float64             `bson:"total_expense"`
+	CategoryBreakdown []CategoryBreakdown `bson:"category_breakdown"`
+	PocketBreakdown   []PocketBreakdown   `bson:"pocket_breakdown"`
+	PlatformBreakdown []PlatformBreakdown `bson:"platform_breakdown"`
+}
+
+func (r *Repository) rebuildRollup(ctx context.Context, granularity string, userIDs []primitive.ObjectID, start, end time.Time) error {
+	from := PeriodStart(granularity, start)
+	to := PeriodEnd(granularity, PeriodStart(granularity, end.AddDate(0, 0, -1)))
+	periodRange := bson.M{"$gte": from, "$lt": to}
+
+	source, dateField := r.dailySummaries, "date"
+	if granularity == GranularityYearly {
+		source, dateField = r.monthlySummaries, "period_start"
+	}
+	target := r.rollupCollection(granularity)
+
+	// Users whose rollups may change: those with summaries in the periods, and those with rollups left there
+	if userIDs == nil {
+		seen := make(map[primitive.ObjectID]bool)
+		for _, c := range []struct {
+			collection *mongo.Collection
+			field      string
+		}{{source, dateField}, {target, "period_start"}} {
+			ids, err := c.collection.Distinct(ctx, "user_id", bson.M{c.field: periodRange})
+			if err != nil {
+				return err
+			}
+			for _, id := range ids {
+				if userID, ok := id.(primitive.ObjectID); ok && !seen[userID] {
+					seen[userID] = true
+					userIDs = append(userIDs, userID)
+				}
+			}
+		}
+	}
+
+	batchSize := 100
+	for i := 0; i < len(userIDs); i += batchSize {
+		batchEnd := i + batchSize
+		if batchEnd > len(userIDs) {
+			batchEnd = len(userIDs)
+		}
+		batchUserIDs := userIDs[i:batchEnd]
+
+		cursor, err := source.Find(ctx, bson.M{
+			"user_id": bson.M{"$in": batchUserIDs},
+			dateField: periodRange,
+		})
+		if err != nil {
+			return err
+		}
+
+		var docs []*rollupSource
+		if err = cursor.All(ctx, &docs); err != nil {
+			return err
+		}
+
+		type rollupKey struct {
+			UserID primitive.ObjectID
+			Start  time.Time
+		}
+		rollups := make(map[rollupKey]*rollupData)
+		for _, doc := range docs {
+			date := doc.Date
+			if dateField == "period_start" {
+				date = doc.PeriodStart
+			}
+
+			key := rollupKey{UserID: doc.UserID, Start: PeriodStart(granularity, date)}
+			data, ok := rollups[key]
+			if !ok {
+				data = newRollupData()
+				rollups[key] = data
+			}
+			data.add(doc)
+		}
+
+		// Replace the rollups that have data and drop the ones whose days no longer have any
+		now := time.Now()
+		models := make([]mongo.WriteModel, 0, len(rollups)+len(batchUserIDs))
+		kept := make(map[primitive.ObjectID][]time.Time)
+		for key, data := range rollups {
+			summary := data.summary()
+			summary.UserID = key.UserID
+			summary.PeriodStart = key.Start
+			summary.PeriodEnd = PeriodEnd(granularity, key.Start)
+			summary.UpdatedAt = now
+
+			kept[key.UserID] = append(kept[key.UserID], key.Start)
+			models = append(models, mongo.NewReplaceOneModel().
+				SetFilter(bson.M{"user_id": key.UserID, "period_start": key.Start}).
+				SetReplacement(summary).
+				SetUpsert(true))
+		}
+		for _, userID := range batchUserIDs {
+			filter := bson.M{"user_id": userID, "period_start": periodRange}
+			if starts := kept[userID]; len(starts) > 0 {
+				filter["period_start"] = bson.M{"$gte": from, "$lt": to, "$nin": starts}
+			}
+			models = append(models, mongo.NewDeleteManyModel().SetFilter(filter))
+		}
+
+		if _, err := target.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// rollupData accumulates the totals and breakdowns of a rollup
+type rollupData struct {
+	totalIncome  float64
+	totalExpense float64
+	categories   map[string]*CategoryBreakdown
+	pockets      map[string]*PocketBreakdown
+	platforms    map[string]*PlatformBreakdown
+}
+
+func newRollupData() *rollupData {
+	return &rollupData{
+		categories: make(map[string]*CategoryBreakdown),
+		pockets:    make(map[string]*PocketBreakdown),
+		platforms:  make(map[string]*PlatformBreakdown),
+	}
+}
+
+func (d *rollupData) add(doc *rollupSource) {
+	d.totalIncome += doc.TotalIncome
+	d.totalExpense += doc.TotalExpense
+
+	for _, cat := range doc.CategoryBreakdown {
+		key := cat.Type + "_uncategorized"
+		if cat.CategoryID != nil {
+			key = cat.Type + "_" + cat.CategoryID.Hex()
+		}
+		if existing, ok := d.categories[key]; ok {
+			existing.Amount += cat.Amount
+		} else {
+			c := cat
+			d.categories[key] = &c
+		}
+	}
+
+	for _, p := range doc.PocketBreakdown {
+		if p.PocketID == nil {
+			continue
+		}
+		key := p.Type + "_" + p.PocketID.Hex()
+		if existing, ok := d.pockets[key]; ok {
+			existing.Amount += p.Amount
+		} else {
+			pk := p
+			d.pockets[key] = &pk
+		}
+	}
+
+	for _, pl := range doc.PlatformBreakdown {
+		if pl.PlatformID == nil {
+			continue
+		}
+		key := pl.Type + "_" + pl.PlatformID.Hex()
+		if existing, ok := d.platforms[key]; ok {
+			existing.Amount += pl.Amount
+		} else {
+			plt := pl
+			d.platforms[key] = &plt
+		}
+	}
+}
+
+func (d *rollupData) summary() *PeriodSummary {
+	cats := make([]CategoryBreakdown, 0, len(d.categories))
+	for _, c := range d.categories {
+		cats = append(cats, *c)
+	}
+	pks := make([]PocketBreakdown, 0, len(d.pockets))
+	for _, p := range d.pockets {
+		pks = append(pks, *p)
+	}
+	pls := make([]PlatformBreakdown, 0, len(d.platforms))
+	for _, pl := range d.platforms {
+		pls = append(pls, *pl)
+	}
+
+	return &PeriodSummary{
+		TotalIncome:       d.totalIncome,
+		TotalExpense:      d.totalExpense,
+		CategoryBreakdown: cats,
+		PocketBreakdown:   pks,
+		PlatformBreakdown: pls,
+	}
+}
+
+// EnsureRollupIndexes creates the indexes of the rollup collections, one rollup per user per period
+func (r *Repository) EnsureRollupIndexes(ctx context.Context) error {
+	for _, granularity := range rollupGranularities {
+		_, err := r.rollupCollection(granularity).Indexes().CreateOne(ctx, mongo.IndexModel{
+			Keys: bson.D{
+				{Key: "user_id", Value: 1},
+				{Key: "period_start", Value: -1},
+			},
+			Options: options.Index().
+				SetName("idx_" + granularity + "_summaries_user_period").
+				SetUnique(true),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -2,6 +2,7 @@ package daily_summary
 
 import (
 	"context"
+	"log"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -17,12 +18,41 @@ func NewService(r *Repository) *Service {
 	}
 }
 
+// AddTransaction adds a transaction dated before today to the summaries of its day. Today's transactions are
+// read live by the dashboard until the nightly cron summarises them.
+func (s *Service) AddTransaction(ctx context.Context, entry *TransactionEntry) error {
+	return s.applyTransaction(ctx, entry, 1)
+}
+
+// RemoveTransaction takes a transaction dated before today out of the summaries of its day
+func (s *Service) RemoveTransaction(ctx context.Context, entry *TransactionEntry) error {
+	return s.applyTransaction(ctx, entry, -1)
+}
+
+func (s *Service) applyTransaction(ctx context.Context, entry *TransactionEntry, sign float64) error {
+	if !PeriodStart(GranularityDaily, entry.Date.UTC()).Before(today()) {
+		return nil
+	}
+	return s.repo.ApplyTransactionDelta(ctx, entry, sign)
+}
+
+// GenerateDailySummary rebuilds the summary of a day from its transactions, repairing one that drifted from them
 func (s *Service) GenerateDailySummary(ctx context.Context, userID primitive.ObjectID, date time.Time) error {
-	return s.repo.GenerateDailySummaryForDate(ctx, userID, date)
+	if err := s.repo.GenerateDailySummaryForDate(ctx, userID, date); err != nil {
+		return err
+	}
+
+	// Keep the week, month and year of the day in step
+	day := PeriodStart(GranularityDaily, date)
+	return s.repo.RebuildRollups(ctx, []primitive.ObjectID{userID}, day, PeriodEnd(GranularityDaily, day))
 }
 
 func (s *Service) GenerateDailySummariesForAllUsers(ctx context.Context, date time.Time) error {
-	return s.repo.GenerateDailySummariesFromTo(ctx, date)
+	if err := s.repo.GenerateDailySummariesFromTo(ctx, date); err != nil {
+		return err
+	}
+
+	return s.repo.RebuildRollups(ctx, nil, PeriodStart(GranularityDaily, date), today())
 }
 
 func (s *Service) SyncDailySummaries(ctx context.Context, startDate time.Time) error {
@@ -32,5 +62,31 @@ func (s *Service) SyncDailySummaries(ctx context.Context, startDate time.Time) e
 	}
 
 	// 2. Simply call the optimized batch generator
-	return s.repo.GenerateDailySummariesFromTo(ctx, startDate)
+	if err := s.repo.GenerateDailySummariesFromTo(ctx, startDate); err != nil {
+		return err
+	}
+
+	// 3. Roll the regenerated days up again, including users left without any
+	return s.repo.RebuildRollups(ctx, nil, PeriodStart(GranularityDaily, startDate), today())
+}
+
+// BackfillRollups builds the rollups of every daily summary once, when none have been built yet
+func (s *Service) BackfillRollups(ctx context.Context) error {
+	built, err := s.repo.HasRollups(ctx)
+	if err != nil || built {
+		return err
+	}
+
+	start := time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+	if err := s.repo.RebuildRollups(ctx, nil, start, today().AddDate(0, 0, 1)); err != nil {
+		return err
+	}
+
+	log.Println("Summary rollups backfilled from daily summaries")
+	return nil
+}
+
+// today returns the start of the current day in UTC, the day daily summaries are generated up to
+func today() time.Time {
+	return PeriodStart(GranularityDaily, time.Now().UTC())
 }
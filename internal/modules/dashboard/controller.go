@@ -23,11 +23,14 @@ func NewController(s *Service, dss *daily_summary.Service) *Controller {
 
 // GetDashboardSummary godoc
 // @Summary Get dashboard summary
-// @Description Get real-time dashboard summary with total net worth and period income/expense using Hybrid Logic. Default is rolling 30 days. Use filter for 7d (rolling 7 days), 1m (calendar month from 1st), 3m (calendar 3 months from 1st)
+// @Description Get real-time dashboard summary with total net worth and period income/expense using Hybrid Logic. Default is the current month. Months are the user's financial months, starting on their chosen day or monthly salary day. Use one of time_range (7d rolling 7 days, 1m current month, 3m from the start of the month 3 months ago, 1y from the start of the month 12 months ago, all since the first summary), period, or start_date and end_date (up to 5 years)
 // @Tags Dashboard
 // @Accept json
 // @Produce json
-// @Param time_range query string false "Time range filter" Enums(7d, 1m, 3m)
+// @Param time_range query string false "Time range filter" Enums(7d, 1m, 3m, 1y, all)
+// @Param period query string false "Named period" Enums(this_week, last_week, this_month, last_month, ytd, last_year)
+// @Param start_date query string false "Start date (YYYY-MM-DD)"
+// @Param end_date query string false "End date (YYYY-MM-DD), inclusive"
 // @Success 200 {object} map[string]interface{} "Dashboard summary retrieved successfully"
 // @Failure 400 {object} map[string]interface{} "Bad request"
 // @Failure 401 {object} map[string]interface{} "Unauthorized"
@@ -41,14 +44,7 @@ func (c *Controller) GetDashboardSummary(ctx *gin.Context) {
 		return
 	}
 
-	timeRange := TimeRange(ctx.DefaultQuery("time_range", ""))
-	if timeRange != "" && timeRange != TimeRange7Days && timeRange != TimeRange1Month && timeRange != TimeRange3Month {
-		resp := utils.NewErrorResponse(http.StatusBadRequest, "invalid time_range, allowed values: 7d, 1m, 3m")
-		ctx.JSON(http.StatusBadRequest, resp)
-		return
-	}
-
-	summary, err := c.service.GetDashboardSummary(ctx, userID.(string), timeRange)
+	summary, err := c.service.GetDashboardSummary(ctx, userID.(string), dashboardQuery(ctx, "time_range"))
 	if err != nil {
 		resp := utils.NewErrorResponse(http.StatusBadRequest, err.Error())
 		ctx.JSON(http.StatusBadRequest, resp)
@@ -61,11 +57,15 @@ func (c *Controller) GetDashboardSummary(ctx *gin.Context) {
 
 // GetDashboardCharts godoc
 // @Summary Get dashboard charts data
-// @Description Get cash flow trends and category breakdown charts using Hybrid Logic
+// @Description Get cash flow trends and category breakdown charts using Hybrid Logic. The days are chosen like the summary. The trend has daily points up to 186 days and monthly points beyond unless granularity is given, with at most 400 points.
 // @Tags Dashboard
 // @Accept json
 // @Produce json
-// @Param range query string false "Date range" Enums(7d, 1m, 3m)
+// @Param range query string false "Date range" Enums(7d, 1m, 3m, 1y, all)
+// @Param period query string false "Named period" Enums(this_week, last_week, this_month, last_month, ytd, last_year)
+// @Param start_date query string false "Start date (YYYY-MM-DD)"
+// @Param end_date query string false "End date (YYYY-MM-DD), inclusive"
+// @Param granularity query string false "Cash flow trend granularity" Enums(day, week, month)
 // @Success 200 {object} map[string]interface{} "Dashboard charts retrieved successfully"
 // @Failure 400 {object} map[string]interface{} "Bad request"
 // @Failure 401 {object} map[string]interface{} "Unauthorized"
@@ -79,14 +79,7 @@ func (c *Controller) GetDashboardCharts(ctx *gin.Context) {
 		return
 	}
 
-	timeRange := TimeRange(ctx.DefaultQuery("range", ""))
-	if timeRange != "" && timeRange != TimeRange7Days && timeRange != TimeRange1Month && timeRange != TimeRange3Month {
-		resp := utils.NewErrorResponse(http.StatusBadRequest, "invalid time_range, allowed values: 7d, 1m, 3m")
-		ctx.JSON(http.StatusBadRequest, resp)
-		return
-	}
-
-	charts, err := c.service.GetDashboardCharts(ctx, userID.(string), timeRange)
+	charts, err := c.service.GetDashboardCharts(ctx, userID.(string), dashboardQuery(ctx, "range"))
 	if err != nil {
 		resp := utils.NewErrorResponse(http.StatusBadRequest, err.Error())
 		ctx.JSON(http.StatusBadRequest, resp)
@@ -134,3 +127,14 @@ func (c *Controller) SyncDailySummaries(ctx *gin.Context) {
 	resp := utils.NewSuccessResponse("Daily summaries synced successfully", nil)
 	ctx.JSON(http.StatusOK, resp)
 }
+
+// dashboardQuery reads the days and trend granularity of a dashboard request, the time range from rangeParam
+func dashboardQuery(ctx *gin.Context, rangeParam string) *DashboardQuery {
+	return &DashboardQuery{
+		TimeRange:   TimeRange(ctx.Query(rangeParam)),
+		Period:      NamedPeriod(ctx.Query("period")),
+		StartDate:   ctx.Query("start_date"),
+		EndDate:     ctx.Query("end_date"),
+		Granularity: ctx.Query("granularity"),
+	}
+}
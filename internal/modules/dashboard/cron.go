@@ -5,28 +5,39 @@ import (
 	"log"
 	"time"
 
+	"github.com/HasanNugroho/coin-be/internal/modules/balance_snapshot"
 	"github.com/HasanNugroho/coin-be/internal/modules/daily_summary"
 	"github.com/robfig/cron/v3"
 )
 
 type CronJob struct {
-	service             *Service
-	dailySummaryService *daily_summary.Service
-	cron                *cron.Cron
+	service                *Service
+	dailySummaryService    *daily_summary.Service
+	balanceSnapshotService *balance_snapshot.Service
+	cron                   *cron.Cron
 }
 
-func NewCronJob(service *Service, dss *daily_summary.Service) *CronJob {
+func NewCronJob(service *Service, dss *daily_summary.Service, bss *balance_snapshot.Service) *CronJob {
 	return &CronJob{
-		service:             service,
-		dailySummaryService: dss,
-		cron:                cron.New(),
+		service:                service,
+		dailySummaryService:    dss,
+		balanceSnapshotService: bss,
+		cron:                   cron.New(),
 	}
 }
 
 func (c *CronJob) Start() {
+	// Rollups are built from the existing daily summaries once, the first time the job starts
+	go func() {
+		if err := c.dailySummaryService.BackfillRollups(context.Background()); err != nil {
+			log.Printf("Error backfilling summary rollups: %v", err)
+		}
+	}()
+
 	c.cron.AddFunc("1 0 * * *", func() {
 		ctx := context.Background()
-		yesterday := time.Now().AddDate(0, 0, -1)
+		// Summaries and snapshots bucket days in UTC, so yesterday is the last full UTC day whatever the server zone
+		yesterday := daily_summary.PeriodStart(daily_summary.GranularityDaily, time.Now().UTC()).AddDate(0, 0, -1)
 
 		log.Printf("Starting daily summary generation for date: %s", yesterday.Format("2006-01-02"))
 
@@ -36,10 +47,17 @@ func (c *CronJob) Start() {
 		} else {
 			log.Printf("Daily summaries generated successfully for date: %s", yesterday.Format("2006-01-02"))
 		}
+
+		err = c.balanceSnapshotService.GenerateSnapshotsForDate(ctx, yesterday)
+		if err != nil {
+			log.Printf("Error generating balance snapshots: %v", err)
+		} else {
+			log.Printf("Balance snapshots generated successfully for date: %s", yesterday.Format("2006-01-02"))
+		}
 	})
 
 	c.cron.Start()
-	log.Println("Dashboard cron job started - Daily summaries will be generated at 00:01 every day")
+	log.Println("Dashboard cron job started - Daily summaries and balance snapshots will be generated at 00:01 every day")
 }
 
 func (c *CronJob) Stop() {
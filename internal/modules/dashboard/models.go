@@ -1,11 +1,24 @@
 package dashboard
 
+// DashboardQuery selects the days a dashboard covers, by time range, named period or explicit start and end
+// dates, and the granularity of its cash flow trend
+type DashboardQuery struct {
+	TimeRange   TimeRange
+	Period      NamedPeriod
+	StartDate   string
+	EndDate     string
+	Granularity string
+}
+
 type DashboardSummary struct {
-	TotalNetWorth float64   `json:"total_net_worth"`
-	PeriodIncome  float64   `json:"period_income"`
-	PeriodExpense float64   `json:"period_expense"`
-	PeriodNet     float64   `json:"period_net"`
-	TimeRange     TimeRange `json:"time_range"`
+	TotalNetWorth float64     `json:"total_net_worth"`
+	PeriodIncome  float64     `json:"period_income"`
+	PeriodExpense float64     `json:"period_expense"`
+	PeriodNet     float64     `json:"period_net"`
+	TimeRange     TimeRange   `json:"time_range,omitempty"`
+	Period        NamedPeriod `json:"period,omitempty"`
+	StartDate     string      `json:"start_date"`
+	EndDate       string      `json:"end_date"`
 }
 
 type ChartDataPoint struct {
@@ -23,6 +36,9 @@ type CategoryChartData struct {
 
 type DashboardCharts struct {
 	CashFlowTrend    []ChartDataPoint    `json:"cash_flow_trend"`
+	TrendGranularity string              `json:"trend_granularity"`
+	StartDate        string              `json:"start_date"`
+	EndDate          string              `json:"end_date"`
 	IncomeBreakdown  []CategoryChartData `json:"income_breakdown"`
 	ExpenseBreakdown []CategoryChartData `json:"expense_breakdown"`
 }
@@ -5,6 +5,7 @@ import (
 
 	"github.com/HasanNugroho/coin-be/internal/core/config"
 	"github.com/HasanNugroho/coin-be/internal/modules/daily_summary"
+	"github.com/HasanNugroho/coin-be/internal/modules/income_source"
 	"github.com/sarulabs/di/v2"
 	"go.mongodb.org/mongo-driver/mongo"
 )
@@ -30,7 +31,8 @@ func Register(builder *di.Builder) {
 		Build: func(ctn di.Container) (interface{}, error) {
 			repo := ctn.Get("dashboardRepository").(*Repository)
 			dsr := ctn.Get("dailySummaryRepository").(*daily_summary.Repository)
-			return NewService(repo, dsr), nil
+			incomeSourceRepo := ctn.Get("incomeSourceRepository").(*income_source.Repository)
+			return NewService(repo, dsr, incomeSourceRepo), nil
 		},
 	})
 
@@ -0,0 +1,249 @@
+package dashboard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/HasanNugroho/coin-be/internal/modules/daily_summary"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// NamedPeriod is a period relative to today, months being the user's financial months
+type NamedPeriod string
+
+const (
+	PeriodThisWeek  NamedPeriod = "this_week"
+	PeriodLastWeek  NamedPeriod = "last_week"
+	PeriodThisMonth NamedPeriod = "this_month"
+	PeriodLastMonth NamedPeriod = "last_month"
+	PeriodYTD       NamedPeriod = "ytd"
+	PeriodLastYear  NamedPeriod = "last_year"
+)
+
+// Cash flow trend granularities
+const (
+	GranularityDay   = "day"
+	GranularityWeek  = "week"
+	GranularityMonth = "month"
+)
+
+const (
+	// maxRangeDays caps explicit date ranges at five years
+	maxRangeDays = 5 * 366
+	// maxTrendPoints caps the points of a cash flow trend
+	maxTrendPoints = 400
+	// dailyTrendMaxDays is the longest range whose trend defaults to daily points, monthly beyond it
+	dailyTrendMaxDays = 186
+)
+
+// dates returns the first and last day of the period. Months are financial months starting on monthStartDay.
+func (p NamedPeriod) dates(today time.Time, monthStartDay int) (time.Time, time.Time, bool) {
+	monday := today.AddDate(0, 0, -((int(today.Weekday()) + 6) % 7))
+	firstOfMonth := daily_summary.FinancialMonthStart(today, monthStartDay)
+	firstOfYear := time.Date(today.Year(), time.January, 1, 0, 0, 0, 0, today.Location())
+
+	switch p {
+	case PeriodThisWeek:
+		return monday, today, true
+	case PeriodLastWeek:
+		return monday.AddDate(0, 0, -7), monday.AddDate(0, 0, -1), true
+	case PeriodThisMonth:
+		return firstOfMonth, today, true
+	case PeriodLastMonth:
+		return daily_summary.AddFinancialMonths(firstOfMonth, monthStartDay, -1), firstOfMonth.AddDate(0, 0, -1), true
+	case PeriodYTD:
+		return firstOfYear, today, true
+	case PeriodLastYear:
+		return firstOfYear.AddDate(-1, 0, 0), firstOfYear.AddDate(0, 0, -1), true
+	default:
+		return time.Time{}, time.Time{}, false
+	}
+}
+
+// dateSpan is the days a dashboard covers, from start to end inclusive
+type dateSpan struct {
+	start         time.Time
+	end           time.Time
+	today         time.Time
+	timeRange     TimeRange // set when the span comes from a time range
+	monthStartDay int       // day the user's financial months start on
+}
+
+// resolveSpan works out the days of a dashboard query: explicit dates, a named period or a time range, the
+// current financial month when none is given
+func (s *Service) resolveSpan(ctx context.Context, userID primitive.ObjectID, query *DashboardQuery) (*dateSpan, error) {
+	given := 0
+	for _, set := range []bool{query.StartDate != "" || query.EndDate != "", query.Period != "", query.TimeRange != ""} {
+		if set {
+			given++
+		}
+	}
+	if given > 1 {
+		return nil, errors.New("use only one of start_date/end_date, period or time_range")
+	}
+
+	monthStartDay := s.financialMonthStartDay(ctx, userID)
+
+	if query.StartDate != "" || query.EndDate != "" {
+		span, err := explicitSpan(query.StartDate, query.EndDate)
+		if err != nil {
+			return nil, err
+		}
+		span.monthStartDay = monthStartDay
+		return span, nil
+	}
+
+	if query.Period != "" {
+		_, today := TimeRange1Month.ToDuration(monthStartDay)
+		start, end, ok := query.Period.dates(today, monthStartDay)
+		if !ok {
+			return nil, errors.New("invalid period, allowed values: this_week, last_week, this_month, last_month, ytd, last_year")
+		}
+		return &dateSpan{start: start, end: end, today: today, monthStartDay: monthStartDay}, nil
+	}
+
+	timeRange := query.TimeRange
+	if timeRange == "" {
+		timeRange = TimeRange1Month
+	}
+	if !timeRange.IsValid() {
+		return nil, errors.New("invalid time_range, allowed values: 7d, 1m, 3m, 1y, all")
+	}
+
+	start, today := timeRange.ToDuration(monthStartDay)
+
+	// All time starts with the month of the user's earliest daily summary
+	if timeRange == TimeRangeAll {
+		first, err := s.dailySummaryRepo.GetFirstSummaryDate(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		if first != nil && first.Before(today) {
+			firstDay := time.Date(first.Year(), first.Month(), first.Day(), 0, 0, 0, 0, today.Location())
+			start = daily_summary.FinancialMonthStart(firstDay, monthStartDay)
+		}
+	}
+
+	return &dateSpan{start: start, end: today, today: today, timeRange: timeRange, monthStartDay: monthStartDay}, nil
+}
+
+// financialMonthStartDay returns the day the user's financial months start on, the 1st when it cannot be read
+func (s *Service) financialMonthStartDay(ctx context.Context, userID primitive.ObjectID) int {
+	day, err := s.incomeSourceRepo.GetFinancialMonthStartDay(ctx, userID)
+	if err != nil {
+		return 1
+	}
+	return day
+}
+
+// explicitSpan checks the start and end dates of a query. An end after today is cut back to today.
+func explicitSpan(startDate, endDate string) (*dateSpan, error) {
+	if startDate == "" || endDate == "" {
+		return nil, errors.New("start_date and end_date are both required")
+	}
+
+	_, today := TimeRange1Month.ToDuration(1)
+
+	start, err := time.ParseInLocation("2006-01-02", startDate, today.Location())
+	if err != nil {
+		return nil, errors.New("invalid start_date format, use YYYY-MM-DD")
+	}
+	end, err := time.ParseInLocation("2006-01-02", endDate, today.Location())
+	if err != nil {
+		return nil, errors.New("invalid end_date format, use YYYY-MM-DD")
+	}
+
+	if end.Before(start) {
+		return nil, errors.New("end_date cannot be before start_date")
+	}
+	if start.After(today) {
+		return nil, errors.New("start_date cannot be in the future")
+	}
+	if end.After(today) {
+		end = today
+	}
+
+	span := &dateSpan{start: start, end: end, today: today}
+	if span.days() > maxRangeDays {
+		return nil, errors.New("date range cannot be longer than 5 years")
+	}
+
+	return span, nil
+}
+
+// days returns the number of days in the span
+func (d *dateSpan) days() int {
+	start := daily_summary.PeriodStart(daily_summary.GranularityDaily, d.start)
+	end := daily_summary.PeriodStart(daily_summary.GranularityDaily, d.end)
+	return int(end.Sub(start).Hours()/24) + 1
+}
+
+// includesToday reports whether the span reaches today, whose transactions are read live
+func (d *dateSpan) includesToday() bool {
+	return !d.end.Before(d.today)
+}
+
+// summaryEnd returns the exclusive end of the days read from daily summaries: the day after the span, or today
+// when the span reaches it
+func (d *dateSpan) summaryEnd() time.Time {
+	if d.includesToday() {
+		return daily_summary.PeriodStart(daily_summary.GranularityDaily, d.today)
+	}
+	return daily_summary.PeriodStart(daily_summary.GranularityDaily, d.end.AddDate(0, 0, 1))
+}
+
+// trendGranularity checks the granularity asked for the cash flow trend, choosing daily or monthly points by the
+// length of the span when none is given
+func (d *dateSpan) trendGranularity(granularity string) (string, error) {
+	switch granularity {
+	case "":
+		if d.days() <= dailyTrendMaxDays {
+			return GranularityDay, nil
+		}
+		return GranularityMonth, nil
+	case GranularityDay, GranularityWeek, GranularityMonth:
+	default:
+		return "", errors.New("invalid granularity, allowed values: day, week, month")
+	}
+
+	if len(d.trendPeriods(granularity)) > maxTrendPoints {
+		return "", fmt.Errorf("too many %s points for this range, use a coarser granularity", granularity)
+	}
+
+	return granularity, nil
+}
+
+// trendPeriods returns the start of each point of the cash flow trend, in UTC days like summaries. The first point
+// starts with the period holding the start of the span, and months are financial months.
+func (d *dateSpan) trendPeriods(granularity string) []time.Time {
+	start := daily_summary.PeriodStart(daily_summary.GranularityDaily, d.start)
+	end := daily_summary.PeriodStart(daily_summary.GranularityDaily, d.end)
+
+	first := daily_summary.PeriodStart(toSummaryGranularity(granularity), start)
+	next := func(p time.Time) time.Time { return daily_summary.PeriodEnd(toSummaryGranularity(granularity), p) }
+	if granularity == GranularityMonth {
+		first = daily_summary.FinancialMonthStart(start, d.monthStartDay)
+		next = func(p time.Time) time.Time { return daily_summary.AddFinancialMonths(p, d.monthStartDay, 1) }
+	}
+
+	// One more than the cap is enough to tell the span has too many points
+	periods := make([]time.Time, 0)
+	for p := first; !p.After(end) && len(periods) <= maxTrendPoints; p = next(p) {
+		periods = append(periods, p)
+	}
+	return periods
+}
+
+// toSummaryGranularity returns the summary granularity of a trend granularity
+func toSummaryGranularity(granularity string) string {
+	switch granularity {
+	case GranularityWeek:
+		return daily_summary.GranularityWeekly
+	case GranularityMonth:
+		return daily_summary.GranularityMonthly
+	default:
+		return daily_summary.GranularityDaily
+	}
+}
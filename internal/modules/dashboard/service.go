@@ -6,18 +6,21 @@ import (
 	"time"
 
 	"github.com/HasanNugroho/coin-be/internal/modules/daily_summary"
+	"github.com/HasanNugroho/coin-be/internal/modules/income_source"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type Service struct {
 	repo             *Repository
 	dailySummaryRepo *daily_summary.Repository
+	incomeSourceRepo *income_source.Repository
 }
 
-func NewService(r *Repository, dsr *daily_summary.Repository) *Service {
+func NewService(r *Repository, dsr *daily_summary.Repository, isr *income_source.Repository) *Service {
 	return &Service{
 		repo:             r,
 		dailySummaryRepo: dsr,
+		incomeSourceRepo: isr,
 	}
 }
 
@@ -27,29 +30,48 @@ const (
 	TimeRange7Days  TimeRange = "7d"
 	TimeRange1Month TimeRange = "1m"
 	TimeRange3Month TimeRange = "3m"
+	TimeRange1Year  TimeRange = "1y"
+	TimeRangeAll    TimeRange = "all"
 )
 
-func (t TimeRange) ToDuration() (time.Time, time.Time) {
+// IsValid reports whether t is a known time range
+func (t TimeRange) IsValid() bool {
+	switch t {
+	case TimeRange7Days, TimeRange1Month, TimeRange3Month, TimeRange1Year, TimeRangeAll:
+		return true
+	}
+	return false
+}
+
+// ToDuration returns the first day of the range and today. Months are financial months starting on
+// monthStartDay, calendar months when it is 1.
+func (t TimeRange) ToDuration(monthStartDay int) (time.Time, time.Time) {
 	now := time.Now()
 	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	thisMonth := daily_summary.FinancialMonthStart(today, monthStartDay)
 
 	switch t {
 	case TimeRange7Days:
 		return today.AddDate(0, 0, -7), today
 	case TimeRange1Month:
-		// calendar: 1st of current month
-		return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location()), today
+		// start of the current month
+		return thisMonth, today
 	case TimeRange3Month:
-		// calendar: 1st of the month, 3 months ago
-		start := today.AddDate(0, -3, 0)
-		return time.Date(start.Year(), start.Month(), 1, 0, 0, 0, 0, now.Location()), today
+		// start of the month, 3 months ago
+		return daily_summary.AddFinancialMonths(thisMonth, monthStartDay, -3), today
+	case TimeRange1Year:
+		// start of the month, 1 year ago
+		return daily_summary.AddFinancialMonths(thisMonth, monthStartDay, -12), today
+	case TimeRangeAll:
+		// resolved by the service from the user's first summary
+		return today, today
 	default:
 		// rolling 30 days
 		return today.AddDate(0, 0, -30), today
 	}
 }
 
-func (s *Service) GetDashboardSummary(ctx context.Context, userID string, timeRange TimeRange) (*DashboardSummary, error) {
+func (s *Service) GetDashboardSummary(ctx context.Context, userID string, query *DashboardQuery) (*DashboardSummary, error) {
 	userObjID, err := primitive.ObjectIDFromHex(userID)
 	if err != nil {
 		return nil, errors.New("invalid user id")
@@ -60,22 +82,24 @@ func (s *Service) GetDashboardSummary(ctx context.Context, userID string, timeRa
 		return nil, err
 	}
 
-	if timeRange == "" {
-		timeRange = TimeRange1Month
-	}
-
-	startDate, today := timeRange.ToDuration()
-
-	summaries, err := s.dailySummaryRepo.GetDailySummariesByDateRange(ctx, userObjID, startDate, today)
+	span, err := s.resolveSpan(ctx, userObjID, query)
 	if err != nil {
 		return nil, err
 	}
 
-	liveIncome, liveExpense, _, err := s.repo.GetLiveDeltaSummary(ctx, userObjID, today)
+	summaries, err := s.dailySummaryRepo.GetCoveringSummaries(ctx, userObjID, span.start, span.summaryEnd(), daily_summary.GranularityYearly)
 	if err != nil {
 		return nil, err
 	}
 
+	var liveIncome, liveExpense float64
+	if span.includesToday() {
+		liveIncome, liveExpense, _, err = s.repo.GetLiveDeltaSummary(ctx, userObjID, span.today)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	historicalIncome := 0.0
 	historicalExpense := 0.0
 	for _, s := range summaries {
@@ -92,35 +116,45 @@ func (s *Service) GetDashboardSummary(ctx context.Context, userID string, timeRa
 		PeriodIncome:  periodIncome,
 		PeriodExpense: periodExpense,
 		PeriodNet:     periodNet,
-		TimeRange:     timeRange,
+		TimeRange:     span.timeRange,
+		Period:        query.Period,
+		StartDate:     span.start.Format("2006-01-02"),
+		EndDate:       span.end.Format("2006-01-02"),
 	}, nil
 }
 
-func (s *Service) GetDashboardCharts(ctx context.Context, userID string, timeRange TimeRange) (*DashboardCharts, error) {
+func (s *Service) GetDashboardCharts(ctx context.Context, userID string, query *DashboardQuery) (*DashboardCharts, error) {
 	userObjID, err := primitive.ObjectIDFromHex(userID)
 	if err != nil {
 		return nil, errors.New("invalid user id")
 	}
 
-	if timeRange == "" {
-		timeRange = TimeRange1Month
+	span, err := s.resolveSpan(ctx, userObjID, query)
+	if err != nil {
+		return nil, err
 	}
 
-	startDate, today := timeRange.ToDuration()
-
-	// 1. Fetch historical summaries and live delta
-	summaries, err := s.dailySummaryRepo.GetDailySummariesByDateRange(ctx, userObjID, startDate, today)
+	granularity, err := span.trendGranularity(query.Granularity)
 	if err != nil {
 		return nil, err
 	}
 
-	liveIncome, liveExpense, liveCategories, err := s.repo.GetLiveDeltaSummary(ctx, userObjID, today)
+	// 1. Fetch historical summaries, as the coarsest rollups covering the range, and live delta
+	summaries, err := s.dailySummaryRepo.GetCoveringSummaries(ctx, userObjID, span.start, span.summaryEnd(), daily_summary.GranularityYearly)
 	if err != nil {
 		return nil, err
 	}
 
-	// 2. Map summaries and collect IDs for name population
-	summaryMap := make(map[string]*daily_summary.DailySummary)
+	var liveIncome, liveExpense float64
+	var liveCategories []daily_summary.CategoryBreakdown
+	if span.includesToday() {
+		liveIncome, liveExpense, liveCategories, err = s.repo.GetLiveDeltaSummary(ctx, userObjID, span.today)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// 2. Total summaries and collect IDs for name population
 	historicalIncome := 0.0
 	historicalExpense := 0.0
 	categoryMap := make(map[string]*daily_summary.CategoryBreakdown)
@@ -128,8 +162,6 @@ func (s *Service) GetDashboardCharts(ctx context.Context, userID string, timeRan
 	catIDs := make(map[primitive.ObjectID]bool)
 
 	for _, sm := range summaries {
-		dateStr := sm.Date.Format("2006-01-02")
-		summaryMap[dateStr] = sm
 		historicalIncome += sm.TotalIncome
 		historicalExpense += sm.TotalExpense
 
@@ -211,20 +243,9 @@ func (s *Service) GetDashboardCharts(ctx context.Context, userID string, timeRan
 	totalExpense := historicalExpense + liveExpense
 
 	// 5. Build CashFlowTrend
-	cashFlowTrend := []ChartDataPoint{}
-	for d := startDate; !d.After(today); d = d.AddDate(0, 0, 1) {
-		dateStr := d.Format("2006-01-02")
-		point := ChartDataPoint{Date: dateStr}
-
-		if d.Equal(today) {
-			point.Income = liveIncome
-			point.Expense = liveExpense
-		} else if sm, ok := summaryMap[dateStr]; ok {
-			point.Income = sm.TotalIncome
-			point.Expense = sm.TotalExpense
-		}
-
-		cashFlowTrend = append(cashFlowTrend, point)
+	cashFlowTrend, err := s.buildCashFlowTrend(ctx, userObjID, granularity, span, liveIncome, liveExpense)
+	if err != nil {
+		return nil, err
 	}
 
 	// 6. Build breakdowns
@@ -264,7 +285,62 @@ func (s *Service) GetDashboardCharts(ctx context.Context, userID string, timeRan
 
 	return &DashboardCharts{
 		CashFlowTrend:    cashFlowTrend,
+		TrendGranularity: granularity,
+		StartDate:        span.start.Format("2006-01-02"),
+		EndDate:          span.end.Format("2006-01-02"),
 		IncomeBreakdown:  incomeBreakdown,
 		ExpenseBreakdown: expenseBreakdown,
 	}, nil
 }
+
+// buildCashFlowTrend returns a point per day, week or financial month of the span. Weekly and monthly points are
+// totalled from the coarsest summaries that fit inside them. The last point includes the live delta when the span
+// reaches today.
+func (s *Service) buildCashFlowTrend(ctx context.Context, userID primitive.ObjectID, granularity string, span *dateSpan, liveIncome, liveExpense float64) ([]ChartDataPoint, error) {
+	periods := span.trendPeriods(granularity)
+	cashFlowTrend := make([]ChartDataPoint, len(periods))
+	for i, p := range periods {
+		cashFlowTrend[i] = ChartDataPoint{Date: p.Format("2006-01-02")}
+	}
+
+	if granularity == GranularityDay {
+		summaries, err := s.dailySummaryRepo.GetDailyTotalsByDateRange(ctx, userID, daily_summary.PeriodStart(daily_summary.GranularityDaily, span.start), span.summaryEnd())
+		if err != nil {
+			return nil, err
+		}
+
+		index := make(map[string]int, len(periods))
+		for i, point := range cashFlowTrend {
+			index[point.Date] = i
+		}
+		for _, sm := range summaries {
+			if i, ok := index[sm.Date.Format("2006-01-02")]; ok {
+				cashFlowTrend[i].Income = sm.TotalIncome
+				cashFlowTrend[i].Expense = sm.TotalExpense
+			}
+		}
+	} else if len(periods) > 0 {
+		// Each point totals its period within the span, the first one from the start of the span
+		bounds := append([]time.Time{}, periods...)
+		bounds[0] = daily_summary.PeriodStart(daily_summary.GranularityDaily, span.start)
+		bounds = append(bounds, span.summaryEnd())
+
+		totals, err := s.dailySummaryRepo.GetPeriodTotals(ctx, userID, bounds, toSummaryGranularity(granularity))
+		if err != nil {
+			return nil, err
+		}
+
+		for i, total := range totals {
+			cashFlowTrend[i].Income = total.TotalIncome
+			cashFlowTrend[i].Expense = total.TotalExpense
+		}
+	}
+
+	if span.includesToday() && len(cashFlowTrend) > 0 {
+		last := len(cashFlowTrend) - 1
+		cashFlowTrend[last].Income += liveIncome
+		cashFlowTrend[last].Expense += liveExpense
+	}
+
+	return cashFlowTrend, nil
+}
@@ -0,0 +1,367 @@
+package envelope
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/HasanNugroho/coin-be/internal/core/utils"
+	"github.com/HasanNugroho/coin-be/internal/modules/envelope/dto"
+	"github.com/gin-gonic/gin"
+)
+
+type Controller struct {
+	service *Service
+}
+
+func NewController(s *Service) *Controller {
+	return &Controller{service: s}
+}
+
+// CreateEnvelope godoc
+// @Summary Create envelope
+// @Description Give an allocation pocket a monthly budget and a rollover policy (carry, reset or cap) applied at the end of each month
+// @Tags Envelopes
+// @Accept json
+// @Produce json
+// @Param request body dto.CreateEnvelopeRequest true "Envelope details"
+// @Success 201 {object} map[string]interface{} "Envelope created successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Security BearerAuth
+// @Router /v1/envelopes [post]
+func (c *Controller) CreateEnvelope(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		resp := utils.NewErrorResponse(http.StatusUnauthorized, "unauthorized")
+		ctx.JSON(http.StatusUnauthorized, resp)
+		return
+	}
+
+	var req dto.CreateEnvelopeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		resp := utils.NewErrorResponse(http.StatusBadRequest, err.Error())
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	if err := utils.ValidateRequest(&req); err != nil {
+		resp := utils.NewErrorResponse(http.StatusBadRequest, err.Error())
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	envelope, err := c.service.CreateEnvelope(ctx, userID.(string), &req)
+	if err != nil {
+		resp := utils.NewErrorResponse(http.StatusBadRequest, err.Error())
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	resp := utils.NewSuccessResponse("Envelope created successfully", c.mapToResponse(envelope))
+	ctx.JSON(http.StatusCreated, resp)
+}
+
+// GetEnvelope godoc
+// @Summary Get envelope by ID
+// @Description Get a specific envelope by ID
+// @Tags Envelopes
+// @Produce json
+// @Param id path string true "Envelope ID"
+// @Success 200 {object} map[string]interface{} "Envelope retrieved successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 404 {object} map[string]interface{} "Not found"
+// @Security BearerAuth
+// @Router /v1/envelopes/{id} [get]
+func (c *Controller) GetEnvelope(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		resp := utils.NewErrorResponse(http.StatusUnauthorized, "unauthorized")
+		ctx.JSON(http.StatusUnauthorized, resp)
+		return
+	}
+
+	envelope, err := c.service.GetEnvelopeByID(ctx, userID.(string), ctx.Param("id"))
+	if err != nil {
+		resp := utils.NewErrorResponse(http.StatusNotFound, err.Error())
+		ctx.JSON(http.StatusNotFound, resp)
+		return
+	}
+
+	resp := utils.NewSuccessResponse("Envelope retrieved successfully", c.mapToResponse(envelope))
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// ListEnvelopes godoc
+// @Summary List envelopes
+// @Description Get all envelopes of the authenticated user
+// @Tags Envelopes
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Envelopes retrieved successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Security BearerAuth
+// @Router /v1/envelopes [get]
+func (c *Controller) ListEnvelopes(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		resp := utils.NewErrorResponse(http.StatusUnauthorized, "unauthorized")
+		ctx.JSON(http.StatusUnauthorized, resp)
+		return
+	}
+
+	envelopes, err := c.service.ListEnvelopes(ctx, userID.(string))
+	if err != nil {
+		resp := utils.NewErrorResponse(http.StatusBadRequest, err.Error())
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	responses := make([]*dto.EnvelopeResponse, len(envelopes))
+	for i, envelope := range envelopes {
+		responses[i] = c.mapToResponse(envelope)
+	}
+
+	resp := utils.NewSuccessResponse("Envelopes retrieved successfully", responses)
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// UpdateEnvelope godoc
+// @Summary Update envelope
+// @Description Update the budget or rollover policy of an envelope
+// @Tags Envelopes
+// @Accept json
+// @Produce json
+// @Param id path string true "Envelope ID"
+// @Param request body dto.UpdateEnvelopeRequest true "Envelope update details"
+// @Success 200 {object} map[string]interface{} "Envelope updated successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Security BearerAuth
+// @Router /v1/envelopes/{id} [put]
+func (c *Controller) UpdateEnvelope(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		resp := utils.NewErrorResponse(http.StatusUnauthorized, "unauthorized")
+		ctx.JSON(http.StatusUnauthorized, resp)
+		return
+	}
+
+	var req dto.UpdateEnvelopeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		resp := utils.NewErrorResponse(http.StatusBadRequest, err.Error())
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	if err := utils.ValidateRequest(&req); err != nil {
+		resp := utils.NewErrorResponse(http.StatusBadRequest, err.Error())
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	envelope, err := c.service.UpdateEnvelope(ctx, userID.(string), ctx.Param("id"), &req)
+	if err != nil {
+		resp := utils.NewErrorResponse(http.StatusBadRequest, err.Error())
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	resp := utils.NewSuccessResponse("Envelope updated successfully", c.mapToResponse(envelope))
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// DeleteEnvelope godoc
+// @Summary Delete envelope
+// @Description Remove the envelope of an allocation pocket. The pocket itself is kept.
+// @Tags Envelopes
+// @Produce json
+// @Param id path string true "Envelope ID"
+// @Success 200 {object} map[string]interface{} "Envelope deleted successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Security BearerAuth
+// @Router /v1/envelopes/{id} [delete]
+func (c *Controller) DeleteEnvelope(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		resp := utils.NewErrorResponse(http.StatusUnauthorized, "unauthorized")
+		ctx.JSON(http.StatusUnauthorized, resp)
+		return
+	}
+
+	if err := c.service.DeleteEnvelope(ctx, userID.(string), ctx.Param("id")); err != nil {
+		resp := utils.NewErrorResponse(http.StatusBadRequest, err.Error())
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	resp := utils.NewSuccessResponse("Envelope deleted successfully", nil)
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// ListPeriods godoc
+// @Summary List closed envelope periods
+// @Description Get the closing records of an envelope, most recent first
+// @Tags Envelopes
+// @Produce json
+// @Param id path string true "Envelope ID"
+// @Param limit query int false "Number of periods (default: 12, max: 36)"
+// @Success 200 {object} map[string]interface{} "Envelope periods retrieved successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Security BearerAuth
+// @Router /v1/envelopes/{id}/periods [get]
+func (c *Controller) ListPeriods(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		resp := utils.NewErrorResponse(http.StatusUnauthorized, "unauthorized")
+		ctx.JSON(http.StatusUnauthorized, resp)
+		return
+	}
+
+	limit, _ := strconv.ParseInt(ctx.DefaultQuery("limit", "12"), 10, 64)
+
+	periods, err := c.service.ListPeriods(ctx, userID.(string), ctx.Param("id"), limit)
+	if err != nil {
+		resp := utils.NewErrorResponse(http.StatusBadRequest, err.Error())
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	responses := make([]*dto.EnvelopePeriodResponse, len(periods))
+	for i, period := range periods {
+		responses[i] = c.mapPeriodToResponse(period)
+	}
+
+	resp := utils.NewSuccessResponse("Envelope periods retrieved successfully", responses)
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// GetReport godoc
+// @Summary Get envelope report
+// @Description Get funded vs spent vs remaining of every envelope for a month
+// @Tags Envelopes
+// @Produce json
+// @Param period query string false "Month (YYYY-MM), defaults to the current month"
+// @Success 200 {object} map[string]interface{} "Envelope report retrieved successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Security BearerAuth
+// @Router /v1/envelopes/report [get]
+func (c *Controller) GetReport(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		resp := utils.NewErrorResponse(http.StatusUnauthorized, "unauthorized")
+		ctx.JSON(http.StatusUnauthorized, resp)
+		return
+	}
+
+	report, err := c.service.GetReport(ctx, userID.(string), ctx.Query("period"))
+	if err != nil {
+		resp := utils.NewErrorResponse(http.StatusBadRequest, err.Error())
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	resp := utils.NewSuccessResponse("Envelope report retrieved successfully", c.mapReportToResponse(report))
+	ctx.JSON(http.StatusOK, resp)
+}
+
+func (c *Controller) mapToResponse(envelope *Envelope) *dto.EnvelopeResponse {
+	return &dto.EnvelopeResponse{
+		ID:             envelope.ID.Hex(),
+		UserID:         envelope.UserID.Hex(),
+		PocketID:       envelope.PocketID.Hex(),
+		MonthlyBudget:  envelope.MonthlyBudget,
+		RolloverPolicy: envelope.RolloverPolicy,
+		RolloverCap:    envelope.RolloverCap,
+		IsActive:       envelope.IsActive,
+		CreatedAt:      envelope.CreatedAt,
+		UpdatedAt:      envelope.UpdatedAt,
+		DeletedAt:      envelope.DeletedAt,
+	}
+}
+
+func (c *Controller) mapPeriodToResponse(period *EnvelopePeriod) *dto.EnvelopePeriodResponse {
+	var transactionID *string
+	if period.TransactionID != nil {
+		id := period.TransactionID.Hex()
+		transactionID = &id
+	}
+	var refillTransactionID *string
+	if period.RefillTransactionID != nil {
+		id := period.RefillTransactionID.Hex()
+		refillTransactionID = &id
+	}
+
+	return &dto.EnvelopePeriodResponse{
+		ID:                  period.ID.Hex(),
+		EnvelopeID:          period.EnvelopeID.Hex(),
+		PocketID:            period.PocketID.Hex(),
+		Period:              period.Period,
+		PeriodStart:         period.PeriodStart,
+		PeriodEnd:           period.PeriodEnd,
+		Budget:              period.Budget,
+		OpeningBalance:      period.OpeningBalance,
+		OpeningRollover:     period.OpeningRollover,
+		Funded:              period.Funded,
+		Spent:               period.Spent,
+		Withdrawn:           period.Withdrawn,
+		ClosingBalance:      period.ClosingBalance,
+		RolloverPolicy:      period.RolloverPolicy,
+		RolloverAmount:      period.RolloverAmount,
+		CarriedOver:         period.CarriedOver,
+		TransactionID:       transactionID,
+		RefillAmount:        period.RefillAmount,
+		RefillTransactionID: refillTransactionID,
+		ClosedAt:            period.ClosedAt,
+	}
+}
+
+func (c *Controller) mapReportToResponse(report *EnvelopeReport) *dto.EnvelopeReportResponse {
+	resp := &dto.EnvelopeReportResponse{
+		Period:      report.Period,
+		PeriodStart: report.PeriodStart,
+		PeriodEnd:   report.PeriodEnd,
+		Items:       make([]*dto.EnvelopeReportItemResponse, len(report.Items)),
+	}
+
+	for i, item := range report.Items {
+		var spentPercentage float64
+		if item.Budget > 0 {
+			spentPercentage = item.Spent / item.Budget * 100
+		}
+
+		underfunded := item.Budget - item.Funded
+		if underfunded < 0 {
+			underfunded = 0
+		}
+
+		resp.Items[i] = &dto.EnvelopeReportItemResponse{
+			EnvelopeID:      item.Envelope.ID.Hex(),
+			PocketID:        item.Envelope.PocketID.Hex(),
+			PocketName:      item.PocketName,
+			RolloverPolicy:  item.Envelope.RolloverPolicy,
+			IsClosed:        item.IsClosed,
+			Budget:          item.Budget,
+			OpeningBalance:  item.OpeningBalance,
+			OpeningRollover: item.OpeningRollover,
+			Funded:          item.Funded,
+			Spent:           item.Spent,
+			Withdrawn:       item.Withdrawn,
+			Remaining:       item.Remaining,
+			Underfunded:     underfunded,
+			SpentPercentage: spentPercentage,
+			RolloverAmount:  item.RolloverAmount,
+			CarriedOver:     item.CarriedOver,
+		}
+
+		resp.TotalBudget += item.Budget
+		resp.TotalFunded += item.Funded
+		resp.TotalSpent += item.Spent
+		resp.TotalRemaining += item.Remaining
+	}
+
+	return resp
+}
@@ -0,0 +1,50 @@
+package envelope
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+type CronJob struct {
+	service *Service
+	cron    *cron.Cron
+}
+
+func NewCronJob(service *Service) *CronJob {
+	return &CronJob{
+		service: service,
+		cron:    cron.New(cron.WithLocation(time.UTC)),
+	}
+}
+
+// Start begins the envelope period closing cron job
+// Runs on the 1st of every month at 00:30 AM (UTC, the time zone of envelope periods)
+func (c *CronJob) Start() error {
+	_, err := c.cron.AddFunc("30 0 1 * *", func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+		defer cancel()
+
+		log.Println("Starting envelope period closing...")
+		if err := c.service.CloseEndedPeriods(ctx); err != nil {
+			log.Printf("Error closing envelope periods: %v", err)
+		}
+		log.Println("Envelope period closing completed")
+	})
+
+	if err != nil {
+		return err
+	}
+
+	c.cron.Start()
+	log.Println("Envelope cron job started")
+	return nil
+}
+
+// Stop stops the cron job
+func (c *CronJob) Stop() {
+	c.cron.Stop()
+	log.Println("Envelope cron job stopped")
+}
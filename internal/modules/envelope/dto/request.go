@@ -0,0 +1,15 @@
+package dto
+
+type CreateEnvelopeRequest struct {
+	PocketID       string   `json:"pocket_id" validate:"required,len=24,hexadecimal"`
+	MonthlyBudget  float64  `json:"monthly_budget" validate:"required,gt=0"`
+	RolloverPolicy string   `json:"rollover_policy" validate:"required,oneof=carry reset cap"`
+	RolloverCap    *float64 `json:"rollover_cap" validate:"omitempty,gte=0"`
+}
+
+type UpdateEnvelopeRequest struct {
+	MonthlyBudget  *float64 `json:"monthly_budget" validate:"omitempty,gt=0"`
+	RolloverPolicy string   `json:"rollover_policy" validate:"omitempty,oneof=carry reset cap"`
+	RolloverCap    *float64 `json:"rollover_cap" validate:"omitempty,gte=0"`
+	IsActive       *bool    `json:"is_active"`
+}
@@ -0,0 +1,69 @@
+package dto
+
+import "time"
+
+type EnvelopeResponse struct {
+	ID             string     `json:"id"`
+	UserID         string     `json:"user_id"`
+	PocketID       string     `json:"pocket_id"`
+	MonthlyBudget  float64    `json:"monthly_budget"`
+	RolloverPolicy string     `json:"rollover_policy"`
+	RolloverCap    *float64   `json:"rollover_cap,omitempty"`
+	IsActive       bool       `json:"is_active"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+	DeletedAt      *time.Time `json:"deleted_at,omitempty"`
+}
+
+type EnvelopePeriodResponse struct {
+	ID                  string    `json:"id"`
+	EnvelopeID          string    `json:"envelope_id"`
+	PocketID            string    `json:"pocket_id"`
+	Period              string    `json:"period"`
+	PeriodStart         time.Time `json:"period_start"`
+	PeriodEnd           time.Time `json:"period_end"`
+	Budget              float64   `json:"budget"`
+	OpeningBalance      float64   `json:"opening_balance"`
+	OpeningRollover     float64   `json:"opening_rollover"`
+	Funded              float64   `json:"funded"`
+	Spent               float64   `json:"spent"`
+	Withdrawn           float64   `json:"withdrawn"`
+	ClosingBalance      float64   `json:"closing_balance"`
+	RolloverPolicy      string    `json:"rollover_policy"`
+	RolloverAmount      float64   `json:"rollover_amount"`
+	CarriedOver         float64   `json:"carried_over"`
+	TransactionID       *string   `json:"transaction_id,omitempty"`
+	RefillAmount        float64   `json:"refill_amount"`
+	RefillTransactionID *string   `json:"refill_transaction_id,omitempty"`
+	ClosedAt            time.Time `json:"closed_at"`
+}
+
+type EnvelopeReportItemResponse struct {
+	EnvelopeID      string  `json:"envelope_id"`
+	PocketID        string  `json:"pocket_id"`
+	PocketName      string  `json:"pocket_name"`
+	RolloverPolicy  string  `json:"rollover_policy"`
+	IsClosed        bool    `json:"is_closed"`
+	Budget          float64 `json:"budget"`
+	OpeningBalance  float64 `json:"opening_balance"`
+	OpeningRollover float64 `json:"opening_rollover"`
+	Funded          float64 `json:"funded"`
+	Spent           float64 `json:"spent"`
+	Withdrawn       float64 `json:"withdrawn"`
+	Remaining       float64 `json:"remaining"`
+	Underfunded     float64 `json:"underfunded"`
+	SpentPercentage float64 `json:"spent_percentage"`
+	RolloverAmount  float64 `json:"rollover_amount"`
+	CarriedOver     float64 `json:"carried_over"`
+}
+
+type EnvelopeReportResponse struct {
+	Period         string                        `json:"period"`
+	PeriodStart    time.Time                     `json:"period_start"`
+	PeriodEnd      time.Time                     `json:"period_end"`
+	TotalBudget    float64                       `json:"total_budget"`
+	TotalFunded    float64                       `json:"total_funded"`
+	TotalSpent     float64                       `json:"total_spent"`
+	TotalRemaining float64                       `json:"total_remaining"`
+	Items          []*EnvelopeReportItemResponse `json:"items"`
+}
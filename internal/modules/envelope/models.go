@@ -0,0 +1,94 @@
+package envelope
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Envelope gives an allocation pocket a monthly budget and a rollover policy applied at the end of each month,
+// after which the pocket is refilled up to the budget from the main pocket
+type Envelope struct {
+	ID             primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID         primitive.ObjectID `bson:"user_id" json:"user_id"`
+	PocketID       primitive.ObjectID `bson:"pocket_id" json:"pocket_id"`
+	MonthlyBudget  float64            `bson:"monthly_budget" json:"monthly_budget"`
+	RolloverPolicy string             `bson:"rollover_policy" json:"rollover_policy" enums:"carry,reset,cap"`
+	RolloverCap    *float64           `bson:"rollover_cap,omitempty" json:"rollover_cap,omitempty"` // maximum balance kept when the policy is cap
+	IsActive       bool               `bson:"is_active" json:"is_active"`
+	CreatedAt      time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt      time.Time          `bson:"updated_at" json:"updated_at"`
+	DeletedAt      *time.Time         `bson:"deleted_at,omitempty" json:"deleted_at,omitempty"`
+}
+
+// EnvelopePeriod is the closing record of an envelope for one month
+type EnvelopePeriod struct {
+	ID                  primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	UserID              primitive.ObjectID  `bson:"user_id" json:"user_id"`
+	EnvelopeID          primitive.ObjectID  `bson:"envelope_id" json:"envelope_id"`
+	PocketID            primitive.ObjectID  `bson:"pocket_id" json:"pocket_id"`
+	Period              string              `bson:"period" json:"period"` // YYYY-MM
+	PeriodStart         time.Time           `bson:"period_start" json:"period_start"`
+	PeriodEnd           time.Time           `bson:"period_end" json:"period_end"`
+	Budget              float64             `bson:"budget" json:"budget"`
+	OpeningBalance      float64             `bson:"opening_balance" json:"opening_balance"`
+	OpeningRollover     float64             `bson:"opening_rollover" json:"opening_rollover"` // previous month's rollover, moved out at the start of this one
+	Funded              float64             `bson:"funded" json:"funded"`
+	Spent               float64             `bson:"spent" json:"spent"`
+	Withdrawn           float64             `bson:"withdrawn" json:"withdrawn"`
+	ClosingBalance      float64             `bson:"closing_balance" json:"closing_balance"`
+	RolloverPolicy      string              `bson:"rollover_policy" json:"rollover_policy"`
+	RolloverAmount      float64             `bson:"rollover_amount" json:"rollover_amount"` // moved back to the main pocket
+	CarriedOver         float64             `bson:"carried_over" json:"carried_over"`
+	TransactionID       *primitive.ObjectID `bson:"transaction_id,omitempty" json:"transaction_id,omitempty"`
+	RefillAmount        float64             `bson:"refill_amount" json:"refill_amount"` // moved in from the main pocket for the next month
+	RefillTransactionID *primitive.ObjectID `bson:"refill_transaction_id,omitempty" json:"refill_transaction_id,omitempty"`
+	ClosedAt            time.Time           `bson:"closed_at" json:"closed_at"`
+}
+
+type RolloverPolicy string
+
+const (
+	RolloverCarry RolloverPolicy = "carry"
+	RolloverReset RolloverPolicy = "reset"
+	RolloverCap   RolloverPolicy = "cap"
+)
+
+// rolloverRefPrefix marks the transfers made when closing a period. They are dated at the start of the next
+// period and reported there on their own line, not as withdrawals.
+const rolloverRefPrefix = "envelope_rollover_"
+
+// refillRefPrefix marks the transfers refilling an envelope for a new period. They are dated at its start and
+// count as funding.
+const refillRefPrefix = "envelope_refill_"
+
+// PeriodFlows are the movements of a pocket within a period
+type PeriodFlows struct {
+	Rollover  float64 // the previous period's rollover, moved out at the start of this one
+	Funded    float64
+	Spent     float64
+	Withdrawn float64
+}
+
+// EnvelopeReportItem is the funded vs spent vs remaining state of one envelope in a period
+type EnvelopeReportItem struct {
+	Envelope        *Envelope
+	PocketName      string
+	IsClosed        bool
+	Budget          float64
+	OpeningBalance  float64
+	OpeningRollover float64
+	Funded          float64
+	Spent           float64
+	Withdrawn       float64
+	Remaining       float64
+	RolloverAmount  float64
+	CarriedOver     float64
+}
+
+type EnvelopeReport struct {
+	Period      string
+	PeriodStart time.Time
+	PeriodEnd   time.Time
+	Items       []*EnvelopeReportItem
+}
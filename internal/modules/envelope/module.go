@@ -0,0 +1,49 @@
+package envelope
+
+import (
+	"context"
+
+	"github.com/HasanNugroho/coin-be/internal/core/config"
+	"github.com/HasanNugroho/coin-be/internal/modules/pocket"
+	"github.com/HasanNugroho/coin-be/internal/modules/transaction"
+	"github.com/sarulabs/di/v2"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func Register(builder *di.Builder) {
+	builder.Add(di.Def{
+		Name: "envelopeRepository",
+		Build: func(ctn di.Container) (interface{}, error) {
+			cfg := ctn.Get("config").(*config.Config)
+			client := ctn.Get("mongo").(*mongo.Client)
+			repo := NewRepository(client.Database(cfg.MongoDB))
+
+			if err := repo.EnsureIndexes(context.Background()); err != nil {
+				return nil, err
+			}
+
+			return repo, nil
+		},
+	})
+
+	builder.Add(di.Def{
+		Name: "envelopeService",
+		Build: func(ctn di.Container) (interface{}, error) {
+			cfg := ctn.Get("config").(*config.Config)
+			client := ctn.Get("mongo").(*mongo.Client)
+			db := client.Database(cfg.MongoDB)
+			repo := ctn.Get("envelopeRepository").(*Repository)
+			pocketRepo := ctn.Get("pocketRepository").(*pocket.Repository)
+			transactionRepo := ctn.Get("transactionRepository").(*transaction.Repository)
+			return NewService(repo, pocketRepo, transactionRepo, db), nil
+		},
+	})
+
+	builder.Add(di.Def{
+		Name: "envelopeController",
+		Build: func(ctn di.Container) (interface{}, error) {
+			service := ctn.Get("envelopeService").(*Service)
+			return NewController(service), nil
+		},
+	})
+}
@@ -0,0 +1,245 @@
+package envelope
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type Repository struct {
+	envelopes    *mongo.Collection
+	periods      *mongo.Collection
+	transactions *mongo.Collection
+}
+
+func NewRepository(db *mongo.Database) *Repository {
+	return &Repository{
+		envelopes:    db.Collection("envelopes"),
+		periods:      db.Collection("envelope_periods"),
+		transactions: db.Collection("transactions"),
+	}
+}
+
+func (r *Repository) CreateEnvelope(ctx context.Context, envelope *Envelope) error {
+	envelope.ID = primitive.NewObjectID()
+	envelope.CreatedAt = time.Now()
+	envelope.UpdatedAt = time.Now()
+	_, err := r.envelopes.InsertOne(ctx, envelope)
+	return err
+}
+
+func (r *Repository) GetEnvelopeByID(ctx context.Context, id primitive.ObjectID) (*Envelope, error) {
+	var envelope Envelope
+	err := r.envelopes.FindOne(ctx, bson.M{"_id": id, "deleted_at": nil}).Decode(&envelope)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.New("envelope not found")
+		}
+		return nil, err
+	}
+	return &envelope, nil
+}
+
+func (r *Repository) GetEnvelopeByPocketID(ctx context.Context, pocketID primitive.ObjectID) (*Envelope, error) {
+	var envelope Envelope
+	err := r.envelopes.FindOne(ctx, bson.M{"pocket_id": pocketID, "deleted_at": nil}).Decode(&envelope)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &envelope, nil
+}
+
+func (r *Repository) GetEnvelopesByUserID(ctx context.Context, userID primitive.ObjectID) ([]*Envelope, error) {
+	opts := options.Find().SetSort(bson.M{"created_at": 1})
+	cursor, err := r.envelopes.Find(ctx, bson.M{"user_id": userID, "deleted_at": nil}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var envelopes []*Envelope
+	if err = cursor.All(ctx, &envelopes); err != nil {
+		return nil, err
+	}
+	return envelopes, nil
+}
+
+func (r *Repository) GetActiveEnvelopes(ctx context.Context) ([]*Envelope, error) {
+	cursor, err := r.envelopes.Find(ctx, bson.M{"is_active": true, "deleted_at": nil})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var envelopes []*Envelope
+	if err = cursor.All(ctx, &envelopes); err != nil {
+		return nil, err
+	}
+	return envelopes, nil
+}
+
+func (r *Repository) UpdateEnvelope(ctx context.Context, id primitive.ObjectID, envelope *Envelope) error {
+	envelope.UpdatedAt = time.Now()
+	result, err := r.envelopes.UpdateOne(ctx, bson.M{"_id": id, "deleted_at": nil}, bson.M{"$set": envelope})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errors.New("envelope not found")
+	}
+	return nil
+}
+
+func (r *Repository) DeleteEnvelope(ctx context.Context, id primitive.ObjectID) error {
+	now := time.Now()
+	result, err := r.envelopes.UpdateOne(
+		ctx,
+		bson.M{"_id": id, "deleted_at": nil},
+		bson.M{
+			"$set": bson.M{
+				"deleted_at": now,
+				"updated_at": now,
+			},
+		},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errors.New("envelope not found")
+	}
+	return nil
+}
+
+func (r *Repository) CreatePeriod(ctx context.Context, period *EnvelopePeriod) error {
+	period.ID = primitive.NewObjectID()
+	period.ClosedAt = time.Now()
+	_, err := r.periods.InsertOne(ctx, period)
+	return err
+}
+
+func (r *Repository) GetPeriod(ctx context.Context, envelopeID primitive.ObjectID, period string) (*EnvelopePeriod, error) {
+	var record EnvelopePeriod
+	err := r.periods.FindOne(ctx, bson.M{"envelope_id": envelopeID, "period": period}).Decode(&record)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &record, nil
+}
+
+func (r *Repository) GetPeriodsByEnvelopeID(ctx context.Context, envelopeID primitive.ObjectID, limit int64) ([]*EnvelopePeriod, error) {
+	opts := options.Find().SetSort(bson.M{"period_start": -1}).SetLimit(limit)
+	cursor, err := r.periods.Find(ctx, bson.M{"envelope_id": envelopeID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var periods []*EnvelopePeriod
+	if err = cursor.All(ctx, &periods); err != nil {
+		return nil, err
+	}
+	return periods, nil
+}
+
+// GetPocketFlows splits the transactions of a pocket dated within [startDate, endDate) into the opening rollover,
+// funded, spent and withdrawn.
+func (r *Repository) GetPocketFlows(ctx context.Context, pocketID primitive.ObjectID, startDate, endDate time.Time) (*PeriodFlows, error) {
+	transactions, err := r.findPocketTransactions(ctx, pocketID, bson.M{"$gte": startDate, "$lt": endDate})
+	if err != nil {
+		return nil, err
+	}
+
+	flows := &PeriodFlows{}
+	for _, tx := range transactions {
+		switch {
+		case tx.PocketToID != nil && *tx.PocketToID == pocketID:
+			flows.Funded += tx.Amount
+		case tx.Type == "expense":
+			flows.Spent += tx.Amount
+		case tx.Ref != nil && strings.HasPrefix(*tx.Ref, rolloverRefPrefix):
+			flows.Rollover += tx.Amount
+		default:
+			flows.Withdrawn += tx.Amount
+		}
+	}
+	return flows, nil
+}
+
+// GetPocketNetFlowSince returns incoming minus outgoing amounts of a pocket dated at or after startDate
+func (r *Repository) GetPocketNetFlowSince(ctx context.Context, pocketID primitive.ObjectID, startDate time.Time) (float64, error) {
+	transactions, err := r.findPocketTransactions(ctx, pocketID, bson.M{"$gte": startDate})
+	if err != nil {
+		return 0, err
+	}
+
+	var net float64
+	for _, tx := range transactions {
+		if tx.PocketToID != nil && *tx.PocketToID == pocketID {
+			net += tx.Amount
+		} else {
+			net -= tx.Amount
+		}
+	}
+	return net, nil
+}
+
+type pocketTransaction struct {
+	Type       string              `bson:"type"`
+	Amount     float64             `bson:"amount"`
+	PocketToID *primitive.ObjectID `bson:"pocket_to_id,omitempty"`
+	Ref        *string             `bson:"ref,omitempty"`
+}
+
+func (r *Repository) findPocketTransactions(ctx context.Context, pocketID primitive.ObjectID, dateFilter bson.M) ([]*pocketTransaction, error) {
+	filter := bson.M{
+		"deleted_at": nil,
+		"date":       dateFilter,
+		"$or": []bson.M{
+			{"pocket_to_id": pocketID},
+			{"pocket_from_id": pocketID},
+		},
+	}
+	opts := options.Find().SetProjection(bson.M{"type": 1, "amount": 1, "pocket_to_id": 1, "ref": 1})
+
+	cursor, err := r.transactions.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var transactions []*pocketTransaction
+	if err = cursor.All(ctx, &transactions); err != nil {
+		return nil, err
+	}
+	return transactions, nil
+}
+
+func (r *Repository) EnsureIndexes(ctx context.Context) error {
+	indexes := []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				{Key: "envelope_id", Value: 1},
+				{Key: "period", Value: 1},
+			},
+			Options: options.Index().
+				SetName("idx_envelope_periods_envelope_period").
+				SetUnique(true),
+		},
+	}
+
+	_, err := r.periods.Indexes().CreateMany(ctx, indexes)
+	return err
+}
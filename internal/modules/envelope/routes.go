@@ -0,0 +1,19 @@
+package envelope
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterRoutes(r *gin.RouterGroup, controller *Controller) {
+	// User routes
+	protected := r.Group("")
+	{
+		protected.POST("", controller.CreateEnvelope)
+		protected.GET("", controller.ListEnvelopes)
+		protected.GET("/report", controller.GetReport)
+		protected.GET("/:id", controller.GetEnvelope)
+		protected.GET("/:id/periods", controller.ListPeriods)
+		protected.PUT("/:id", controller.UpdateEnvelope)
+		protected.DELETE("/:id", controller.DeleteEnvelope)
+	}
+}
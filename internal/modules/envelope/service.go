@@ -0,0 +1,500 @@
+package envelope
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math"
+	"time"
+
+	"github.com/HasanNugroho/coin-be/internal/core/utils"
+	"github.com/HasanNugroho/coin-be/internal/modules/envelope/dto"
+	"github.com/HasanNugroho/coin-be/internal/modules/pocket"
+	"github.com/HasanNugroho/coin-be/internal/modules/transaction"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const periodLayout = "2006-01"
+
+type Service struct {
+	repo            *Repository
+	pocketRepo      *pocket.Repository
+	transactionRepo *transaction.Repository
+	db              *mongo.Database
+}
+
+func NewService(r *Repository, pr *pocket.Repository, tr *transaction.Repository, db *mongo.Database) *Service {
+	return &Service{
+		repo:            r,
+		pocketRepo:      pr,
+		transactionRepo: tr,
+		db:              db,
+	}
+}
+
+func (s *Service) CreateEnvelope(ctx context.Context, userID string, req *dto.CreateEnvelopeRequest) (*Envelope, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, errors.New("invalid user id")
+	}
+
+	pocketObjID, err := primitive.ObjectIDFromHex(req.PocketID)
+	if err != nil {
+		return nil, errors.New("invalid pocket id")
+	}
+
+	p, err := s.pocketRepo.GetPocketByID(ctx, pocketObjID)
+	if err != nil {
+		return nil, errors.New("pocket not found")
+	}
+
+	if p.UserID != userObjID {
+		return nil, errors.New("unauthorized: pocket does not belong to user")
+	}
+
+	if p.Type != string(pocket.TypeAllocation) {
+		return nil, errors.New("envelopes can only be set on allocation pockets")
+	}
+
+	existing, err := s.repo.GetEnvelopeByPocketID(ctx, pocketObjID)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, errors.New("pocket already has an envelope")
+	}
+
+	if err := validateRolloverPolicy(req.RolloverPolicy, req.RolloverCap); err != nil {
+		return nil, err
+	}
+
+	envelope := &Envelope{
+		UserID:         userObjID,
+		PocketID:       pocketObjID,
+		MonthlyBudget:  req.MonthlyBudget,
+		RolloverPolicy: req.RolloverPolicy,
+		RolloverCap:    req.RolloverCap,
+		IsActive:       true,
+	}
+
+	if err := s.repo.CreateEnvelope(ctx, envelope); err != nil {
+		return nil, err
+	}
+
+	return envelope, nil
+}
+
+func (s *Service) GetEnvelopeByID(ctx context.Context, userID string, envelopeID string) (*Envelope, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, errors.New("invalid user id")
+	}
+
+	envelopeObjID, err := primitive.ObjectIDFromHex(envelopeID)
+	if err != nil {
+		return nil, errors.New("invalid envelope id")
+	}
+
+	envelope, err := s.repo.GetEnvelopeByID(ctx, envelopeObjID)
+	if err != nil {
+		return nil, err
+	}
+
+	if envelope.UserID != userObjID {
+		return nil, errors.New("unauthorized")
+	}
+
+	return envelope, nil
+}
+
+func (s *Service) ListEnvelopes(ctx context.Context, userID string) ([]*Envelope, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, errors.New("invalid user id")
+	}
+
+	return s.repo.GetEnvelopesByUserID(ctx, userObjID)
+}
+
+func (s *Service) UpdateEnvelope(ctx context.Context, userID string, envelopeID string, req *dto.UpdateEnvelopeRequest) (*Envelope, error) {
+	envelope, err := s.GetEnvelopeByID(ctx, userID, envelopeID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.MonthlyBudget != nil {
+		envelope.MonthlyBudget = *req.MonthlyBudget
+	}
+
+	if req.RolloverPolicy != "" {
+		envelope.RolloverPolicy = req.RolloverPolicy
+	}
+
+	if req.RolloverCap != nil {
+		envelope.RolloverCap = req.RolloverCap
+	}
+
+	if envelope.RolloverPolicy != string(RolloverCap) {
+		envelope.RolloverCap = nil
+	}
+
+	if err := validateRolloverPolicy(envelope.RolloverPolicy, envelope.RolloverCap); err != nil {
+		return nil, err
+	}
+
+	if req.IsActive != nil {
+		envelope.IsActive = *req.IsActive
+	}
+
+	if err := s.repo.UpdateEnvelope(ctx, envelope.ID, envelope); err != nil {
+		return nil, err
+	}
+
+	return envelope, nil
+}
+
+func (s *Service) DeleteEnvelope(ctx context.Context, userID string, envelopeID string) error {
+	envelope, err := s.GetEnvelopeByID(ctx, userID, envelopeID)
+	if err != nil {
+		return err
+	}
+
+	return s.repo.DeleteEnvelope(ctx, envelope.ID)
+}
+
+func (s *Service) ListPeriods(ctx context.Context, userID string, envelopeID string, limit int64) ([]*EnvelopePeriod, error) {
+	envelope, err := s.GetEnvelopeByID(ctx, userID, envelopeID)
+	if err != nil {
+		return nil, err
+	}
+
+	if limit <= 0 || limit > 36 {
+		limit = 12
+	}
+
+	return s.repo.GetPeriodsByEnvelopeID(ctx, envelope.ID, limit)
+}
+
+// GetReport returns funded vs spent vs remaining of every envelope of the user for a month (YYYY-MM).
+// Closed months come from the period records, the running month is computed live.
+func (s *Service) GetReport(ctx context.Context, userID string, period string) (*EnvelopeReport, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, errors.New("invalid user id")
+	}
+
+	now := time.Now().UTC()
+	if period == "" {
+		period = now.Format(periodLayout)
+	}
+
+	start, end, err := periodBounds(period)
+	if err != nil {
+		return nil, err
+	}
+
+	if start.After(now) {
+		return nil, errors.New("period has not started yet")
+	}
+
+	envelopes, err := s.repo.GetEnvelopesByUserID(ctx, userObjID)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &EnvelopeReport{
+		Period:      period,
+		PeriodStart: start,
+		PeriodEnd:   end,
+		Items:       make([]*EnvelopeReportItem, 0, len(envelopes)),
+	}
+
+	for _, envelope := range envelopes {
+		p, err := s.pocketRepo.GetPocketByID(ctx, envelope.PocketID)
+		if err != nil {
+			log.Printf("skipping envelope %s in report: %v", envelope.ID.Hex(), err)
+			continue
+		}
+
+		item, err := s.buildReportItem(ctx, envelope, p, period, start, end)
+		if err != nil {
+			return nil, err
+		}
+		report.Items = append(report.Items, item)
+	}
+
+	return report, nil
+}
+
+// CloseEndedPeriods closes the previous month of every active envelope, applies its rollover policy and refills
+// it up to its monthly budget from the main pocket. Periods that already have a record are skipped, so the job can
+// safely run more than once.
+func (s *Service) CloseEndedPeriods(ctx context.Context) error {
+	now := time.Now().UTC()
+	period := time.Date(now.Year(), now.Month()-1, 1, 0, 0, 0, 0, time.UTC).Format(periodLayout)
+
+	start, end, err := periodBounds(period)
+	if err != nil {
+		return err
+	}
+
+	envelopes, err := s.repo.GetActiveEnvelopes(ctx)
+	if err != nil {
+		return err
+	}
+
+	successCount := 0
+	failureCount := 0
+
+	for _, envelope := range envelopes {
+		if err := s.closePeriod(ctx, envelope, period, start, end); err != nil {
+			log.Printf("failed to close envelope %s for period %s: %v", envelope.ID.Hex(), period, err)
+			failureCount++
+			continue
+		}
+		successCount++
+	}
+
+	log.Printf("envelope period closing complete for %s: %d success, %d failures", period, successCount, failureCount)
+	return nil
+}
+
+func (s *Service) closePeriod(ctx context.Context, envelope *Envelope, period string, start, end time.Time) error {
+	existing, err := s.repo.GetPeriod(ctx, envelope.ID, period)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		return nil
+	}
+
+	envelopePocket, err := s.pocketRepo.GetPocketByID(ctx, envelope.PocketID)
+	if err != nil {
+		return err
+	}
+
+	item, err := s.buildReportItem(ctx, envelope, envelopePocket, period, start, end)
+	if err != nil {
+		return err
+	}
+
+	var rolloverAmount float64
+	switch RolloverPolicy(envelope.RolloverPolicy) {
+	case RolloverReset:
+		rolloverAmount = math.Max(item.Remaining, 0)
+	case RolloverCap:
+		if envelope.RolloverCap != nil {
+			rolloverAmount = math.Max(item.Remaining-*envelope.RolloverCap, 0)
+		}
+	}
+
+	record := &EnvelopePeriod{
+		UserID:          envelope.UserID,
+		EnvelopeID:      envelope.ID,
+		PocketID:        envelope.PocketID,
+		Period:          period,
+		PeriodStart:     start,
+		PeriodEnd:       end,
+		Budget:          envelope.MonthlyBudget,
+		OpeningBalance:  item.OpeningBalance,
+		OpeningRollover: item.OpeningRollover,
+		Funded:          item.Funded,
+		Spent:           item.Spent,
+		Withdrawn:       item.Withdrawn,
+		ClosingBalance:  item.Remaining,
+		RolloverPolicy:  envelope.RolloverPolicy,
+		RolloverAmount:  rolloverAmount,
+		CarriedOver:     item.Remaining - rolloverAmount,
+	}
+
+	var mainPocket *pocket.Pocket
+	if rolloverAmount > 0 || envelope.MonthlyBudget > 0 {
+		mainPocket, err = s.pocketRepo.GetMainPocketByUserID(ctx, envelope.UserID)
+		if err != nil {
+			return err
+		}
+	}
+
+	// The envelope is refilled up to its budget for the next month, as far as the main pocket allows
+	var refillAmount float64
+	nextPeriod := end.Format(periodLayout)
+	refillRef := refillRefPrefix + envelope.ID.Hex() + "_" + nextPeriod
+	if envelope.MonthlyBudget > 0 {
+		existingRefill, err := s.transactionRepo.GetTransactionByRef(ctx, envelope.UserID, refillRef)
+		if err != nil {
+			return err
+		}
+		if existingRefill == nil {
+			available := utils.Decimal128ToFloat64(mainPocket.Balance) + rolloverAmount
+			refillAmount = math.Max(math.Min(envelope.MonthlyBudget-record.CarriedOver, available), 0)
+		}
+	}
+
+	session, err := s.db.Client().StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	return mongo.WithSession(ctx, session, func(sessionCtx mongo.SessionContext) error {
+		if err := session.StartTransaction(); err != nil {
+			return err
+		}
+
+		if rolloverAmount > 0 {
+			rolloverTx := &transaction.Transaction{
+				UserID:       envelope.UserID,
+				Type:         string(transaction.TypeTransfer),
+				Amount:       rolloverAmount,
+				PocketFromID: &envelope.PocketID,
+				PocketToID:   &mainPocket.ID,
+				Date:         end,
+				Note:         stringPtr("Envelope rollover " + period),
+				Ref:          stringPtr(rolloverRefPrefix + envelope.ID.Hex() + "_" + period),
+			}
+
+			if err := s.transactionRepo.CreateTransaction(sessionCtx, rolloverTx); err != nil {
+				session.AbortTransaction(sessionCtx)
+				return errors.New("failed to create rollover transaction: " + err.Error())
+			}
+
+			if err := s.addPocketBalance(sessionCtx, envelope.PocketID, -rolloverAmount); err != nil {
+				session.AbortTransaction(sessionCtx)
+				return errors.New("failed to update balances: " + err.Error())
+			}
+			if err := s.addPocketBalance(sessionCtx, mainPocket.ID, rolloverAmount); err != nil {
+				session.AbortTransaction(sessionCtx)
+				return errors.New("failed to update balances: " + err.Error())
+			}
+
+			record.TransactionID = &rolloverTx.ID
+		}
+
+		if refillAmount > 0 {
+			refillTx := &transaction.Transaction{
+				UserID:       envelope.UserID,
+				Type:         string(transaction.TypeTransfer),
+				Amount:       refillAmount,
+				PocketFromID: &mainPocket.ID,
+				PocketToID:   &envelope.PocketID,
+				Date:         end,
+				Note:         stringPtr("Envelope refill " + nextPeriod),
+				Ref:          stringPtr(refillRef),
+			}
+
+			if err := s.transactionRepo.CreateTransaction(sessionCtx, refillTx); err != nil {
+				session.AbortTransaction(sessionCtx)
+				return errors.New("failed to create refill transaction: " + err.Error())
+			}
+
+			if err := s.addPocketBalance(sessionCtx, mainPocket.ID, -refillAmount); err != nil {
+				session.AbortTransaction(sessionCtx)
+				return errors.New("failed to update balances: " + err.Error())
+			}
+			if err := s.addPocketBalance(sessionCtx, envelope.PocketID, refillAmount); err != nil {
+				session.AbortTransaction(sessionCtx)
+				return errors.New("failed to update balances: " + err.Error())
+			}
+
+			record.RefillAmount = refillAmount
+			record.RefillTransactionID = &refillTx.ID
+		}
+
+		if err := s.repo.CreatePeriod(sessionCtx, record); err != nil {
+			session.AbortTransaction(sessionCtx)
+			return err
+		}
+
+		return session.CommitTransaction(sessionCtx)
+	})
+}
+
+func (s *Service) buildReportItem(ctx context.Context, envelope *Envelope, p *pocket.Pocket, period string, start, end time.Time) (*EnvelopeReportItem, error) {
+	item := &EnvelopeReportItem{
+		Envelope:   envelope,
+		PocketName: p.Name,
+		Budget:     envelope.MonthlyBudget,
+	}
+
+	record, err := s.repo.GetPeriod(ctx, envelope.ID, period)
+	if err != nil {
+		return nil, err
+	}
+
+	if record != nil {
+		item.IsClosed = true
+		item.Budget = record.Budget
+		item.OpeningBalance = record.OpeningBalance
+		item.OpeningRollover = record.OpeningRollover
+		item.Funded = record.Funded
+		item.Spent = record.Spent
+		item.Withdrawn = record.Withdrawn
+		item.Remaining = record.ClosingBalance
+		item.RolloverAmount = record.RolloverAmount
+		item.CarriedOver = record.CarriedOver
+		return item, nil
+	}
+
+	flows, err := s.repo.GetPocketFlows(ctx, p.ID, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	// Everything dated after the period, including its own rollover, is undone to get the closing balance
+	netAfter, err := s.repo.GetPocketNetFlowSince(ctx, p.ID, end)
+	if err != nil {
+		return nil, err
+	}
+
+	item.Funded = flows.Funded
+	item.Spent = flows.Spent
+	item.Withdrawn = flows.Withdrawn
+	item.OpeningRollover = flows.Rollover
+	item.Remaining = utils.Decimal128ToFloat64(p.Balance) - netAfter
+	item.OpeningBalance = item.Remaining + flows.Rollover - flows.Funded + flows.Spent + flows.Withdrawn
+	item.CarriedOver = item.Remaining
+
+	return item, nil
+}
+
+// addPocketBalance applies a balance delta to a pocket
+func (s *Service) addPocketBalance(ctx context.Context, pocketID primitive.ObjectID, delta float64) error {
+	p, err := s.pocketRepo.GetPocketByID(ctx, pocketID)
+	if err != nil {
+		return err
+	}
+	p.Balance = utils.AddDecimal128(p.Balance, delta)
+	return s.pocketRepo.UpdatePocket(ctx, p.ID, p)
+}
+
+func validateRolloverPolicy(policy string, rolloverCap *float64) error {
+	switch RolloverPolicy(policy) {
+	case RolloverCarry, RolloverReset:
+		return nil
+	case RolloverCap:
+		if rolloverCap == nil {
+			return errors.New("rollover_cap is required for cap rollover policy")
+		}
+		return nil
+	default:
+		return errors.New("invalid rollover policy")
+	}
+}
+
+// periodBounds returns the start and end (exclusive) of a YYYY-MM month in UTC, like budget and daily summary periods
+func periodBounds(period string) (time.Time, time.Time, error) {
+	start, err := time.Parse(periodLayout, period)
+	if err != nil {
+		return time.Time{}, time.Time{}, errors.New("invalid period format, use YYYY-MM")
+	}
+	return start, start.AddDate(0, 1, 0), nil
+}
+
+// stringPtr converts a string to a pointer, returning nil for empty strings
+func stringPtr(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
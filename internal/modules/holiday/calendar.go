@@ -0,0 +1,87 @@
+package holiday
+
+import (
+	"time"
+)
+
+// Calendar tells business days apart from weekends and holidays. A nil calendar knows no holidays.
+type Calendar struct {
+	holidays map[string]string
+}
+
+func NewCalendar(holidays []*Holiday) *Calendar {
+	c := &Calendar{holidays: make(map[string]string, len(holidays))}
+	for _, h := range holidays {
+		c.holidays[h.Date] = h.Name
+	}
+	return c
+}
+
+// IsHoliday reports whether t falls on a holiday
+func (c *Calendar) IsHoliday(t time.Time) bool {
+	if c == nil {
+		return false
+	}
+	_, ok := c.holidays[t.Format(DateLayout)]
+	return ok
+}
+
+// IsBusinessDay reports whether t falls on Monday to Friday and is not a holiday
+func (c *Calendar) IsBusinessDay(t time.Time) bool {
+	return t.Weekday() != time.Saturday && t.Weekday() != time.Sunday && !c.IsHoliday(t)
+}
+
+// Adjust moves a date that is not a business day to the previous or next business day, following the policy
+func (c *Calendar) Adjust(t time.Time, adjustment string) time.Time {
+	step := 0
+	switch NormalizeAdjustment(adjustment) {
+	case AdjustPreviousBusinessDay:
+		step = -1
+	case AdjustNextBusinessDay:
+		step = 1
+	default:
+		return t
+	}
+
+	// A run of holidays is never longer than a few weeks
+	for i := 0; i < 31 && !c.IsBusinessDay(t); i++ {
+		t = t.AddDate(0, 0, step)
+	}
+	return t
+}
+
+// DueMonthDays returns the days of the month (1-31) that fall on date once moved by the policy, each with the
+// date it was scheduled for. Scheduled dates of the months before and after are included, since moving a date
+// can cross into another month.
+func (c *Calendar) DueMonthDays(date time.Time, adjustment string) map[int]time.Time {
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
+	firstOfMonth := time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, day.Location())
+
+	days := make(map[int]time.Time)
+	for _, offset := range []int{-1, 0, 1} {
+		month := firstOfMonth.AddDate(0, offset, 0)
+		for monthDay := 1; monthDay <= 31; monthDay++ {
+			scheduled := MonthDate(monthDay, month)
+			if c.Adjust(scheduled, adjustment).Equal(day) {
+				days[monthDay] = scheduled
+			}
+		}
+	}
+	return days
+}
+
+// FallsOn reports whether a day of the month, moved by the policy, falls on date
+func (c *Calendar) FallsOn(monthDay int, adjustment string, date time.Time) bool {
+	_, ok := c.DueMonthDays(date, adjustment)[monthDay]
+	return ok
+}
+
+// MonthDate returns the date of a day of the month in the month of t. Days beyond the end of a short month
+// fall on its last day.
+func MonthDate(monthDay int, t time.Time) time.Time {
+	lastDay := time.Date(t.Year(), t.Month()+1, 0, 0, 0, 0, 0, t.Location()).Day()
+	if monthDay > lastDay {
+		monthDay = lastDay
+	}
+	return time.Date(t.Year(), t.Month(), monthDay, 0, 0, 0, 0, t.Location())
+}
@@ -0,0 +1,137 @@
+package holiday
+
+import (
+	"testing"
+	"time"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestCalendarAdjust(t *testing.T) {
+	// Labour Day 2026 falls on a Friday, making a long weekend
+	cal := NewCalendar([]*Holiday{{Date: "2026-05-01", Name: "Hari Buruh"}})
+
+	tests := []struct {
+		name       string
+		t          time.Time
+		adjustment string
+		want       time.Time
+	}{
+		{"business day stays", date(2026, time.April, 29), AdjustPreviousBusinessDay, date(2026, time.April, 29)},
+		{"weekend without a policy stays", date(2026, time.January, 31), AdjustNone, date(2026, time.January, 31)},
+		{"unknown policy stays", date(2026, time.January, 31), "", date(2026, time.January, 31)},
+		{"Saturday back to Friday", date(2026, time.January, 31), AdjustPreviousBusinessDay, date(2026, time.January, 30)},
+		{"Saturday on to Monday across the month end", date(2026, time.January, 31), AdjustNextBusinessDay, date(2026, time.February, 2)},
+		{"holiday back over the month end", date(2026, time.May, 1), AdjustPreviousBusinessDay, date(2026, time.April, 30)},
+		{"weekend after a holiday back past it", date(2026, time.May, 2), AdjustPreviousBusinessDay, date(2026, time.April, 30)},
+		{"holiday on past the weekend", date(2026, time.May, 1), AdjustNextBusinessDay, date(2026, time.May, 4)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cal.Adjust(tt.t, tt.adjustment); !got.Equal(tt.want) {
+				t.Errorf("Adjust(%s, %s) = %s, want %s", tt.t.Format(DateLayout), tt.adjustment, got.Format(DateLayout), tt.want.Format(DateLayout))
+			}
+		})
+	}
+}
+
+func TestCalendarDueMonthDays(t *testing.T) {
+	cal := NewCalendar([]*Holiday{
+		{Date: "2026-04-30", Name: "Libur"},
+		{Date: "2026-05-01", Name: "Hari Buruh"},
+	})
+
+	tests := []struct {
+		name       string
+		date       time.Time
+		adjustment string
+		want       map[int]time.Time
+	}{
+		{
+			name:       "end of February pays every later day",
+			date:       date(2026, time.February, 28),
+			adjustment: AdjustNone,
+			want: map[int]time.Time{
+				28: date(2026, time.February, 28),
+				29: date(2026, time.February, 28),
+				30: date(2026, time.February, 28),
+				31: date(2026, time.February, 28),
+			},
+		},
+		{
+			name:       "February 29 of a leap year",
+			date:       date(2024, time.February, 29),
+			adjustment: AdjustNone,
+			want: map[int]time.Time{
+				29: date(2024, time.February, 29),
+				30: date(2024, time.February, 29),
+				31: date(2024, time.February, 29),
+			},
+		},
+		{
+			name:       "month-end weekend paid the Friday before",
+			date:       date(2026, time.January, 30),
+			adjustment: AdjustPreviousBusinessDay,
+			want: map[int]time.Time{
+				30: date(2026, time.January, 30),
+				31: date(2026, time.January, 31),
+				1:  date(2026, time.February, 1),
+			},
+		},
+		{
+			name:       "February end on a Saturday paid the Monday after",
+			date:       date(2026, time.March, 2),
+			adjustment: AdjustNextBusinessDay,
+			want: map[int]time.Time{
+				28: date(2026, time.February, 28),
+				29: date(2026, time.February, 28),
+				30: date(2026, time.February, 28),
+				31: date(2026, time.February, 28),
+				1:  date(2026, time.March, 1),
+				2:  date(2026, time.March, 2),
+			},
+		},
+		{
+			name:       "end-of-month holiday paid the day before",
+			date:       date(2026, time.April, 29),
+			adjustment: AdjustPreviousBusinessDay,
+			want: map[int]time.Time{
+				29: date(2026, time.April, 29),
+				30: date(2026, time.April, 30),
+				31: date(2026, time.April, 30),
+				1:  date(2026, time.May, 1),
+				2:  date(2026, time.May, 2),
+				3:  date(2026, time.May, 3),
+			},
+		},
+		{
+			name:       "nothing due on a holiday",
+			date:       date(2026, time.May, 1),
+			adjustment: AdjustNextBusinessDay,
+			want:       map[int]time.Time{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := cal.DueMonthDays(tt.date, tt.adjustment)
+
+			if len(got) != len(tt.want) {
+				t.Errorf("got %d due days, want %d: %v", len(got), len(tt.want), got)
+			}
+			for day, want := range tt.want {
+				scheduled, ok := got[day]
+				if !ok {
+					t.Errorf("day %d is not due", day)
+					continue
+				}
+				if !scheduled.Equal(want) {
+					t.Errorf("day %d scheduled %s, want %s", day, scheduled.Format(DateLayout), want.Format(DateLayout))
+				}
+			}
+		})
+	}
+}
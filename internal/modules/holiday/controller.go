@@ -0,0 +1,164 @@
+package holiday
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/HasanNugroho/coin-be/internal/core/utils"
+	"github.com/HasanNugroho/coin-be/internal/modules/holiday/dto"
+	"github.com/gin-gonic/gin"
+)
+
+type Controller struct {
+	service *Service
+}
+
+func NewController(s *Service) *Controller {
+	return &Controller{service: s}
+}
+
+// CreateHoliday godoc
+// @Summary Create a holiday
+// @Description Add a national holiday or collective leave day. Pay dates and scheduled allocations falling on it are moved to a business day. (admin only)
+// @Tags Holidays
+// @Accept json
+// @Produce json
+// @Param request body dto.CreateHolidayRequest true "Holiday details"
+// @Success 201 {object} map[string]interface{} "Holiday created successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 403 {object} map[string]interface{} "Admin access required"
+// @Security BearerAuth
+// @Router /v1/holidays/admin [post]
+func (c *Controller) CreateHoliday(ctx *gin.Context) {
+	var req dto.CreateHolidayRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		resp := utils.NewErrorResponse(http.StatusBadRequest, err.Error())
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	if err := utils.ValidateRequest(&req); err != nil {
+		resp := utils.NewErrorResponse(http.StatusBadRequest, err.Error())
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	holiday, err := c.service.CreateHoliday(ctx, &req)
+	if err != nil {
+		resp := utils.NewErrorResponse(http.StatusBadRequest, err.Error())
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	resp := utils.NewSuccessResponse("Holiday created successfully", c.mapToResponse(holiday))
+	ctx.JSON(http.StatusCreated, resp)
+}
+
+// ListHolidays godoc
+// @Summary List holidays
+// @Description Get the holidays of a year in date order
+// @Tags Holidays
+// @Produce json
+// @Param year query int false "Year, defaults to the current year"
+// @Success 200 {object} map[string]interface{} "Holidays retrieved successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Security BearerAuth
+// @Router /v1/holidays [get]
+func (c *Controller) ListHolidays(ctx *gin.Context) {
+	year := time.Now().Year()
+	if y := ctx.Query("year"); y != "" {
+		parsed, err := strconv.Atoi(y)
+		if err != nil {
+			resp := utils.NewErrorResponse(http.StatusBadRequest, "invalid year")
+			ctx.JSON(http.StatusBadRequest, resp)
+			return
+		}
+		year = parsed
+	}
+
+	holidays, err := c.service.ListHolidays(ctx, year)
+	if err != nil {
+		resp := utils.NewErrorResponse(http.StatusBadRequest, err.Error())
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	responses := make([]*dto.HolidayResponse, len(holidays))
+	for i, holiday := range holidays {
+		responses[i] = c.mapToResponse(holiday)
+	}
+
+	resp := utils.NewSuccessResponse("Holidays retrieved successfully", responses)
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// UpdateHoliday godoc
+// @Summary Update a holiday
+// @Description Update a holiday (admin only)
+// @Tags Holidays
+// @Accept json
+// @Produce json
+// @Param id path string true "Holiday ID"
+// @Param request body dto.UpdateHolidayRequest true "Update details"
+// @Success 200 {object} map[string]interface{} "Holiday updated successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 403 {object} map[string]interface{} "Admin access required"
+// @Security BearerAuth
+// @Router /v1/holidays/admin/{id} [put]
+func (c *Controller) UpdateHoliday(ctx *gin.Context) {
+	var req dto.UpdateHolidayRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		resp := utils.NewErrorResponse(http.StatusBadRequest, err.Error())
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	if err := utils.ValidateRequest(&req); err != nil {
+		resp := utils.NewErrorResponse(http.StatusBadRequest, err.Error())
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	holiday, err := c.service.UpdateHoliday(ctx, ctx.Param("id"), &req)
+	if err != nil {
+		resp := utils.NewErrorResponse(http.StatusBadRequest, err.Error())
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	resp := utils.NewSuccessResponse("Holiday updated successfully", c.mapToResponse(holiday))
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// DeleteHoliday godoc
+// @Summary Delete a holiday
+// @Description Delete a holiday (admin only)
+// @Tags Holidays
+// @Produce json
+// @Param id path string true "Holiday ID"
+// @Success 200 {object} map[string]interface{} "Holiday deleted successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 403 {object} map[string]interface{} "Admin access required"
+// @Security BearerAuth
+// @Router /v1/holidays/admin/{id} [delete]
+func (c *Controller) DeleteHoliday(ctx *gin.Context) {
+	if err := c.service.DeleteHoliday(ctx, ctx.Param("id")); err != nil {
+		resp := utils.NewErrorResponse(http.StatusBadRequest, err.Error())
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	resp := utils.NewSuccessResponse("Holiday deleted successfully", nil)
+	ctx.JSON(http.StatusOK, resp)
+}
+
+func (c *Controller) mapToResponse(holiday *Holiday) *dto.HolidayResponse {
+	return &dto.HolidayResponse{
+		ID:        holiday.ID.Hex(),
+		Date:      holiday.Date,
+		Name:      holiday.Name,
+		CreatedAt: holiday.CreatedAt,
+		UpdatedAt: holiday.UpdatedAt,
+	}
+}
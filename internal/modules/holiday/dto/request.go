@@ -0,0 +1,11 @@
+package dto
+
+type CreateHolidayRequest struct {
+	Date string `json:"date" validate:"required"` // YYYY-MM-DD
+	Name string `json:"name" validate:"required,min=1,max=255"`
+}
+
+type UpdateHolidayRequest struct {
+	Date string `json:"date"` // YYYY-MM-DD
+	Name string `json:"name" validate:"omitempty,min=1,max=255"`
+}
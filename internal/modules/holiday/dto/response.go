@@ -0,0 +1,11 @@
+package dto
+
+import "time"
+
+type HolidayResponse struct {
+	ID        string    `json:"id"`
+	Date      string    `json:"date"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
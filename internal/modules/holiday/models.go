@@ -0,0 +1,38 @@
+package holiday
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Holiday is a national holiday or collective leave day on which employers and banks do not pay out
+type Holiday struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Date      string             `bson:"date" json:"date"` // YYYY-MM-DD
+	Name      string             `bson:"name" json:"name"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time          `bson:"updated_at" json:"updated_at"`
+	DeletedAt *time.Time         `bson:"deleted_at,omitempty" json:"deleted_at,omitempty"`
+}
+
+// DateLayout is the format holiday dates are stored in
+const DateLayout = "2006-01-02"
+
+// Adjustment policies for a scheduled date that falls on a weekend or holiday
+const (
+	AdjustNone                = "NONE"
+	AdjustPreviousBusinessDay = "PREVIOUS_BUSINESS_DAY"
+	AdjustNextBusinessDay     = "NEXT_BUSINESS_DAY"
+)
+
+// Adjustments lists every adjustment policy
+var Adjustments = []string{AdjustNone, AdjustPreviousBusinessDay, AdjustNextBusinessDay}
+
+// NormalizeAdjustment returns an adjustment policy, NONE when none is set
+func NormalizeAdjustment(adjustment string) string {
+	if adjustment == AdjustPreviousBusinessDay || adjustment == AdjustNextBusinessDay {
+		return adjustment
+	}
+	return AdjustNone
+}
@@ -0,0 +1,117 @@
+package holiday
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type Repository struct {
+	holidays *mongo.Collection
+}
+
+func NewRepository(db *mongo.Database) *Repository {
+	return &Repository{
+		holidays: db.Collection("holidays"),
+	}
+}
+
+func (r *Repository) CreateHoliday(ctx context.Context, holiday *Holiday) error {
+	holiday.ID = primitive.NewObjectID()
+	holiday.CreatedAt = time.Now()
+	holiday.UpdatedAt = time.Now()
+	_, err := r.holidays.InsertOne(ctx, holiday)
+	return err
+}
+
+func (r *Repository) GetHolidayByID(ctx context.Context, id primitive.ObjectID) (*Holiday, error) {
+	var holiday Holiday
+	err := r.holidays.FindOne(ctx, bson.M{"_id": id, "deleted_at": nil}).Decode(&holiday)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.New("holiday not found")
+		}
+		return nil, err
+	}
+	return &holiday, nil
+}
+
+func (r *Repository) GetHolidayByDate(ctx context.Context, date string) (*Holiday, error) {
+	var holiday Holiday
+	err := r.holidays.FindOne(ctx, bson.M{"date": date, "deleted_at": nil}).Decode(&holiday)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.New("holiday not found")
+		}
+		return nil, err
+	}
+	return &holiday, nil
+}
+
+// GetHolidaysBetween returns the holidays from one date to another (YYYY-MM-DD, both inclusive) in date order
+func (r *Repository) GetHolidaysBetween(ctx context.Context, from string, to string) ([]*Holiday, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "date", Value: 1}})
+	cursor, err := r.holidays.Find(ctx, bson.M{
+		"date":       bson.M{"$gte": from, "$lte": to},
+		"deleted_at": nil,
+	}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	holidays := make([]*Holiday, 0)
+	if err = cursor.All(ctx, &holidays); err != nil {
+		return nil, err
+	}
+	return holidays, nil
+}
+
+func (r *Repository) UpdateHoliday(ctx context.Context, id primitive.ObjectID, holiday *Holiday) error {
+	holiday.UpdatedAt = time.Now()
+	result, err := r.holidays.UpdateOne(ctx, bson.M{"_id": id, "deleted_at": nil}, bson.M{"$set": holiday})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errors.New("holiday not found")
+	}
+	return nil
+}
+
+func (r *Repository) DeleteHoliday(ctx context.Context, id primitive.ObjectID) error {
+	now := time.Now()
+	result, err := r.holidays.UpdateOne(
+		ctx,
+		bson.M{"_id": id, "deleted_at": nil},
+		bson.M{"$set": bson.M{"deleted_at": now, "updated_at": now}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errors.New("holiday not found")
+	}
+	return nil
+}
+
+func (r *Repository) EnsureIndexes(ctx context.Context) error {
+	indexes := []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				{Key: "date", Value: 1},
+				{Key: "deleted_at", Value: 1},
+			},
+			Options: options.Index().
+				SetName("idx_holidays_date"),
+		},
+	}
+
+	_, err := r.holidays.Indexes().CreateMany(ctx, indexes)
+	return err
+}
@@ -0,0 +1,21 @@
+package holiday
+
+import (
+	"github.com/HasanNugroho/coin-be/internal/core/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterRoutes(r *gin.RouterGroup, controller *Controller) {
+	protected := r.Group("")
+	{
+		protected.GET("", controller.ListHolidays)
+	}
+
+	admin := r.Group("admin")
+	admin.Use(middleware.AdminMiddleware())
+	{
+		admin.POST("", controller.CreateHoliday)
+		admin.PUT("/:id", controller.UpdateHoliday)
+		admin.DELETE("/:id", controller.DeleteHoliday)
+	}
+}
@@ -0,0 +1,122 @@
+package holiday
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/HasanNugroho/coin-be/internal/modules/holiday/dto"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type Service struct {
+	repo *Repository
+}
+
+func NewService(r *Repository) *Service {
+	return &Service{
+		repo: r,
+	}
+}
+
+func (s *Service) CreateHoliday(ctx context.Context, req *dto.CreateHolidayRequest) (*Holiday, error) {
+	date, err := parseDate(req.Date)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, _ := s.repo.GetHolidayByDate(ctx, date)
+	if existing != nil {
+		return nil, errors.New("a holiday already exists on this date")
+	}
+
+	holiday := &Holiday{
+		Date: date,
+		Name: req.Name,
+	}
+
+	if err := s.repo.CreateHoliday(ctx, holiday); err != nil {
+		return nil, err
+	}
+
+	return holiday, nil
+}
+
+// ListHolidays returns the holidays of a year in date order
+func (s *Service) ListHolidays(ctx context.Context, year int) ([]*Holiday, error) {
+	if year < 1 || year > 9999 {
+		return nil, errors.New("invalid year")
+	}
+
+	y := strconv.Itoa(year)
+	return s.repo.GetHolidaysBetween(ctx, y+"-01-01", y+"-12-31")
+}
+
+func (s *Service) UpdateHoliday(ctx context.Context, id string, req *dto.UpdateHolidayRequest) (*Holiday, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, errors.New("invalid holiday id")
+	}
+
+	holiday, err := s.repo.GetHolidayByID(ctx, objID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Date != "" {
+		date, err := parseDate(req.Date)
+		if err != nil {
+			return nil, err
+		}
+
+		existing, _ := s.repo.GetHolidayByDate(ctx, date)
+		if existing != nil && existing.ID != holiday.ID {
+			return nil, errors.New("a holiday already exists on this date")
+		}
+		holiday.Date = date
+	}
+
+	if req.Name != "" {
+		holiday.Name = req.Name
+	}
+
+	if err := s.repo.UpdateHoliday(ctx, holiday.ID, holiday); err != nil {
+		return nil, err
+	}
+
+	return holiday, nil
+}
+
+func (s *Service) DeleteHoliday(ctx context.Context, id string) error {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return errors.New("invalid holiday id")
+	}
+
+	return s.repo.DeleteHoliday(ctx, objID)
+}
+
+// LoadCalendar returns a calendar of the holidays from one date to another. Dates outside the range are treated
+// as having no holidays.
+func (s *Service) LoadCalendar(ctx context.Context, from time.Time, to time.Time) (*Calendar, error) {
+	holidays, err := s.repo.GetHolidaysBetween(ctx, from.Format(DateLayout), to.Format(DateLayout))
+	if err != nil {
+		return nil, err
+	}
+	return NewCalendar(holidays), nil
+}
+
+// CalendarAround returns a calendar covering the months around date, enough to move any date scheduled near it
+func (s *Service) CalendarAround(ctx context.Context, date time.Time) (*Calendar, error) {
+	return s.LoadCalendar(ctx, date.AddDate(0, -2, 0), date.AddDate(0, 2, 0))
+}
+
+// parseDate checks a YYYY-MM-DD date and returns it in the stored format
+func parseDate(date string) (string, error) {
+	t, err := time.Parse(DateLayout, date)
+	if err != nil {
+		return "", errors.New("invalid date format, expected YYYY-MM-DD")
+	}
+	return t.Format(DateLayout), nil
+}
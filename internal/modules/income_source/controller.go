@@ -0,0 +1,463 @@
+package income_source
+
+import (
+	"net/http"
+
+	"github.com/HasanNugroho/coin-be/internal/core/utils"
+	"github.com/HasanNugroho/coin-be/internal/modules/holiday"
+	"github.com/HasanNugroho/coin-be/internal/modules/income_source/dto"
+	"github.com/gin-gonic/gin"
+)
+
+type Controller struct {
+	service *Service
+}
+
+func NewController(s *Service) *Controller {
+	return &Controller{service: s}
+}
+
+// CreateIncomeSource godoc
+// @Summary Create income source
+// @Description Add a recurring income such as a salary, a retainer or rent. Sources with auto input are credited by the payroll cron on their pay dates, moved off weekends and holidays by the pay day adjustment.
+// @Tags Income Sources
+// @Accept json
+// @Produce json
+// @Param request body dto.CreateIncomeSourceRequest true "Income source details"
+// @Success 201 {object} map[string]interface{} "Income source created successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Security BearerAuth
+// @Router /v1/income-sources [post]
+func (c *Controller) CreateIncomeSource(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		resp := utils.NewErrorResponse(http.StatusUnauthorized, "unauthorized")
+		ctx.JSON(http.StatusUnauthorized, resp)
+		return
+	}
+
+	var req dto.CreateIncomeSourceRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		resp := utils.NewErrorResponse(http.StatusBadRequest, err.Error())
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	if err := utils.ValidateRequest(&req); err != nil {
+		resp := utils.NewErrorResponse(http.StatusBadRequest, err.Error())
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	source, err := c.service.CreateIncomeSource(ctx, userID.(string), &req)
+	if err != nil {
+		resp := utils.NewErrorResponse(http.StatusBadRequest, err.Error())
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	resp := utils.NewSuccessResponse("Income source created successfully", c.mapToResponse(source))
+	ctx.JSON(http.StatusCreated, resp)
+}
+
+// ListIncomeSources godoc
+// @Summary List income sources
+// @Description Get all income sources of the authenticated user
+// @Tags Income Sources
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Income sources retrieved successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Security BearerAuth
+// @Router /v1/income-sources [get]
+func (c *Controller) ListIncomeSources(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		resp := utils.NewErrorResponse(http.StatusUnauthorized, "unauthorized")
+		ctx.JSON(http.StatusUnauthorized, resp)
+		return
+	}
+
+	sources, err := c.service.ListIncomeSources(ctx, userID.(string))
+	if err != nil {
+		resp := utils.NewErrorResponse(http.StatusBadRequest, err.Error())
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	responses := make([]*dto.IncomeSourceResponse, len(sources))
+	for i, source := range sources {
+		responses[i] = c.mapToResponse(source)
+	}
+
+	resp := utils.NewSuccessResponse("Income sources retrieved successfully", responses)
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// GetIncomeSource godoc
+// @Summary Get income source by ID
+// @Description Get a specific income source by ID
+// @Tags Income Sources
+// @Produce json
+// @Param id path string true "Income source ID"
+// @Success 200 {object} map[string]interface{} "Income source retrieved successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Security BearerAuth
+// @Router /v1/income-sources/{id} [get]
+func (c *Controller) GetIncomeSource(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		resp := utils.NewErrorResponse(http.StatusUnauthorized, "unauthorized")
+		ctx.JSON(http.StatusUnauthorized, resp)
+		return
+	}
+
+	source, err := c.service.GetIncomeSourceByID(ctx, userID.(string), ctx.Param("id"))
+	if err != nil {
+		resp := utils.NewErrorResponse(http.StatusBadRequest, err.Error())
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	resp := utils.NewSuccessResponse("Income source retrieved successfully", c.mapToResponse(source))
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// UpdateIncomeSource godoc
+// @Summary Update income source
+// @Description Update an income source
+// @Tags Income Sources
+// @Accept json
+// @Produce json
+// @Param id path string true "Income source ID"
+// @Param request body dto.UpdateIncomeSourceRequest true "Update details"
+// @Success 200 {object} map[string]interface{} "Income source updated successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Security BearerAuth
+// @Router /v1/income-sources/{id} [put]
+func (c *Controller) UpdateIncomeSource(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		resp := utils.NewErrorResponse(http.StatusUnauthorized, "unauthorized")
+		ctx.JSON(http.StatusUnauthorized, resp)
+		return
+	}
+
+	var req dto.UpdateIncomeSourceRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		resp := utils.NewErrorResponse(http.StatusBadRequest, err.Error())
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	if err := utils.ValidateRequest(&req); err != nil {
+		resp := utils.NewErrorResponse(http.StatusBadRequest, err.Error())
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	source, err := c.service.UpdateIncomeSource(ctx, userID.(string), ctx.Param("id"), &req)
+	if err != nil {
+		resp := utils.NewErrorResponse(http.StatusBadRequest, err.Error())
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	resp := utils.NewSuccessResponse("Income source updated successfully", c.mapToResponse(source))
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// DeleteIncomeSource godoc
+// @Summary Delete income source
+// @Description Delete an income source. Its payroll history is kept.
+// @Tags Income Sources
+// @Produce json
+// @Param id path string true "Income source ID"
+// @Success 200 {object} map[string]interface{} "Income source deleted successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Security BearerAuth
+// @Router /v1/income-sources/{id} [delete]
+func (c *Controller) DeleteIncomeSource(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		resp := utils.NewErrorResponse(http.StatusUnauthorized, "unauthorized")
+		ctx.JSON(http.StatusUnauthorized, resp)
+		return
+	}
+
+	if err := c.service.DeleteIncomeSource(ctx, userID.(string), ctx.Param("id")); err != nil {
+		resp := utils.NewErrorResponse(http.StatusBadRequest, err.Error())
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	resp := utils.NewSuccessResponse("Income source deleted successfully", nil)
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// CreateIncomeEvent godoc
+// @Summary Schedule a one-off income
+// @Description Schedule a one-off income such as THR or an annual bonus, a fixed amount or a multiple of the base salary. The payroll cron credits it once on its date, optionally running the income-triggered allocations.
+// @Tags Income Events
+// @Accept json
+// @Produce json
+// @Param request body dto.CreateIncomeEventRequest true "Income event details"
+// @Success 201 {object} map[string]interface{} "Income event created successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Security BearerAuth
+// @Router /v1/income-sources/events [post]
+func (c *Controller) CreateIncomeEvent(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		resp := utils.NewErrorResponse(http.StatusUnauthorized, "unauthorized")
+		ctx.JSON(http.StatusUnauthorized, resp)
+		return
+	}
+
+	var req dto.CreateIncomeEventRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		resp := utils.NewErrorResponse(http.StatusBadRequest, err.Error())
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	if err := utils.ValidateRequest(&req); err != nil {
+		resp := utils.NewErrorResponse(http.StatusBadRequest, err.Error())
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	event, err := c.service.CreateIncomeEvent(ctx, userID.(string), &req)
+	if err != nil {
+		resp := utils.NewErrorResponse(http.StatusBadRequest, err.Error())
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	resp := utils.NewSuccessResponse("Income event created successfully", c.mapEventToResponse(event))
+	ctx.JSON(http.StatusCreated, resp)
+}
+
+// ListIncomeEvents godoc
+// @Summary List income events
+// @Description Get all one-off income events of the authenticated user, latest first
+// @Tags Income Events
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Income events retrieved successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Security BearerAuth
+// @Router /v1/income-sources/events [get]
+func (c *Controller) ListIncomeEvents(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		resp := utils.NewErrorResponse(http.StatusUnauthorized, "unauthorized")
+		ctx.JSON(http.StatusUnauthorized, resp)
+		return
+	}
+
+	events, err := c.service.ListIncomeEvents(ctx, userID.(string))
+	if err != nil {
+		resp := utils.NewErrorResponse(http.StatusBadRequest, err.Error())
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	responses := make([]*dto.IncomeEventResponse, len(events))
+	for i, event := range events {
+		responses[i] = c.mapEventToResponse(event)
+	}
+
+	resp := utils.NewSuccessResponse("Income events retrieved successfully", responses)
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// GetIncomeEvent godoc
+// @Summary Get income event by ID
+// @Description Get a specific one-off income event by ID
+// @Tags Income Events
+// @Produce json
+// @Param id path string true "Income event ID"
+// @Success 200 {object} map[string]interface{} "Income event retrieved successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Security BearerAuth
+// @Router /v1/income-sources/events/{id} [get]
+func (c *Controller) GetIncomeEvent(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		resp := utils.NewErrorResponse(http.StatusUnauthorized, "unauthorized")
+		ctx.JSON(http.StatusUnauthorized, resp)
+		return
+	}
+
+	event, err := c.service.GetIncomeEventByID(ctx, userID.(string), ctx.Param("id"))
+	if err != nil {
+		resp := utils.NewErrorResponse(http.StatusBadRequest, err.Error())
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	resp := utils.NewSuccessResponse("Income event retrieved successfully", c.mapEventToResponse(event))
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// UpdateIncomeEvent godoc
+// @Summary Update income event
+// @Description Update a one-off income event that has not been paid yet
+// @Tags Income Events
+// @Accept json
+// @Produce json
+// @Param id path string true "Income event ID"
+// @Param request body dto.UpdateIncomeEventRequest true "Update details"
+// @Success 200 {object} map[string]interface{} "Income event updated successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Security BearerAuth
+// @Router /v1/income-sources/events/{id} [put]
+func (c *Controller) UpdateIncomeEvent(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		resp := utils.NewErrorResponse(http.StatusUnauthorized, "unauthorized")
+		ctx.JSON(http.StatusUnauthorized, resp)
+		return
+	}
+
+	var req dto.UpdateIncomeEventRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		resp := utils.NewErrorResponse(http.StatusBadRequest, err.Error())
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	if err := utils.ValidateRequest(&req); err != nil {
+		resp := utils.NewErrorResponse(http.StatusBadRequest, err.Error())
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	event, err := c.service.UpdateIncomeEvent(ctx, userID.(string), ctx.Param("id"), &req)
+	if err != nil {
+		resp := utils.NewErrorResponse(http.StatusBadRequest, err.Error())
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	resp := utils.NewSuccessResponse("Income event updated successfully", c.mapEventToResponse(event))
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// DeleteIncomeEvent godoc
+// @Summary Delete income event
+// @Description Cancel a one-off income event. Paid events cannot be deleted.
+// @Tags Income Events
+// @Produce json
+// @Param id path string true "Income event ID"
+// @Success 200 {object} map[string]interface{} "Income event deleted successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Security BearerAuth
+// @Router /v1/income-sources/events/{id} [delete]
+func (c *Controller) DeleteIncomeEvent(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		resp := utils.NewErrorResponse(http.StatusUnauthorized, "unauthorized")
+		ctx.JSON(http.StatusUnauthorized, resp)
+		return
+	}
+
+	if err := c.service.DeleteIncomeEvent(ctx, userID.(string), ctx.Param("id")); err != nil {
+		resp := utils.NewErrorResponse(http.StatusBadRequest, err.Error())
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	resp := utils.NewSuccessResponse("Income event deleted successfully", nil)
+	ctx.JSON(http.StatusOK, resp)
+}
+
+func (c *Controller) mapToResponse(source *IncomeSource) *dto.IncomeSourceResponse {
+	var userPlatformID *string
+	if source.UserPlatformID != nil {
+		id := source.UserPlatformID.Hex()
+		userPlatformID = &id
+	}
+
+	var pocketID *string
+	if source.PocketID != nil {
+		id := source.PocketID.Hex()
+		pocketID = &id
+	}
+
+	var categoryID *string
+	if source.CategoryID != nil {
+		id := source.CategoryID.Hex()
+		categoryID = &id
+	}
+
+	return &dto.IncomeSourceResponse{
+		ID:               source.ID.Hex(),
+		Name:             source.Name,
+		Amount:           source.Amount,
+		Cycle:            source.Cycle,
+		PayDay:           source.PayDay,
+		WorkingDaysOnly:  source.WorkingDaysOnly,
+		PayDayAdjustment: holiday.NormalizeAdjustment(source.PayDayAdjustment),
+		UserPlatformID:   userPlatformID,
+		PocketID:         pocketID,
+		CategoryID:       categoryID,
+		AutoInput:        source.AutoInput,
+		IsActive:         source.IsActive,
+		CreatedAt:        source.CreatedAt,
+		UpdatedAt:        source.UpdatedAt,
+	}
+}
+
+func (c *Controller) mapEventToResponse(event *IncomeEvent) *dto.IncomeEventResponse {
+	var incomeSourceID *string
+	if event.IncomeSourceID != nil {
+		id := event.IncomeSourceID.Hex()
+		incomeSourceID = &id
+	}
+
+	var userPlatformID *string
+	if event.UserPlatformID != nil {
+		id := event.UserPlatformID.Hex()
+		userPlatformID = &id
+	}
+
+	var pocketID *string
+	if event.PocketID != nil {
+		id := event.PocketID.Hex()
+		pocketID = &id
+	}
+
+	var categoryID *string
+	if event.CategoryID != nil {
+		id := event.CategoryID.Hex()
+		categoryID = &id
+	}
+
+	return &dto.IncomeEventResponse{
+		ID:              event.ID.Hex(),
+		Name:            event.Name,
+		Type:            event.Type,
+		AmountType:      event.AmountType,
+		Amount:          event.Amount,
+		Multiplier:      event.Multiplier,
+		IncomeSourceID:  incomeSourceID,
+		Date:            event.Date,
+		UserPlatformID:  userPlatformID,
+		PocketID:        pocketID,
+		CategoryID:      categoryID,
+		FireAllocations: event.FireAllocations,
+		Status:          event.Status,
+		CreatedAt:       event.CreatedAt,
+		UpdatedAt:       event.UpdatedAt,
+	}
+}
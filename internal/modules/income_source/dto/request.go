@@ -0,0 +1,56 @@
+package dto
+
+type CreateIncomeSourceRequest struct {
+	Name             string  `json:"name" validate:"required,max=100"`
+	Amount           float64 `json:"amount" validate:"required,gt=0"`
+	Cycle            string  `json:"cycle" validate:"required,oneof=daily weekly monthly"`
+	PayDay           int     `json:"pay_day" validate:"omitempty,min=1,max=31"` // 1=Monday..7=Sunday for weekly, unused for daily
+	WorkingDaysOnly  bool    `json:"working_days_only"`
+	PayDayAdjustment string  `json:"pay_day_adjustment" validate:"omitempty,oneof=NONE PREVIOUS_BUSINESS_DAY NEXT_BUSINESS_DAY"` // defaults to PREVIOUS_BUSINESS_DAY
+	UserPlatformID   string  `json:"user_platform_id" validate:"omitempty,len=24,hexadecimal"`
+	PocketID         string  `json:"pocket_id" validate:"omitempty,len=24,hexadecimal"`
+	CategoryID       string  `json:"category_id" validate:"omitempty,len=24,hexadecimal"`
+	AutoInput        bool    `json:"auto_input"`
+}
+
+type UpdateIncomeSourceRequest struct {
+	Name             string   `json:"name" validate:"omitempty,max=100"`
+	Amount           *float64 `json:"amount" validate:"omitempty,gt=0"`
+	Cycle            string   `json:"cycle" validate:"omitempty,oneof=daily weekly monthly"`
+	PayDay           *int     `json:"pay_day" validate:"omitempty,min=1,max=31"`
+	WorkingDaysOnly  *bool    `json:"working_days_only"`
+	PayDayAdjustment string   `json:"pay_day_adjustment" validate:"omitempty,oneof=NONE PREVIOUS_BUSINESS_DAY NEXT_BUSINESS_DAY"`
+	UserPlatformID   string   `json:"user_platform_id" validate:"omitempty,len=24,hexadecimal"`
+	PocketID         string   `json:"pocket_id" validate:"omitempty,len=24,hexadecimal"`
+	CategoryID       string   `json:"category_id" validate:"omitempty,len=24,hexadecimal"`
+	AutoInput        *bool    `json:"auto_input"`
+	IsActive         *bool    `json:"is_active"`
+}
+
+type CreateIncomeEventRequest struct {
+	Name            string  `json:"name" validate:"required,max=100"`
+	Type            string  `json:"type" validate:"required,oneof=THR BONUS OTHER"`
+	AmountType      string  `json:"amount_type" validate:"required,oneof=FIXED SALARY_MULTIPLE"`
+	Amount          float64 `json:"amount" validate:"omitempty,gt=0"`                         // required for FIXED
+	Multiplier      float64 `json:"multiplier" validate:"omitempty,gt=0,lte=24"`              // required for SALARY_MULTIPLE, e.g. 1 for a month of salary
+	IncomeSourceID  string  `json:"income_source_id" validate:"omitempty,len=24,hexadecimal"` // salary a multiple is taken of, defaults to the profile salary
+	Date            string  `json:"date" validate:"required,datetime=2006-01-02"`             // YYYY-MM-DD, today or later
+	UserPlatformID  string  `json:"user_platform_id" validate:"omitempty,len=24,hexadecimal"`
+	PocketID        string  `json:"pocket_id" validate:"omitempty,len=24,hexadecimal"`
+	CategoryID      string  `json:"category_id" validate:"omitempty,len=24,hexadecimal"`
+	FireAllocations bool    `json:"fire_allocations"`
+}
+
+type UpdateIncomeEventRequest struct {
+	Name            string   `json:"name" validate:"omitempty,max=100"`
+	Type            string   `json:"type" validate:"omitempty,oneof=THR BONUS OTHER"`
+	AmountType      string   `json:"amount_type" validate:"omitempty,oneof=FIXED SALARY_MULTIPLE"`
+	Amount          *float64 `json:"amount" validate:"omitempty,gt=0"`
+	Multiplier      *float64 `json:"multiplier" validate:"omitempty,gt=0,lte=24"`
+	IncomeSourceID  string   `json:"income_source_id" validate:"omitempty,len=24,hexadecimal"`
+	Date            string   `json:"date" validate:"omitempty,datetime=2006-01-02"`
+	UserPlatformID  string   `json:"user_platform_id" validate:"omitempty,len=24,hexadecimal"`
+	PocketID        string   `json:"pocket_id" validate:"omitempty,len=24,hexadecimal"`
+	CategoryID      string   `json:"category_id" validate:"omitempty,len=24,hexadecimal"`
+	FireAllocations *bool    `json:"fire_allocations"`
+}
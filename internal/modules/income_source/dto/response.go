@@ -0,0 +1,38 @@
+package dto
+
+import "time"
+
+type IncomeSourceResponse struct {
+	ID               string    `json:"id"`
+	Name             string    `json:"name"`
+	Amount           float64   `json:"amount"`
+	Cycle            string    `json:"cycle"`
+	PayDay           int       `json:"pay_day"`
+	WorkingDaysOnly  bool      `json:"working_days_only"`
+	PayDayAdjustment string    `json:"pay_day_adjustment"`
+	UserPlatformID   *string   `json:"user_platform_id,omitempty"`
+	PocketID         *string   `json:"pocket_id,omitempty"`
+	CategoryID       *string   `json:"category_id,omitempty"`
+	AutoInput        bool      `json:"auto_input"`
+	IsActive         bool      `json:"is_active"`
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+type IncomeEventResponse struct {
+	ID              string    `json:"id"`
+	Name            string    `json:"name"`
+	Type            string    `json:"type"`
+	AmountType      string    `json:"amount_type"`
+	Amount          float64   `json:"amount"`
+	Multiplier      float64   `json:"multiplier,omitempty"`
+	IncomeSourceID  *string   `json:"income_source_id,omitempty"`
+	Date            string    `json:"date"`
+	UserPlatformID  *string   `json:"user_platform_id,omitempty"`
+	PocketID        *string   `json:"pocket_id,omitempty"`
+	CategoryID      *string   `json:"category_id,omitempty"`
+	FireAllocations bool      `json:"fire_allocations"`
+	Status          string    `json:"status"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
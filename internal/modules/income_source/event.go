@@ -0,0 +1,209 @@
+package income_source
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/HasanNugroho/coin-be/internal/core/utils"
+	"github.com/HasanNugroho/coin-be/internal/modules/holiday"
+	"github.com/HasanNugroho/coin-be/internal/modules/income_source/dto"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func (s *Service) CreateIncomeEvent(ctx context.Context, userID string, req *dto.CreateIncomeEventRequest) (*IncomeEvent, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, errors.New("invalid user id")
+	}
+
+	event := &IncomeEvent{
+		UserID:          userObjID,
+		Name:            req.Name,
+		Type:            req.Type,
+		AmountType:      req.AmountType,
+		Amount:          req.Amount,
+		Multiplier:      req.Multiplier,
+		Date:            req.Date,
+		FireAllocations: req.FireAllocations,
+		Status:          EventStatusScheduled,
+	}
+
+	if err := s.applyEventTargets(ctx, event, req.IncomeSourceID, req.UserPlatformID, req.PocketID, req.CategoryID); err != nil {
+		return nil, err
+	}
+
+	if err := validateIncomeEvent(event); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.CreateIncomeEvent(ctx, event); err != nil {
+		return nil, err
+	}
+
+	return event, nil
+}
+
+func (s *Service) GetIncomeEventByID(ctx context.Context, userID string, eventID string) (*IncomeEvent, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, errors.New("invalid user id")
+	}
+
+	eventObjID, err := primitive.ObjectIDFromHex(eventID)
+	if err != nil {
+		return nil, errors.New("invalid income event id")
+	}
+
+	event, err := s.repo.GetIncomeEventByID(ctx, eventObjID)
+	if err != nil {
+		return nil, err
+	}
+
+	if event.UserID != userObjID {
+		return nil, errors.New("unauthorized")
+	}
+
+	return event, nil
+}
+
+func (s *Service) ListIncomeEvents(ctx context.Context, userID string) ([]*IncomeEvent, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, errors.New("invalid user id")
+	}
+
+	return s.repo.GetIncomeEventsByUserID(ctx, userObjID)
+}
+
+// UpdateIncomeEvent updates an income event that has not been paid yet
+func (s *Service) UpdateIncomeEvent(ctx context.Context, userID string, eventID string, req *dto.UpdateIncomeEventRequest) (*IncomeEvent, error) {
+	event, err := s.GetIncomeEventByID(ctx, userID, eventID)
+	if err != nil {
+		return nil, err
+	}
+
+	if event.Status != EventStatusScheduled {
+		return nil, errors.New("only scheduled income events can be updated")
+	}
+
+	if req.Name != "" {
+		event.Name = req.Name
+	}
+	if req.Type != "" {
+		event.Type = req.Type
+	}
+	if req.AmountType != "" {
+		event.AmountType = req.AmountType
+	}
+	if req.Amount != nil {
+		event.Amount = *req.Amount
+	}
+	if req.Multiplier != nil {
+		event.Multiplier = *req.Multiplier
+	}
+	if req.Date != "" {
+		event.Date = req.Date
+	}
+	if req.FireAllocations != nil {
+		event.FireAllocations = *req.FireAllocations
+	}
+
+	if err := s.applyEventTargets(ctx, event, req.IncomeSourceID, req.UserPlatformID, req.PocketID, req.CategoryID); err != nil {
+		return nil, err
+	}
+
+	if err := validateIncomeEvent(event); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.UpdateIncomeEvent(ctx, event.ID, event); err != nil {
+		return nil, err
+	}
+
+	return event, nil
+}
+
+// DeleteIncomeEvent cancels an income event. Paid events are kept, their income has been credited.
+func (s *Service) DeleteIncomeEvent(ctx context.Context, userID string, eventID string) error {
+	event, err := s.GetIncomeEventByID(ctx, userID, eventID)
+	if err != nil {
+		return err
+	}
+
+	if event.Status == EventStatusPaid {
+		return errors.New("paid income events cannot be deleted")
+	}
+
+	return s.repo.DeleteIncomeEvent(ctx, event.ID)
+}
+
+// applyEventTargets sets the salary, platform, pocket and category of an income event when they are given,
+// checking that they belong to the user
+func (s *Service) applyEventTargets(ctx context.Context, event *IncomeEvent, incomeSourceID, userPlatformID, pocketID, categoryID string) error {
+	if incomeSourceID != "" {
+		sourceObjID, err := primitive.ObjectIDFromHex(incomeSourceID)
+		if err != nil {
+			return errors.New("invalid income source id")
+		}
+
+		source, err := s.repo.GetIncomeSourceByID(ctx, sourceObjID)
+		if err != nil {
+			return err
+		}
+
+		if source.UserID != event.UserID {
+			return errors.New("unauthorized: income source does not belong to user")
+		}
+
+		event.IncomeSourceID = &sourceObjID
+	}
+
+	targets, err := s.resolveTargets(ctx, event.UserID, userPlatformID, pocketID, categoryID)
+	if err != nil {
+		return err
+	}
+
+	if targets.userPlatformID != nil {
+		event.UserPlatformID = targets.userPlatformID
+	}
+	if targets.pocketID != nil {
+		event.PocketID = targets.pocketID
+	}
+	if targets.categoryID != nil {
+		event.CategoryID = targets.categoryID
+	}
+	return nil
+}
+
+// validateIncomeEvent checks that the event has an amount for its amount type and is not dated in the past
+func validateIncomeEvent(event *IncomeEvent) error {
+	switch event.AmountType {
+	case AmountTypeFixed:
+		if event.Amount <= 0 {
+			return errors.New("amount is required for a fixed income event")
+		}
+		event.Multiplier = 0
+	case AmountTypeSalaryMultiple:
+		if event.Multiplier <= 0 {
+			return errors.New("multiplier is required for a salary multiple income event")
+		}
+		event.Amount = 0
+	default:
+		return errors.New("invalid amount type")
+	}
+
+	loc := utils.GetJakartaLocation()
+	date, err := time.ParseInLocation(holiday.DateLayout, event.Date, loc)
+	if err != nil {
+		return errors.New("invalid date format, expected YYYY-MM-DD")
+	}
+
+	now := time.Now().In(loc)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	if date.Before(today) {
+		return errors.New("income event date cannot be in the past")
+	}
+
+	return nil
+}
@@ -0,0 +1,74 @@
+package income_source
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// IncomeSource is a recurring income of a user, such as a main job, a freelance retainer or rent. The payroll
+// cron credits every active source with auto input on its pay dates.
+type IncomeSource struct {
+	ID                  primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	UserID              primitive.ObjectID  `bson:"user_id" json:"user_id"`
+	Name                string              `bson:"name" json:"name"`
+	Amount              float64             `bson:"amount" json:"amount"`
+	Cycle               string              `bson:"cycle" json:"cycle" enums:"daily,weekly,monthly"`
+	PayDay              int                 `bson:"pay_day" json:"pay_day"`                                                                                                // day of month for monthly, 1=Monday..7=Sunday for weekly
+	WorkingDaysOnly     bool                `bson:"working_days_only" json:"working_days_only"`                                                                            // daily cycle pays on business days only
+	PayDayAdjustment    string              `bson:"pay_day_adjustment,omitempty" json:"pay_day_adjustment,omitempty" enums:"NONE,PREVIOUS_BUSINESS_DAY,NEXT_BUSINESS_DAY"` // moves a pay date on a weekend or holiday
+	UserPlatformID      *primitive.ObjectID `bson:"user_platform_id,omitempty" json:"user_platform_id,omitempty"`                                                          // default user platform when null
+	PocketID            *primitive.ObjectID `bson:"pocket_id,omitempty" json:"pocket_id,omitempty"`                                                                        // main pocket when null
+	CategoryID          *primitive.ObjectID `bson:"category_id,omitempty" json:"category_id,omitempty"`
+	AutoInput           bool                `bson:"auto_input" json:"auto_input"`
+	IsActive            bool                `bson:"is_active" json:"is_active"`
+	MigratedFromProfile bool                `bson:"migrated_from_profile,omitempty" json:"-"` // created from the salary fields of the user profile
+	CreatedAt           time.Time           `bson:"created_at" json:"created_at"`
+	UpdatedAt           time.Time           `bson:"updated_at" json:"updated_at"`
+	DeletedAt           *time.Time          `bson:"deleted_at,omitempty" json:"deleted_at,omitempty"`
+}
+
+// MigratedSourceName names the income source created from a profile's salary fields
+const MigratedSourceName = "Gaji"
+
+// IncomeEvent is a one-off income on a set date, such as THR before Lebaran or an annual bonus. The payroll cron
+// credits it once on its date.
+type IncomeEvent struct {
+	ID              primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	UserID          primitive.ObjectID  `bson:"user_id" json:"user_id"`
+	Name            string              `bson:"name" json:"name"`
+	Type            string              `bson:"type" json:"type" enums:"THR,BONUS,OTHER"`
+	AmountType      string              `bson:"amount_type" json:"amount_type" enums:"FIXED,SALARY_MULTIPLE"`
+	Amount          float64             `bson:"amount" json:"amount"`                                         // fixed amount, or the amount credited once a salary multiple is paid
+	Multiplier      float64             `bson:"multiplier,omitempty" json:"multiplier,omitempty"`             // times the base salary for SALARY_MULTIPLE
+	IncomeSourceID  *primitive.ObjectID `bson:"income_source_id,omitempty" json:"income_source_id,omitempty"` // salary a multiple is taken of, the migrated salary when null
+	Date            string              `bson:"date" json:"date"`                                             // YYYY-MM-DD
+	UserPlatformID  *primitive.ObjectID `bson:"user_platform_id,omitempty" json:"user_platform_id,omitempty"` // default user platform when null
+	PocketID        *primitive.ObjectID `bson:"pocket_id,omitempty" json:"pocket_id,omitempty"`               // main pocket when null
+	CategoryID      *primitive.ObjectID `bson:"category_id,omitempty" json:"category_id,omitempty"`
+	FireAllocations bool                `bson:"fire_allocations" json:"fire_allocations"` // run income-triggered allocations on the credited income
+	Status          string              `bson:"status" json:"status" enums:"SCHEDULED,PAID,FAILED"`
+	CreatedAt       time.Time           `bson:"created_at" json:"created_at"`
+	UpdatedAt       time.Time           `bson:"updated_at" json:"updated_at"`
+	DeletedAt       *time.Time          `bson:"deleted_at,omitempty" json:"deleted_at,omitempty"`
+}
+
+// IncomeEvent types
+const (
+	EventTypeTHR   = "THR"
+	EventTypeBonus = "BONUS"
+	EventTypeOther = "OTHER"
+)
+
+// IncomeEvent amount types
+const (
+	AmountTypeFixed          = "FIXED"
+	AmountTypeSalaryMultiple = "SALARY_MULTIPLE"
+)
+
+// IncomeEvent statuses
+const (
+	EventStatusScheduled = "SCHEDULED"
+	EventStatusPaid      = "PAID"
+	EventStatusFailed    = "FAILED"
+)
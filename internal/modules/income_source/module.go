@@ -0,0 +1,48 @@
+package income_source
+
+import (
+	"context"
+
+	"github.com/HasanNugroho/coin-be/internal/core/config"
+	"github.com/HasanNugroho/coin-be/internal/modules/pocket"
+	"github.com/HasanNugroho/coin-be/internal/modules/user_category"
+	"github.com/HasanNugroho/coin-be/internal/modules/user_platform"
+	"github.com/sarulabs/di/v2"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func Register(builder *di.Builder) {
+	builder.Add(di.Def{
+		Name: "incomeSourceRepository",
+		Build: func(ctn di.Container) (interface{}, error) {
+			cfg := ctn.Get("config").(*config.Config)
+			client := ctn.Get("mongo").(*mongo.Client)
+			repo := NewRepository(client.Database(cfg.MongoDB))
+
+			if err := repo.EnsureIndexes(context.Background()); err != nil {
+				return nil, err
+			}
+
+			return repo, nil
+		},
+	})
+
+	builder.Add(di.Def{
+		Name: "incomeSourceService",
+		Build: func(ctn di.Container) (interface{}, error) {
+			repo := ctn.Get("incomeSourceRepository").(*Repository)
+			pocketRepo := ctn.Get("pocketRepository").(*pocket.Repository)
+			userPlatformRepo := ctn.Get("userPlatformRepository").(*user_platform.UserPlatformRepository)
+			categoryRepo := ctn.Get("userCategoryRepository").(*user_category.Repository)
+			return NewService(repo, pocketRepo, userPlatformRepo, categoryRepo), nil
+		},
+	})
+
+	builder.Add(di.Def{
+		Name: "incomeSourceController",
+		Build: func(ctn di.Container) (interface{}, error) {
+			service := ctn.Get("incomeSourceService").(*Service)
+			return NewController(service), nil
+		},
+	})
+}
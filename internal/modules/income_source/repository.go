@@ -0,0 +1,463 @@
+package income_source
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/HasanNugroho/coin-be/internal/modules/holiday"
+	"github.com/HasanNugroho/coin-be/internal/modules/user"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type Repository struct {
+	incomeSources *mongo.Collection
+	incomeEvents  *mongo.Collection
+	profiles      *mongo.Collection
+}
+
+func NewRepository(db *mongo.Database) *Repository {
+	return &Repository{
+		incomeSources: db.Collection("income_sources"),
+		incomeEvents:  db.Collection("income_events"),
+		profiles:      db.Collection("user_profiles"),
+	}
+}
+
+func (r *Repository) CreateIncomeSource(ctx context.Context, source *IncomeSource) error {
+	source.ID = primitive.NewObjectID()
+	source.CreatedAt = time.Now()
+	source.UpdatedAt = time.Now()
+	_, err := r.incomeSources.InsertOne(ctx, source)
+	return err
+}
+
+func (r *Repository) GetIncomeSourceByID(ctx context.Context, id primitive.ObjectID) (*IncomeSource, error) {
+	var source IncomeSource
+	err := r.incomeSources.FindOne(ctx, bson.M{"_id": id, "deleted_at": nil}).Decode(&source)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.New("income source not found")
+		}
+		return nil, err
+	}
+	return &source, nil
+}
+
+func (r *Repository) GetIncomeSourcesByUserID(ctx context.Context, userID primitive.ObjectID) ([]*IncomeSource, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+	cursor, err := r.incomeSources.Find(ctx, bson.M{"user_id": userID, "deleted_at": nil}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var sources []*IncomeSource
+	if err = cursor.All(ctx, &sources); err != nil {
+		return nil, err
+	}
+	return sources, nil
+}
+
+func (r *Repository) UpdateIncomeSource(ctx context.Context, id primitive.ObjectID, source *IncomeSource) error {
+	source.UpdatedAt = time.Now()
+	result, err := r.incomeSources.UpdateOne(
+		ctx,
+		bson.M{"_id": id, "deleted_at": nil},
+		bson.M{"$set": source},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errors.New("income source not found")
+	}
+	return nil
+}
+
+func (r *Repository) DeleteIncomeSource(ctx context.Context, id primitive.ObjectID) error {
+	now := time.Now()
+	result, err := r.incomeSources.UpdateOne(
+		ctx,
+		bson.M{"_id": id, "deleted_at": nil},
+		bson.M{
+			"$set": bson.M{
+				"deleted_at": now,
+				"updated_at": now,
+			},
+		},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errors.New("income source not found")
+	}
+	return nil
+}
+
+// GetDueIncomeSources returns the active auto-input sources of active users that pay on date, with pay dates
+// on weekends and holidays moved by each source's adjustment policy
+func (r *Repository) GetDueIncomeSources(ctx context.Context, date time.Time, cal *holiday.Calendar) ([]*IncomeSource, error) {
+	daily := bson.D{{Key: "cycle", Value: user.SalaryCycleDaily}}
+	if !cal.IsBusinessDay(date) {
+		daily = append(daily, bson.E{Key: "working_days_only", Value: bson.D{{Key: "$ne", Value: true}}})
+	}
+
+	cycles := bson.A{daily}
+	for _, cycle := range []string{user.SalaryCycleMonthly, user.SalaryCycleWeekly} {
+		for _, adjustment := range holiday.Adjustments {
+			due := DuePayDays(cycle, adjustment, date, cal)
+			if len(due) == 0 {
+				continue
+			}
+
+			payDays := make([]int, 0, len(due))
+			for payDay := range due {
+				payDays = append(payDays, payDay)
+			}
+
+			// Sources created before adjustments were added have none and are not moved
+			adjustments := bson.A{adjustment}
+			if adjustment == holiday.AdjustNone {
+				adjustments = append(adjustments, "", nil)
+			}
+
+			cycles = append(cycles, bson.D{
+				{Key: "cycle", Value: cycle},
+				{Key: "pay_day_adjustment", Value: bson.D{{Key: "$in", Value: adjustments}}},
+				{Key: "pay_day", Value: bson.D{{Key: "$in", Value: payDays}}},
+			})
+		}
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.D{
+			{Key: "$or", Value: cycles},
+			{Key: "auto_input", Value: true},
+			{Key: "is_active", Value: true},
+			{Key: "amount", Value: bson.D{{Key: "$gt", Value: 0}}},
+			{Key: "deleted_at", Value: nil},
+		}}},
+		{{Key: "$lookup", Value: bson.D{
+			{Key: "from", Value: "users"},
+			{Key: "localField", Value: "user_id"},
+			{Key: "foreignField", Value: "_id"},
+			{Key: "as", Value: "user"},
+		}}},
+		{{Key: "$match", Value: bson.D{
+			{Key: "user.is_active", Value: true},
+		}}},
+		{{Key: "$unset", Value: "user"}},
+	}
+
+	cursor, err := r.incomeSources.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	sources := make([]*IncomeSource, 0)
+	if err := cursor.All(ctx, &sources); err != nil {
+		return nil, err
+	}
+	return sources, nil
+}
+
+// GetUnpayableIncomeSources returns the active auto-input sources no pay date ever matches: a cycle no longer
+// supported, such as biweekly, or a pay day outside its cycle
+func (r *Repository) GetUnpayableIncomeSources(ctx context.Context) ([]*IncomeSource, error) {
+	cursor, err := r.incomeSources.Find(ctx, bson.M{
+		"auto_input": true,
+		"is_active":  true,
+		"deleted_at": nil,
+		"$or": bson.A{
+			bson.M{"cycle": bson.M{"$nin": []string{user.SalaryCycleDaily, user.SalaryCycleWeekly, user.SalaryCycleMonthly}}},
+			bson.M{"cycle": user.SalaryCycleWeekly, "pay_day": bson.M{"$not": bson.M{"$gte": 1, "$lte": 7}}},
+			bson.M{"cycle": user.SalaryCycleMonthly, "pay_day": bson.M{"$not": bson.M{"$gte": 1, "$lte": 31}}},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	sources := make([]*IncomeSource, 0)
+	if err := cursor.All(ctx, &sources); err != nil {
+		return nil, err
+	}
+	return sources, nil
+}
+
+// GetMonthlyPayDay returns the pay day of the user's monthly salary, taken from their active monthly income
+// source paid in automatically: the one migrated from the profile, else the largest. It is 0 when there is none.
+func (r *Repository) GetMonthlyPayDay(ctx context.Context, userID primitive.ObjectID) (int, error) {
+	var source IncomeSource
+	opts := options.FindOne().SetSort(bson.D{{Key: "migrated_from_profile", Value: -1}, {Key: "amount", Value: -1}})
+	err := r.incomeSources.FindOne(ctx, bson.M{
+		"user_id":    userID,
+		"cycle":      user.SalaryCycleMonthly,
+		"auto_input": true,
+		"is_active":  true,
+		"deleted_at": nil,
+	}, opts).Decode(&source)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return 0, nil
+		}
+		return 0, err
+	}
+	return source.PayDay, nil
+}
+
+// GetFinancialMonthStartDay returns the day the user's financial months start on: the day chosen on their profile,
+// else the pay day of their monthly income source, else the 1st
+func (r *Repository) GetFinancialMonthStartDay(ctx context.Context, userID primitive.ObjectID) (int, error) {
+	var profile user.UserProfile
+	if err := r.profiles.FindOne(ctx, bson.M{"user_id": userID}).Decode(&profile); err != nil && err != mongo.ErrNoDocuments {
+		return 0, err
+	}
+
+	payDay, err := r.GetMonthlyPayDay(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+	return profile.FinancialMonthStartDay(payDay), nil
+}
+
+// GetMigratedIncomeSource returns the source created from the user's profile salary, or nil when there is none
+func (r *Repository) GetMigratedIncomeSource(ctx context.Context, userID primitive.ObjectID) (*IncomeSource, error) {
+	var source IncomeSource
+	err := r.incomeSources.FindOne(ctx, bson.M{
+		"user_id":               userID,
+		"migrated_from_profile": true,
+		"deleted_at":            nil,
+	}).Decode(&source)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &source, nil
+}
+
+func (r *Repository) CreateIncomeEvent(ctx context.Context, event *IncomeEvent) error {
+	event.ID = primitive.NewObjectID()
+	event.CreatedAt = time.Now()
+	event.UpdatedAt = time.Now()
+	_, err := r.incomeEvents.InsertOne(ctx, event)
+	return err
+}
+
+func (r *Repository) GetIncomeEventByID(ctx context.Context, id primitive.ObjectID) (*IncomeEvent, error) {
+	var event IncomeEvent
+	err := r.incomeEvents.FindOne(ctx, bson.M{"_id": id, "deleted_at": nil}).Decode(&event)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.New("income event not found")
+		}
+		return nil, err
+	}
+	return &event, nil
+}
+
+// GetIncomeEventsByUserID returns the user's income events by date, latest first
+func (r *Repository) GetIncomeEventsByUserID(ctx context.Context, userID primitive.ObjectID) ([]*IncomeEvent, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "date", Value: -1}, {Key: "created_at", Value: -1}})
+	cursor, err := r.incomeEvents.Find(ctx, bson.M{"user_id": userID, "deleted_at": nil}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	events := make([]*IncomeEvent, 0)
+	if err = cursor.All(ctx, &events); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+func (r *Repository) UpdateIncomeEvent(ctx context.Context, id primitive.ObjectID, event *IncomeEvent) error {
+	event.UpdatedAt = time.Now()
+	result, err := r.incomeEvents.UpdateOne(
+		ctx,
+		bson.M{"_id": id, "deleted_at": nil},
+		bson.M{"$set": event},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errors.New("income event not found")
+	}
+	return nil
+}
+
+// SetIncomeEventOutcome stores the status of an income event after a payroll run, with the amount it credited
+// when known
+func (r *Repository) SetIncomeEventOutcome(ctx context.Context, id primitive.ObjectID, status string, amount float64) error {
+	set := bson.M{
+		"status":     status,
+		"updated_at": time.Now(),
+	}
+	if amount > 0 {
+		set["amount"] = amount
+	}
+
+	_, err := r.incomeEvents.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": set})
+	return err
+}
+
+func (r *Repository) DeleteIncomeEvent(ctx context.Context, id primitive.ObjectID) error {
+	now := time.Now()
+	result, err := r.incomeEvents.UpdateOne(
+		ctx,
+		bson.M{"_id": id, "deleted_at": nil},
+		bson.M{
+			"$set": bson.M{
+				"deleted_at": now,
+				"updated_at": now,
+			},
+		},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errors.New("income event not found")
+	}
+	return nil
+}
+
+// GetDueIncomeEvents returns the scheduled income events of active users dated on or before date (YYYY-MM-DD),
+// so events missed while the cron was down are paid on its next run
+func (r *Repository) GetDueIncomeEvents(ctx context.Context, date string) ([]*IncomeEvent, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.D{
+			{Key: "status", Value: EventStatusScheduled},
+			{Key: "date", Value: bson.D{{Key: "$lte", Value: date}}},
+			{Key: "deleted_at", Value: nil},
+		}}},
+		{{Key: "$lookup", Value: bson.D{
+			{Key: "from", Value: "users"},
+			{Key: "localField", Value: "user_id"},
+			{Key: "foreignField", Value: "_id"},
+			{Key: "as", Value: "user"},
+		}}},
+		{{Key: "$match", Value: bson.D{
+			{Key: "user.is_active", Value: true},
+		}}},
+		{{Key: "$unset", Value: "user"}},
+		{{Key: "$sort", Value: bson.D{{Key: "date", Value: 1}}}},
+	}
+
+	cursor, err := r.incomeEvents.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	events := make([]*IncomeEvent, 0)
+	if err := cursor.All(ctx, &events); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// GetUnmigratedProfiles returns profiles with a salary that has not been moved into an income source yet
+func (r *Repository) GetUnmigratedProfiles(ctx context.Context) ([]*user.UserProfile, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.D{
+			{Key: "base_salary", Value: bson.D{{Key: "$gt", Value: 0}}},
+		}}},
+		{{Key: "$lookup", Value: bson.D{
+			{Key: "from", Value: "income_sources"},
+			{Key: "let", Value: bson.D{{Key: "user_id", Value: "$user_id"}}},
+			{Key: "pipeline", Value: mongo.Pipeline{
+				{{Key: "$match", Value: bson.D{
+					{Key: "$expr", Value: bson.D{{Key: "$eq", Value: bson.A{"$user_id", "$$user_id"}}}},
+					{Key: "migrated_from_profile", Value: true},
+				}}},
+				{{Key: "$limit", Value: 1}},
+			}},
+			{Key: "as", Value: "migrated"},
+		}}},
+		{{Key: "$match", Value: bson.D{
+			{Key: "migrated", Value: bson.D{{Key: "$size", Value: 0}}},
+		}}},
+		{{Key: "$unset", Value: "migrated"}},
+	}
+
+	cursor, err := r.profiles.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	profiles := make([]*user.UserProfile, 0)
+	if err := cursor.All(ctx, &profiles); err != nil {
+		return nil, err
+	}
+	return profiles, nil
+}
+
+func (r *Repository) EnsureIndexes(ctx context.Context) error {
+	indexes := []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				{Key: "user_id", Value: 1},
+				{Key: "created_at", Value: 1},
+			},
+			Options: options.Index().
+				SetName("idx_income_sources_user_created"),
+		},
+		{
+			Keys: bson.D{
+				{Key: "cycle", Value: 1},
+				{Key: "pay_day", Value: 1},
+				{Key: "auto_input", Value: 1},
+				{Key: "is_active", Value: 1},
+			},
+			Options: options.Index().
+				SetName("idx_income_sources_schedule"),
+		},
+		{
+			// At most one source migrated from a profile per user
+			Keys: bson.D{{Key: "user_id", Value: 1}},
+			Options: options.Index().
+				SetName("idx_income_sources_user_migrated_unique").
+				SetUnique(true).
+				SetPartialFilterExpression(bson.M{"migrated_from_profile": true}),
+		},
+	}
+
+	if _, err := r.incomeSources.Indexes().CreateMany(ctx, indexes); err != nil {
+		return err
+	}
+
+	eventIndexes := []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				{Key: "user_id", Value: 1},
+				{Key: "date", Value: -1},
+			},
+			Options: options.Index().
+				SetName("idx_income_events_user_date"),
+		},
+		{
+			Keys: bson.D{
+				{Key: "status", Value: 1},
+				{Key: "date", Value: 1},
+			},
+			Options: options.Index().
+				SetName("idx_income_events_status_date"),
+		},
+	}
+
+	_, err := r.incomeEvents.Indexes().CreateMany(ctx, eventIndexes)
+	return err
+}
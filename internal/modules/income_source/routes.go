@@ -0,0 +1,22 @@
+package income_source
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterRoutes(r *gin.RouterGroup, controller *Controller) {
+	// User routes
+	protected := r.Group("")
+	{
+		protected.POST("", controller.CreateIncomeSource)
+		protected.GET("", controller.ListIncomeSources)
+		protected.POST("/events", controller.CreateIncomeEvent)
+		protected.GET("/events", controller.ListIncomeEvents)
+		protected.GET("/events/:id", controller.GetIncomeEvent)
+		protected.PUT("/events/:id", controller.UpdateIncomeEvent)
+		protected.DELETE("/events/:id", controller.DeleteIncomeEvent)
+		protected.GET("/:id", controller.GetIncomeSource)
+		protected.PUT("/:id", controller.UpdateIncomeSource)
+		protected.DELETE("/:id", controller.DeleteIncomeSource)
+	}
+}
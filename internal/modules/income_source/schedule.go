@@ -0,0 +1,85 @@
+package income_source
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/HasanNugroho/coin-be/internal/modules/holiday"
+	"github.com/HasanNugroho/coin-be/internal/modules/user"
+)
+
+// NormalizeCycle returns a pay cycle, monthly when none is set. Cycles no longer supported, such as biweekly, are
+// kept as they are so schedule validation rejects them instead of paying them monthly.
+func NormalizeCycle(cycle string) string {
+	if cycle == "" {
+		return user.SalaryCycleMonthly
+	}
+	return cycle
+}
+
+// weeklyPayDate returns the pay date of a weekly pay day (1=Monday..7=Sunday) in the ISO week of t
+func weeklyPayDate(weekday int, t time.Time) time.Time {
+	monday := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location()).AddDate(0, 0, -(isoWeekday(t) - 1))
+	return monday.AddDate(0, 0, weekday-1)
+}
+
+// isoWeekday returns the weekday of t with Monday as 1 and Sunday as 7
+func isoWeekday(t time.Time) int {
+	if t.Weekday() == time.Sunday {
+		return 7
+	}
+	return int(t.Weekday())
+}
+
+// DuePayDays returns the pay days of a cycle that pay on date once their pay date is moved by the adjustment
+// policy, each with the pay date it was scheduled for: days of the month for monthly, weekdays for weekly
+func DuePayDays(cycle string, adjustment string, date time.Time, cal *holiday.Calendar) map[int]time.Time {
+	if cycle != user.SalaryCycleWeekly {
+		return cal.DueMonthDays(date, adjustment)
+	}
+
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
+	days := make(map[int]time.Time)
+	for _, offset := range []int{-7, 0, 7} {
+		week := day.AddDate(0, 0, offset)
+		for payDay := 1; payDay <= 7; payDay++ {
+			scheduled := weeklyPayDate(payDay, week)
+			if cal.Adjust(scheduled, adjustment).Equal(day) {
+				days[payDay] = scheduled
+			}
+		}
+	}
+	return days
+}
+
+// ScheduledPayDate returns the pay date a source paying on date was scheduled for, which decides its pay period.
+// It reports false when the source does not pay on date.
+func ScheduledPayDate(source *IncomeSource, date time.Time, cal *holiday.Calendar) (time.Time, bool) {
+	cycle := NormalizeCycle(source.Cycle)
+	if !user.IsValidSalaryDay(cycle, source.PayDay) {
+		return time.Time{}, false
+	}
+	if cycle == user.SalaryCycleDaily {
+		if source.WorkingDaysOnly && !cal.IsBusinessDay(date) {
+			return time.Time{}, false
+		}
+		return date, true
+	}
+
+	scheduled, ok := DuePayDays(cycle, source.PayDayAdjustment, date, cal)[source.PayDay]
+	return scheduled, ok
+}
+
+// PeriodKey identifies the pay period of date in a cycle. A source is paid at most once per period, so changing
+// its pay day within a month or week never pays twice.
+func PeriodKey(cycle string, date time.Time) string {
+	switch cycle {
+	case user.SalaryCycleDaily:
+		return date.Format("2006-01-02")
+	case user.SalaryCycleWeekly:
+		year, week := date.ISOWeek()
+		return fmt.Sprintf("%04d-W%02d", year, week)
+	default:
+		return date.Format("2006-01")
+	}
+}
@@ -0,0 +1,303 @@
+package income_source
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"github.com/HasanNugroho/coin-be/internal/modules/holiday"
+	"github.com/HasanNugroho/coin-be/internal/modules/income_source/dto"
+	"github.com/HasanNugroho/coin-be/internal/modules/pocket"
+	"github.com/HasanNugroho/coin-be/internal/modules/user"
+	"github.com/HasanNugroho/coin-be/internal/modules/user_category"
+	"github.com/HasanNugroho/coin-be/internal/modules/user_platform"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type Service struct {
+	repo             *Repository
+	pocketRepo       *pocket.Repository
+	userPlatformRepo *user_platform.UserPlatformRepository
+	categoryRepo     *user_category.Repository
+}
+
+func NewService(r *Repository, pr *pocket.Repository, upr *user_platform.UserPlatformRepository, cr *user_category.Repository) *Service {
+	return &Service{
+		repo:             r,
+		pocketRepo:       pr,
+		userPlatformRepo: upr,
+		categoryRepo:     cr,
+	}
+}
+
+func (s *Service) CreateIncomeSource(ctx context.Context, userID string, req *dto.CreateIncomeSourceRequest) (*IncomeSource, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, errors.New("invalid user id")
+	}
+
+	source := &IncomeSource{
+		UserID:          userObjID,
+		Name:            req.Name,
+		Amount:          req.Amount,
+		Cycle:           req.Cycle,
+		PayDay:          req.PayDay,
+		WorkingDaysOnly: req.WorkingDaysOnly,
+		AutoInput:       req.AutoInput,
+		IsActive:        true,
+	}
+
+	// Employers pay on the previous working day when payday is a weekend or holiday
+	source.PayDayAdjustment = holiday.AdjustPreviousBusinessDay
+	if req.PayDayAdjustment != "" {
+		source.PayDayAdjustment = req.PayDayAdjustment
+	}
+
+	if err := s.applyTargets(ctx, source, req.UserPlatformID, req.PocketID, req.CategoryID); err != nil {
+		return nil, err
+	}
+
+	if err := validateSchedule(source); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.CreateIncomeSource(ctx, source); err != nil {
+		return nil, err
+	}
+
+	return source, nil
+}
+
+func (s *Service) GetIncomeSourceByID(ctx context.Context, userID string, sourceID string) (*IncomeSource, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, errors.New("invalid user id")
+	}
+
+	sourceObjID, err := primitive.ObjectIDFromHex(sourceID)
+	if err != nil {
+		return nil, errors.New("invalid income source id")
+	}
+
+	source, err := s.repo.GetIncomeSourceByID(ctx, sourceObjID)
+	if err != nil {
+		return nil, err
+	}
+
+	if source.UserID != userObjID {
+		return nil, errors.New("unauthorized")
+	}
+
+	return source, nil
+}
+
+func (s *Service) ListIncomeSources(ctx context.Context, userID string) ([]*IncomeSource, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, errors.New("invalid user id")
+	}
+
+	return s.repo.GetIncomeSourcesByUserID(ctx, userObjID)
+}
+
+func (s *Service) UpdateIncomeSource(ctx context.Context, userID string, sourceID string, req *dto.UpdateIncomeSourceRequest) (*IncomeSource, error) {
+	source, err := s.GetIncomeSourceByID(ctx, userID, sourceID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != "" {
+		source.Name = req.Name
+	}
+	if req.Amount != nil {
+		source.Amount = *req.Amount
+	}
+	if req.Cycle != "" {
+		source.Cycle = req.Cycle
+	}
+	if req.PayDay != nil {
+		source.PayDay = *req.PayDay
+	}
+	if req.WorkingDaysOnly != nil {
+		source.WorkingDaysOnly = *req.WorkingDaysOnly
+	}
+	if req.PayDayAdjustment != "" {
+		source.PayDayAdjustment = req.PayDayAdjustment
+	}
+	if req.AutoInput != nil {
+		source.AutoInput = *req.AutoInput
+	}
+	if req.IsActive != nil {
+		source.IsActive = *req.IsActive
+	}
+
+	if err := s.applyTargets(ctx, source, req.UserPlatformID, req.PocketID, req.CategoryID); err != nil {
+		return nil, err
+	}
+
+	if err := validateSchedule(source); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.UpdateIncomeSource(ctx, source.ID, source); err != nil {
+		return nil, err
+	}
+
+	return source, nil
+}
+
+func (s *Service) DeleteIncomeSource(ctx context.Context, userID string, sourceID string) error {
+	source, err := s.GetIncomeSourceByID(ctx, userID, sourceID)
+	if err != nil {
+		return err
+	}
+
+	return s.repo.DeleteIncomeSource(ctx, source.ID)
+}
+
+// MigrateProfiles moves the salary of every profile that has one into an income source, once per user. The
+// migrated source pays like the profile did: into the default user platform and the main pocket. Profiles whose
+// salary schedule is no longer valid are logged and left for the user to fix.
+func (s *Service) MigrateProfiles(ctx context.Context) error {
+	profiles, err := s.repo.GetUnmigratedProfiles(ctx)
+	if err != nil {
+		return err
+	}
+
+	migrated := 0
+	for _, profile := range profiles {
+		// A retired cycle such as biweekly, or a weekly salary day past Sunday, is not paid as something else
+		if !user.IsValidSalaryDay(NormalizeCycle(profile.SalaryCycle), profile.SalaryDay) {
+			log.Printf("salary of user %s not migrated into an income source: salary cycle %q with salary day %d is not supported, the profile must be updated", profile.UserID.Hex(), profile.SalaryCycle, profile.SalaryDay)
+			continue
+		}
+
+		source := &IncomeSource{
+			UserID:              profile.UserID,
+			Name:                MigratedSourceName,
+			Amount:              profile.BaseSalary,
+			Cycle:               NormalizeCycle(profile.SalaryCycle),
+			PayDay:              profile.SalaryDay,
+			WorkingDaysOnly:     profile.SalaryWorkingDaysOnly,
+			AutoInput:           profile.AutoInputPayroll,
+			IsActive:            profile.IsActive,
+			MigratedFromProfile: true,
+		}
+
+		if err := s.repo.CreateIncomeSource(ctx, source); err != nil {
+			log.Printf("failed to migrate salary of user %s into an income source: %v", profile.UserID.Hex(), err)
+			continue
+		}
+		migrated++
+	}
+
+	if migrated > 0 {
+		log.Printf("migrated %d profile salaries into income sources", migrated)
+	}
+	return nil
+}
+
+// applyTargets sets the platform, pocket and category an income source pays into when they are given,
+// checking that they belong to the user
+func (s *Service) applyTargets(ctx context.Context, source *IncomeSource, userPlatformID, pocketID, categoryID string) error {
+	targets, err := s.resolveTargets(ctx, source.UserID, userPlatformID, pocketID, categoryID)
+	if err != nil {
+		return err
+	}
+
+	if targets.userPlatformID != nil {
+		source.UserPlatformID = targets.userPlatformID
+	}
+	if targets.pocketID != nil {
+		source.PocketID = targets.pocketID
+	}
+	if targets.categoryID != nil {
+		source.CategoryID = targets.categoryID
+	}
+	return nil
+}
+
+// incomeTargets are the platform, pocket and category an income is credited into, nil when not given
+type incomeTargets struct {
+	userPlatformID *primitive.ObjectID
+	pocketID       *primitive.ObjectID
+	categoryID     *primitive.ObjectID
+}
+
+// resolveTargets parses the given platform, pocket and category ids, checking that they belong to the user
+func (s *Service) resolveTargets(ctx context.Context, userID primitive.ObjectID, userPlatformID, pocketID, categoryID string) (*incomeTargets, error) {
+	targets := &incomeTargets{}
+
+	if userPlatformID != "" {
+		userPlatformObjID, err := primitive.ObjectIDFromHex(userPlatformID)
+		if err != nil {
+			return nil, errors.New("invalid user platform id")
+		}
+
+		userPlatform, err := s.userPlatformRepo.GetUserPlatformByID(ctx, userPlatformObjID)
+		if err != nil {
+			return nil, errors.New("user platform not found")
+		}
+
+		if userPlatform.UserID != userID {
+			return nil, errors.New("unauthorized: user platform does not belong to user")
+		}
+
+		if !userPlatform.IsActive {
+			return nil, errors.New("user platform is not active")
+		}
+
+		targets.userPlatformID = &userPlatformObjID
+	}
+
+	if pocketID != "" {
+		pocketObjID, err := primitive.ObjectIDFromHex(pocketID)
+		if err != nil {
+			return nil, errors.New("invalid pocket id")
+		}
+
+		pocket, err := s.pocketRepo.GetPocketByID(ctx, pocketObjID)
+		if err != nil {
+			return nil, errors.New("pocket not found")
+		}
+
+		if pocket.UserID != userID {
+			return nil, errors.New("unauthorized: pocket does not belong to user")
+		}
+
+		if !pocket.IsActive {
+			return nil, errors.New("pocket is not active")
+		}
+
+		targets.pocketID = &pocketObjID
+	}
+
+	if categoryID != "" {
+		categoryObjID, err := primitive.ObjectIDFromHex(categoryID)
+		if err != nil {
+			return nil, errors.New("invalid category id")
+		}
+
+		category, err := s.categoryRepo.FindByID(ctx, categoryObjID, userID)
+		if err != nil {
+			return nil, errors.New("category not found")
+		}
+
+		if category.TransactionType != nil && *category.TransactionType != user_category.TransactionIncome {
+			return nil, errors.New("category must be an income category")
+		}
+
+		targets.categoryID = &categoryObjID
+	}
+
+	return targets, nil
+}
+
+// validateSchedule checks that the pay day fits the cycle
+func validateSchedule(source *IncomeSource) error {
+	source.Cycle = NormalizeCycle(source.Cycle)
+	if !user.IsValidSalaryDay(source.Cycle, source.PayDay) {
+		return errors.New("invalid pay day for cycle")
+	}
+	return nil
+}
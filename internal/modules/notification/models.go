@@ -0,0 +1,10 @@
+package notification
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// Recipient holds the delivery addresses of a user
+type Recipient struct {
+	UserID     primitive.ObjectID
+	Email      string
+	TelegramID string
+}
@@ -0,0 +1,43 @@
+package notification
+
+import (
+	"log"
+
+	"github.com/HasanNugroho/coin-be/internal/core/config"
+	"github.com/HasanNugroho/coin-be/internal/core/utils"
+	"github.com/sarulabs/di/v2"
+	"go.mongodb.org/mongo-driver/mongo"
+	tele "gopkg.in/telebot.v4"
+)
+
+func Register(builder *di.Builder) {
+	builder.Add(di.Def{
+		Name: "notificationRepository",
+		Build: func(ctn di.Container) (interface{}, error) {
+			cfg := ctn.Get("config").(*config.Config)
+			client := ctn.Get("mongo").(*mongo.Client)
+			return NewRepository(client.Database(cfg.MongoDB)), nil
+		},
+	})
+
+	builder.Add(di.Def{
+		Name: "notificationService",
+		Build: func(ctn di.Container) (interface{}, error) {
+			cfg := ctn.Get("config").(*config.Config)
+			repo := ctn.Get("notificationRepository").(*Repository)
+
+			// The bot is only used to push messages, so it never polls nor calls getMe
+			var bot *tele.Bot
+			if cfg.TelegramToken != "" {
+				b, err := tele.NewBot(tele.Settings{Token: cfg.TelegramToken, Offline: true})
+				if err != nil {
+					log.Printf("failed to create telegram notifier: %v", err)
+				} else {
+					bot = b
+				}
+			}
+
+			return NewService(repo, utils.NewMailer(), bot), nil
+		},
+	})
+}
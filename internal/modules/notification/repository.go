@@ -0,0 +1,51 @@
+package notification
+
+import (
+	"context"
+	"errors"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type Repository struct {
+	users        *mongo.Collection
+	userProfiles *mongo.Collection
+}
+
+func NewRepository(db *mongo.Database) *Repository {
+	return &Repository{
+		users:        db.Collection("users"),
+		userProfiles: db.Collection("user_profiles"),
+	}
+}
+
+// GetRecipient resolves the email address and verified Telegram chat of a user
+func (r *Repository) GetRecipient(ctx context.Context, userID primitive.ObjectID) (*Recipient, error) {
+	var user struct {
+		Email string `bson:"email"`
+	}
+	if err := r.users.FindOne(ctx, bson.M{"_id": userID}).Decode(&user); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.New("user not found")
+		}
+		return nil, err
+	}
+
+	recipient := &Recipient{UserID: userID, Email: user.Email}
+
+	var profile struct {
+		TelegramId       string `bson:"telegram_id"`
+		TelegramVerified bool   `bson:"telegram_verified"`
+	}
+	err := r.userProfiles.FindOne(ctx, bson.M{"user_id": userID}).Decode(&profile)
+	if err != nil && err != mongo.ErrNoDocuments {
+		return nil, err
+	}
+	if profile.TelegramVerified {
+		recipient.TelegramID = profile.TelegramId
+	}
+
+	return recipient, nil
+}
@@ -0,0 +1,81 @@
+package notification
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"github.com/HasanNugroho/coin-be/internal/core/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	tele "gopkg.in/telebot.v4"
+)
+
+type Service struct {
+	repo   *Repository
+	mailer utils.Mailer
+	bot    *tele.Bot
+}
+
+// NewService creates a notification service. bot may be nil when Telegram delivery is not configured.
+func NewService(r *Repository, mailer utils.Mailer, bot *tele.Bot) *Service {
+	return &Service{
+		repo:   r,
+		mailer: mailer,
+		bot:    bot,
+	}
+}
+
+// Notify sends a message to the user by email and, when linked, by Telegram.
+// Delivery is attempted on every channel; the returned error joins the failures.
+func (s *Service) Notify(ctx context.Context, userID primitive.ObjectID, subject, message string) error {
+	recipient, err := s.repo.GetRecipient(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+
+	if recipient.Email != "" {
+		if err := s.mailer.Send(ctx, recipient.Email, subject, message); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if recipient.TelegramID != "" && s.bot != nil {
+		if err := s.sendTelegram(recipient.TelegramID, subject+"\n\n"+message); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (s *Service) sendTelegram(telegramID string, text string) error {
+	chatID, err := strconv.ParseInt(telegramID, 10, 64)
+	if err != nil {
+		return errors.New("invalid telegram id")
+	}
+
+	_, err = s.bot.Send(&tele.Chat{ID: chatID}, text)
+	return err
+}
+
+// FormatRupiah formats an amount the same way the Telegram bot displays it, e.g. "Rp. 1.500.000"
+func FormatRupiah(amount float64) string {
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+
+	str := strconv.FormatInt(int64(amount), 10)
+	n := len(str)
+	result := ""
+	for i, c := range str {
+		if i > 0 && (n-i)%3 == 0 {
+			result += "."
+		}
+		result += string(c)
+	}
+	return sign + "Rp. " + result
+}
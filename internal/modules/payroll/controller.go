@@ -0,0 +1,540 @@
+package payroll
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/HasanNugroho/coin-be/internal/core/utils"
+	"github.com/HasanNugroho/coin-be/internal/modules/payroll/dto"
+	"github.com/gin-gonic/gin"
+)
+
+type Controller struct {
+	service *Service
+}
+
+func NewController(s *Service) *Controller {
+	return &Controller{service: s}
+}
+
+// RerunPayroll godoc
+// @Summary Re-run failed payroll
+// @Description Credit the authenticated user's failed payroll of a date again. An income that already went through settles the record instead of crediting the salary twice.
+// @Tags Payroll
+// @Accept json
+// @Produce json
+// @Param request body dto.RerunPayrollRequest true "Payroll date and income source"
+// @Success 200 {object} map[string]interface{} "Payroll re-run"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Security BearerAuth
+// @Router /v1/payroll/records/rerun [post]
+func (c *Controller) RerunPayroll(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		resp := utils.NewErrorResponse(http.StatusUnauthorized, "unauthorized")
+		ctx.JSON(http.StatusUnauthorized, resp)
+		return
+	}
+
+	c.rerun(ctx, userID.(string))
+}
+
+// AdminRerunPayroll godoc
+// @Summary Re-run a user's failed payroll (Admin only)
+// @Description Credit a user's failed payroll of a date again. An income that already went through settles the record instead of crediting the salary twice.
+// @Tags Payroll
+// @Accept json
+// @Produce json
+// @Param user_id path string true "User ID"
+// @Param request body dto.RerunPayrollRequest true "Payroll date and income source"
+// @Success 200 {object} map[string]interface{} "Payroll re-run"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Security BearerAuth
+// @Router /v1/payroll/admin/users/{user_id}/records/rerun [post]
+func (c *Controller) AdminRerunPayroll(ctx *gin.Context) {
+	c.rerun(ctx, ctx.Param("user_id"))
+}
+
+func (c *Controller) rerun(ctx *gin.Context, userID string) {
+	var req dto.RerunPayrollRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		resp := utils.NewErrorResponse(http.StatusBadRequest, err.Error())
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	if err := utils.ValidateRequest(&req); err != nil {
+		resp := utils.NewErrorResponse(http.StatusBadRequest, err.Error())
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	record, err := c.service.RerunPayroll(ctx, userID, req.Date, req.IncomeSourceID, req.IncomeEventID)
+	if err != nil {
+		resp := utils.NewErrorResponse(http.StatusBadRequest, err.Error())
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	resp := utils.NewSuccessResponse("Payroll re-run", c.mapRecordToResponse(record))
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// ListIncomeSourceRecords godoc
+// @Summary List payroll history of an income source
+// @Description Get the payroll records of one of the authenticated user's income sources, newest first
+// @Tags Payroll
+// @Produce json
+// @Param income_source_id path string true "Income source ID"
+// @Param limit query int false "Number of records (max 100)" default(20)
+// @Success 200 {object} map[string]interface{} "Payroll records retrieved successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Security BearerAuth
+// @Router /v1/payroll/income-sources/{income_source_id}/records [get]
+func (c *Controller) ListIncomeSourceRecords(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		resp := utils.NewErrorResponse(http.StatusUnauthorized, "unauthorized")
+		ctx.JSON(http.StatusUnauthorized, resp)
+		return
+	}
+
+	limit, _ := strconv.ParseInt(ctx.DefaultQuery("limit", "20"), 10, 64)
+
+	records, err := c.service.ListIncomeSourceRecords(ctx, userID.(string), ctx.Param("income_source_id"), limit)
+	if err != nil {
+		resp := utils.NewErrorResponse(http.StatusBadRequest, err.Error())
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	responses := make([]*dto.PayrollRecordResponse, len(records))
+	for i, record := range records {
+		responses[i] = c.mapRecordToResponse(record)
+	}
+
+	resp := utils.NewSuccessResponse("Payroll records retrieved successfully", responses)
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// ListPayrollRecords godoc
+// @Summary List payroll records
+// @Description Get the authenticated user's credited and failed payroll, newest first
+// @Tags Payroll
+// @Produce json
+// @Param status query string false "Filter by status" Enums(SUCCESS, FAILED, PENDING)
+// @Param page query int false "Page number (default: 1)"
+// @Param page_size query int false "Page size (default: 10, max: 100)"
+// @Success 200 {object} map[string]interface{} "Payroll records retrieved successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Security BearerAuth
+// @Router /v1/payroll/records [get]
+func (c *Controller) ListPayrollRecords(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		resp := utils.NewErrorResponse(http.StatusUnauthorized, "unauthorized")
+		ctx.JSON(http.StatusUnauthorized, resp)
+		return
+	}
+
+	pagination := utils.ParsePaginationParams(ctx, 10)
+
+	records, total, err := c.service.ListPayrollRecords(ctx, userID.(string), ctx.Query("status"), pagination.Page, pagination.PageSize)
+	if err != nil {
+		resp := utils.NewErrorResponse(http.StatusBadRequest, err.Error())
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	responses := make([]*dto.PayrollRecordResponse, len(records))
+	for i, record := range records {
+		responses[i] = c.mapRecordToResponse(record)
+	}
+
+	meta := utils.CalculatePaginationMeta(total, pagination.Page, pagination.PageSize)
+	resp := utils.NewSuccessResponse("Payroll records retrieved successfully", utils.BuildPaginatedResponse(responses, meta))
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// AdminListRuns godoc
+// @Summary List payroll runs (Admin only)
+// @Description Get payroll runs by pay date, newest first, with their success, failure and skipped counts
+// @Tags Payroll
+// @Produce json
+// @Param start_date query string false "First pay date (YYYY-MM-DD)"
+// @Param end_date query string false "Last pay date (YYYY-MM-DD)"
+// @Param page query int false "Page number (default: 1)"
+// @Param page_size query int false "Page size (default: 10, max: 100)"
+// @Success 200 {object} map[string]interface{} "Payroll runs retrieved successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Security BearerAuth
+// @Router /v1/payroll/admin/runs [get]
+func (c *Controller) AdminListRuns(ctx *gin.Context) {
+	pagination := utils.ParsePaginationParams(ctx, 10)
+
+	runs, total, err := c.service.ListRuns(ctx, ctx.Query("start_date"), ctx.Query("end_date"), pagination.Page, pagination.PageSize)
+	if err != nil {
+		resp := utils.NewErrorResponse(http.StatusBadRequest, err.Error())
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	responses := make([]*dto.PayrollRunResponse, len(runs))
+	for i, run := range runs {
+		responses[i] = c.mapRunToResponse(run)
+	}
+
+	meta := utils.CalculatePaginationMeta(total, pagination.Page, pagination.PageSize)
+	resp := utils.NewSuccessResponse("Payroll runs retrieved successfully", utils.BuildPaginatedResponse(responses, meta))
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// AdminGetRun godoc
+// @Summary Get a payroll run (Admin only)
+// @Description Get a payroll run with the records it created and their current status counts
+// @Tags Payroll
+// @Produce json
+// @Param run_id path string true "Payroll run ID"
+// @Success 200 {object} map[string]interface{} "Payroll run retrieved successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Security BearerAuth
+// @Router /v1/payroll/admin/runs/{run_id} [get]
+func (c *Controller) AdminGetRun(ctx *gin.Context) {
+	run, records, counts, err := c.service.GetRun(ctx, ctx.Param("run_id"))
+	if err != nil {
+		resp := utils.NewErrorResponse(http.StatusBadRequest, err.Error())
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	recordResponses := make([]*dto.PayrollRecordResponse, len(records))
+	for i, record := range records {
+		recordResponses[i] = c.mapRecordToResponse(record)
+	}
+
+	resp := utils.NewSuccessResponse("Payroll run retrieved successfully", &dto.PayrollRunDetailResponse{
+		Run: c.mapRunToResponse(run),
+		CurrentCounts: &dto.RunStatusCountsResponse{
+			Success: counts.Success,
+			Failed:  counts.Failed,
+			Pending: counts.Pending,
+		},
+		Records: recordResponses,
+	})
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// AdminBackfillPayroll godoc
+// @Summary Run payroll for a past date (Admin only)
+// @Description Run the payroll of a past date, for example after the cron was down. Income sources already paid for the pay period are skipped, and a date can only run once at a time.
+// @Tags Payroll
+// @Accept json
+// @Produce json
+// @Param request body dto.BackfillPayrollRequest true "Pay date"
+// @Success 201 {object} map[string]interface{} "Payroll run completed"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Security BearerAuth
+// @Router /v1/payroll/admin/runs [post]
+func (c *Controller) AdminBackfillPayroll(ctx *gin.Context) {
+	adminID, exists := ctx.Get("user_id")
+	if !exists {
+		resp := utils.NewErrorResponse(http.StatusUnauthorized, "unauthorized")
+		ctx.JSON(http.StatusUnauthorized, resp)
+		return
+	}
+
+	var req dto.BackfillPayrollRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		resp := utils.NewErrorResponse(http.StatusBadRequest, err.Error())
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	if err := utils.ValidateRequest(&req); err != nil {
+		resp := utils.NewErrorResponse(http.StatusBadRequest, err.Error())
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	run, err := c.service.BackfillPayroll(ctx, adminID.(string), req.Date)
+	if err != nil {
+		resp := utils.NewErrorResponse(http.StatusBadRequest, err.Error())
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	resp := utils.NewSuccessResponse("Payroll run completed", c.mapRunToResponse(run))
+	ctx.JSON(http.StatusCreated, resp)
+}
+
+// GetTaxSettings godoc
+// @Summary Get salary deduction settings
+// @Description Get the PTKP status and BPJS programs used to pay the authenticated user's salary net of PPh 21 and BPJS
+// @Tags Payroll
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Tax settings retrieved successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Security BearerAuth
+// @Router /v1/payroll/tax-settings [get]
+func (c *Controller) GetTaxSettings(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		resp := utils.NewErrorResponse(http.StatusUnauthorized, "unauthorized")
+		ctx.JSON(http.StatusUnauthorized, resp)
+		return
+	}
+
+	settings, err := c.service.GetTaxSettings(ctx, userID.(string))
+	if err != nil {
+		resp := utils.NewErrorResponse(http.StatusBadRequest, err.Error())
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	resp := utils.NewSuccessResponse("Tax settings retrieved successfully", c.mapTaxSettingsToResponse(settings))
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// UpdateTaxSettings godoc
+// @Summary Update salary deduction settings
+// @Description When enabled, the monthly salary is credited net of PPh 21 (TER method) and the BPJS Kesehatan and Ketenagakerjaan employee contributions. December reconciles the PPh 21 of the year.
+// @Tags Payroll
+// @Accept json
+// @Produce json
+// @Param request body dto.UpdateTaxSettingsRequest true "Tax settings"
+// @Success 200 {object} map[string]interface{} "Tax settings updated successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Security BearerAuth
+// @Router /v1/payroll/tax-settings [put]
+func (c *Controller) UpdateTaxSettings(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		resp := utils.NewErrorResponse(http.StatusUnauthorized, "unauthorized")
+		ctx.JSON(http.StatusUnauthorized, resp)
+		return
+	}
+
+	var req dto.UpdateTaxSettingsRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		resp := utils.NewErrorResponse(http.StatusBadRequest, err.Error())
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	if err := utils.ValidateRequest(&req); err != nil {
+		resp := utils.NewErrorResponse(http.StatusBadRequest, err.Error())
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	settings, err := c.service.UpdateTaxSettings(ctx, userID.(string), &req)
+	if err != nil {
+		resp := utils.NewErrorResponse(http.StatusBadRequest, err.Error())
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	resp := utils.NewSuccessResponse("Tax settings updated successfully", c.mapTaxSettingsToResponse(settings))
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// CalculateNetSalary godoc
+// @Summary Calculate net salary
+// @Description Work out the net pay of a monthly gross salary after PPh 21 (TER method) and BPJS employee contributions, without saving anything
+// @Tags Payroll
+// @Accept json
+// @Produce json
+// @Param request body dto.CalculateNetSalaryRequest true "Gross salary and PTKP status"
+// @Success 200 {object} map[string]interface{} "Net salary calculated successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Security BearerAuth
+// @Router /v1/payroll/net-salary [post]
+func (c *Controller) CalculateNetSalary(ctx *gin.Context) {
+	var req dto.CalculateNetSalaryRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		resp := utils.NewErrorResponse(http.StatusBadRequest, err.Error())
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	if err := utils.ValidateRequest(&req); err != nil {
+		resp := utils.NewErrorResponse(http.StatusBadRequest, err.Error())
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	breakdown, err := c.service.CalculateNetSalary(ctx, &req)
+	if err != nil {
+		resp := utils.NewErrorResponse(http.StatusBadRequest, err.Error())
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	resp := utils.NewSuccessResponse("Net salary calculated successfully", c.mapBreakdownToResponse(breakdown))
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// GetDeductionSummary godoc
+// @Summary Get yearly salary deductions
+// @Description Total the gross salary, net pay and PPh 21 and BPJS deductions of the authenticated user in a tax year
+// @Tags Payroll
+// @Produce json
+// @Param year query int false "Tax year, defaults to the current year"
+// @Success 200 {object} map[string]interface{} "Deduction summary retrieved successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Security BearerAuth
+// @Router /v1/payroll/deductions [get]
+func (c *Controller) GetDeductionSummary(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		resp := utils.NewErrorResponse(http.StatusUnauthorized, "unauthorized")
+		ctx.JSON(http.StatusUnauthorized, resp)
+		return
+	}
+
+	year := time.Now().In(getJakartaLocation()).Year()
+	if y := ctx.Query("year"); y != "" {
+		parsed, err := strconv.Atoi(y)
+		if err != nil {
+			resp := utils.NewErrorResponse(http.StatusBadRequest, "invalid year")
+			ctx.JSON(http.StatusBadRequest, resp)
+			return
+		}
+		year = parsed
+	}
+
+	summary, err := c.service.GetDeductionSummary(ctx, userID.(string), year)
+	if err != nil {
+		resp := utils.NewErrorResponse(http.StatusBadRequest, err.Error())
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	resp := utils.NewSuccessResponse("Deduction summary retrieved successfully", &dto.DeductionSummaryResponse{
+		Year:       summary.Year,
+		Gross:      summary.Gross,
+		Net:        summary.Net,
+		Deductions: summary.Deductions,
+	})
+	ctx.JSON(http.StatusOK, resp)
+}
+
+func (c *Controller) mapRunToResponse(run *PayrollRun) *dto.PayrollRunResponse {
+	var triggeredBy *string
+	if run.TriggeredBy != nil {
+		id := run.TriggeredBy.Hex()
+		triggeredBy = &id
+	}
+
+	return &dto.PayrollRunResponse{
+		ID:           run.ID.Hex(),
+		Date:         run.Date,
+		Trigger:      run.Trigger,
+		TriggeredBy:  triggeredBy,
+		Status:       run.Status,
+		SuccessCount: run.SuccessCount,
+		FailureCount: run.FailureCount,
+		SkippedCount: run.SkippedCount,
+		Error:        run.Error,
+		StartedAt:    run.StartedAt,
+		FinishedAt:   run.FinishedAt,
+	}
+}
+
+func (c *Controller) mapRecordToResponse(record *PayrollRecord) *dto.PayrollRecordResponse {
+	var runID *string
+	if record.RunID != nil {
+		id := record.RunID.Hex()
+		runID = &id
+	}
+
+	var incomeSourceID *string
+	if record.IncomeSourceID != nil {
+		id := record.IncomeSourceID.Hex()
+		incomeSourceID = &id
+	}
+
+	var incomeEventID *string
+	if record.IncomeEventID != nil {
+		id := record.IncomeEventID.Hex()
+		incomeEventID = &id
+	}
+
+	return &dto.PayrollRecordResponse{
+		ID:             record.ID.Hex(),
+		UserID:         record.UserID.Hex(),
+		RunID:          runID,
+		IncomeSourceID: incomeSourceID,
+		IncomeEventID:  incomeEventID,
+		Date:           fmt.Sprintf("%04d-%02d-%02d", record.Year, record.Month, record.Day),
+		Cycle:          record.Cycle,
+		PeriodKey:      record.PeriodKey,
+		Amount:         record.Amount,
+		Breakdown:      c.mapBreakdownToResponse(record.Breakdown),
+		Status:         record.Status,
+		Error:          record.Error,
+		Attempts:       record.Attempts,
+		NextRetryAt:    record.NextRetryAt,
+		CreatedAt:      record.CreatedAt,
+	}
+}
+
+func (c *Controller) mapTaxSettingsToResponse(settings *TaxSettings) *dto.TaxSettingsResponse {
+	var incomeSourceID *string
+	if settings.IncomeSourceID != nil {
+		id := settings.IncomeSourceID.Hex()
+		incomeSourceID = &id
+	}
+
+	return &dto.TaxSettingsResponse{
+		Enabled:             settings.Enabled,
+		PTKPStatus:          settings.PTKPStatus,
+		IncomeSourceID:      incomeSourceID,
+		BPJSKesehatan:       settings.BPJSKesehatan,
+		BPJSKetenagakerjaan: settings.BPJSKetenagakerjaan,
+		JKKRate:             settings.JKKRate,
+	}
+}
+
+func (c *Controller) mapBreakdownToResponse(breakdown *SalaryBreakdown) *dto.SalaryBreakdownResponse {
+	if breakdown == nil {
+		return nil
+	}
+
+	deductions := make([]*dto.DeductionResponse, len(breakdown.Deductions))
+	for i, deduction := range breakdown.Deductions {
+		deductions[i] = &dto.DeductionResponse{
+			Category: deduction.Category,
+			Amount:   deduction.Amount,
+		}
+	}
+
+	return &dto.SalaryBreakdownResponse{
+		TaxYear:      breakdown.TaxYear,
+		TaxMonth:     breakdown.TaxMonth,
+		PTKPStatus:   breakdown.PTKPStatus,
+		TERCategory:  breakdown.TERCategory,
+		Gross:        breakdown.Gross,
+		TaxableGross: breakdown.TaxableGross,
+		TERRate:      breakdown.TERRate,
+		Deductions:   deductions,
+		Net:          breakdown.Net,
+	}
+}
@@ -0,0 +1,195 @@
+package payroll
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/HasanNugroho/coin-be/internal/modules/income_source"
+	"github.com/HasanNugroho/coin-be/internal/modules/payroll/dto"
+	"github.com/HasanNugroho/coin-be/internal/modules/user"
+	"github.com/HasanNugroho/coin-be/internal/modules/user_category"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// GetTaxSettings returns the tax settings of the user, disabled defaults when they have none yet
+func (s *Service) GetTaxSettings(ctx context.Context, userID string) (*TaxSettings, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, errors.New("invalid user id")
+	}
+
+	settings, err := s.payrollRepo.GetTaxSettings(ctx, userObjID)
+	if err != nil {
+		return nil, err
+	}
+	if settings == nil {
+		settings = &TaxSettings{
+			UserID:              userObjID,
+			PTKPStatus:          "TK/0",
+			BPJSKesehatan:       true,
+			BPJSKetenagakerjaan: true,
+			JKKRate:             DefaultJKKRate,
+		}
+	}
+
+	return settings, nil
+}
+
+// UpdateTaxSettings updates the tax settings of the user. The deductions apply to a monthly salary.
+func (s *Service) UpdateTaxSettings(ctx context.Context, userID string, req *dto.UpdateTaxSettingsRequest) (*TaxSettings, error) {
+	settings, err := s.GetTaxSettings(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Enabled != nil {
+		settings.Enabled = *req.Enabled
+	}
+	if req.PTKPStatus != "" {
+		settings.PTKPStatus = req.PTKPStatus
+	}
+	if req.BPJSKesehatan != nil {
+		settings.BPJSKesehatan = *req.BPJSKesehatan
+	}
+	if req.BPJSKetenagakerjaan != nil {
+		settings.BPJSKetenagakerjaan = *req.BPJSKetenagakerjaan
+	}
+	if req.JKKRate != nil {
+		settings.JKKRate = *req.JKKRate
+	}
+
+	if req.IncomeSourceID != "" {
+		sourceObjID, err := primitive.ObjectIDFromHex(req.IncomeSourceID)
+		if err != nil {
+			return nil, errors.New("invalid income source id")
+		}
+
+		source, err := s.incomeSourceRepo.GetIncomeSourceByID(ctx, sourceObjID)
+		if err != nil {
+			return nil, err
+		}
+
+		if source.UserID != settings.UserID {
+			return nil, errors.New("unauthorized: income source does not belong to user")
+		}
+
+		if income_source.NormalizeCycle(source.Cycle) != user.SalaryCycleMonthly {
+			return nil, errors.New("deductions only apply to a monthly salary")
+		}
+
+		settings.IncomeSourceID = &sourceObjID
+	}
+
+	if !IsValidPTKPStatus(settings.PTKPStatus) {
+		return nil, errors.New("invalid ptkp status")
+	}
+
+	if err := s.payrollRepo.SaveTaxSettings(ctx, settings); err != nil {
+		return nil, err
+	}
+
+	return settings, nil
+}
+
+// CalculateNetSalary works out the net pay of a monthly gross salary outside December, without saving anything
+func (s *Service) CalculateNetSalary(ctx context.Context, req *dto.CalculateNetSalaryRequest) (*SalaryBreakdown, error) {
+	if !IsValidPTKPStatus(req.PTKPStatus) {
+		return nil, errors.New("invalid ptkp status")
+	}
+
+	settings := &TaxSettings{
+		PTKPStatus:          req.PTKPStatus,
+		BPJSKesehatan:       req.BPJSKesehatan,
+		BPJSKetenagakerjaan: req.BPJSKetenagakerjaan,
+		JKKRate:             req.JKKRate,
+	}
+
+	now := time.Now().In(getJakartaLocation())
+	payMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	if payMonth.Month() == time.December {
+		payMonth = payMonth.AddDate(0, -1, 0)
+	}
+
+	return calculateNetSalary(req.Gross, settings, payMonth, nil), nil
+}
+
+// GetDeductionSummary totals the user's salary, net pay and deductions of a tax year
+func (s *Service) GetDeductionSummary(ctx context.Context, userID string, year int) (*DeductionSummary, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, errors.New("invalid user id")
+	}
+
+	return s.payrollRepo.GetDeductionSummary(ctx, userObjID, year)
+}
+
+// appliesTo reports whether the deductions of the settings apply to an income source: the chosen monthly
+// salary, or the salary migrated from the profile when none is chosen
+func (t *TaxSettings) appliesTo(source *income_source.IncomeSource) bool {
+	if t == nil || !t.Enabled || income_source.NormalizeCycle(source.Cycle) != user.SalaryCycleMonthly {
+		return false
+	}
+	if t.IncomeSourceID == nil {
+		return source.MigratedFromProfile
+	}
+	return *t.IncomeSourceID == source.ID
+}
+
+// applyDeductions turns the gross amount of a payroll record into its net pay, keeping the calculation on the
+// record. December reconciles the PPh 21 of the year against what the earlier months withheld.
+func (s *Service) applyDeductions(ctx context.Context, settings *TaxSettings, record *PayrollRecord, scheduled time.Time) error {
+	payMonth := time.Date(scheduled.Year(), scheduled.Month(), 1, 0, 0, 0, 0, scheduled.Location())
+
+	ytd := &yearToDate{}
+	if payMonth.Month() == time.December {
+		var err error
+		ytd, err = s.payrollRepo.GetYearToDate(ctx, record.UserID, payMonth.Year())
+		if err != nil {
+			return err
+		}
+	}
+
+	record.Breakdown = calculateNetSalary(record.Amount, settings, payMonth, ytd)
+	record.Amount = record.Breakdown.Net
+	return nil
+}
+
+// getDeductionCategories returns the expense category of each deduction by its name, creating the ones the user
+// does not have yet
+func (s *Service) getDeductionCategories(ctx context.Context, userID primitive.ObjectID) (map[string]primitive.ObjectID, error) {
+	categories, err := s.categoryRepo.FindAllByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	byName := make(map[string]primitive.ObjectID)
+	for _, category := range categories {
+		if category.TransactionType != nil && *category.TransactionType != user_category.TransactionExpense {
+			continue
+		}
+		byName[strings.ToLower(category.Name)] = category.ID
+	}
+
+	ids := make(map[string]primitive.ObjectID, len(deductionCategoryNames))
+	for deduction, name := range deductionCategoryNames {
+		if id, ok := byName[strings.ToLower(name)]; ok {
+			ids[deduction] = id
+			continue
+		}
+
+		expense := user_category.TransactionExpense
+		category := &user_category.UserCategory{
+			UserID:          userID,
+			Name:            name,
+			TransactionType: &expense,
+		}
+		if err := s.categoryRepo.Create(ctx, category); err != nil {
+			return nil, err
+		}
+		ids[deduction] = category.ID
+	}
+
+	return ids, nil
+}
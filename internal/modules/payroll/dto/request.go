@@ -0,0 +1,28 @@
+package dto
+
+type RerunPayrollRequest struct {
+	Date           string `json:"date" validate:"required"`   // YYYY-MM-DD
+	IncomeSourceID string `json:"income_source_id,omitempty"` // required when several income sources failed on the date
+	IncomeEventID  string `json:"income_event_id,omitempty"`  // picks a failed one-off income event instead
+}
+
+type BackfillPayrollRequest struct {
+	Date string `json:"date" validate:"required"` // YYYY-MM-DD, today or earlier
+}
+
+type UpdateTaxSettingsRequest struct {
+	Enabled             *bool    `json:"enabled"`
+	PTKPStatus          string   `json:"ptkp_status" validate:"omitempty,oneof=TK/0 TK/1 TK/2 TK/3 K/0 K/1 K/2 K/3"`
+	IncomeSourceID      string   `json:"income_source_id" validate:"omitempty,len=24,hexadecimal"` // monthly salary the deductions apply to, defaults to the profile salary
+	BPJSKesehatan       *bool    `json:"bpjs_kesehatan"`
+	BPJSKetenagakerjaan *bool    `json:"bpjs_ketenagakerjaan"`
+	JKKRate             *float64 `json:"jkk_rate" validate:"omitempty,gte=0.0024,lte=0.0174"`
+}
+
+type CalculateNetSalaryRequest struct {
+	Gross               float64 `json:"gross" validate:"required,gt=0"` // monthly gross salary
+	PTKPStatus          string  `json:"ptkp_status" validate:"required,oneof=TK/0 TK/1 TK/2 TK/3 K/0 K/1 K/2 K/3"`
+	BPJSKesehatan       bool    `json:"bpjs_kesehatan"`
+	BPJSKetenagakerjaan bool    `json:"bpjs_ketenagakerjaan"`
+	JKKRate             float64 `json:"jkk_rate" validate:"omitempty,gte=0.0024,lte=0.0174"` // defaults to 0.0024
+}
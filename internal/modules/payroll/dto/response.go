@@ -0,0 +1,82 @@
+package dto
+
+import "time"
+
+type PayrollRecordResponse struct {
+	ID             string                   `json:"id"`
+	UserID         string                   `json:"user_id"`
+	RunID          *string                  `json:"run_id,omitempty"`
+	IncomeSourceID *string                  `json:"income_source_id,omitempty"`
+	IncomeEventID  *string                  `json:"income_event_id,omitempty"`
+	Date           string                   `json:"date"`
+	Cycle          string                   `json:"cycle,omitempty"`
+	PeriodKey      string                   `json:"period_key,omitempty"`
+	Amount         float64                  `json:"amount"`
+	Breakdown      *SalaryBreakdownResponse `json:"breakdown,omitempty"`
+	Status         string                   `json:"status"`
+	Error          *string                  `json:"error,omitempty"`
+	Attempts       int                      `json:"attempts"`
+	NextRetryAt    *time.Time               `json:"next_retry_at,omitempty"`
+	CreatedAt      time.Time                `json:"created_at"`
+}
+
+type PayrollRunResponse struct {
+	ID           string     `json:"id"`
+	Date         string     `json:"date"`
+	Trigger      string     `json:"trigger"`
+	TriggeredBy  *string    `json:"triggered_by,omitempty"`
+	Status       string     `json:"status"`
+	SuccessCount int        `json:"success_count"`
+	FailureCount int        `json:"failure_count"`
+	SkippedCount int        `json:"skipped_count"`
+	Error        *string    `json:"error,omitempty"`
+	StartedAt    time.Time  `json:"started_at"`
+	FinishedAt   *time.Time `json:"finished_at,omitempty"`
+}
+
+// PayrollRunDetailResponse is a run with its records. Current counts follow the records as failures are
+// retried, while the counts of the run are those at the time it finished.
+type PayrollRunDetailResponse struct {
+	Run           *PayrollRunResponse      `json:"run"`
+	CurrentCounts *RunStatusCountsResponse `json:"current_counts"`
+	Records       []*PayrollRecordResponse `json:"records"`
+}
+
+type RunStatusCountsResponse struct {
+	Success int `json:"success"`
+	Failed  int `json:"failed"`
+	Pending int `json:"pending"`
+}
+
+type TaxSettingsResponse struct {
+	Enabled             bool    `json:"enabled"`
+	PTKPStatus          string  `json:"ptkp_status"`
+	IncomeSourceID      *string `json:"income_source_id,omitempty"`
+	BPJSKesehatan       bool    `json:"bpjs_kesehatan"`
+	BPJSKetenagakerjaan bool    `json:"bpjs_ketenagakerjaan"`
+	JKKRate             float64 `json:"jkk_rate"`
+}
+
+type SalaryBreakdownResponse struct {
+	TaxYear      int                  `json:"tax_year"`
+	TaxMonth     int                  `json:"tax_month"`
+	PTKPStatus   string               `json:"ptkp_status"`
+	TERCategory  string               `json:"ter_category"`
+	Gross        float64              `json:"gross"`
+	TaxableGross float64              `json:"taxable_gross"`
+	TERRate      float64              `json:"ter_rate"`
+	Deductions   []*DeductionResponse `json:"deductions"`
+	Net          float64              `json:"net"`
+}
+
+type DeductionResponse struct {
+	Category string  `json:"category"`
+	Amount   float64 `json:"amount"`
+}
+
+type DeductionSummaryResponse struct {
+	Year       int                `json:"year"`
+	Gross      float64            `json:"gross"`
+	Net        float64            `json:"net"`
+	Deductions map[string]float64 `json:"deductions"` // total by category
+}
@@ -0,0 +1,166 @@
+package payroll
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/HasanNugroho/coin-be/internal/modules/income_source"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// processIncomeEvents credits the scheduled income events dated on or before now, each once. An event paid by an
+// earlier run is skipped through its record, and the ref of its income guards against crediting it twice.
+func (s *Service) processIncomeEvents(ctx context.Context, now time.Time, run *PayrollRun) error {
+	events, err := s.incomeSourceRepo.GetDueIncomeEvents(ctx, run.Date)
+	if err != nil {
+		return err
+	}
+
+	if len(events) == 0 {
+		return nil
+	}
+
+	userIDs := make([]primitive.ObjectID, 0, len(events))
+	periodKeys := make([]string, 0, len(events))
+	seenUsers := make(map[primitive.ObjectID]bool)
+	for _, event := range events {
+		if !seenUsers[event.UserID] {
+			seenUsers[event.UserID] = true
+			userIDs = append(userIDs, event.UserID)
+		}
+		periodKeys = append(periodKeys, eventPeriodKey(event.ID))
+	}
+
+	existingRecords, err := s.payrollRepo.GetPayrollRecordsByPeriods(ctx, userIDs, periodKeys)
+	if err != nil {
+		return err
+	}
+
+	existingRecordsMap := make(map[string]*PayrollRecord, len(existingRecords))
+	for _, record := range existingRecords {
+		existingRecordsMap[recordKey(record.UserID, nil, record.PeriodKey)] = record
+	}
+
+	newPayrollRecords := make([]*PayrollRecord, 0, len(events))
+
+	for _, event := range events {
+		key := eventPeriodKey(event.ID)
+
+		// A record left by a run that stopped before settling the event settles it now
+		if existing := existingRecordsMap[recordKey(event.UserID, nil, key)]; existing != nil {
+			log.Printf("income event %s already processed", event.ID.Hex())
+			s.settleIncomeEvent(ctx, event.ID, existing)
+			run.SkippedCount++
+			continue
+		}
+
+		// An event missed while the cron was down is still credited on its own date
+		payDate := now
+		if event.Date < run.Date {
+			if date, err := time.ParseInLocation("2006-01-02", event.Date, now.Location()); err == nil {
+				payDate = date
+			}
+		}
+
+		record := &PayrollRecord{
+			UserID:        event.UserID,
+			IncomeEventID: &event.ID,
+			RunID:         &run.ID,
+			Year:          payDate.Year(),
+			Month:         int(payDate.Month()),
+			Day:           payDate.Day(),
+			PeriodKey:     key,
+			Attempts:      1,
+		}
+
+		record.Amount, err = s.eventAmount(ctx, event)
+		if err == nil {
+			err = s.creditIncome(ctx, eventSource(event), record, payDate, event.FireAllocations)
+		}
+
+		if err != nil {
+			log.Printf("failed to process income event %s: %v", event.ID.Hex(), err)
+			run.FailureCount++
+		} else {
+			log.Printf("successfully processed income event %s", event.ID.Hex())
+			run.SuccessCount++
+		}
+		setRecordOutcome(record, err)
+
+		newPayrollRecords = append(newPayrollRecords, record)
+		s.settleIncomeEvent(ctx, event.ID, record)
+	}
+
+	s.savePayrollRecords(ctx, newPayrollRecords)
+	return nil
+}
+
+// eventAmount returns the amount an income event credits: its fixed amount, or a multiple of the salary of its
+// income source. Without a source the salary migrated from the profile is used, then the profile itself.
+func (s *Service) eventAmount(ctx context.Context, event *income_source.IncomeEvent) (float64, error) {
+	if event.AmountType != income_source.AmountTypeSalaryMultiple {
+		return event.Amount, nil
+	}
+
+	var salary float64
+	if event.IncomeSourceID != nil {
+		source, err := s.incomeSourceRepo.GetIncomeSourceByID(ctx, *event.IncomeSourceID)
+		if err != nil {
+			return 0, err
+		}
+		salary = source.Amount
+	} else {
+		source, err := s.incomeSourceRepo.GetMigratedIncomeSource(ctx, event.UserID)
+		if err != nil {
+			return 0, err
+		}
+		if source != nil {
+			salary = source.Amount
+		} else {
+			profile, err := s.userRepo.GetUserProfileByUserID(ctx, event.UserID)
+			if err != nil {
+				return 0, err
+			}
+			salary = profile.BaseSalary
+		}
+	}
+
+	if salary <= 0 {
+		return 0, errors.New("no base salary to multiply")
+	}
+
+	return salary * event.Multiplier, nil
+}
+
+// settleIncomeEvent stores the outcome of the payroll record of an income event on the event
+func (s *Service) settleIncomeEvent(ctx context.Context, eventID primitive.ObjectID, record *PayrollRecord) {
+	status := income_source.EventStatusPaid
+	switch record.Status {
+	case StatusFailed:
+		status = income_source.EventStatusFailed
+	case StatusPending:
+		return
+	}
+
+	if err := s.incomeSourceRepo.SetIncomeEventOutcome(ctx, eventID, status, record.Amount); err != nil {
+		log.Printf("failed to update income event %s: %v", eventID.Hex(), err)
+	}
+}
+
+// eventSource returns the income source an income event is credited as, paying into the event's targets
+func eventSource(event *income_source.IncomeEvent) *income_source.IncomeSource {
+	return &income_source.IncomeSource{
+		UserID:         event.UserID,
+		Name:           event.Name,
+		UserPlatformID: event.UserPlatformID,
+		PocketID:       event.PocketID,
+		CategoryID:     event.CategoryID,
+	}
+}
+
+// eventPeriodKey is the period key of the single payroll record of an income event
+func eventPeriodKey(eventID primitive.ObjectID) string {
+	return "event-" + eventID.Hex()
+}
@@ -8,19 +8,122 @@ import (
 
 // PayrollRecord tracks executed payroll to ensure idempotency
 type PayrollRecord struct {
-	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	UserID    primitive.ObjectID `bson:"user_id" json:"user_id"`
-	Year      int                `bson:"year" json:"year"`
-	Month     int                `bson:"month" json:"month"`
-	Day       int                `bson:"day" json:"day"`
-	Amount    float64            `bson:"amount" json:"amount"`
-	Status    string             `bson:"status" json:"status"` // SUCCESS, FAILED
-	Error     *string            `bson:"error,omitempty" json:"error,omitempty"`
-	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+	ID             primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	UserID         primitive.ObjectID  `bson:"user_id" json:"user_id"`
+	RunID          *primitive.ObjectID `bson:"run_id,omitempty" json:"run_id,omitempty"`                     // run that created the record
+	IncomeSourceID *primitive.ObjectID `bson:"income_source_id,omitempty" json:"income_source_id,omitempty"` // nil for payroll paid from the profile salary
+	IncomeEventID  *primitive.ObjectID `bson:"income_event_id,omitempty" json:"income_event_id,omitempty"`   // set for a one-off income event
+	Year           int                 `bson:"year" json:"year"`
+	Month          int                 `bson:"month" json:"month"`
+	Day            int                 `bson:"day" json:"day"`
+	Cycle          string              `bson:"cycle,omitempty" json:"cycle,omitempty"`           // daily, weekly, monthly
+	PeriodKey      string              `bson:"period_key,omitempty" json:"period_key,omitempty"` // pay period, e.g. 2026-10, 2026-W42 or 2026-10-18
+	Amount         float64             `bson:"amount" json:"amount"`                             // credited amount, the net pay when deductions apply
+	Breakdown      *SalaryBreakdown    `bson:"breakdown,omitempty" json:"breakdown,omitempty"`   // gross-to-net calculation when deductions apply
+	Status         string              `bson:"status" json:"status"`                             // PENDING, SUCCESS, FAILED
+	Error          *string             `bson:"error,omitempty" json:"error,omitempty"`
+	Attempts       int                 `bson:"attempts" json:"attempts"`
+	NextRetryAt    *time.Time          `bson:"next_retry_at,omitempty" json:"next_retry_at,omitempty"` // set while a transient failure waits for a retry
+	CreatedAt      time.Time           `bson:"created_at" json:"created_at"`
 }
 
 // PayrollStatus constants
 const (
+	StatusPending = "PENDING" // a re-run is in progress
 	StatusSuccess = "SUCCESS"
 	StatusFailed  = "FAILED"
 )
+
+// PayrollRun is one pass of the payroll over a pay date, by the daily cron or an admin backfill. Only one run
+// of a date can be in progress at a time.
+type PayrollRun struct {
+	ID           primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	Date         string              `bson:"date" json:"date"`                                     // pay date, YYYY-MM-DD
+	Trigger      string              `bson:"trigger" json:"trigger"`                               // SCHEDULED, BACKFILL
+	TriggeredBy  *primitive.ObjectID `bson:"triggered_by,omitempty" json:"triggered_by,omitempty"` // admin who started a backfill
+	Status       string              `bson:"status" json:"status"`                                 // RUNNING, COMPLETED, FAILED
+	SuccessCount int                 `bson:"success_count" json:"success_count"`
+	FailureCount int                 `bson:"failure_count" json:"failure_count"`
+	SkippedCount int                 `bson:"skipped_count" json:"skipped_count"` // sources already paid for the period or created after the date
+	Error        *string             `bson:"error,omitempty" json:"error,omitempty"`
+	StartedAt    time.Time           `bson:"started_at" json:"started_at"`
+	FinishedAt   *time.Time          `bson:"finished_at,omitempty" json:"finished_at,omitempty"`
+}
+
+// PayrollRun triggers
+const (
+	RunTriggerScheduled = "SCHEDULED"
+	RunTriggerBackfill  = "BACKFILL"
+)
+
+// PayrollRun statuses
+const (
+	RunStatusRunning   = "RUNNING"
+	RunStatusCompleted = "COMPLETED"
+	RunStatusFailed    = "FAILED"
+)
+
+// RunStatusCounts counts the records of a run by their current status, which changes as failures are retried
+type RunStatusCounts struct {
+	Success int `json:"success"`
+	Failed  int `json:"failed"`
+	Pending int `json:"pending"`
+}
+
+// TaxSettings configures the gross-to-net calculation of a user's salary. When enabled, the salary is credited
+// net of PPh 21 and the BPJS employee contributions, and the deductions are kept on its payroll records.
+type TaxSettings struct {
+	ID                  primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	UserID              primitive.ObjectID  `bson:"user_id" json:"user_id"`
+	Enabled             bool                `bson:"enabled" json:"enabled"`
+	PTKPStatus          string              `bson:"ptkp_status" json:"ptkp_status"`                               // TK/0..TK/3, K/0..K/3
+	IncomeSourceID      *primitive.ObjectID `bson:"income_source_id,omitempty" json:"income_source_id,omitempty"` // salary the deductions apply to, the migrated salary when null
+	BPJSKesehatan       bool                `bson:"bpjs_kesehatan" json:"bpjs_kesehatan"`
+	BPJSKetenagakerjaan bool                `bson:"bpjs_ketenagakerjaan" json:"bpjs_ketenagakerjaan"`
+	JKKRate             float64             `bson:"jkk_rate" json:"jkk_rate"` // employer work accident premium, 0.0024 to 0.0174 by risk class
+	CreatedAt           time.Time           `bson:"created_at" json:"created_at"`
+	UpdatedAt           time.Time           `bson:"updated_at" json:"updated_at"`
+}
+
+// SalaryBreakdown is the gross-to-net calculation of one salary payment
+type SalaryBreakdown struct {
+	TaxYear      int          `bson:"tax_year" json:"tax_year"`
+	TaxMonth     int          `bson:"tax_month" json:"tax_month"`
+	PTKPStatus   string       `bson:"ptkp_status" json:"ptkp_status"`
+	TERCategory  string       `bson:"ter_category" json:"ter_category"` // A, B, C
+	Gross        float64      `bson:"gross" json:"gross"`
+	TaxableGross float64      `bson:"taxable_gross" json:"taxable_gross"` // gross with the taxable premiums paid by the employer
+	TERRate      float64      `bson:"ter_rate" json:"ter_rate"`           // monthly rate, zero in December when the year is reconciled
+	Deductions   []*Deduction `bson:"deductions" json:"deductions"`
+	Net          float64      `bson:"net" json:"net"`
+}
+
+// Deduction is an amount withheld from a salary payment
+type Deduction struct {
+	Category string  `bson:"category" json:"category" enums:"PPH21,BPJS_KESEHATAN,BPJS_JHT,BPJS_JP"`
+	Amount   float64 `bson:"amount" json:"amount"`
+}
+
+// Deduction categories
+const (
+	DeductionPPh21         = "PPH21"
+	DeductionBPJSKesehatan = "BPJS_KESEHATAN"
+	DeductionBPJSJHT       = "BPJS_JHT" // Jaminan Hari Tua
+	DeductionBPJSJP        = "BPJS_JP"  // Jaminan Pensiun
+)
+
+// deductionCategoryNames are the names of the expense categories deductions are posted under
+var deductionCategoryNames = map[string]string{
+	DeductionPPh21:         "PPh 21",
+	DeductionBPJSKesehatan: "BPJS Kesehatan",
+	DeductionBPJSJHT:       "BPJS JHT",
+	DeductionBPJSJP:        "BPJS JP",
+}
+
+// DeductionSummary totals the salary deductions of a user in a tax year
+type DeductionSummary struct {
+	Year       int                `json:"year"`
+	Gross      float64            `json:"gross"`
+	Net        float64            `json:"net"`
+	Deductions map[string]float64 `json:"deductions"`
+}
@@ -1,10 +1,15 @@
 package payroll
 
 import (
+	"context"
+
 	"github.com/HasanNugroho/coin-be/internal/core/config"
+	"github.com/HasanNugroho/coin-be/internal/modules/holiday"
+	"github.com/HasanNugroho/coin-be/internal/modules/income_source"
 	"github.com/HasanNugroho/coin-be/internal/modules/pocket"
 	"github.com/HasanNugroho/coin-be/internal/modules/transaction"
 	"github.com/HasanNugroho/coin-be/internal/modules/user"
+	"github.com/HasanNugroho/coin-be/internal/modules/user_category"
 	"github.com/HasanNugroho/coin-be/internal/modules/user_platform"
 	"github.com/sarulabs/di/v2"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -16,7 +21,13 @@ func Register(builder *di.Builder) {
 		Build: func(ctn di.Container) (interface{}, error) {
 			cfg := ctn.Get("config").(*config.Config)
 			client := ctn.Get("mongo").(*mongo.Client)
-			return NewRepository(client.Database(cfg.MongoDB)), nil
+			repo := NewRepository(client.Database(cfg.MongoDB))
+
+			if err := repo.EnsureIndexes(context.Background()); err != nil {
+				return nil, err
+			}
+
+			return repo, nil
 		},
 	})
 
@@ -24,17 +35,30 @@ func Register(builder *di.Builder) {
 		Name: "payrollService",
 		Build: func(ctn di.Container) (interface{}, error) {
 			payrollRepo := ctn.Get("payrollRepository").(*Repository)
+			incomeSourceRepo := ctn.Get("incomeSourceRepository").(*income_source.Repository)
+			incomeSourceSvc := ctn.Get("incomeSourceService").(*income_source.Service)
+			holidaySvc := ctn.Get("holidayService").(*holiday.Service)
 			userRepo := ctn.Get("userRepository").(*user.Repository)
 			userPlatformRepo := ctn.Get("userPlatformRepository").(*user_platform.UserPlatformRepository)
 			pocketRepo := ctn.Get("pocketRepository").(*pocket.Repository)
 			transactionRepo := ctn.Get("transactionRepository").(*transaction.Repository)
+			transactionSvc := ctn.Get("transactionService").(*transaction.Service)
+			categoryRepo := ctn.Get("userCategoryRepository").(*user_category.Repository)
 
 			balanceProcessor := transaction.NewBalanceProcessor(pocketRepo, userPlatformRepo)
 
 			cfg := ctn.Get("config").(*config.Config)
 			client := ctn.Get("mongo").(*mongo.Client)
 			db := client.Database(cfg.MongoDB)
-			return NewService(payrollRepo, userRepo, userPlatformRepo, pocketRepo, transactionRepo, balanceProcessor, db), nil
+			return NewService(payrollRepo, incomeSourceRepo, incomeSourceSvc, holidaySvc, userRepo, userPlatformRepo, pocketRepo, transactionRepo, transactionSvc, balanceProcessor, categoryRepo, db), nil
+		},
+	})
+
+	builder.Add(di.Def{
+		Name: "payrollController",
+		Build: func(ctn di.Container) (interface{}, error) {
+			service := ctn.Get("payrollService").(*Service)
+			return NewController(service), nil
 		},
 	})
 }
@@ -2,20 +2,26 @@ package payroll
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type Repository struct {
 	payrollRecords *mongo.Collection
+	payrollRuns    *mongo.Collection
+	taxSettings    *mongo.Collection
 }
 
 func NewRepository(db *mongo.Database) *Repository {
 	return &Repository{
 		payrollRecords: db.Collection("payroll_records"),
+		payrollRuns:    db.Collection("payroll_runs"),
+		taxSettings:    db.Collection("payroll_tax_settings"),
 	}
 }
 
@@ -26,6 +32,8 @@ func (r *Repository) CreatePayrollRecord(ctx context.Context, record *PayrollRec
 	return err
 }
 
+// CreatePayrollRecordBulk inserts records without stopping at the first failure, so a record rejected as a
+// duplicate pay period does not keep the others from being stored
 func (r *Repository) CreatePayrollRecordBulk(ctx context.Context, records []*PayrollRecord) error {
 	if len(records) == 0 {
 		return nil
@@ -40,7 +48,7 @@ func (r *Repository) CreatePayrollRecordBulk(ctx context.Context, records []*Pay
 	}
 
 	// Insert all records in bulk
-	_, err := r.payrollRecords.InsertMany(ctx, recordInterfaces)
+	_, err := r.payrollRecords.InsertMany(ctx, recordInterfaces, options.InsertMany().SetOrdered(false))
 	return err
 }
 
@@ -61,6 +69,54 @@ func (r *Repository) GetPayrollRecord(ctx context.Context, userID primitive.Obje
 	return &record, nil
 }
 
+func (r *Repository) UpdatePayrollRecord(ctx context.Context, record *PayrollRecord) error {
+	_, err := r.payrollRecords.UpdateOne(ctx, bson.M{"_id": record.ID}, bson.M{"$set": record})
+	return err
+}
+
+// GetDueRetries returns failed payroll records whose next retry is due
+func (r *Repository) GetDueRetries(ctx context.Context, now time.Time, limit int64) ([]*PayrollRecord, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "next_retry_at", Value: 1}}).SetLimit(limit)
+	cursor, err := r.payrollRecords.Find(ctx, bson.M{
+		"status":        StatusFailed,
+		"next_retry_at": bson.M{"$lte": now},
+	}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var records []*PayrollRecord
+	if err = cursor.All(ctx, &records); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// ClaimFailedRecord moves a failed payroll record to PENDING for a re-run and counts the attempt.
+// It returns nil when the record is no longer failed, so a payroll is never re-run twice at once.
+func (r *Repository) ClaimFailedRecord(ctx context.Context, id primitive.ObjectID) (*PayrollRecord, error) {
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var record PayrollRecord
+	err := r.payrollRecords.FindOneAndUpdate(ctx,
+		bson.M{"_id": id, "status": StatusFailed},
+		bson.M{
+			"$set":   bson.M{"status": StatusPending},
+			"$unset": bson.M{"error": "", "next_retry_at": ""},
+			"$inc":   bson.M{"attempts": 1},
+		},
+		opts,
+	).Decode(&record)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &record, nil
+}
+
 func (r *Repository) GetPayrollRecordByUserIDs(ctx context.Context, userIDs []primitive.ObjectID, year, month, day int) ([]*PayrollRecord, error) {
 	var records []*PayrollRecord
 	cursor, err := r.payrollRecords.Find(ctx, bson.M{
@@ -80,16 +136,408 @@ func (r *Repository) GetPayrollRecordByUserIDs(ctx context.Context, userIDs []pr
 	return records, nil
 }
 
-func (r *Repository) GetUserPayrollRecords(ctx context.Context, userID primitive.ObjectID, limit int64) ([]*PayrollRecord, error) {
-	cursor, err := r.payrollRecords.Find(ctx, bson.M{"user_id": userID})
+// GetPayrollRecordsByPeriods returns the records of the given users for any of the given pay periods
+func (r *Repository) GetPayrollRecordsByPeriods(ctx context.Context, userIDs []primitive.ObjectID, periodKeys []string) ([]*PayrollRecord, error) {
+	var records []*PayrollRecord
+	cursor, err := r.payrollRecords.Find(ctx, bson.M{
+		"user_id":    bson.M{"$in": userIDs},
+		"period_key": bson.M{"$in": periodKeys},
+	})
 	if err != nil {
 		return nil, err
 	}
 	defer cursor.Close(ctx)
 
+	if err = cursor.All(ctx, &records); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// GetPayrollRecordsOnDate returns the records of a user on a date, one per income source paid that day
+func (r *Repository) GetPayrollRecordsOnDate(ctx context.Context, userID primitive.ObjectID, year, month, day int) ([]*PayrollRecord, error) {
 	var records []*PayrollRecord
+	cursor, err := r.payrollRecords.Find(ctx, bson.M{
+		"user_id": userID,
+		"year":    year,
+		"month":   month,
+		"day":     day,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
 	if err = cursor.All(ctx, &records); err != nil {
 		return nil, err
 	}
 	return records, nil
 }
+
+// GetIncomeSourceRecords returns the payroll history of an income source, newest first
+func (r *Repository) GetIncomeSourceRecords(ctx context.Context, incomeSourceID primitive.ObjectID, limit int64) ([]*PayrollRecord, error) {
+	opts := options.Find().
+		SetSort(bson.D{{Key: "year", Value: -1}, {Key: "month", Value: -1}, {Key: "day", Value: -1}}).
+		SetLimit(limit)
+	cursor, err := r.payrollRecords.Find(ctx, bson.M{"income_source_id": incomeSourceID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	records := make([]*PayrollRecord, 0)
+	if err = cursor.All(ctx, &records); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// GetUserPayrollRecords returns a page of a user's payroll records, newest first, optionally of one status
+func (r *Repository) GetUserPayrollRecords(ctx context.Context, userID primitive.ObjectID, status *string, limit int64, skip int64) ([]*PayrollRecord, int64, error) {
+	filter := bson.M{"user_id": userID}
+	if status != nil {
+		filter["status"] = *status
+	}
+
+	total, err := r.payrollRecords.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "year", Value: -1}, {Key: "month", Value: -1}, {Key: "day", Value: -1}, {Key: "created_at", Value: -1}}).
+		SetLimit(limit).
+		SetSkip(skip)
+	cursor, err := r.payrollRecords.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	records := make([]*PayrollRecord, 0)
+	if err = cursor.All(ctx, &records); err != nil {
+		return nil, 0, err
+	}
+	return records, total, nil
+}
+
+// GetRunRecords returns the records created by a run
+func (r *Repository) GetRunRecords(ctx context.Context, runID primitive.ObjectID) ([]*PayrollRecord, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+	cursor, err := r.payrollRecords.Find(ctx, bson.M{"run_id": runID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	records := make([]*PayrollRecord, 0)
+	if err = cursor.All(ctx, &records); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// CreateRun starts a run of a pay date. It fails while another run of the date is in progress.
+func (r *Repository) CreateRun(ctx context.Context, run *PayrollRun) error {
+	run.ID = primitive.NewObjectID()
+	run.Status = RunStatusRunning
+	run.StartedAt = time.Now()
+	_, err := r.payrollRuns.InsertOne(ctx, run)
+	if mongo.IsDuplicateKeyError(err) {
+		return errors.New("payroll for this date is already running")
+	}
+	return err
+}
+
+// FinishRun stores the outcome of a run
+func (r *Repository) FinishRun(ctx context.Context, run *PayrollRun) error {
+	now := time.Now()
+	run.FinishedAt = &now
+	_, err := r.payrollRuns.UpdateOne(ctx, bson.M{"_id": run.ID}, bson.M{"$set": run})
+	return err
+}
+
+// AbandonStaleRuns fails runs of a date still in progress since before a cutoff, left behind by a crashed
+// process, so the date can run again
+func (r *Repository) AbandonStaleRuns(ctx context.Context, date string, startedBefore time.Time) error {
+	now := time.Now()
+	_, err := r.payrollRuns.UpdateMany(ctx,
+		bson.M{
+			"date":       date,
+			"status":     RunStatusRunning,
+			"started_at": bson.M{"$lt": startedBefore},
+		},
+		bson.M{"$set": bson.M{
+			"status":      RunStatusFailed,
+			"error":       "run abandoned",
+			"finished_at": now,
+		}},
+	)
+	return err
+}
+
+func (r *Repository) GetRunByID(ctx context.Context, id primitive.ObjectID) (*PayrollRun, error) {
+	var run PayrollRun
+	err := r.payrollRuns.FindOne(ctx, bson.M{"_id": id}).Decode(&run)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.New("payroll run not found")
+		}
+		return nil, err
+	}
+	return &run, nil
+}
+
+// GetRuns returns a page of runs with pay dates in a range (YYYY-MM-DD, both inclusive, empty for open ends),
+// newest pay date first
+func (r *Repository) GetRuns(ctx context.Context, from string, to string, limit int64, skip int64) ([]*PayrollRun, int64, error) {
+	filter := bson.M{}
+	dateFilter := bson.M{}
+	if from != "" {
+		dateFilter["$gte"] = from
+	}
+	if to != "" {
+		dateFilter["$lte"] = to
+	}
+	if len(dateFilter) > 0 {
+		filter["date"] = dateFilter
+	}
+
+	total, err := r.payrollRuns.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "date", Value: -1}, {Key: "started_at", Value: -1}}).
+		SetLimit(limit).
+		SetSkip(skip)
+	cursor, err := r.payrollRuns.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	runs := make([]*PayrollRun, 0)
+	if err = cursor.All(ctx, &runs); err != nil {
+		return nil, 0, err
+	}
+	return runs, total, nil
+}
+
+// GetTaxSettings returns the tax settings of a user, or nil when they have none
+func (r *Repository) GetTaxSettings(ctx context.Context, userID primitive.ObjectID) (*TaxSettings, error) {
+	var settings TaxSettings
+	err := r.taxSettings.FindOne(ctx, bson.M{"user_id": userID}).Decode(&settings)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &settings, nil
+}
+
+// GetEnabledTaxSettings returns the enabled tax settings of the given users, by user
+func (r *Repository) GetEnabledTaxSettings(ctx context.Context, userIDs []primitive.ObjectID) (map[primitive.ObjectID]*TaxSettings, error) {
+	cursor, err := r.taxSettings.Find(ctx, bson.M{
+		"user_id": bson.M{"$in": userIDs},
+		"enabled": true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var settings []*TaxSettings
+	if err = cursor.All(ctx, &settings); err != nil {
+		return nil, err
+	}
+
+	byUser := make(map[primitive.ObjectID]*TaxSettings, len(settings))
+	for _, s := range settings {
+		byUser[s.UserID] = s
+	}
+	return byUser, nil
+}
+
+// SaveTaxSettings creates or replaces the tax settings of a user
+func (r *Repository) SaveTaxSettings(ctx context.Context, settings *TaxSettings) error {
+	now := time.Now()
+	if settings.ID.IsZero() {
+		settings.ID = primitive.NewObjectID()
+		settings.CreatedAt = now
+	}
+	settings.UpdatedAt = now
+
+	_, err := r.taxSettings.ReplaceOne(ctx,
+		bson.M{"user_id": settings.UserID},
+		settings,
+		options.Replace().SetUpsert(true),
+	)
+	return err
+}
+
+// GetYearToDate totals the successful salary payments of a user with deductions in a tax year before December
+func (r *Repository) GetYearToDate(ctx context.Context, userID primitive.ObjectID, year int) (*yearToDate, error) {
+	cursor, err := r.payrollRecords.Find(ctx, bson.M{
+		"user_id":             userID,
+		"status":              StatusSuccess,
+		"breakdown.tax_year":  year,
+		"breakdown.tax_month": bson.M{"$lt": 12},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var records []*PayrollRecord
+	if err = cursor.All(ctx, &records); err != nil {
+		return nil, err
+	}
+
+	ytd := &yearToDate{}
+	for _, record := range records {
+		ytd.taxableGross += record.Breakdown.TaxableGross
+		ytd.pension += record.Breakdown.pension()
+		ytd.pph21 += record.Breakdown.pph21()
+	}
+	return ytd, nil
+}
+
+// GetDeductionSummary totals the gross, net and deductions of a user's successful salary payments in a tax year
+func (r *Repository) GetDeductionSummary(ctx context.Context, userID primitive.ObjectID, year int) (*DeductionSummary, error) {
+	cursor, err := r.payrollRecords.Find(ctx, bson.M{
+		"user_id":            userID,
+		"status":             StatusSuccess,
+		"breakdown.tax_year": year,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var records []*PayrollRecord
+	if err = cursor.All(ctx, &records); err != nil {
+		return nil, err
+	}
+
+	summary := &DeductionSummary{
+		Year:       year,
+		Deductions: make(map[string]float64),
+	}
+	for _, record := range records {
+		summary.Gross += record.Breakdown.Gross
+		summary.Net += record.Breakdown.Net
+		for _, deduction := range record.Breakdown.Deductions {
+			summary.Deductions[deduction.Category] += deduction.Amount
+		}
+	}
+	return summary, nil
+}
+
+func (r *Repository) EnsureIndexes(ctx context.Context) error {
+	indexes := []mongo.IndexModel{
+		{
+			// One payroll per income source per pay period. Records from before pay periods were tracked are left out.
+			Keys: bson.D{
+				{Key: "user_id", Value: 1},
+				{Key: "income_source_id", Value: 1},
+				{Key: "period_key", Value: 1},
+			},
+			Options: options.Index().
+				SetName("idx_payroll_records_source_period_unique").
+				SetUnique(true).
+				SetPartialFilterExpression(bson.M{"period_key": bson.M{"$exists": true}}),
+		},
+		{
+			Keys: bson.D{
+				{Key: "status", Value: 1},
+				{Key: "next_retry_at", Value: 1},
+			},
+			Options: options.Index().
+				SetName("idx_payroll_records_retry"),
+		},
+		{
+			Keys: bson.D{
+				{Key: "income_source_id", Value: 1},
+				{Key: "year", Value: -1},
+				{Key: "month", Value: -1},
+				{Key: "day", Value: -1},
+			},
+			Options: options.Index().
+				SetName("idx_payroll_records_source_date"),
+		},
+		{
+			Keys: bson.D{
+				{Key: "user_id", Value: 1},
+				{Key: "year", Value: -1},
+				{Key: "month", Value: -1},
+				{Key: "day", Value: -1},
+			},
+			Options: options.Index().
+				SetName("idx_payroll_records_user_date"),
+		},
+		{
+			Keys: bson.D{
+				{Key: "user_id", Value: 1},
+				{Key: "breakdown.tax_year", Value: 1},
+			},
+			Options: options.Index().
+				SetName("idx_payroll_records_user_tax_year").
+				SetPartialFilterExpression(bson.M{"breakdown": bson.M{"$exists": true}}),
+		},
+		{
+			Keys: bson.D{{Key: "run_id", Value: 1}},
+			Options: options.Index().
+				SetName("idx_payroll_records_run"),
+		},
+	}
+
+	taxSettingsIndexes := []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "user_id", Value: 1}},
+			Options: options.Index().
+				SetName("idx_payroll_tax_settings_user_unique").
+				SetUnique(true),
+		},
+	}
+
+	if _, err := r.taxSettings.Indexes().CreateMany(ctx, taxSettingsIndexes); err != nil {
+		return err
+	}
+
+	runIndexes := []mongo.IndexModel{
+		{
+			// One run of a pay date in progress at a time
+			Keys: bson.D{{Key: "date", Value: 1}},
+			Options: options.Index().
+				SetName("idx_payroll_runs_date_running_unique").
+				SetUnique(true).
+				SetPartialFilterExpression(bson.M{"status": RunStatusRunning}),
+		},
+		{
+			Keys: bson.D{
+				{Key: "date", Value: -1},
+				{Key: "started_at", Value: -1},
+			},
+			Options: options.Index().
+				SetName("idx_payroll_runs_date_started"),
+		},
+	}
+
+	if _, err := r.payrollRuns.Indexes().CreateMany(ctx, runIndexes); err != nil {
+		return err
+	}
+
+	// One payroll per user per pay period no longer holds with several income sources
+	if _, err := r.payrollRecords.Indexes().DropOne(ctx, "idx_payroll_records_user_period_unique"); err != nil {
+		var cmdErr mongo.CommandError
+		if !errors.As(err, &cmdErr) || (cmdErr.Name != "IndexNotFound" && cmdErr.Name != "NamespaceNotFound") {
+			return err
+		}
+	}
+
+	_, err := r.payrollRecords.Indexes().CreateMany(ctx, indexes)
+	return err
+}
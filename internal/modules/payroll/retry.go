@@ -0,0 +1,210 @@
+package payroll
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/HasanNugroho/coin-be/internal/core/utils"
+	"github.com/HasanNugroho/coin-be/internal/modules/income_source"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// RetryFailedPayroll re-runs failed payroll whose retry is due
+func (s *Service) RetryFailedPayroll(ctx context.Context) error {
+	records, err := s.payrollRepo.GetDueRetries(ctx, time.Now(), 100)
+	if err != nil {
+		return err
+	}
+
+	for _, record := range records {
+		if _, err := s.rerunRecord(ctx, record.ID); err != nil {
+			log.Printf("failed to retry payroll record %s: %v", record.ID.Hex(), err)
+		}
+	}
+
+	if len(records) > 0 {
+		log.Printf("retried %d failed payroll records", len(records))
+	}
+	return nil
+}
+
+// RerunPayroll re-runs the failed payroll of a user on the given date (YYYY-MM-DD). When several income sources
+// or income events failed that day, incomeSourceID or incomeEventID picks the one to re-run. It serves both the
+// user re-running their own payroll and an admin re-running anyone's.
+func (s *Service) RerunPayroll(ctx context.Context, userID string, date string, incomeSourceID string, incomeEventID string) (*PayrollRecord, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, errors.New("invalid user id")
+	}
+
+	var sourceObjID *primitive.ObjectID
+	if incomeSourceID != "" {
+		id, err := primitive.ObjectIDFromHex(incomeSourceID)
+		if err != nil {
+			return nil, errors.New("invalid income source id")
+		}
+		sourceObjID = &id
+	}
+
+	var eventObjID *primitive.ObjectID
+	if incomeEventID != "" {
+		id, err := primitive.ObjectIDFromHex(incomeEventID)
+		if err != nil {
+			return nil, errors.New("invalid income event id")
+		}
+		eventObjID = &id
+	}
+
+	payDate, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return nil, errors.New("invalid date format, expected YYYY-MM-DD")
+	}
+
+	records, err := s.payrollRepo.GetPayrollRecordsOnDate(ctx, userObjID, payDate.Year(), int(payDate.Month()), payDate.Day())
+	if err != nil {
+		return nil, err
+	}
+
+	var failed []*PayrollRecord
+	for _, record := range records {
+		if sourceObjID != nil && (record.IncomeSourceID == nil || *record.IncomeSourceID != *sourceObjID) {
+			continue
+		}
+		if eventObjID != nil && (record.IncomeEventID == nil || *record.IncomeEventID != *eventObjID) {
+			continue
+		}
+		if record.Status == StatusFailed {
+			failed = append(failed, record)
+		}
+	}
+
+	if len(failed) == 0 {
+		if len(records) == 0 {
+			return nil, errors.New("no payroll record on this date")
+		}
+		return nil, errors.New("payroll on this date did not fail")
+	}
+	if len(failed) > 1 {
+		return nil, errors.New("several income sources failed on this date, income_source_id is required")
+	}
+
+	return s.rerunRecord(ctx, failed[0].ID)
+}
+
+// rerunRecord claims a failed payroll record and credits the salary again. An income already recorded under the
+// payroll ref of that day, for example when the commit result was unknown, settles the record instead of
+// crediting the salary a second time.
+func (s *Service) rerunRecord(ctx context.Context, recordID primitive.ObjectID) (*PayrollRecord, error) {
+	record, err := s.payrollRepo.ClaimFailedRecord(ctx, recordID)
+	if err != nil {
+		return nil, err
+	}
+	if record == nil {
+		return nil, errors.New("payroll is not failed or is already being re-run")
+	}
+
+	payDate := time.Date(record.Year, time.Month(record.Month), record.Day, 0, 0, 0, 0, getJakartaLocation())
+
+	err = s.rerunPayroll(ctx, record, payDate)
+	setRecordOutcome(record, err)
+
+	if record.IncomeEventID != nil {
+		s.settleIncomeEvent(ctx, *record.IncomeEventID, record)
+	}
+
+	if err := s.payrollRepo.UpdatePayrollRecord(ctx, record); err != nil {
+		log.Printf("failed to update payroll record %s: %v", record.ID.Hex(), err)
+	}
+
+	return record, nil
+}
+
+func (s *Service) rerunPayroll(ctx context.Context, record *PayrollRecord, payDate time.Time) error {
+	u, err := s.userRepo.GetUserByID(ctx, record.UserID)
+	if err != nil {
+		return err
+	}
+	if !u.IsActive {
+		return errors.New("user is not active")
+	}
+
+	if record.IncomeEventID != nil {
+		event, err := s.incomeSourceRepo.GetIncomeEventByID(ctx, *record.IncomeEventID)
+		if err != nil {
+			return err
+		}
+		// A salary multiple whose salary could not be found has no amount yet
+		if record.Amount <= 0 {
+			if record.Amount, err = s.eventAmount(ctx, event); err != nil {
+				return err
+			}
+		}
+		return s.creditIncome(ctx, eventSource(event), record, payDate, event.FireAllocations)
+	}
+
+	// Payroll from before income sources paid the profile salary into the default targets
+	source := &income_source.IncomeSource{UserID: record.UserID}
+	if record.IncomeSourceID != nil {
+		source, err = s.incomeSourceRepo.GetIncomeSourceByID(ctx, *record.IncomeSourceID)
+		if err != nil {
+			return err
+		}
+	}
+
+	// A run that failed working out the deductions left the gross salary on the record
+	if record.Breakdown == nil {
+		settings, err := s.payrollRepo.GetTaxSettings(ctx, record.UserID)
+		if err != nil {
+			return err
+		}
+		if settings.appliesTo(source) {
+			if err := s.applyDeductions(ctx, settings, record, recordPayMonth(record, payDate)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return s.creditIncome(ctx, source, record, payDate, true)
+}
+
+// recordPayMonth returns the month a monthly payroll record pays for, which a pay date moved ahead of a holiday
+// can fall before
+func recordPayMonth(record *PayrollRecord, payDate time.Time) time.Time {
+	if month, err := time.ParseInLocation("2006-01", record.PeriodKey, payDate.Location()); err == nil {
+		return month
+	}
+	return payDate
+}
+
+// setRecordOutcome stores the result of a payroll run on its record. Transient failures are queued for a retry
+// with backoff.
+func setRecordOutcome(record *PayrollRecord, err error) {
+	record.NextRetryAt = nil
+
+	if err == nil {
+		record.Status = StatusSuccess
+		record.Error = nil
+		return
+	}
+
+	errMsg := err.Error()
+	record.Status = StatusFailed
+	record.Error = &errMsg
+	if utils.IsTransientError(err) {
+		record.NextRetryAt = utils.NextRetryAt(record.Attempts, time.Now())
+	}
+}
+
+// payrollRef is the ref of the income a payroll record credits on payDate. Payroll from before income sources
+// keeps its per-day ref, and an income event is credited once whatever the day.
+func payrollRef(record *PayrollRecord, payDate time.Time) string {
+	if record.IncomeEventID != nil {
+		return "income_event_" + record.IncomeEventID.Hex()
+	}
+	if record.IncomeSourceID == nil {
+		return "payroll_" + payDate.Format("2006_01_02")
+	}
+	return "payroll_" + record.IncomeSourceID.Hex() + "_" + payDate.Format("2006_01_02")
+}
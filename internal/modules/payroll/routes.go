@@ -0,0 +1,30 @@
+package payroll
+
+import (
+	"github.com/HasanNugroho/coin-be/internal/core/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterRoutes(r *gin.RouterGroup, controller *Controller) {
+	// User routes
+	protected := r.Group("")
+	{
+		protected.GET("/records", controller.ListPayrollRecords)
+		protected.POST("/records/rerun", controller.RerunPayroll)
+		protected.GET("/income-sources/:income_source_id/records", controller.ListIncomeSourceRecords)
+		protected.GET("/tax-settings", controller.GetTaxSettings)
+		protected.PUT("/tax-settings", controller.UpdateTaxSettings)
+		protected.POST("/net-salary", controller.CalculateNetSalary)
+		protected.GET("/deductions", controller.GetDeductionSummary)
+	}
+
+	// Admin routes
+	admin := r.Group("admin")
+	admin.Use(middleware.AdminMiddleware())
+	{
+		admin.GET("/runs", controller.AdminListRuns)
+		admin.POST("/runs", controller.AdminBackfillPayroll)
+		admin.GET("/runs/:run_id", controller.AdminGetRun)
+		admin.POST("/users/:user_id/records/rerun", controller.AdminRerunPayroll)
+	}
+}
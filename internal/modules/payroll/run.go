@@ -0,0 +1,131 @@
+package payroll
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// staleRunAge is how long a run may stay in progress before it is taken for abandoned by a crashed process
+const staleRunAge = time.Hour
+
+// BackfillPayroll runs the payroll of a past date (YYYY-MM-DD) for an admin, for example after the cron was
+// down. Sources already paid for the period are skipped, so a backfill never pays twice.
+func (s *Service) BackfillPayroll(ctx context.Context, adminID string, date string) (*PayrollRun, error) {
+	adminObjID, err := primitive.ObjectIDFromHex(adminID)
+	if err != nil {
+		return nil, errors.New("invalid user id")
+	}
+
+	loc := getJakartaLocation()
+	payDate, err := time.ParseInLocation("2006-01-02", date, loc)
+	if err != nil {
+		return nil, errors.New("invalid date format, expected YYYY-MM-DD")
+	}
+
+	if payDate.After(time.Now().In(loc)) {
+		return nil, errors.New("cannot run payroll for a future date")
+	}
+
+	return s.runPayroll(ctx, payDate, RunTriggerBackfill, &adminObjID)
+}
+
+// runPayroll runs the payroll of a date and records the run with its outcome
+func (s *Service) runPayroll(ctx context.Context, date time.Time, trigger string, triggeredBy *primitive.ObjectID) (*PayrollRun, error) {
+	run := &PayrollRun{
+		Date:        date.Format("2006-01-02"),
+		Trigger:     trigger,
+		TriggeredBy: triggeredBy,
+	}
+
+	if err := s.payrollRepo.AbandonStaleRuns(ctx, run.Date, time.Now().Add(-staleRunAge)); err != nil {
+		return nil, err
+	}
+
+	if err := s.payrollRepo.CreateRun(ctx, run); err != nil {
+		return nil, err
+	}
+
+	err := s.processPayroll(ctx, date, run)
+
+	run.Status = RunStatusCompleted
+	if err != nil {
+		errMsg := err.Error()
+		run.Status = RunStatusFailed
+		run.Error = &errMsg
+	}
+
+	if finishErr := s.payrollRepo.FinishRun(ctx, run); finishErr != nil {
+		log.Printf("failed to finish payroll run %s: %v", run.ID.Hex(), finishErr)
+	}
+
+	return run, err
+}
+
+// ListRuns returns a page of payroll runs with pay dates between two dates (YYYY-MM-DD, optional)
+func (s *Service) ListRuns(ctx context.Context, startDate string, endDate string, page int64, pageSize int64) ([]*PayrollRun, int64, error) {
+	for _, date := range []string{startDate, endDate} {
+		if date == "" {
+			continue
+		}
+		if _, err := time.Parse("2006-01-02", date); err != nil {
+			return nil, 0, errors.New("invalid date format, expected YYYY-MM-DD")
+		}
+	}
+
+	return s.payrollRepo.GetRuns(ctx, startDate, endDate, pageSize, (page-1)*pageSize)
+}
+
+// GetRun returns a payroll run with the records it created and how many of them currently succeeded, failed
+// or are being re-run
+func (s *Service) GetRun(ctx context.Context, runID string) (*PayrollRun, []*PayrollRecord, *RunStatusCounts, error) {
+	runObjID, err := primitive.ObjectIDFromHex(runID)
+	if err != nil {
+		return nil, nil, nil, errors.New("invalid payroll run id")
+	}
+
+	run, err := s.payrollRepo.GetRunByID(ctx, runObjID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	records, err := s.payrollRepo.GetRunRecords(ctx, run.ID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	counts := &RunStatusCounts{}
+	for _, record := range records {
+		switch record.Status {
+		case StatusSuccess:
+			counts.Success++
+		case StatusFailed:
+			counts.Failed++
+		case StatusPending:
+			counts.Pending++
+		}
+	}
+
+	return run, records, counts, nil
+}
+
+// ListPayrollRecords returns a page of the user's payroll records, newest first, optionally of one status
+func (s *Service) ListPayrollRecords(ctx context.Context, userID string, status string, page int64, pageSize int64) ([]*PayrollRecord, int64, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, 0, errors.New("invalid user id")
+	}
+
+	var statusFilter *string
+	if status != "" {
+		if status != StatusSuccess && status != StatusFailed && status != StatusPending {
+			return nil, 0, errors.New("invalid status")
+		}
+		statusFilter = &status
+	}
+
+	return s.payrollRepo.GetUserPayrollRecords(ctx, userObjID, statusFilter, pageSize, (page-1)*pageSize)
+}
@@ -3,74 +3,138 @@ package payroll
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log"
+	"strings"
 	"time"
 
 	"github.com/HasanNugroho/coin-be/internal/core/utils"
+	"github.com/HasanNugroho/coin-be/internal/modules/holiday"
+	"github.com/HasanNugroho/coin-be/internal/modules/income_source"
 	"github.com/HasanNugroho/coin-be/internal/modules/pocket"
 	"github.com/HasanNugroho/coin-be/internal/modules/transaction"
 	"github.com/HasanNugroho/coin-be/internal/modules/user"
+	"github.com/HasanNugroho/coin-be/internal/modules/user_category"
 	"github.com/HasanNugroho/coin-be/internal/modules/user_platform"
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
 type Service struct {
 	payrollRepo      *Repository
+	incomeSourceRepo *income_source.Repository
+	incomeSourceSvc  *income_source.Service
+	holidaySvc       *holiday.Service
 	userRepo         *user.Repository
 	userPlatformRepo *user_platform.UserPlatformRepository
 	pocketRepo       *pocket.Repository
 	transactionRepo  *transaction.Repository
+	transactionSvc   *transaction.Service
 	balanceProcessor *transaction.BalanceProcessor
+	categoryRepo     *user_category.Repository
 	db               *mongo.Database
 }
 
 func NewService(
 	payrollRepo *Repository,
+	incomeSourceRepo *income_source.Repository,
+	incomeSourceSvc *income_source.Service,
+	holidaySvc *holiday.Service,
 	userRepo *user.Repository,
 	userPlatformRepo *user_platform.UserPlatformRepository,
 	pocketRepo *pocket.Repository,
 	transactionRepo *transaction.Repository,
+	transactionSvc *transaction.Service,
 	balanceProcessor *transaction.BalanceProcessor,
+	categoryRepo *user_category.Repository,
 	db *mongo.Database,
 ) *Service {
 	return &Service{
 		payrollRepo:      payrollRepo,
+		incomeSourceRepo: incomeSourceRepo,
+		incomeSourceSvc:  incomeSourceSvc,
+		holidaySvc:       holidaySvc,
 		userRepo:         userRepo,
 		userPlatformRepo: userPlatformRepo,
 		pocketRepo:       pocketRepo,
 		transactionRepo:  transactionRepo,
+		transactionSvc:   transactionSvc,
 		balanceProcessor: balanceProcessor,
+		categoryRepo:     categoryRepo,
 		db:               db,
 	}
 }
 
-// ProcessDailyPayroll processes payroll for all eligible users on their salary day
+// ProcessDailyPayroll runs the payroll of today as a scheduled run
 func (s *Service) ProcessDailyPayroll(ctx context.Context) error {
-	now := time.Now()
-	today := now.Day()
+	now := time.Now().In(getJakartaLocation())
 
-	// Fetch eligible users using aggregation pipeline
-	eligibleUsers, err := s.getEligibleUsersForPayroll(ctx, today)
+	_, err := s.runPayroll(ctx, now, RunTriggerScheduled, nil)
+	return err
+}
+
+// processPayroll credits every auto-input income source that pays on date: monthly sources on their day of
+// the month, weekly sources on their weekday and daily sources every day, or every business day. Pay dates on a
+// weekend or holiday move by the source's adjustment policy. Each source is paid at most once per pay period,
+// and sources created after date are left out. Income events due by date are paid once as well. The outcome is
+// counted on the run.
+func (s *Service) processPayroll(ctx context.Context, now time.Time, run *PayrollRun) error {
+	// Salaries still set on a profile only, e.g. by an older client, become income sources first
+	if err := s.incomeSourceSvc.MigrateProfiles(ctx); err != nil {
+		log.Printf("failed to migrate profile salaries into income sources: %v", err)
+	}
+	s.reportUnpayableSources(ctx)
+
+	// One-off incomes such as THR or bonuses are paid before the recurring sources
+	if err := s.processIncomeEvents(ctx, now, run); err != nil {
+		log.Printf("failed to process income events for payroll: %v", err)
+	}
+
+	cal, err := s.holidaySvc.CalendarAround(ctx, now)
+	if err != nil {
+		log.Printf("failed to load holiday calendar for payroll: %v", err)
+		return err
+	}
+
+	dueSources, err := s.incomeSourceRepo.GetDueIncomeSources(ctx, now, cal)
 	if err != nil {
-		log.Printf("failed to fetch eligible users for payroll: %v", err)
+		log.Printf("failed to fetch due income sources for payroll: %v", err)
 		return err
 	}
 
-	if len(eligibleUsers) == 0 {
-		log.Printf("no eligible users for payroll processing today")
+	if len(dueSources) == 0 {
+		log.Printf("no income sources due for payroll processing on %s", run.Date)
 		return nil
 	}
 
-	// Extract user IDs for bulk payroll record check
-	userIDs := make([]primitive.ObjectID, len(eligibleUsers))
-	for i, userData := range eligibleUsers {
-		userIDs[i] = userData.Profile.UserID
+	// Extract user IDs and pay periods for bulk payroll record check
+	userIDs := make([]primitive.ObjectID, 0, len(dueSources))
+	periodKeys := make([]string, 0, 3)
+	seenUsers := make(map[primitive.ObjectID]bool)
+	seenKeys := make(map[string]bool)
+	scheduledDates := make(map[primitive.ObjectID]time.Time, len(dueSources))
+	for _, source := range dueSources {
+		if !seenUsers[source.UserID] {
+			seenUsers[source.UserID] = true
+			userIDs = append(userIDs, source.UserID)
+		}
+
+		// A moved pay date still belongs to the period it was scheduled in
+		scheduled, ok := income_source.ScheduledPayDate(source, now, cal)
+		if !ok {
+			scheduled = now
+		}
+		scheduledDates[source.ID] = scheduled
+
+		key := income_source.PeriodKey(income_source.NormalizeCycle(source.Cycle), scheduled)
+		if !seenKeys[key] {
+			seenKeys[key] = true
+			periodKeys = append(periodKeys, key)
+		}
 	}
 
 	// Check existing payroll records in bulk
-	existingRecords, err := s.payrollRepo.GetPayrollRecordByUserIDs(ctx, userIDs, now.Year(), int(now.Month()), today)
+	existingRecords, err := s.payrollRepo.GetPayrollRecordsByPeriods(ctx, userIDs, periodKeys)
 	if err != nil {
 		log.Printf("error checking payroll records: %v", err)
 		// Continue processing, don't fail entirely
@@ -78,135 +142,174 @@ func (s *Service) ProcessDailyPayroll(ctx context.Context) error {
 	}
 
 	// Create a map for faster lookup
-	existingRecordsMap := make(map[primitive.ObjectID]bool)
+	existingRecordsMap := make(map[string]bool)
 	for _, record := range existingRecords {
-		existingRecordsMap[record.UserID] = true
+		existingRecordsMap[recordKey(record.UserID, record.IncomeSourceID, record.PeriodKey)] = true
+	}
+
+	// Salaries paid net of PPh 21 and BPJS
+	taxSettings, err := s.payrollRepo.GetEnabledTaxSettings(ctx, userIDs)
+	if err != nil {
+		log.Printf("failed to fetch tax settings for payroll: %v", err)
+		return err
 	}
 
-	successCount := 0
-	failureCount := 0
-	newPayrollRecords := make([]*PayrollRecord, 0, len(eligibleUsers))
+	endOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location()).AddDate(0, 0, 1)
+	newPayrollRecords := make([]*PayrollRecord, 0, len(dueSources))
 
-	for _, userData := range eligibleUsers {
-		// Check if payroll already processed today
-		if existingRecordsMap[userData.Profile.UserID] {
-			log.Printf("payroll already processed for user %s", userData.Profile.UserID.Hex())
+	for _, source := range dueSources {
+		// A backfilled date does not pay sources that did not exist yet
+		if !source.CreatedAt.Before(endOfDay) {
+			run.SkippedCount++
 			continue
 		}
 
-		// Process payroll for this user
-		err = s.processUserPayroll(ctx, &userData.User, &userData.Profile)
+		cycle := income_source.NormalizeCycle(source.Cycle)
+		key := income_source.PeriodKey(cycle, scheduledDates[source.ID])
+
+		// Check if payroll already processed for this pay period. A source migrated from a profile also counts
+		// the period as paid when the profile salary was paid in it before the migration.
+		if existingRecordsMap[recordKey(source.UserID, &source.ID, key)] ||
+			(source.MigratedFromProfile && existingRecordsMap[recordKey(source.UserID, nil, key)]) {
+			log.Printf("payroll already processed for income source %s in period %s", source.ID.Hex(), key)
+			run.SkippedCount++
+			continue
+		}
 
 		// Prepare payroll record
 		record := &PayrollRecord{
-			UserID: userData.Profile.UserID,
-			Year:   now.Year(),
-			Month:  int(now.Month()),
-			Day:    today,
-			Amount: userData.Profile.BaseSalary,
+			UserID:         source.UserID,
+			IncomeSourceID: &source.ID,
+			RunID:          &run.ID,
+			Year:           now.Year(),
+			Month:          int(now.Month()),
+			Day:            now.Day(),
+			Cycle:          cycle,
+			PeriodKey:      key,
+			Amount:         source.Amount,
+			Attempts:       1,
+		}
+
+		// Credit the income of this source, net of deductions when the user set them up for it
+		err = nil
+		if settings := taxSettings[source.UserID]; settings.appliesTo(source) {
+			err = s.applyDeductions(ctx, settings, record, scheduledDates[source.ID])
+		}
+		if err == nil {
+			err = s.creditIncome(ctx, source, record, now, true)
 		}
 
 		if err != nil {
-			log.Printf("failed to process payroll for user %s: %v", userData.Profile.UserID.Hex(), err)
-			failureCount++
-			errMsg := err.Error()
-			record.Status = StatusFailed
-			record.Error = &errMsg
+			log.Printf("failed to process payroll for income source %s: %v", source.ID.Hex(), err)
+			run.FailureCount++
 		} else {
-			log.Printf("successfully processed payroll for user %s", userData.Profile.UserID.Hex())
-			successCount++
-			record.Status = StatusSuccess
+			log.Printf("successfully processed payroll for income source %s", source.ID.Hex())
+			run.SuccessCount++
 		}
+		setRecordOutcome(record, err)
 
 		newPayrollRecords = append(newPayrollRecords, record)
 	}
 
 	// Bulk insert all new payroll records
+	s.savePayrollRecords(ctx, newPayrollRecords)
+
+	log.Printf("payroll processing complete for %s: %d success, %d failures, %d skipped", run.Date, run.SuccessCount, run.FailureCount, run.SkippedCount)
+	return nil
+}
+
+// reportUnpayableSources logs the income sources left with a schedule that is never due, e.g. a biweekly cycle
+// from before it was retired, so they are fixed rather than silently never paid
+func (s *Service) reportUnpayableSources(ctx context.Context) {
+	sources, err := s.incomeSourceRepo.GetUnpayableIncomeSources(ctx)
+	if err != nil {
+		log.Printf("failed to check income sources for unsupported schedules: %v", err)
+		return
+	}
+	for _, source := range sources {
+		log.Printf("income source %s of user %s is never paid: cycle %q with pay day %d is not supported", source.ID.Hex(), source.UserID.Hex(), source.Cycle, source.PayDay)
+	}
+}
+
+// savePayrollRecords inserts the records of a run. A failure is logged rather than failing the run, the
+// income has already been credited.
+func (s *Service) savePayrollRecords(ctx context.Context, newPayrollRecords []*PayrollRecord) {
 	if len(newPayrollRecords) > 0 {
 		if err := s.payrollRepo.CreatePayrollRecordBulk(ctx, newPayrollRecords); err != nil {
 			log.Printf("failed to bulk insert payroll records: %v", err)
 			// Don't fail the entire process if record creation fails
 		}
 	}
-
-	log.Printf("payroll processing complete: %d success, %d failures", successCount, failureCount)
-	return nil
 }
 
-// UserPayrollData represents the joined data from user_profiles and users
-type UserPayrollData struct {
-	Profile user.UserProfile `bson:",inline"`
-	User    user.User        `bson:"user"`
-}
+// ListIncomeSourceRecords returns the payroll history of one of the user's income sources, newest first
+func (s *Service) ListIncomeSourceRecords(ctx context.Context, userID string, incomeSourceID string, limit int64) ([]*PayrollRecord, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, errors.New("invalid user id")
+	}
 
-// getEligibleUsersForPayroll fetches users eligible for payroll using aggregation
-func (s *Service) getEligibleUsersForPayroll(ctx context.Context, salaryDay int) ([]UserPayrollData, error) {
-	pipeline := mongo.Pipeline{
-		// Match profiles with the given salary day and auto_input_payroll enabled
-		{{Key: "$match", Value: bson.D{
-			{Key: "salary_day", Value: salaryDay},
-			{Key: "auto_input_payroll", Value: true},
-			{Key: "base_salary", Value: bson.D{{Key: "$gt", Value: 0}}},
-			{Key: "is_active", Value: true},
-		}}},
-		// Lookup user data
-		{{Key: "$lookup", Value: bson.D{
-			{Key: "from", Value: "users"},
-			{Key: "localField", Value: "user_id"},
-			{Key: "foreignField", Value: "_id"},
-			{Key: "as", Value: "user"},
-		}}},
-		// Unwind user array
-		{{Key: "$unwind", Value: bson.D{
-			{Key: "path", Value: "$user"},
-			{Key: "preserveNullAndEmptyArrays", Value: false},
-		}}},
-		// Filter only active users
-		{{Key: "$match", Value: bson.D{
-			{Key: "user.is_active", Value: true},
-		}}},
-	}
-
-	profileCol := s.db.Collection("user_profiles")
-	cursor, err := profileCol.Aggregate(ctx, pipeline)
+	sourceObjID, err := primitive.ObjectIDFromHex(incomeSourceID)
+	if err != nil {
+		return nil, errors.New("invalid income source id")
+	}
+
+	source, err := s.incomeSourceRepo.GetIncomeSourceByID(ctx, sourceObjID)
 	if err != nil {
 		return nil, err
 	}
-	defer cursor.Close(ctx)
 
-	results := make([]UserPayrollData, 0)
+	if source.UserID != userObjID {
+		return nil, errors.New("unauthorized")
+	}
 
-	if err := cursor.All(ctx, &results); err != nil {
-		return nil, err
+	if limit < 1 || limit > 100 {
+		limit = 20
 	}
 
-	return results, nil
+	return s.payrollRepo.GetIncomeSourceRecords(ctx, source.ID, limit)
 }
 
-// processUserPayroll processes payroll for a single user within a database transaction
-func (s *Service) processUserPayroll(ctx context.Context, u *user.User, profile *user.UserProfile) error {
-	// Validate default user platform
-	if profile.DefaultUserPlatformID == nil {
-		return errors.New("no default user platform configured")
+// creditIncome credits the amount of a payroll record as income of the source within a database transaction.
+// The source's user platform and pocket are used when set, otherwise the default user platform of the profile
+// and the main pocket. Income-triggered allocations run on the income when fireAllocations is set.
+func (s *Service) creditIncome(ctx context.Context, source *income_source.IncomeSource, record *PayrollRecord, payDate time.Time, fireAllocations bool) error {
+	// An income already recorded under the ref, for example by a backfill overlapping the cron or a commit whose
+	// result was unknown, settles the record instead of crediting twice
+	ref := payrollRef(record, payDate)
+	existing, err := s.transactionRepo.GetTransactionByRef(ctx, source.UserID, ref)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		return nil
 	}
 
-	defaultUserPlatform, err := s.userPlatformRepo.GetUserPlatformByID(ctx, *profile.DefaultUserPlatformID)
+	userPlatform, err := s.getTargetUserPlatform(ctx, source)
 	if err != nil {
-		return errors.New("default user platform not found")
+		return err
 	}
 
-	if !defaultUserPlatform.IsActive {
-		return errors.New("default user platform is not active")
+	targetPocket, err := s.getTargetPocket(ctx, source)
+	if err != nil {
+		return err
 	}
 
-	if defaultUserPlatform.UserID != u.ID {
-		return errors.New("default user platform does not belong to user")
+	note := "Payroll auto-input"
+	if source.Name != "" {
+		note = "Income auto-input: " + source.Name
 	}
 
-	// Get main pocket for income transaction
-	mainPocket, err := s.getMainPocket(ctx, u.ID)
-	if err != nil {
-		return err
+	// A salary with deductions is posted as its gross pay and one expense per deduction, so the taxes and
+	// contributions of the year show up in reports
+	amount := record.Amount
+	var deductionCategories map[string]primitive.ObjectID
+	if record.Breakdown != nil {
+		amount = record.Breakdown.Gross
+		deductionCategories, err = s.getDeductionCategories(ctx, source.UserID)
+		if err != nil {
+			return err
+		}
 	}
 
 	// Start database transaction
@@ -216,39 +319,140 @@ func (s *Service) processUserPayroll(ctx context.Context, u *user.User, profile
 	}
 	defer session.EndSession(ctx)
 
+	var incomeTransaction *transaction.Transaction
 	err = mongo.WithSession(ctx, session, func(sessionCtx mongo.SessionContext) error {
 		if err := session.StartTransaction(); err != nil {
 			return err
 		}
 
 		// Step 1: Create INCOME transaction
-		incomeTransaction := &transaction.Transaction{
-			UserID:           u.ID,
+		incomeTransaction = &transaction.Transaction{
+			UserID:           source.UserID,
 			Type:             string(transaction.TypeIncome),
-			Amount:           profile.BaseSalary,
-			PocketToID:       &mainPocket.ID,
-			UserPlatformToID: &defaultUserPlatform.ID,
-			Date:             time.Now(),
-			Note:             stringPtr("Payroll auto-input"),
-			Ref:              stringPtr("payroll_" + time.Now().Format("2006_01_02")),
+			Amount:           amount,
+			PocketToID:       &targetPocket.ID,
+			UserPlatformToID: &userPlatform.ID,
+			CategoryID:       source.CategoryID,
+			Date:             payDate,
+			Note:             stringPtr(note),
+			Ref:              stringPtr(ref),
 		}
 
-		// Persist income transaction
-		if err := s.transactionRepo.CreateTransaction(sessionCtx, incomeTransaction); err != nil {
+		// Persist income transaction, keeping the daily summary of a back-dated pay date in step
+		if err := s.transactionSvc.RecordTransaction(sessionCtx, incomeTransaction); err != nil {
 			session.AbortTransaction(sessionCtx)
-			return errors.New("failed to create income transaction: " + err.Error())
+			return fmt.Errorf("failed to create income transaction: %w", err)
+		}
+
+		// Step 2: Create an EXPENSE transaction per deduction, out of the pocket the salary went to
+		if record.Breakdown != nil {
+			for _, deduction := range record.Breakdown.Deductions {
+				if deduction.Amount <= 0 {
+					continue
+				}
+
+				categoryID := deductionCategories[deduction.Category]
+				deductionTransaction := &transaction.Transaction{
+					UserID:             source.UserID,
+					Type:               string(transaction.TypeExpense),
+					Amount:             deduction.Amount,
+					PocketFromID:       &targetPocket.ID,
+					UserPlatformFromID: &userPlatform.ID,
+					CategoryID:         &categoryID,
+					Date:               payDate,
+					Note:               stringPtr("Salary deduction: " + deductionCategoryNames[deduction.Category]),
+					Ref:                stringPtr(ref + "_" + strings.ToLower(deduction.Category)),
+				}
+
+				if err := s.transactionSvc.RecordTransaction(sessionCtx, deductionTransaction); err != nil {
+					session.AbortTransaction(sessionCtx)
+					return fmt.Errorf("failed to create deduction transaction: %w", err)
+				}
+			}
 		}
 
-		// Step 2: Update balances for income transaction
-		if err := s.updateBalancesForIncome(sessionCtx, mainPocket, defaultUserPlatform, profile.BaseSalary); err != nil {
+		// Step 3: Update balances by the net pay, the deductions never reaching the account
+		if err := s.updateBalancesForIncome(sessionCtx, targetPocket, userPlatform, record.Amount); err != nil {
 			session.AbortTransaction(sessionCtx)
-			return errors.New("failed to update balances for income: " + err.Error())
+			return fmt.Errorf("failed to update balances for income: %w", err)
 		}
 
 		return session.CommitTransaction(sessionCtx)
 	})
+	if err != nil {
+		return err
+	}
 
-	return err
+	// Step 4: Let income-triggered allocations run against the net pay that was credited
+	if fireAllocations {
+		credited := *incomeTransaction
+		credited.Amount = record.Amount
+		s.transactionSvc.NotifyIncome(&credited)
+	}
+
+	return nil
+}
+
+// getTargetUserPlatform returns the user platform an income source pays into
+func (s *Service) getTargetUserPlatform(ctx context.Context, source *income_source.IncomeSource) (*user_platform.UserPlatform, error) {
+	userPlatformID := source.UserPlatformID
+	if userPlatformID == nil {
+		profile, err := s.userRepo.GetUserProfileByUserID(ctx, source.UserID)
+		if err != nil {
+			return nil, err
+		}
+		if profile.DefaultUserPlatformID == nil {
+			return nil, errors.New("no default user platform configured")
+		}
+		userPlatformID = profile.DefaultUserPlatformID
+	}
+
+	userPlatform, err := s.userPlatformRepo.GetUserPlatformByID(ctx, *userPlatformID)
+	if err != nil {
+		return nil, errors.New("user platform not found")
+	}
+
+	if !userPlatform.IsActive {
+		return nil, errors.New("user platform is not active")
+	}
+
+	if userPlatform.UserID != source.UserID {
+		return nil, errors.New("user platform does not belong to user")
+	}
+
+	return userPlatform, nil
+}
+
+// getTargetPocket returns the pocket an income source pays into
+func (s *Service) getTargetPocket(ctx context.Context, source *income_source.IncomeSource) (*pocket.Pocket, error) {
+	if source.PocketID == nil {
+		return s.getMainPocket(ctx, source.UserID)
+	}
+
+	targetPocket, err := s.pocketRepo.GetPocketByID(ctx, *source.PocketID)
+	if err != nil {
+		return nil, errors.New("pocket not found")
+	}
+
+	if !targetPocket.IsActive {
+		return nil, errors.New("pocket is not active")
+	}
+
+	if targetPocket.UserID != source.UserID {
+		return nil, errors.New("pocket does not belong to user")
+	}
+
+	return targetPocket, nil
+}
+
+// recordKey identifies the payroll of an income source in a pay period. Records from before income sources have
+// no source.
+func recordKey(userID primitive.ObjectID, incomeSourceID *primitive.ObjectID, periodKey string) string {
+	source := "profile"
+	if incomeSourceID != nil {
+		source = incomeSourceID.Hex()
+	}
+	return userID.Hex() + "_" + source + "_" + periodKey
 }
 
 // balanceUpdate tracks a balance change for batch processing
@@ -0,0 +1,228 @@
+package payroll
+
+import (
+	"math"
+	"time"
+)
+
+// PTKP statuses: TK for unmarried and K for married taxpayers, with up to three dependents
+var ptkpAmounts = map[string]float64{
+	"TK/0": 54000000,
+	"TK/1": 58500000,
+	"TK/2": 63000000,
+	"TK/3": 67500000,
+	"K/0":  58500000,
+	"K/1":  63000000,
+	"K/2":  67500000,
+	"K/3":  72000000,
+}
+
+// IsValidPTKPStatus reports whether status is a known PTKP status
+func IsValidPTKPStatus(status string) bool {
+	_, ok := ptkpAmounts[status]
+	return ok
+}
+
+// BPJS contribution rates and wage caps
+const (
+	bpjsKesehatanEmployeeRate = 0.01
+	bpjsKesehatanEmployerRate = 0.04
+	bpjsKesehatanWageCap      = 12000000
+	bpjsJHTEmployeeRate       = 0.02
+	bpjsJPEmployeeRate        = 0.01
+	bpjsJPWageCap             = 10547400 // set by BPJS Ketenagakerjaan every year
+	bpjsJKMEmployerRate       = 0.003
+	DefaultJKKRate            = 0.0024 // lowest risk class
+)
+
+// Annual PPh 21 parameters
+const (
+	biayaJabatanRate      = 0.05
+	biayaJabatanAnnualCap = 6000000
+)
+
+// terBracket is the monthly TER rate of gross income up to max
+type terBracket struct {
+	max  float64
+	rate float64
+}
+
+// terTables are the monthly effective rates (tarif efektif rata-rata) of PP 58/2023 by TER category. The last
+// bracket of each table has no upper bound.
+var terTables = map[string][]terBracket{
+	"A": {
+		{5400000, 0}, {5650000, 0.0025}, {5950000, 0.005}, {6300000, 0.0075}, {6750000, 0.01},
+		{7500000, 0.0125}, {8550000, 0.015}, {9650000, 0.0175}, {10050000, 0.02}, {10350000, 0.0225},
+		{10700000, 0.025}, {11050000, 0.03}, {11600000, 0.035}, {12500000, 0.04}, {13750000, 0.05},
+		{15100000, 0.06}, {16950000, 0.07}, {19750000, 0.08}, {24150000, 0.09}, {26450000, 0.10},
+		{28000000, 0.11}, {30050000, 0.12}, {32400000, 0.13}, {35400000, 0.14}, {39100000, 0.15},
+		{43850000, 0.16}, {47800000, 0.17}, {51400000, 0.18}, {56300000, 0.19}, {62200000, 0.20},
+		{68600000, 0.21}, {77500000, 0.22}, {89000000, 0.23}, {103000000, 0.24}, {125000000, 0.25},
+		{157000000, 0.26}, {206000000, 0.27}, {337000000, 0.28}, {454000000, 0.29}, {550000000, 0.30},
+		{695000000, 0.31}, {910000000, 0.32}, {1400000000, 0.33}, {math.Inf(1), 0.34},
+	},
+	"B": {
+		{6200000, 0}, {6500000, 0.0025}, {6850000, 0.005}, {7300000, 0.0075}, {9200000, 0.01},
+		{10750000, 0.015}, {11250000, 0.02}, {11600000, 0.025}, {12600000, 0.03}, {13600000, 0.04},
+		{14950000, 0.05}, {16400000, 0.06}, {18450000, 0.07}, {21850000, 0.08}, {26000000, 0.09},
+		{27700000, 0.10}, {29350000, 0.11}, {31450000, 0.12}, {33950000, 0.13}, {37100000, 0.14},
+		{41100000, 0.15}, {45800000, 0.16}, {49500000, 0.17}, {53800000, 0.18}, {58500000, 0.19},
+		{64000000, 0.20}, {71000000, 0.21}, {80000000, 0.22}, {93000000, 0.23}, {109000000, 0.24},
+		{129000000, 0.25}, {163000000, 0.26}, {211000000, 0.27}, {374000000, 0.28}, {459000000, 0.29},
+		{555000000, 0.30}, {704000000, 0.31}, {957000000, 0.32}, {1405000000, 0.33}, {math.Inf(1), 0.34},
+	},
+	"C": {
+		{6600000, 0}, {6950000, 0.0025}, {7350000, 0.005}, {7800000, 0.0075}, {8850000, 0.01},
+		{9800000, 0.0125}, {10950000, 0.015}, {11200000, 0.0175}, {12050000, 0.02}, {12950000, 0.03},
+		{14150000, 0.04}, {15550000, 0.05}, {17050000, 0.06}, {19500000, 0.07}, {22700000, 0.08},
+		{26600000, 0.09}, {28100000, 0.10}, {30100000, 0.11}, {32600000, 0.12}, {35400000, 0.13},
+		{38900000, 0.14}, {43000000, 0.15}, {47400000, 0.16}, {51200000, 0.17}, {55800000, 0.18},
+		{60400000, 0.19}, {66700000, 0.20}, {74500000, 0.21}, {83200000, 0.22}, {95600000, 0.23},
+		{110000000, 0.24}, {134000000, 0.25}, {169000000, 0.26}, {221000000, 0.27}, {390000000, 0.28},
+		{463000000, 0.29}, {561000000, 0.30}, {709000000, 0.31}, {965000000, 0.32}, {1419000000, 0.33},
+		{math.Inf(1), 0.34},
+	},
+}
+
+// annualTaxBrackets are the progressive PPh 21 rates of UU HPP on taxable income (PKP) up to max
+var annualTaxBrackets = []terBracket{
+	{60000000, 0.05},
+	{250000000, 0.15},
+	{500000000, 0.25},
+	{5000000000, 0.30},
+	{math.Inf(1), 0.35},
+}
+
+// yearToDate totals the salary payments of a tax year before December, for the year-end reconciliation
+type yearToDate struct {
+	taxableGross float64
+	pension      float64 // JHT and JP paid by the employee, deductible from the yearly income
+	pph21        float64
+}
+
+// terCategory returns the TER category of a PTKP status
+func terCategory(ptkpStatus string) string {
+	switch ptkpStatus {
+	case "TK/2", "TK/3", "K/1", "K/2":
+		return "B"
+	case "K/3":
+		return "C"
+	default:
+		return "A"
+	}
+}
+
+// terRate returns the monthly TER rate of a gross income
+func terRate(category string, taxableGross float64) float64 {
+	for _, bracket := range terTables[category] {
+		if taxableGross <= bracket.max {
+			return bracket.rate
+		}
+	}
+	return 0
+}
+
+// annualIncomeTax returns the PPh 21 of a year's taxable income, rounded down to a thousand rupiah first
+func annualIncomeTax(pkp float64) float64 {
+	pkp = math.Floor(pkp/1000) * 1000
+
+	tax, lower := 0.0, 0.0
+	for _, bracket := range annualTaxBrackets {
+		if pkp <= lower {
+			break
+		}
+		tax += (math.Min(pkp, bracket.max) - lower) * bracket.rate
+		lower = bracket.max
+	}
+	return tax
+}
+
+// calculateNetSalary works out the net pay of a monthly gross salary. PPh 21 is withheld at the TER rate of the
+// taxable gross from January to November. In December it is the tax of the whole year less what was withheld
+// before, given in ytd.
+func calculateNetSalary(gross float64, settings *TaxSettings, payMonth time.Time, ytd *yearToDate) *SalaryBreakdown {
+	if ytd == nil {
+		ytd = &yearToDate{}
+	}
+
+	breakdown := &SalaryBreakdown{
+		TaxYear:     payMonth.Year(),
+		TaxMonth:    int(payMonth.Month()),
+		PTKPStatus:  settings.PTKPStatus,
+		TERCategory: terCategory(settings.PTKPStatus),
+		Gross:       gross,
+		Deductions:  make([]*Deduction, 0, 4),
+	}
+
+	taxableGross := gross
+	var pension float64
+
+	if settings.BPJSKesehatan {
+		base := math.Min(gross, bpjsKesehatanWageCap)
+		taxableGross += math.Round(base * bpjsKesehatanEmployerRate)
+		breakdown.addDeduction(DeductionBPJSKesehatan, math.Round(base*bpjsKesehatanEmployeeRate))
+	}
+
+	if settings.BPJSKetenagakerjaan {
+		jkkRate := settings.JKKRate
+		if jkkRate <= 0 {
+			jkkRate = DefaultJKKRate
+		}
+		taxableGross += math.Round(gross*jkkRate) + math.Round(gross*bpjsJKMEmployerRate)
+
+		jht := math.Round(gross * bpjsJHTEmployeeRate)
+		jp := math.Round(math.Min(gross, bpjsJPWageCap) * bpjsJPEmployeeRate)
+		pension = jht + jp
+		breakdown.addDeduction(DeductionBPJSJHT, jht)
+		breakdown.addDeduction(DeductionBPJSJP, jp)
+	}
+
+	breakdown.TaxableGross = taxableGross
+
+	var pph21 float64
+	if payMonth.Month() == time.December {
+		yearGross := ytd.taxableGross + taxableGross
+		biayaJabatan := math.Min(yearGross*biayaJabatanRate, biayaJabatanAnnualCap)
+		pkp := yearGross - biayaJabatan - (ytd.pension + pension) - ptkpAmounts[settings.PTKPStatus]
+		if pkp > 0 {
+			pph21 = math.Max(annualIncomeTax(pkp)-ytd.pph21, 0)
+		}
+	} else {
+		breakdown.TERRate = terRate(breakdown.TERCategory, taxableGross)
+		pph21 = math.Floor(taxableGross * breakdown.TERRate)
+	}
+	breakdown.addDeduction(DeductionPPh21, pph21)
+
+	breakdown.Net = gross
+	for _, deduction := range breakdown.Deductions {
+		breakdown.Net -= deduction.Amount
+	}
+
+	return breakdown
+}
+
+// addDeduction adds a deduction to the breakdown
+func (b *SalaryBreakdown) addDeduction(category string, amount float64) {
+	b.Deductions = append(b.Deductions, &Deduction{Category: category, Amount: amount})
+}
+
+// pension returns the JHT and JP contributions of the breakdown
+func (b *SalaryBreakdown) pension() float64 {
+	var total float64
+	for _, deduction := range b.Deductions {
+		if deduction.Category == DeductionBPJSJHT || deduction.Category == DeductionBPJSJP {
+			total += deduction.Amount
+		}
+	}
+	return total
+}
+
+// pph21 returns the PPh 21 withheld in the breakdown
+func (b *SalaryBreakdown) pph21() float64 {
+	for _, deduction := range b.Deductions {
+		if deduction.Category == DeductionPPh21 {
+			return deduction.Amount
+		}
+	}
+	return 0
+}
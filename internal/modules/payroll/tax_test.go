@@ -0,0 +1,135 @@
+package payroll
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCalculateNetSalary(t *testing.T) {
+	bothPrograms := &TaxSettings{PTKPStatus: "TK/0", BPJSKesehatan: true, BPJSKetenagakerjaan: true}
+	june := time.Date(2026, time.June, 1, 0, 0, 0, 0, time.UTC)
+	december := time.Date(2026, time.December, 1, 0, 0, 0, 0, time.UTC)
+
+	// Eleven months of the 10,000,000 salary below: taxable gross 10,454,000, JHT and JP 300,000, PPh 21 261,350
+	elevenMonths := &yearToDate{taxableGross: 11 * 10454000, pension: 11 * 300000, pph21: 11 * 261350}
+
+	tests := []struct {
+		name         string
+		gross        float64
+		settings     *TaxSettings
+		payMonth     time.Time
+		ytd          *yearToDate
+		taxableGross float64
+		terRate      float64
+		deductions   map[string]float64
+		net          float64
+	}{
+		{
+			name:         "TK/0 mid-year at the TER rate",
+			gross:        10000000,
+			settings:     bothPrograms,
+			payMonth:     june,
+			taxableGross: 10454000, // with Kesehatan 400,000, JKK 24,000 and JKM 30,000 paid by the employer
+			terRate:      0.025,
+			deductions: map[string]float64{
+				DeductionBPJSKesehatan: 100000,
+				DeductionBPJSJHT:       200000,
+				DeductionBPJSJP:        100000,
+				DeductionPPh21:         261350,
+			},
+			net: 9338650,
+		},
+		{
+			name:         "JP and Kesehatan above their wage caps",
+			gross:        15000000,
+			settings:     bothPrograms,
+			payMonth:     june,
+			taxableGross: 15561000, // Kesehatan 480,000 on the 12,000,000 cap, JKK 36,000, JKM 45,000
+			terRate:      0.07,
+			deductions: map[string]float64{
+				DeductionBPJSKesehatan: 120000,
+				DeductionBPJSJHT:       300000,
+				DeductionBPJSJP:        105474, // 1% of the 10,547,400 cap
+				DeductionPPh21:         1089270,
+			},
+			net: 13385256,
+		},
+		{
+			name:         "JP cap without BPJS Kesehatan",
+			gross:        15000000,
+			settings:     &TaxSettings{PTKPStatus: "TK/0", BPJSKetenagakerjaan: true},
+			payMonth:     june,
+			taxableGross: 15081000,
+			terRate:      0.06,
+			deductions: map[string]float64{
+				DeductionBPJSJHT: 300000,
+				DeductionBPJSJP:  105474,
+				DeductionPPh21:   904860,
+			},
+			net: 13689666,
+		},
+		{
+			name:         "December true-up against the year to date",
+			gross:        10000000,
+			settings:     bothPrograms,
+			payMonth:     december,
+			ytd:          elevenMonths,
+			taxableGross: 10454000,
+			// PKP 125,448,000 - 6,000,000 biaya jabatan - 3,600,000 pension - 54,000,000 PTKP = 61,848,000, taxed
+			// 3,277,200 for the year, less the 2,874,850 withheld before
+			deductions: map[string]float64{
+				DeductionBPJSKesehatan: 100000,
+				DeductionBPJSJHT:       200000,
+				DeductionBPJSJP:        100000,
+				DeductionPPh21:         402350,
+			},
+			net: 9197650,
+		},
+		{
+			name:         "December after withholding more than the year's tax",
+			gross:        10000000,
+			settings:     bothPrograms,
+			payMonth:     december,
+			ytd:          &yearToDate{taxableGross: elevenMonths.taxableGross, pension: elevenMonths.pension, pph21: 4000000},
+			taxableGross: 10454000,
+			deductions: map[string]float64{
+				DeductionBPJSKesehatan: 100000,
+				DeductionBPJSJHT:       200000,
+				DeductionBPJSJP:        100000,
+				DeductionPPh21:         0,
+			},
+			net: 9600000,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			breakdown := calculateNetSalary(tt.gross, tt.settings, tt.payMonth, tt.ytd)
+
+			if breakdown.TaxableGross != tt.taxableGross {
+				t.Errorf("taxable gross = %v, want %v", breakdown.TaxableGross, tt.taxableGross)
+			}
+			if breakdown.TERRate != tt.terRate {
+				t.Errorf("TER rate = %v, want %v", breakdown.TERRate, tt.terRate)
+			}
+
+			if len(breakdown.Deductions) != len(tt.deductions) {
+				t.Errorf("got %d deductions, want %d", len(breakdown.Deductions), len(tt.deductions))
+			}
+			for _, deduction := range breakdown.Deductions {
+				want, ok := tt.deductions[deduction.Category]
+				if !ok {
+					t.Errorf("unexpected %s deduction", deduction.Category)
+					continue
+				}
+				if deduction.Amount != want {
+					t.Errorf("%s = %v, want %v", deduction.Category, deduction.Amount, want)
+				}
+			}
+
+			if breakdown.Net != tt.net {
+				t.Errorf("net = %v, want %v", breakdown.Net, tt.net)
+			}
+		})
+	}
+}
@@ -5,16 +5,18 @@ import (
 	"strconv"
 
 	"github.com/HasanNugroho/coin-be/internal/core/utils"
+	"github.com/HasanNugroho/coin-be/internal/modules/balance_snapshot"
 	"github.com/HasanNugroho/coin-be/internal/modules/pocket/dto"
 	"github.com/gin-gonic/gin"
 )
 
 type Controller struct {
-	service *Service
+	service                *Service
+	balanceSnapshotService *balance_snapshot.Service
 }
 
-func NewController(s *Service) *Controller {
-	return &Controller{service: s}
+func NewController(s *Service, bss *balance_snapshot.Service) *Controller {
+	return &Controller{service: s, balanceSnapshotService: bss}
 }
 
 // CreatePocket godoc
@@ -473,6 +475,8 @@ func (c *Controller) mapToResponse(pocket *Pocket) *dto.PocketResponse {
 		CategoryID:      categoryID,
 		Balance:         utils.Decimal128ToFloat64(pocket.Balance),
 		TargetBalance:   targetBalance,
+		TargetDate:      pocket.TargetDate,
+		Loan:            c.mapLoanToResponse(pocket.Loan),
 		IsDefault:       pocket.IsDefault,
 		IsActive:        pocket.IsActive,
 		IsLocked:        pocket.IsLocked,
@@ -485,6 +489,92 @@ func (c *Controller) mapToResponse(pocket *Pocket) *dto.PocketResponse {
 	}
 }
 
+func (c *Controller) mapLoanToResponse(loan *LoanDetail) *dto.LoanResponse {
+	if loan == nil {
+		return nil
+	}
+
+	return &dto.LoanResponse{
+		Principal:    loan.Principal,
+		InterestRate: loan.InterestRate,
+		InterestType: loan.InterestType,
+		TenorMonths:  loan.TenorMonths,
+		DueDay:       loan.DueDay,
+		StartDate:    loan.StartDate,
+	}
+}
+
+func (c *Controller) mapLoanSummaryToResponse(summary *LoanSummary) *dto.LoanSummaryResponse {
+	schedule := make([]*dto.AmortizationRowResponse, len(summary.Schedule))
+	for i, row := range summary.Schedule {
+		schedule[i] = &dto.AmortizationRowResponse{
+			Installment:      row.Installment,
+			DueDate:          row.DueDate,
+			Payment:          row.Payment,
+			Principal:        row.Principal,
+			Interest:         row.Interest,
+			RemainingBalance: row.RemainingBalance,
+			PaidAmount:       row.PaidAmount,
+			IsPaid:           row.IsPaid,
+		}
+	}
+
+	payments := make([]*dto.LoanPaymentResponse, len(summary.Payments))
+	for i, payment := range summary.Payments {
+		payments[i] = &dto.LoanPaymentResponse{
+			TransactionID: payment.TransactionID.Hex(),
+			Date:          payment.Date,
+			Amount:        payment.Amount,
+			Principal:     payment.Principal,
+			Interest:      payment.Interest,
+			Excess:        payment.Excess,
+		}
+	}
+
+	return &dto.LoanSummaryResponse{
+		PocketID:            summary.Pocket.ID.Hex(),
+		Name:                summary.Pocket.Name,
+		Loan:                c.mapLoanToResponse(summary.Pocket.Loan),
+		InstallmentAmount:   summary.InstallmentAmount,
+		TotalInterest:       summary.TotalInterest,
+		TotalPayable:        summary.TotalPayable,
+		PrincipalPaid:       summary.PrincipalPaid,
+		InterestPaid:        summary.InterestPaid,
+		RemainingPrincipal:  summary.RemainingPrincipal,
+		RemainingBalance:    summary.RemainingBalance,
+		PaidInstallments:    summary.PaidInstallments,
+		OverdueInstallments: summary.OverdueInstallments,
+		OverdueAmount:       summary.OverdueAmount,
+		NextDueDate:         summary.NextDueDate,
+		PayoffDate:          summary.PayoffDate,
+		IsPaidOff:           summary.IsPaidOff,
+		Schedule:            schedule,
+		Payments:            payments,
+	}
+}
+
+func (c *Controller) mapGoalToResponse(goal *GoalProgress) *dto.GoalResponse {
+	return &dto.GoalResponse{
+		PocketID:                    goal.Pocket.ID.Hex(),
+		Name:                        goal.Pocket.Name,
+		Type:                        goal.Pocket.Type,
+		Icon:                        goal.Pocket.Icon,
+		IconColor:                   goal.Pocket.IconColor,
+		BackgroundColor:             goal.Pocket.BackgroundColor,
+		TargetAmount:                goal.TargetAmount,
+		CurrentAmount:               goal.CurrentAmount,
+		RemainingAmount:             goal.RemainingAmount,
+		ProgressPercentage:          goal.ProgressPercentage,
+		TargetDate:                  goal.Pocket.TargetDate,
+		MonthsRemaining:             goal.MonthsRemaining,
+		RequiredMonthlyContribution: goal.RequiredMonthlyContribution,
+		AverageMonthlyContribution:  goal.AverageMonthlyContribution,
+		ProjectedCompletionDate:     goal.ProjectedCompletionDate,
+		Status:                      string(goal.Status),
+		LastMilestone:               goal.Pocket.GoalMilestone,
+	}
+}
+
 func (c *Controller) mapToResponseList(pockets []*Pocket) []*dto.PocketResponse {
 	responses := make([]*dto.PocketResponse, len(pockets))
 	for i, pocket := range pockets {
@@ -512,3 +602,161 @@ func (c *Controller) mapToResponseDropdownList(pockets []*Pocket) []*dto.PocketD
 	}
 	return responses
 }
+
+// GetPocketBalanceHistory godoc
+// @Summary Get pocket balance history
+// @Description Get the daily closing balances of a pocket within a date range. Defaults to the last 30 days.
+// Pocketsags Pockets
+// @Accept json
+// @Produce json
+// @Param id path string true "Pocket ID"
+// @Param start_date query string false "Start date (YYYY-MM-DD)"
+// @Param end_date query string false "End date (YYYY-MM-DD)"
+// @Success 200 {object} map[string]interface{} "Pocket balance history retrieved successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Security BearerAuth
+// @Router /v1/pockets/{id}/balance-history [get]
+func (c *Controller) GetPocketBalanceHistory(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		resp := utils.NewErrorResponse(http.StatusUnauthorized, "unauthorized")
+		ctx.JSON(http.StatusUnauthorized, resp)
+		return
+	}
+
+	startDate, err := balance_snapshot.ParseDate(ctx.Query("start_date"))
+	if err != nil {
+		resp := utils.NewErrorResponse(http.StatusBadRequest, err.Error())
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	endDate, err := balance_snapshot.ParseDate(ctx.Query("end_date"))
+	if err != nil {
+		resp := utils.NewErrorResponse(http.StatusBadRequest, err.Error())
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	history, err := c.balanceSnapshotService.GetBalanceHistory(ctx, userID.(string), balance_snapshot.EntityTypePocket, ctx.Param("id"), startDate, endDate)
+	if err != nil {
+		resp := utils.NewErrorResponse(http.StatusBadRequest, err.Error())
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	resp := utils.NewSuccessResponse("Pocket balance history retrieved successfully", history)
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// GetGoalsOverview godoc
+// @Summary Get savings goals overview
+// @Description Get progress, required monthly contribution and projected completion of every pocket with a target balance
+// @Tags Pockets
+// @Accept json
+// @Produce json
+// @Param months query int false "Number of past months used to project contributions (default: 3, max: 12)"
+// @Success 200 {object} map[string]interface{} "Goals overview retrieved successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Security BearerAuth
+// @Router /v1/pockets/goals [get]
+func (c *Controller) GetGoalsOverview(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		resp := utils.NewErrorResponse(http.StatusUnauthorized, "unauthorized")
+		ctx.JSON(http.StatusUnauthorized, resp)
+		return
+	}
+
+	months, _ := strconv.Atoi(ctx.DefaultQuery("months", "0"))
+
+	overview, err := c.service.GetGoalsOverview(ctx, userID.(string), months)
+	if err != nil {
+		resp := utils.NewErrorResponse(http.StatusBadRequest, err.Error())
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	goals := make([]*dto.GoalResponse, len(overview.Goals))
+	for i, goal := range overview.Goals {
+		goals[i] = c.mapGoalToResponse(goal)
+	}
+
+	resp := utils.NewSuccessResponse("Goals overview retrieved successfully", &dto.GoalOverviewResponse{
+		Goals:                     goals,
+		TotalTargetAmount:         overview.TotalTargetAmount,
+		TotalCurrentAmount:        overview.TotalCurrentAmount,
+		OverallProgressPercentage: overview.OverallProgressPercentage,
+		TotalRequiredMonthly:      overview.TotalRequiredMonthly,
+		AchievedCount:             overview.AchievedCount,
+		BehindCount:               overview.BehindCount,
+	})
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// GetGoal godoc
+// @Summary Get savings goal progress
+// @Description Get progress, required monthly contribution and projected completion of a pocket with a target balance
+// @Tags Pockets
+// @Accept json
+// @Produce json
+// @Param id path string true "Pocket ID"
+// @Param months query int false "Number of past months used to project contributions (default: 3, max: 12)"
+// @Success 200 {object} map[string]interface{} "Goal retrieved successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Security BearerAuth
+// @Router /v1/pockets/{id}/goal [get]
+func (c *Controller) GetGoal(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		resp := utils.NewErrorResponse(http.StatusUnauthorized, "unauthorized")
+		ctx.JSON(http.StatusUnauthorized, resp)
+		return
+	}
+
+	months, _ := strconv.Atoi(ctx.DefaultQuery("months", "0"))
+
+	goal, err := c.service.GetGoal(ctx, userID.(string), ctx.Param("id"), months)
+	if err != nil {
+		resp := utils.NewErrorResponse(http.StatusBadRequest, err.Error())
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	resp := utils.NewSuccessResponse("Goal retrieved successfully", c.mapGoalToResponse(goal))
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// GetLoan godoc
+// @Summary Get debt pocket loan summary
+// @Description Get the amortization schedule of a debt pocket, its payments split into principal and interest, the remaining balance and the payoff date. Payments are expenses paid out of the debt pocket.
+// @Tags Pockets
+// @Accept json
+// @Produce json
+// @Param id path string true "Pocket ID"
+// @Success 200 {object} map[string]interface{} "Loan retrieved successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Security BearerAuth
+// @Router /v1/pockets/{id}/loan [get]
+func (c *Controller) GetLoan(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		resp := utils.NewErrorResponse(http.StatusUnauthorized, "unauthorized")
+		ctx.JSON(http.StatusUnauthorized, resp)
+		return
+	}
+
+	summary, err := c.service.GetLoanSummary(ctx, userID.(string), ctx.Param("id"))
+	if err != nil {
+		resp := utils.NewErrorResponse(http.StatusBadRequest, err.Error())
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	resp := utils.NewSuccessResponse("Loan retrieved successfully", c.mapLoanSummaryToResponse(summary))
+	ctx.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,49 @@
+package pocket
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/HasanNugroho/coin-be/internal/core/utils"
+	"github.com/robfig/cron/v3"
+)
+
+type CronJob struct {
+	service *Service
+	cron    *cron.Cron
+}
+
+func NewCronJob(service *Service) *CronJob {
+	return &CronJob{
+		service: service,
+		cron:    cron.New(cron.WithLocation(utils.GetJakartaLocation())),
+	}
+}
+
+// Start begins the savings goal milestone check
+// Runs every hour at minute 30 (Asia/Jakarta timezone)
+func (c *CronJob) Start() error {
+	_, err := c.cron.AddFunc("30 * * * *", func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+		defer cancel()
+
+		if err := c.service.CheckGoalMilestones(ctx); err != nil {
+			log.Printf("Error checking goal milestones: %v", err)
+		}
+	})
+
+	if err != nil {
+		return err
+	}
+
+	c.cron.Start()
+	log.Println("Pocket goal cron job started")
+	return nil
+}
+
+// Stop stops the cron job
+func (c *CronJob) Stop() {
+	c.cron.Stop()
+	log.Println("Pocket goal cron job stopped")
+}
@@ -1,24 +1,38 @@
 package dto
 
+// LoanRequest describes the loan of a debt pocket. InterestRate is the annual rate in percent.
+type LoanRequest struct {
+	Principal    float64 `json:"principal" validate:"required,gt=0"`
+	InterestRate float64 `json:"interest_rate" validate:"gte=0,lte=100"`
+	InterestType string  `json:"interest_type" validate:"required,oneof=flat effective"`
+	TenorMonths  int     `json:"tenor_months" validate:"required,gt=0,lte=600"`
+	DueDay       int     `json:"due_day" validate:"required,min=1,max=31"`
+	StartDate    string  `json:"start_date" validate:"required,datetime=2006-01-02"`
+}
+
 type CreatePocketRequest struct {
-	Name            string   `json:"name" validate:"required,min=2,max=255"`
-	Type            string   `json:"type" validate:"required,oneof=main allocation saving debt"`
-	CategoryID      string   `json:"category_id" validate:"omitempty,len=24,hexadecimal"`
-	TargetBalance   *float64 `json:"target_balance" validate:"omitempty,gt=0"`
-	Icon            string   `json:"icon" validate:"omitempty,max=100"`
-	IconColor       string   `json:"icon_color" validate:"omitempty,max=50"`
-	BackgroundColor string   `json:"background_color" validate:"omitempty,max=50"`
+	Name            string       `json:"name" validate:"required,min=2,max=255"`
+	Type            string       `json:"type" validate:"required,oneof=main allocation saving debt"`
+	CategoryID      string       `json:"category_id" validate:"omitempty,len=24,hexadecimal"`
+	TargetBalance   *float64     `json:"target_balance" validate:"omitempty,gt=0"`
+	TargetDate      string       `json:"target_date" validate:"omitempty,datetime=2006-01-02"`
+	Loan            *LoanRequest `json:"loan" validate:"omitempty"`
+	Icon            string       `json:"icon" validate:"omitempty,max=100"`
+	IconColor       string       `json:"icon_color" validate:"omitempty,max=50"`
+	BackgroundColor string       `json:"background_color" validate:"omitempty,max=50"`
 }
 
 type UpdatePocketRequest struct {
-	Name            string   `json:"name" validate:"omitempty,min=2,max=255"`
-	Type            string   `json:"type" validate:"omitempty,oneof=main allocation saving debt"`
-	CategoryID      string   `json:"category_id" validate:"omitempty,len=24,hexadecimal"`
-	TargetBalance   *float64 `json:"target_balance" validate:"omitempty,gt=0"`
-	Icon            string   `json:"icon" validate:"omitempty,max=100"`
-	IconColor       string   `json:"icon_color" validate:"omitempty,max=50"`
-	BackgroundColor string   `json:"background_color" validate:"omitempty,max=50"`
-	IsActive        *bool    `json:"is_active"`
+	Name            string       `json:"name" validate:"omitempty,min=2,max=255"`
+	Type            string       `json:"type" validate:"omitempty,oneof=main allocation saving debt"`
+	CategoryID      string       `json:"category_id" validate:"omitempty,len=24,hexadecimal"`
+	TargetBalance   *float64     `json:"target_balance" validate:"omitempty,gt=0"`
+	TargetDate      string       `json:"target_date" validate:"omitempty,datetime=2006-01-02"`
+	Loan            *LoanRequest `json:"loan" validate:"omitempty"`
+	Icon            string       `json:"icon" validate:"omitempty,max=100"`
+	IconColor       string       `json:"icon_color" validate:"omitempty,max=50"`
+	BackgroundColor string       `json:"background_color" validate:"omitempty,max=50"`
+	IsActive        *bool        `json:"is_active"`
 }
 
 type CreateSystemPocketRequest struct {
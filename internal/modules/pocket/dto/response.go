@@ -3,22 +3,24 @@ package dto
 import "time"
 
 type PocketResponse struct {
-	ID              string     `json:"id"`
-	UserID          string     `json:"user_id"`
-	Name            string     `json:"name"`
-	Type            string     `json:"type"`
-	CategoryID      *string    `json:"category_id,omitempty"`
-	Balance         float64    `json:"balance"`
-	TargetBalance   *float64   `json:"target_balance,omitempty"`
-	IsDefault       bool       `json:"is_default"`
-	IsActive        bool       `json:"is_active"`
-	IsLocked        bool       `json:"is_locked"`
-	Icon            string     `json:"icon,omitempty"`
-	IconColor       string     `json:"icon_color,omitempty"`
-	BackgroundColor string     `json:"background_color,omitempty"`
-	CreatedAt       time.Time  `json:"created_at"`
-	UpdatedAt       time.Time  `json:"updated_at"`
-	DeletedAt       *time.Time `json:"deleted_at,omitempty"`
+	ID              string        `json:"id"`
+	UserID          string        `json:"user_id"`
+	Name            string        `json:"name"`
+	Type            string        `json:"type"`
+	CategoryID      *string       `json:"category_id,omitempty"`
+	Balance         float64       `json:"balance"`
+	TargetBalance   *float64      `json:"target_balance,omitempty"`
+	TargetDate      *time.Time    `json:"target_date,omitempty"`
+	Loan            *LoanResponse `json:"loan,omitempty"`
+	IsDefault       bool          `json:"is_default"`
+	IsActive        bool          `json:"is_active"`
+	IsLocked        bool          `json:"is_locked"`
+	Icon            string        `json:"icon,omitempty"`
+	IconColor       string        `json:"icon_color,omitempty"`
+	BackgroundColor string        `json:"background_color,omitempty"`
+	CreatedAt       time.Time     `json:"created_at"`
+	UpdatedAt       time.Time     `json:"updated_at"`
+	DeletedAt       *time.Time    `json:"deleted_at,omitempty"`
 }
 
 type PocketDropdownResponse struct {
@@ -30,3 +32,83 @@ type PocketDropdownResponse struct {
 	IsActive        bool    `json:"is_active"`
 	IsLocked        bool    `json:"is_locked"`
 }
+
+type GoalResponse struct {
+	PocketID                    string     `json:"pocket_id"`
+	Name                        string     `json:"name"`
+	Type                        string     `json:"type"`
+	Icon                        string     `json:"icon,omitempty"`
+	IconColor                   string     `json:"icon_color,omitempty"`
+	BackgroundColor             string     `json:"background_color,omitempty"`
+	TargetAmount                float64    `json:"target_amount"`
+	CurrentAmount               float64    `json:"current_amount"`
+	RemainingAmount             float64    `json:"remaining_amount"`
+	ProgressPercentage          float64    `json:"progress_percentage"`
+	TargetDate                  *time.Time `json:"target_date,omitempty"`
+	MonthsRemaining             float64    `json:"months_remaining"`
+	RequiredMonthlyContribution float64    `json:"required_monthly_contribution"`
+	AverageMonthlyContribution  float64    `json:"average_monthly_contribution"`
+	ProjectedCompletionDate     *time.Time `json:"projected_completion_date,omitempty"`
+	Status                      string     `json:"status" enums:"achieved,on_track,behind,no_target_date"`
+	LastMilestone               int        `json:"last_milestone"`
+}
+
+type GoalOverviewResponse struct {
+	Goals                     []*GoalResponse `json:"goals"`
+	TotalTargetAmount         float64         `json:"total_target_amount"`
+	TotalCurrentAmount        float64         `json:"total_current_amount"`
+	OverallProgressPercentage float64         `json:"overall_progress_percentage"`
+	TotalRequiredMonthly      float64         `json:"total_required_monthly"`
+	AchievedCount             int             `json:"achieved_count"`
+	BehindCount               int             `json:"behind_count"`
+}
+
+type LoanResponse struct {
+	Principal    float64   `json:"principal"`
+	InterestRate float64   `json:"interest_rate"`
+	InterestType string    `json:"interest_type"`
+	TenorMonths  int       `json:"tenor_months"`
+	DueDay       int       `json:"due_day"`
+	StartDate    time.Time `json:"start_date"`
+}
+
+type AmortizationRowResponse struct {
+	Installment      int       `json:"installment"`
+	DueDate          time.Time `json:"due_date"`
+	Payment          float64   `json:"payment"`
+	Principal        float64   `json:"principal"`
+	Interest         float64   `json:"interest"`
+	RemainingBalance float64   `json:"remaining_balance"`
+	PaidAmount       float64   `json:"paid_amount"`
+	IsPaid           bool      `json:"is_paid"`
+}
+
+type LoanPaymentResponse struct {
+	TransactionID string    `json:"transaction_id"`
+	Date          time.Time `json:"date"`
+	Amount        float64   `json:"amount"`
+	Principal     float64   `json:"principal"`
+	Interest      float64   `json:"interest"`
+	Excess        float64   `json:"excess"`
+}
+
+type LoanSummaryResponse struct {
+	PocketID            string                     `json:"pocket_id"`
+	Name                string                     `json:"name"`
+	Loan                *LoanResponse              `json:"loan"`
+	InstallmentAmount   float64                    `json:"installment_amount"`
+	TotalInterest       float64                    `json:"total_interest"`
+	TotalPayable        float64                    `json:"total_payable"`
+	PrincipalPaid       float64                    `json:"principal_paid"`
+	InterestPaid        float64                    `json:"interest_paid"`
+	RemainingPrincipal  float64                    `json:"remaining_principal"`
+	RemainingBalance    float64                    `json:"remaining_balance"`
+	PaidInstallments    int                        `json:"paid_installments"`
+	OverdueInstallments int                        `json:"overdue_installments"`
+	OverdueAmount       float64                    `json:"overdue_amount"`
+	NextDueDate         *time.Time                 `json:"next_due_date,omitempty"`
+	PayoffDate          time.Time                  `json:"payoff_date"`
+	IsPaidOff           bool                       `json:"is_paid_off"`
+	Schedule            []*AmortizationRowResponse `json:"schedule"`
+	Payments            []*LoanPaymentResponse     `json:"payments"`
+}
@@ -0,0 +1,108 @@
+package pocket
+
+import (
+	"math"
+	"time"
+)
+
+// buildAmortizationSchedule generates the installments of a loan.
+// Flat interest charges the same interest on the original principal every month, while effective
+// interest uses an annuity: a fixed installment whose interest part is charged on the remaining principal.
+func buildAmortizationSchedule(loan *LoanDetail) []*AmortizationRow {
+	n := loan.TenorMonths
+	monthlyRate := loan.InterestRate / 100 / 12
+	remaining := loan.Principal
+
+	installment := loan.Principal / float64(n)
+	switch LoanInterestType(loan.InterestType) {
+	case LoanInterestFlat:
+		installment += loan.Principal * monthlyRate
+	case LoanInterestEffective:
+		if monthlyRate > 0 {
+			installment = loan.Principal * monthlyRate / (1 - math.Pow(1+monthlyRate, -float64(n)))
+		}
+	}
+	installment = roundCurrency(installment)
+
+	rows := make([]*AmortizationRow, n)
+	for i := 0; i < n; i++ {
+		var interest float64
+		if LoanInterestType(loan.InterestType) == LoanInterestFlat {
+			interest = loan.Principal * monthlyRate
+		} else {
+			interest = remaining * monthlyRate
+		}
+		interest = roundCurrency(interest)
+
+		principal := installment - interest
+		// The last installment settles whatever is left after rounding
+		if i == n-1 || principal > remaining {
+			principal = remaining
+		}
+		principal = roundCurrency(principal)
+		remaining = roundCurrency(remaining - principal)
+
+		rows[i] = &AmortizationRow{
+			Installment:      i + 1,
+			DueDate:          loanDueDate(loan.StartDate, loan.DueDay, i+1),
+			Payment:          roundCurrency(principal + interest),
+			Principal:        principal,
+			Interest:         interest,
+			RemainingBalance: remaining,
+		}
+	}
+
+	return rows
+}
+
+// applyLoanPayments settles the schedule in order with the given payments (sorted by date).
+// Each payment covers the interest of the oldest unpaid installment first, then its principal;
+// whatever exceeds the whole schedule is reported as excess.
+func applyLoanPayments(schedule []*AmortizationRow, payments []*LoanPayment) {
+	interestPaid := make([]float64, len(schedule))
+	idx := 0
+
+	for _, payment := range payments {
+		left := payment.Amount
+		for left > 0 && idx < len(schedule) {
+			row := schedule[idx]
+			principalPaid := row.PaidAmount - interestPaid[idx]
+
+			interest := math.Min(left, math.Max(row.Interest-interestPaid[idx], 0))
+			interestPaid[idx] += interest
+			left -= interest
+
+			principal := math.Min(left, math.Max(row.Principal-principalPaid, 0))
+			left -= principal
+
+			row.PaidAmount = roundCurrency(row.PaidAmount + interest + principal)
+			payment.Interest += interest
+			payment.Principal += principal
+
+			// Nothing left to settle on this row (rounding leftovers included), move to the next one
+			if row.PaidAmount >= row.Payment || interest+principal == 0 {
+				row.IsPaid = true
+				idx++
+			}
+		}
+
+		payment.Interest = roundCurrency(payment.Interest)
+		payment.Principal = roundCurrency(payment.Principal)
+		payment.Excess = roundCurrency(math.Max(left, 0))
+	}
+}
+
+// loanDueDate returns the due date of the installment monthOffset months after the start date,
+// clamping the due day to the last day of shorter months
+func loanDueDate(start time.Time, dueDay int, monthOffset int) time.Time {
+	firstOfMonth := time.Date(start.Year(), start.Month()+time.Month(monthOffset), 1, 0, 0, 0, 0, start.Location())
+	lastDay := firstOfMonth.AddDate(0, 1, -1).Day()
+	if dueDay > lastDay {
+		dueDay = lastDay
+	}
+	return time.Date(firstOfMonth.Year(), firstOfMonth.Month(), dueDay, 0, 0, 0, 0, start.Location())
+}
+
+func roundCurrency(value float64) float64 {
+	return math.Round(value*100) / 100
+}
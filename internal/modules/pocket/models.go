@@ -16,6 +16,12 @@ type Pocket struct {
 
 	Balance       primitive.Decimal128  `bson:"balance" json:"balance"`
 	TargetBalance *primitive.Decimal128 `bson:"target_balance,omitempty" json:"target_balance,omitempty"`
+	TargetDate    *time.Time            `bson:"target_date,omitempty" json:"target_date,omitempty"`
+	// GoalMilestone is the highest progress milestone (percent) the user has been notified about
+	GoalMilestone int `bson:"goal_milestone" json:"goal_milestone"`
+
+	// Loan is only set on debt pockets that track a loan
+	Loan *LoanDetail `bson:"loan,omitempty" json:"loan,omitempty"`
 
 	IsDefault bool `bson:"is_default" json:"is_default"`
 	IsActive  bool `bson:"is_active" json:"is_active"`
@@ -40,3 +46,97 @@ const (
 	TypeDebt       PocketType = "debt"
 	TypeSystem     PocketType = "system"
 )
+
+type GoalStatus string
+
+const (
+	GoalStatusAchieved     GoalStatus = "achieved"
+	GoalStatusOnTrack      GoalStatus = "on_track"
+	GoalStatusBehind       GoalStatus = "behind"
+	GoalStatusNoTargetDate GoalStatus = "no_target_date"
+)
+
+// GoalMilestones are the progress percentages that trigger a notification
+var GoalMilestones = []int{25, 50, 75, 100}
+
+// GoalProgress is the computed state of a pocket that has a target balance
+type GoalProgress struct {
+	Pocket                      *Pocket
+	TargetAmount                float64
+	CurrentAmount               float64
+	RemainingAmount             float64
+	ProgressPercentage          float64
+	MonthsRemaining             float64
+	RequiredMonthlyContribution float64
+	AverageMonthlyContribution  float64
+	ProjectedCompletionDate     *time.Time
+	Status                      GoalStatus
+}
+
+type GoalOverview struct {
+	Goals                     []*GoalProgress
+	TotalTargetAmount         float64
+	TotalCurrentAmount        float64
+	OverallProgressPercentage float64
+	TotalRequiredMonthly      float64
+	AchievedCount             int
+	BehindCount               int
+}
+
+type LoanInterestType string
+
+const (
+	LoanInterestFlat      LoanInterestType = "flat"
+	LoanInterestEffective LoanInterestType = "effective"
+)
+
+// LoanDetail describes the loan repaid from a debt pocket. InterestRate is the annual rate in percent.
+type LoanDetail struct {
+	Principal    float64   `bson:"principal" json:"principal"`
+	InterestRate float64   `bson:"interest_rate" json:"interest_rate"`
+	InterestType string    `bson:"interest_type" json:"interest_type" enums:"flat,effective"`
+	TenorMonths  int       `bson:"tenor_months" json:"tenor_months"`
+	DueDay       int       `bson:"due_day" json:"due_day"`
+	StartDate    time.Time `bson:"start_date" json:"start_date"`
+}
+
+// AmortizationRow is a single installment of a loan schedule
+type AmortizationRow struct {
+	Installment      int
+	DueDate          time.Time
+	Payment          float64
+	Principal        float64
+	Interest         float64
+	RemainingBalance float64
+	PaidAmount       float64
+	IsPaid           bool
+}
+
+// LoanPayment is an expense out of a debt pocket split into principal and interest
+type LoanPayment struct {
+	TransactionID primitive.ObjectID
+	Date          time.Time
+	Amount        float64
+	Principal     float64
+	Interest      float64
+	Excess        float64
+}
+
+type LoanSummary struct {
+	Pocket              *Pocket
+	Schedule            []*AmortizationRow
+	Payments            []*LoanPayment
+	InstallmentAmount   float64
+	TotalInterest       float64
+	TotalPayable        float64
+	PrincipalPaid       float64
+	InterestPaid        float64
+	RemainingPrincipal  float64
+	RemainingBalance    float64
+	PaidInstallments    int
+	OverdueInstallments int
+	OverdueAmount       float64
+	NextDueDate         *time.Time
+	PayoffDate          time.Time
+	IsPaidOff           bool
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 
 	"github.com/HasanNugroho/coin-be/internal/core/config"
+	"github.com/HasanNugroho/coin-be/internal/modules/balance_snapshot"
+	"github.com/HasanNugroho/coin-be/internal/modules/notification"
 	"github.com/sarulabs/di/v2"
 	"go.mongodb.org/mongo-driver/mongo"
 )
@@ -25,7 +27,8 @@ func Register(builder *di.Builder) {
 		Name: "pocketService",
 		Build: func(ctn di.Container) (interface{}, error) {
 			repo := ctn.Get("pocketRepository").(*Repository)
-			return NewService(repo), nil
+			ns := ctn.Get("notificationService").(*notification.Service)
+			return NewService(repo, ns), nil
 		},
 	})
 
@@ -33,7 +36,8 @@ func Register(builder *di.Builder) {
 		Name: "pocketController",
 		Build: func(ctn di.Container) (interface{}, error) {
 			service := ctn.Get("pocketService").(*Service)
-			return NewController(service), nil
+			bss := ctn.Get("balanceSnapshotService").(*balance_snapshot.Service)
+			return NewController(service, bss), nil
 		},
 	})
 }
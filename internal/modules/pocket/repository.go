@@ -13,12 +13,14 @@ import (
 )
 
 type Repository struct {
-	pockets *mongo.Collection
+	pockets      *mongo.Collection
+	transactions *mongo.Collection
 }
 
 func NewRepository(db *mongo.Database) *Repository {
 	return &Repository{
-		pockets: db.Collection("pockets"),
+		pockets:      db.Collection("pockets"),
+		transactions: db.Collection("transactions"),
 	}
 }
 
@@ -210,3 +212,126 @@ func (r *Repository) CountUserPockets(ctx context.Context, userID primitive.Obje
 	count, err := r.pockets.CountDocuments(ctx, bson.M{"user_id": userID, "deleted_at": nil})
 	return count, err
 }
+
+func (r *Repository) GetGoalPocketsByUserID(ctx context.Context, userID primitive.ObjectID) ([]*Pocket, error) {
+	return r.findGoalPockets(ctx, bson.M{"user_id": userID})
+}
+
+func (r *Repository) GetAllGoalPockets(ctx context.Context) ([]*Pocket, error) {
+	return r.findGoalPockets(ctx, bson.M{})
+}
+
+func (r *Repository) findGoalPockets(ctx context.Context, filter bson.M) ([]*Pocket, error) {
+	filter["deleted_at"] = nil
+	filter["is_active"] = true
+	filter["target_balance"] = bson.M{"$ne": nil}
+
+	opts := options.Find().SetSort(bson.D{{Key: "target_date", Value: 1}, {Key: "created_at", Value: 1}})
+	cursor, err := r.pockets.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var pockets []*Pocket
+	if err = cursor.All(ctx, &pockets); err != nil {
+		return nil, err
+	}
+	return pockets, nil
+}
+
+// GetNetInflowsSince sums incoming minus outgoing transaction amounts per pocket from startDate onwards
+func (r *Repository) GetNetInflowsSince(ctx context.Context, pocketIDs []primitive.ObjectID, startDate time.Time) (map[primitive.ObjectID]float64, error) {
+	result := make(map[primitive.ObjectID]float64, len(pocketIDs))
+	if len(pocketIDs) == 0 {
+		return result, nil
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{
+			"deleted_at": nil,
+			"date":       bson.M{"$gte": startDate},
+			"$or": []bson.M{
+				{"pocket_to_id": bson.M{"$in": pocketIDs}},
+				{"pocket_from_id": bson.M{"$in": pocketIDs}},
+			},
+		}}},
+		{{Key: "$project", Value: bson.M{
+			"flows": bson.A{
+				bson.M{"pocket_id": "$pocket_to_id", "amount": "$amount"},
+				bson.M{"pocket_id": "$pocket_from_id", "amount": bson.M{"$multiply": bson.A{"$amount", -1}}},
+			},
+		}}},
+		{{Key: "$unwind", Value: "$flows"}},
+		{{Key: "$match", Value: bson.M{"flows.pocket_id": bson.M{"$in": pocketIDs}}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":   "$flows.pocket_id",
+			"total": bson.M{"$sum": "$flows.amount"},
+		}}},
+	}
+
+	cursor, err := r.transactions.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var rows []struct {
+		PocketID primitive.ObjectID `bson:"_id"`
+		Total    float64            `bson:"total"`
+	}
+	if err = cursor.All(ctx, &rows); err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		result[row.PocketID] = row.Total
+	}
+	return result, nil
+}
+
+func (r *Repository) SetGoalMilestone(ctx context.Context, id primitive.ObjectID, milestone int) error {
+	_, err := r.pockets.UpdateOne(
+		ctx,
+		bson.M{"_id": id},
+		bson.M{"$set": bson.M{"goal_milestone": milestone}},
+	)
+	return err
+}
+
+// GetLoanPayments returns the expenses paid out of a debt pocket, oldest first
+func (r *Repository) GetLoanPayments(ctx context.Context, pocketID primitive.ObjectID) ([]*LoanPayment, error) {
+	filter := bson.M{
+		"pocket_from_id": pocketID,
+		"type":           "expense",
+		"deleted_at":     nil,
+	}
+	opts := options.Find().
+		SetProjection(bson.M{"_id": 1, "amount": 1, "date": 1}).
+		SetSort(bson.D{{Key: "date", Value: 1}, {Key: "created_at", Value: 1}})
+
+	cursor, err := r.transactions.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var rows []struct {
+		ID     primitive.ObjectID `bson:"_id"`
+		Amount float64            `bson:"amount"`
+		Date   time.Time          `bson:"date"`
+	}
+	if err = cursor.All(ctx, &rows); err != nil {
+		return nil, err
+	}
+
+	payments := make([]*LoanPayment, len(rows))
+	for i, row := range rows {
+		payments[i] = &LoanPayment{
+			TransactionID: row.ID,
+			Date:          row.Date,
+			Amount:        row.Amount,
+		}
+	}
+	return payments, nil
+}
@@ -14,7 +14,11 @@ func RegisterRoutes(r *gin.RouterGroup, controller *Controller) {
 		protected.GET("/main", controller.GetMainPocket)
 		protected.GET("/active", controller.ListActivePockets)
 		protected.GET("/dropdown", controller.ListPocketsDropdown)
+		protected.GET("/goals", controller.GetGoalsOverview)
 		protected.GET("/:id", controller.GetPocket)
+		protected.GET("/:id/balance-history", controller.GetPocketBalanceHistory)
+		protected.GET("/:id/goal", controller.GetGoal)
+		protected.GET("/:id/loan", controller.GetLoan)
 		protected.PUT("/:id", controller.UpdatePocket)
 		protected.PUT("/:id/lock", controller.LockPocket)
 		protected.PUT("/:id/unlock", controller.UnlockPocket)
@@ -3,20 +3,32 @@ package pocket
 import (
 	"context"
 	"errors"
+	"fmt"
+	"log"
+	"math"
 	"time"
 
 	"github.com/HasanNugroho/coin-be/internal/core/utils"
+	"github.com/HasanNugroho/coin-be/internal/modules/notification"
 	"github.com/HasanNugroho/coin-be/internal/modules/pocket/dto"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+const (
+	defaultGoalLookbackMonths = 3
+	maxGoalLookbackMonths     = 12
+	averageDaysPerMonth       = 365.25 / 12
+)
+
 type Service struct {
-	repo *Repository
+	repo                *Repository
+	notificationService *notification.Service
 }
 
-func NewService(r *Repository) *Service {
+func NewService(r *Repository, ns *notification.Service) *Service {
 	return &Service{
-		repo: r,
+		repo:                r,
+		notificationService: ns,
 	}
 }
 
@@ -48,6 +60,16 @@ func (s *Service) CreatePocket(ctx context.Context, userID string, req *dto.Crea
 		targetBalance = &tb
 	}
 
+	targetDate, err := parseTargetDate(req.TargetDate)
+	if err != nil {
+		return nil, err
+	}
+
+	loan, err := parseLoan(req.Type, req.Loan)
+	if err != nil {
+		return nil, err
+	}
+
 	pocket := &Pocket{
 		UserID:          userObjID,
 		Name:            req.Name,
@@ -55,6 +77,8 @@ func (s *Service) CreatePocket(ctx context.Context, userID string, req *dto.Crea
 		CategoryID:      categoryID,
 		Balance:         utils.NewDecimal128FromFloat(0),
 		TargetBalance:   targetBalance,
+		TargetDate:      targetDate,
+		Loan:            loan,
 		IsDefault:       req.Type == string(TypeMain),
 		IsActive:        true,
 		IsLocked:        false,
@@ -153,6 +177,29 @@ func (s *Service) UpdatePocket(ctx context.Context, userID string, pocketID stri
 	if req.TargetBalance != nil {
 		tb := utils.NewDecimal128FromFloat(*req.TargetBalance)
 		pocket.TargetBalance = &tb
+		// Only milestones reached against the new target should be notified from now on
+		pocket.GoalMilestone = reachedGoalMilestone(goalProgressPercentage(utils.Decimal128ToFloat64(pocket.Balance), *req.TargetBalance))
+	}
+
+	if req.TargetDate != "" {
+		targetDate, err := parseTargetDate(req.TargetDate)
+		if err != nil {
+			return nil, err
+		}
+		pocket.TargetDate = targetDate
+	}
+
+	if req.Loan != nil {
+		loan, err := parseLoan(pocket.Type, req.Loan)
+		if err != nil {
+			return nil, err
+		}
+		pocket.Loan = loan
+	}
+
+	// Loan details only make sense while the pocket stays a debt pocket
+	if pocket.Type != string(TypeDebt) {
+		pocket.Loan = nil
 	}
 
 	if req.IsActive != nil {
@@ -332,6 +379,290 @@ func (s *Service) GetAllPockets(ctx context.Context, limit int64, skip int64) ([
 	return s.repo.GetAllPockets(ctx, limit, skip)
 }
 
+func (s *Service) GetGoal(ctx context.Context, userID string, pocketID string, lookbackMonths int) (*GoalProgress, error) {
+	pocket, err := s.GetPocketByID(ctx, userID, pocketID)
+	if err != nil {
+		return nil, err
+	}
+
+	if pocket.TargetBalance == nil {
+		return nil, errors.New("pocket has no savings goal")
+	}
+
+	goals, err := s.buildGoalProgress(ctx, []*Pocket{pocket}, lookbackMonths)
+	if err != nil {
+		return nil, err
+	}
+
+	return goals[0], nil
+}
+
+func (s *Service) GetGoalsOverview(ctx context.Context, userID string, lookbackMonths int) (*GoalOverview, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, errors.New("invalid user id")
+	}
+
+	pockets, err := s.repo.GetGoalPocketsByUserID(ctx, userObjID)
+	if err != nil {
+		return nil, err
+	}
+
+	goals, err := s.buildGoalProgress(ctx, pockets, lookbackMonths)
+	if err != nil {
+		return nil, err
+	}
+
+	overview := &GoalOverview{Goals: goals}
+	for _, goal := range goals {
+		overview.TotalTargetAmount += goal.TargetAmount
+		overview.TotalCurrentAmount += math.Min(goal.CurrentAmount, goal.TargetAmount)
+		overview.TotalRequiredMonthly += goal.RequiredMonthlyContribution
+
+		switch goal.Status {
+		case GoalStatusAchieved:
+			overview.AchievedCount++
+		case GoalStatusBehind:
+			overview.BehindCount++
+		}
+	}
+	overview.OverallProgressPercentage = goalProgressPercentage(overview.TotalCurrentAmount, overview.TotalTargetAmount)
+
+	return overview, nil
+}
+
+// CheckGoalMilestones notifies users whose savings goals crossed a new milestone since the last check
+func (s *Service) CheckGoalMilestones(ctx context.Context) error {
+	pockets, err := s.repo.GetAllGoalPockets(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, pocket := range pockets {
+		current := utils.Decimal128ToFloat64(pocket.Balance)
+		target := utils.Decimal128ToFloat64(*pocket.TargetBalance)
+		progress := goalProgressPercentage(current, target)
+
+		milestone := reachedGoalMilestone(progress)
+		if milestone <= pocket.GoalMilestone {
+			continue
+		}
+
+		if err := s.repo.SetGoalMilestone(ctx, pocket.ID, milestone); err != nil {
+			log.Printf("failed to update goal milestone for pocket %s: %v", pocket.ID.Hex(), err)
+			continue
+		}
+
+		subject := fmt.Sprintf("Finlet - Target %s tercapai %d%%", pocket.Name, milestone)
+		if milestone == 100 {
+			subject = fmt.Sprintf("Finlet - Selamat! Target %s telah tercapai", pocket.Name)
+		}
+		body := fmt.Sprintf("Saldo pocket %s kini %s dari target %s (%.0f%%).",
+			pocket.Name, notification.FormatRupiah(current), notification.FormatRupiah(target), progress)
+
+		if err := s.notificationService.Notify(ctx, pocket.UserID, subject, body); err != nil {
+			log.Printf("failed to send goal milestone notification for pocket %s: %v", pocket.ID.Hex(), err)
+		}
+	}
+
+	return nil
+}
+
+func (s *Service) GetLoanSummary(ctx context.Context, userID string, pocketID string) (*LoanSummary, error) {
+	pocket, err := s.GetPocketByID(ctx, userID, pocketID)
+	if err != nil {
+		return nil, err
+	}
+
+	if pocket.Type != string(TypeDebt) || pocket.Loan == nil {
+		return nil, errors.New("pocket has no loan")
+	}
+
+	payments, err := s.repo.GetLoanPayments(ctx, pocket.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	schedule := buildAmortizationSchedule(pocket.Loan)
+	applyLoanPayments(schedule, payments)
+
+	summary := &LoanSummary{
+		Pocket:            pocket,
+		Schedule:          schedule,
+		Payments:          payments,
+		InstallmentAmount: schedule[0].Payment,
+		IsPaidOff:         true,
+	}
+
+	now := time.Now()
+	for _, row := range schedule {
+		summary.TotalInterest += row.Interest
+		summary.TotalPayable += row.Payment
+
+		if row.IsPaid {
+			summary.PaidInstallments++
+			continue
+		}
+
+		summary.IsPaidOff = false
+		if summary.NextDueDate == nil {
+			dueDate := row.DueDate
+			summary.NextDueDate = &dueDate
+		}
+		if row.DueDate.Before(now) {
+			summary.OverdueInstallments++
+			summary.OverdueAmount += row.Payment - row.PaidAmount
+		}
+	}
+
+	for _, payment := range payments {
+		summary.PrincipalPaid += payment.Principal
+		summary.InterestPaid += payment.Interest
+	}
+
+	summary.TotalInterest = roundCurrency(summary.TotalInterest)
+	summary.TotalPayable = roundCurrency(summary.TotalPayable)
+	summary.PrincipalPaid = roundCurrency(summary.PrincipalPaid)
+	summary.InterestPaid = roundCurrency(summary.InterestPaid)
+	summary.OverdueAmount = roundCurrency(summary.OverdueAmount)
+	summary.RemainingPrincipal = roundCurrency(pocket.Loan.Principal - summary.PrincipalPaid)
+	summary.RemainingBalance = roundCurrency(summary.TotalPayable - summary.PrincipalPaid - summary.InterestPaid)
+
+	summary.PayoffDate = schedule[len(schedule)-1].DueDate
+	if summary.IsPaidOff && len(payments) > 0 {
+		summary.PayoffDate = payments[len(payments)-1].Date
+	}
+
+	return summary, nil
+}
+
+func (s *Service) buildGoalProgress(ctx context.Context, pockets []*Pocket, lookbackMonths int) ([]*GoalProgress, error) {
+	if lookbackMonths <= 0 {
+		lookbackMonths = defaultGoalLookbackMonths
+	}
+	if lookbackMonths > maxGoalLookbackMonths {
+		lookbackMonths = maxGoalLookbackMonths
+	}
+
+	now := time.Now()
+	pocketIDs := make([]primitive.ObjectID, len(pockets))
+	for i, pocket := range pockets {
+		pocketIDs[i] = pocket.ID
+	}
+
+	inflows, err := s.repo.GetNetInflowsSince(ctx, pocketIDs, now.AddDate(0, -lookbackMonths, 0))
+	if err != nil {
+		return nil, err
+	}
+
+	goals := make([]*GoalProgress, len(pockets))
+	for i, pocket := range pockets {
+		goals[i] = computeGoalProgress(pocket, inflows[pocket.ID]/float64(lookbackMonths), now)
+	}
+	return goals, nil
+}
+
+func computeGoalProgress(pocket *Pocket, averageMonthly float64, now time.Time) *GoalProgress {
+	current := utils.Decimal128ToFloat64(pocket.Balance)
+	target := utils.Decimal128ToFloat64(*pocket.TargetBalance)
+	remaining := math.Max(target-current, 0)
+
+	goal := &GoalProgress{
+		Pocket:                     pocket,
+		TargetAmount:               target,
+		CurrentAmount:              current,
+		RemainingAmount:            remaining,
+		ProgressPercentage:         goalProgressPercentage(current, target),
+		AverageMonthlyContribution: averageMonthly,
+	}
+
+	if remaining == 0 {
+		goal.Status = GoalStatusAchieved
+		return goal
+	}
+
+	if averageMonthly > 0 {
+		days := int(math.Ceil(remaining / averageMonthly * averageDaysPerMonth))
+		projected := now.AddDate(0, 0, days)
+		goal.ProjectedCompletionDate = &projected
+	}
+
+	if pocket.TargetDate == nil {
+		goal.Status = GoalStatusNoTargetDate
+		return goal
+	}
+
+	// A target date that has already passed requires the full remaining amount now
+	goal.MonthsRemaining = math.Max(pocket.TargetDate.Sub(now).Hours()/24/averageDaysPerMonth, 0)
+	goal.RequiredMonthlyContribution = remaining / math.Max(goal.MonthsRemaining, 1)
+
+	goal.Status = GoalStatusBehind
+	if goal.ProjectedCompletionDate != nil && !goal.ProjectedCompletionDate.After(*pocket.TargetDate) {
+		goal.Status = GoalStatusOnTrack
+	}
+
+	return goal
+}
+
+func goalProgressPercentage(current, target float64) float64 {
+	if target <= 0 {
+		return 0
+	}
+	return math.Min(math.Max(current/target*100, 0), 100)
+}
+
+func reachedGoalMilestone(progress float64) int {
+	reached := 0
+	for _, milestone := range GoalMilestones {
+		if progress >= float64(milestone) {
+			reached = milestone
+		}
+	}
+	return reached
+}
+
+func parseLoan(pocketType string, req *dto.LoanRequest) (*LoanDetail, error) {
+	if req == nil {
+		return nil, nil
+	}
+
+	if pocketType != string(TypeDebt) {
+		return nil, errors.New("loan can only be set on debt pockets")
+	}
+
+	startDate, err := time.Parse("2006-01-02", req.StartDate)
+	if err != nil {
+		return nil, errors.New("invalid loan start date format, use YYYY-MM-DD")
+	}
+
+	return &LoanDetail{
+		Principal:    req.Principal,
+		InterestRate: req.InterestRate,
+		InterestType: req.InterestType,
+		TenorMonths:  req.TenorMonths,
+		DueDay:       req.DueDay,
+		StartDate:    startDate,
+	}, nil
+}
+
+func parseTargetDate(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	date, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return nil, errors.New("invalid target date format, use YYYY-MM-DD")
+	}
+
+	now := time.Now().UTC()
+	if date.Before(time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)) {
+		return nil, errors.New("target date must not be in the past")
+	}
+
+	return &date, nil
+}
+
 func stringPtr(s string) *string {
 	if s == "" {
 		return nil
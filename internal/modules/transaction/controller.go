@@ -2,10 +2,12 @@ package transaction
 
 import (
 	"net/http"
+	"strconv"
 
 	"github.com/HasanNugroho/coin-be/internal/core/utils"
 	"github.com/HasanNugroho/coin-be/internal/modules/transaction/dto"
 	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type Controller struct {
@@ -287,6 +289,252 @@ func (c *Controller) UpdateTransaction(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, resp)
 }
 
+// CreateTemplate godoc
+// @Summary Create a transaction template
+// @Description Save a transaction template (type, optional amount, pocket, platform, category, note) for quick-add
+// @Tags Transaction Templates
+// @Accept json
+// @Produce json
+// @Param request body dto.CreateTransactionTemplateRequest true "Template details"
+// @Success 201 {object} map[string]interface{} "Transaction template created successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Security BearerAuth
+// @Router /v1/transactions/templates [post]
+func (c *Controller) CreateTemplate(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		resp := utils.NewErrorResponse(http.StatusUnauthorized, "unauthorized")
+		ctx.JSON(http.StatusUnauthorized, resp)
+		return
+	}
+
+	var req dto.CreateTransactionTemplateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		resp := utils.NewErrorResponse(http.StatusBadRequest, err.Error())
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	if err := utils.ValidateRequest(&req); err != nil {
+		resp := utils.NewErrorResponse(http.StatusBadRequest, err.Error())
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	template, err := c.service.CreateTemplate(ctx, userID.(string), &req)
+	if err != nil {
+		resp := utils.NewErrorResponse(http.StatusBadRequest, err.Error())
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	resp := utils.NewSuccessResponse("Transaction template created successfully", c.mapTemplateToResponse(template))
+	ctx.JSON(http.StatusCreated, resp)
+}
+
+// ListTemplates godoc
+// @Summary List transaction templates
+// @Description Get the user's transaction templates ordered by usage (most used first)
+// @Tags Transaction Templates
+// @Produce json
+// @Param type query string false "Transaction type" Enums(income, expense, transfer)
+// @Param limit query int false "Limit (default: all)"
+// @Success 200 {object} map[string]interface{} "Transaction templates retrieved successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Security BearerAuth
+// @Router /v1/transactions/templates [get]
+func (c *Controller) ListTemplates(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		resp := utils.NewErrorResponse(http.StatusUnauthorized, "unauthorized")
+		ctx.JSON(http.StatusUnauthorized, resp)
+		return
+	}
+
+	var typeFilter *string
+	if txType := ctx.Query("type"); txType != "" {
+		typeFilter = &txType
+	}
+
+	limit := int64(0)
+	if l := ctx.Query("limit"); l != "" {
+		if parsed, err := strconv.ParseInt(l, 10, 64); err == nil && parsed > 0 {
+			limit = parsed
+		}
+	}
+
+	templates, err := c.service.ListTemplates(ctx, userID.(string), typeFilter, limit)
+	if err != nil {
+		resp := utils.NewErrorResponse(http.StatusBadRequest, err.Error())
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	resp := utils.NewSuccessResponse("Transaction templates retrieved successfully", c.mapTemplateToResponseList(templates))
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// GetTemplate godoc
+// @Summary Get transaction template by ID
+// @Description Get a specific transaction template by ID
+// @Tags Transaction Templates
+// @Produce json
+// @Param id path string true "Template ID"
+// @Success 200 {object} map[string]interface{} "Transaction template retrieved successfully"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 404 {object} map[string]interface{} "Transaction template not found"
+// @Security BearerAuth
+// @Router /v1/transactions/templates/{id} [get]
+func (c *Controller) GetTemplate(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		resp := utils.NewErrorResponse(http.StatusUnauthorized, "unauthorized")
+		ctx.JSON(http.StatusUnauthorized, resp)
+		return
+	}
+
+	id := ctx.Param("id")
+
+	template, err := c.service.GetTemplateByID(ctx, userID.(string), id)
+	if err != nil {
+		resp := utils.NewErrorResponse(http.StatusNotFound, err.Error())
+		ctx.JSON(http.StatusNotFound, resp)
+		return
+	}
+
+	resp := utils.NewSuccessResponse("Transaction template retrieved successfully", c.mapTemplateToResponse(template))
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// UpdateTemplate godoc
+// @Summary Update transaction template
+// @Description Update an existing transaction template
+// @Tags Transaction Templates
+// @Accept json
+// @Produce json
+// @Param id path string true "Template ID"
+// @Param request body dto.UpdateTransactionTemplateRequest true "Update details"
+// @Success 200 {object} map[string]interface{} "Transaction template updated successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Security BearerAuth
+// @Router /v1/transactions/templates/{id} [put]
+func (c *Controller) UpdateTemplate(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		resp := utils.NewErrorResponse(http.StatusUnauthorized, "unauthorized")
+		ctx.JSON(http.StatusUnauthorized, resp)
+		return
+	}
+
+	id := ctx.Param("id")
+
+	var req dto.UpdateTransactionTemplateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		resp := utils.NewErrorResponse(http.StatusBadRequest, err.Error())
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	if err := utils.ValidateRequest(&req); err != nil {
+		resp := utils.NewErrorResponse(http.StatusBadRequest, err.Error())
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	template, err := c.service.UpdateTemplate(ctx, userID.(string), id, &req)
+	if err != nil {
+		resp := utils.NewErrorResponse(http.StatusBadRequest, err.Error())
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	resp := utils.NewSuccessResponse("Transaction template updated successfully", c.mapTemplateToResponse(template))
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// DeleteTemplate godoc
+// @Summary Delete transaction template
+// @Description Soft delete a transaction template
+// @Tags Transaction Templates
+// @Produce json
+// @Param id path string true "Template ID"
+// @Success 200 {object} map[string]interface{} "Transaction template deleted successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Security BearerAuth
+// @Router /v1/transactions/templates/{id} [delete]
+func (c *Controller) DeleteTemplate(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		resp := utils.NewErrorResponse(http.StatusUnauthorized, "unauthorized")
+		ctx.JSON(http.StatusUnauthorized, resp)
+		return
+	}
+
+	id := ctx.Param("id")
+
+	if err := c.service.DeleteTemplate(ctx, userID.(string), id); err != nil {
+		resp := utils.NewErrorResponse(http.StatusBadRequest, err.Error())
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	resp := utils.NewSuccessResponse("Transaction template deleted successfully", nil)
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// CreateTransactionFromTemplate godoc
+// @Summary Create a transaction from a template
+// @Description Create a transaction from a saved template. Amount, note, date and ref in the body override the template
+// @Tags Transactions
+// @Accept json
+// @Produce json
+// @Param id path string true "Template ID"
+// @Param request body dto.CreateTransactionFromTemplateRequest false "Overrides"
+// @Success 201 {object} map[string]interface{} "Transaction created successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Security BearerAuth
+// @Router /v1/transactions/from-template/{id} [post]
+func (c *Controller) CreateTransactionFromTemplate(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		resp := utils.NewErrorResponse(http.StatusUnauthorized, "unauthorized")
+		ctx.JSON(http.StatusUnauthorized, resp)
+		return
+	}
+
+	id := ctx.Param("id")
+
+	var req dto.CreateTransactionFromTemplateRequest
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			resp := utils.NewErrorResponse(http.StatusBadRequest, err.Error())
+			ctx.JSON(http.StatusBadRequest, resp)
+			return
+		}
+	}
+
+	if err := utils.ValidateRequest(&req); err != nil {
+		resp := utils.NewErrorResponse(http.StatusBadRequest, err.Error())
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	transaction, err := c.service.CreateTransactionFromTemplate(ctx, userID.(string), id, &req)
+	if err != nil {
+		resp := utils.NewErrorResponse(http.StatusBadRequest, err.Error())
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	resp := utils.NewSuccessResponse("Transaction created successfully", c.mapToResponse(transaction))
+	ctx.JSON(http.StatusCreated, resp)
+}
+
 func (c *Controller) mapToResponse(transaction *Transaction) *dto.TransactionResponse {
 	var pocketFromID *string
 	if transaction.PocketFromID != nil {
@@ -344,3 +592,39 @@ func (c *Controller) mapToResponseList(transactions []*Transaction) []*dto.Trans
 	}
 	return responses
 }
+
+func (c *Controller) mapTemplateToResponse(template *TransactionTemplate) *dto.TransactionTemplateResponse {
+	return &dto.TransactionTemplateResponse{
+		ID:                 template.ID.Hex(),
+		UserID:             template.UserID.Hex(),
+		Name:               template.Name,
+		Type:               template.Type,
+		Amount:             template.Amount,
+		PocketFromID:       hexPtr(template.PocketFromID),
+		PocketToID:         hexPtr(template.PocketToID),
+		UserPlatformFromID: hexPtr(template.UserPlatformFromID),
+		UserPlatformToID:   hexPtr(template.UserPlatformToID),
+		CategoryID:         hexPtr(template.CategoryID),
+		Note:               template.Note,
+		UsageCount:         template.UsageCount,
+		LastUsedAt:         template.LastUsedAt,
+		CreatedAt:          template.CreatedAt,
+		UpdatedAt:          template.UpdatedAt,
+	}
+}
+
+func (c *Controller) mapTemplateToResponseList(templates []*TransactionTemplate) []*dto.TransactionTemplateResponse {
+	responses := make([]*dto.TransactionTemplateResponse, len(templates))
+	for i, template := range templates {
+		responses[i] = c.mapTemplateToResponse(template)
+	}
+	return responses
+}
+
+func hexPtr(id *primitive.ObjectID) *string {
+	if id == nil {
+		return nil
+	}
+	hex := id.Hex()
+	return &hex
+}
@@ -25,3 +25,33 @@ type UpdateTransactionRequest struct {
 	Date               string  `json:"date" validate:"required,datetime=2006-01-02T15:04:05Z07:00"`
 	Ref                string  `json:"ref" validate:"omitempty,max=100"`
 }
+
+type CreateTransactionTemplateRequest struct {
+	Name               string   `json:"name" validate:"required,min=1,max=100"`
+	Type               string   `json:"type" validate:"required,oneof=income expense transfer"`
+	Amount             *float64 `json:"amount" validate:"omitempty,gt=0"`
+	PocketFromID       string   `json:"pocket_from_id" validate:"omitempty,len=24,hexadecimal"`
+	PocketToID         string   `json:"pocket_to_id" validate:"omitempty,len=24,hexadecimal"`
+	UserPlatformFromID string   `json:"user_platform_from_id" validate:"omitempty,len=24,hexadecimal"`
+	UserPlatformToID   string   `json:"user_platform_to_id" validate:"omitempty,len=24,hexadecimal"`
+	CategoryID         string   `json:"category_id" validate:"omitempty,len=24,hexadecimal"`
+	Note               string   `json:"note" validate:"omitempty,max=500"`
+}
+
+type UpdateTransactionTemplateRequest struct {
+	Name               string   `json:"name" validate:"omitempty,min=1,max=100"`
+	Amount             *float64 `json:"amount" validate:"omitempty,gt=0"`
+	PocketFromID       string   `json:"pocket_from_id" validate:"omitempty,len=24,hexadecimal"`
+	PocketToID         string   `json:"pocket_to_id" validate:"omitempty,len=24,hexadecimal"`
+	UserPlatformFromID string   `json:"user_platform_from_id" validate:"omitempty,len=24,hexadecimal"`
+	UserPlatformToID   string   `json:"user_platform_to_id" validate:"omitempty,len=24,hexadecimal"`
+	CategoryID         string   `json:"category_id" validate:"omitempty,len=24,hexadecimal"`
+	Note               *string  `json:"note" validate:"omitempty,max=500"`
+}
+
+type CreateTransactionFromTemplateRequest struct {
+	Amount *float64 `json:"amount" validate:"omitempty,gt=0"`
+	Note   string   `json:"note" validate:"omitempty,max=500"`
+	Date   string   `json:"date" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+	Ref    string   `json:"ref" validate:"omitempty,max=100"`
+}
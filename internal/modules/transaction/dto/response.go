@@ -22,3 +22,21 @@ type TransactionResponse struct {
 	UpdatedAt          time.Time  `bson:"updated_at"            json:"updated_at"`
 	DeletedAt          *time.Time `bson:"deleted_at"            json:"deleted_at,omitempty"`
 }
+
+type TransactionTemplateResponse struct {
+	ID                 string     `json:"id"`
+	UserID             string     `json:"user_id"`
+	Name               string     `json:"name"`
+	Type               string     `json:"type"`
+	Amount             *float64   `json:"amount,omitempty"`
+	PocketFromID       *string    `json:"pocket_from_id,omitempty"`
+	PocketToID         *string    `json:"pocket_to_id,omitempty"`
+	UserPlatformFromID *string    `json:"user_platform_from_id,omitempty"`
+	UserPlatformToID   *string    `json:"user_platform_to_id,omitempty"`
+	CategoryID         *string    `json:"category_id,omitempty"`
+	Note               *string    `json:"note,omitempty"`
+	UsageCount         int64      `json:"usage_count"`
+	LastUsedAt         *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt          time.Time  `json:"created_at"`
+	UpdatedAt          time.Time  `json:"updated_at"`
+}
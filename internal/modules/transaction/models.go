@@ -41,3 +41,25 @@ func IsValidTransactionType(t string) bool {
 		return false
 	}
 }
+
+// TransactionTemplate is a saved transaction shape that can be replayed with one call.
+// Amount is optional; when nil it must be supplied at creation time.
+type TransactionTemplate struct {
+	ID                 primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	UserID             primitive.ObjectID  `bson:"user_id" json:"user_id"`
+	Name               string              `bson:"name" json:"name"`
+	Type               string              `bson:"type" json:"type" enums:"income,expense,transfer"`
+	Amount             *float64            `bson:"amount,omitempty" json:"amount,omitempty"`
+	PocketFromID       *primitive.ObjectID `bson:"pocket_from_id,omitempty" json:"pocket_from_id,omitempty"`
+	PocketToID         *primitive.ObjectID `bson:"pocket_to_id,omitempty" json:"pocket_to_id,omitempty"`
+	UserPlatformFromID *primitive.ObjectID `bson:"user_platform_from_id,omitempty" json:"user_platform_from_id,omitempty"`
+	UserPlatformToID   *primitive.ObjectID `bson:"user_platform_to_id,omitempty" json:"user_platform_to_id,omitempty"`
+	CategoryID         *primitive.ObjectID `bson:"category_id,omitempty" json:"category_id,omitempty"`
+	Note               *string             `bson:"note,omitempty" json:"note,omitempty"`
+	UsageCount         int64               `bson:"usage_count" json:"usage_count"`
+	LastUsedAt         *time.Time          `bson:"last_used_at,omitempty" json:"last_used_at,omitempty"`
+
+	CreatedAt time.Time  `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time  `bson:"updated_at" json:"updated_at"`
+	DeletedAt *time.Time `bson:"deleted_at,omitempty" json:"deleted_at,omitempty"`
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/HasanNugroho/coin-be/internal/core/config"
+	"github.com/HasanNugroho/coin-be/internal/modules/balance_snapshot"
 	"github.com/HasanNugroho/coin-be/internal/modules/daily_summary"
 	"github.com/HasanNugroho/coin-be/internal/modules/pocket"
 	"github.com/HasanNugroho/coin-be/internal/modules/user_platform"
@@ -30,11 +31,15 @@ func Register(builder *di.Builder) {
 	builder.Add(di.Def{
 		Name: "transactionService",
 		Build: func(ctn di.Container) (interface{}, error) {
+			cfg := ctn.Get("config").(*config.Config)
+			client := ctn.Get("mongo").(*mongo.Client)
+			db := client.Database(cfg.MongoDB)
 			repo := ctn.Get("transactionRepository").(*Repository)
 			pocketRepo := ctn.Get("pocketRepository").(*pocket.Repository)
 			userPlatformRepo := ctn.Get("userPlatformRepository").(*user_platform.UserPlatformRepository)
 			dss := ctn.Get("dailySummaryService").(*daily_summary.Service)
-			return NewService(repo, pocketRepo, userPlatformRepo, dss), nil
+			bss := ctn.Get("balanceSnapshotService").(*balance_snapshot.Service)
+			return NewService(repo, pocketRepo, userPlatformRepo, dss, bss, db), nil
 		},
 	})
 
@@ -16,11 +16,13 @@ import (
 
 type Repository struct {
 	transactions *mongo.Collection
+	templates    *mongo.Collection
 }
 
 func NewRepository(db *mongo.Database) *Repository {
 	return &Repository{
 		transactions: db.Collection("transactions"),
+		templates:    db.Collection("transaction_templates"),
 	}
 }
 
@@ -44,6 +46,19 @@ func (r *Repository) GetTransactionByID(ctx context.Context, id primitive.Object
 	return &transaction, nil
 }
 
+// GetTransactionByRef returns the user's transaction with the given ref, or nil when there is none
+func (r *Repository) GetTransactionByRef(ctx context.Context, userID primitive.ObjectID, ref string) (*Transaction, error) {
+	var transaction Transaction
+	err := r.transactions.FindOne(ctx, bson.M{"user_id": userID, "ref": ref, "deleted_at": nil}).Decode(&transaction)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &transaction, nil
+}
+
 func (r *Repository) GetTransactionsByUserID(ctx context.Context, userID primitive.ObjectID, limit int64, skip int64) ([]*Transaction, error) {
 	opts := options.Find().SetLimit(limit).SetSkip(skip).SetSort(bson.M{"date": -1})
 	cursor, err := r.transactions.Find(ctx, bson.M{"user_id": userID, "deleted_at": nil}, opts)
@@ -350,6 +365,107 @@ func (r *Repository) GetTransactionsByUserIDAndType(ctx context.Context, userID
 	return transactions, nil
 }
 
+func (r *Repository) CreateTemplate(ctx context.Context, template *TransactionTemplate) error {
+	template.ID = primitive.NewObjectID()
+	template.CreatedAt = time.Now()
+	template.UpdatedAt = time.Now()
+	_, err := r.templates.InsertOne(ctx, template)
+	return err
+}
+
+func (r *Repository) GetTemplateByID(ctx context.Context, id primitive.ObjectID) (*TransactionTemplate, error) {
+	var template TransactionTemplate
+	err := r.templates.FindOne(ctx, bson.M{"_id": id, "deleted_at": nil}).Decode(&template)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.New("transaction template not found")
+		}
+		return nil, err
+	}
+	return &template, nil
+}
+
+// GetTemplatesByUserID returns the user's templates ordered by how often they are used,
+// most used first. A limit of 0 returns every template.
+func (r *Repository) GetTemplatesByUserID(ctx context.Context, userID primitive.ObjectID, txType *string, limit int64) ([]*TransactionTemplate, error) {
+	filter := bson.M{"user_id": userID, "deleted_at": nil}
+	if txType != nil && *txType != "" {
+		filter["type"] = *txType
+	}
+
+	opts := options.Find().SetSort(bson.D{
+		{Key: "usage_count", Value: -1},
+		{Key: "last_used_at", Value: -1},
+		{Key: "created_at", Value: -1},
+	})
+	if limit > 0 {
+		opts.SetLimit(limit)
+	}
+
+	cursor, err := r.templates.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var templates []*TransactionTemplate
+	if err = cursor.All(ctx, &templates); err != nil {
+		return nil, err
+	}
+	return templates, nil
+}
+
+func (r *Repository) UpdateTemplate(ctx context.Context, id primitive.ObjectID, template *TransactionTemplate) error {
+	template.UpdatedAt = time.Now()
+	result, err := r.templates.UpdateOne(
+		ctx,
+		bson.M{"_id": id, "deleted_at": nil},
+		bson.M{"$set": template},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errors.New("transaction template not found")
+	}
+	return nil
+}
+
+func (r *Repository) DeleteTemplate(ctx context.Context, id primitive.ObjectID) error {
+	now := time.Now()
+	result, err := r.templates.UpdateOne(
+		ctx,
+		bson.M{"_id": id, "deleted_at": nil},
+		bson.M{
+			"$set": bson.M{
+				"deleted_at": now,
+				"updated_at": now,
+			},
+		},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errors.New("transaction template not found")
+	}
+	return nil
+}
+
+// IncrementTemplateUsage bumps the usage counter atomically so concurrent quick-adds are counted.
+func (r *Repository) IncrementTemplateUsage(ctx context.Context, id primitive.ObjectID) error {
+	now := time.Now()
+	_, err := r.templates.UpdateOne(
+		ctx,
+		bson.M{"_id": id, "deleted_at": nil},
+		bson.M{
+			"$inc": bson.M{"usage_count": 1},
+			"$set": bson.M{"last_used_at": now},
+		},
+	)
+	return err
+}
+
 func (r *Repository) EnsureIndexes(ctx context.Context) error {
 	indexes := []mongo.IndexModel{
 		{
@@ -362,6 +478,22 @@ func (r *Repository) EnsureIndexes(ctx context.Context) error {
 		},
 	}
 
-	_, err := r.transactions.Indexes().CreateMany(ctx, indexes)
+	if _, err := r.transactions.Indexes().CreateMany(ctx, indexes); err != nil {
+		return err
+	}
+
+	templateIndexes := []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				{Key: "user_id", Value: 1},
+				{Key: "type", Value: 1},
+				{Key: "usage_count", Value: -1},
+			},
+			Options: options.Index().
+				SetName("idx_transaction_templates_user_type_usage"),
+		},
+	}
+
+	_, err := r.templates.Indexes().CreateMany(ctx, templateIndexes)
 	return err
 }
@@ -9,6 +9,12 @@ func RegisterRoutes(r *gin.RouterGroup, controller *Controller) {
 	{
 		protected.POST("", controller.CreateTransaction)
 		protected.GET("", controller.ListUserTransactions)
+		protected.POST("/templates", controller.CreateTemplate)
+		protected.GET("/templates", controller.ListTemplates)
+		protected.GET("/templates/:id", controller.GetTemplate)
+		protected.PUT("/templates/:id", controller.UpdateTemplate)
+		protected.DELETE("/templates/:id", controller.DeleteTemplate)
+		protected.POST("/from-template/:id", controller.CreateTransactionFromTemplate)
 		protected.GET("/:id", controller.GetTransaction)
 		protected.PUT("/:id", controller.UpdateTransaction)
 		protected.DELETE("/:id", controller.DeleteTransaction)
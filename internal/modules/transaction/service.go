@@ -3,31 +3,42 @@ package transaction
 import (
 	"context"
 	"errors"
+	"log"
 	"time"
 
 	"github.com/HasanNugroho/coin-be/internal/core/utils"
+	"github.com/HasanNugroho/coin-be/internal/modules/balance_snapshot"
 	"github.com/HasanNugroho/coin-be/internal/modules/daily_summary"
 	"github.com/HasanNugroho/coin-be/internal/modules/pocket"
 	"github.com/HasanNugroho/coin-be/internal/modules/transaction/dto"
 	"github.com/HasanNugroho/coin-be/internal/modules/user_platform"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
+// IncomeHandler is called with every income transaction once it has been created and its balances applied
+type IncomeHandler func(ctx context.Context, tx *Transaction)
+
 type Service struct {
-	repo                *Repository
-	pocketRepo          *pocket.Repository
-	userPlatformRepo    *user_platform.UserPlatformRepository
-	balanceProcessor    *BalanceProcessor
-	dailySummaryService *daily_summary.Service
+	repo                   *Repository
+	pocketRepo             *pocket.Repository
+	userPlatformRepo       *user_platform.UserPlatformRepository
+	balanceProcessor       *BalanceProcessor
+	dailySummaryService    *daily_summary.Service
+	balanceSnapshotService *balance_snapshot.Service
+	incomeHandlers         []IncomeHandler
+	db                     *mongo.Database
 }
 
-func NewService(r *Repository, pr *pocket.Repository, upr *user_platform.UserPlatformRepository, dss *daily_summary.Service) *Service {
+func NewService(r *Repository, pr *pocket.Repository, upr *user_platform.UserPlatformRepository, dss *daily_summary.Service, bss *balance_snapshot.Service, db *mongo.Database) *Service {
 	return &Service{
-		repo:                r,
-		pocketRepo:          pr,
-		userPlatformRepo:    upr,
-		balanceProcessor:    NewBalanceProcessor(pr, upr),
-		dailySummaryService: dss,
+		repo:                   r,
+		pocketRepo:             pr,
+		userPlatformRepo:       upr,
+		balanceProcessor:       NewBalanceProcessor(pr, upr),
+		dailySummaryService:    dss,
+		balanceSnapshotService: bss,
+		db:                     db,
 	}
 }
 
@@ -125,27 +136,50 @@ func (s *Service) CreateTransaction(ctx context.Context, userID string, req *dto
 		Ref:                stringPtr(req.Ref),
 	}
 
-	// Create transaction record
-	if err := s.repo.CreateTransaction(ctx, transaction); err != nil {
-		return nil, err
-	}
+	// Create the record, apply its balances and add it to the daily summary together
+	err = s.withTransaction(ctx, func(sessionCtx mongo.SessionContext) error {
+		if err := s.repo.CreateTransaction(sessionCtx, transaction); err != nil {
+			return err
+		}
 
-	// Process balance updates through centralized processor
-	if err := s.balanceProcessor.ProcessTransaction(ctx, req.Type, req.Amount, pocketFrom, pocketTo, userPlatformFrom, userPlatformTo); err != nil {
-		s.repo.DeleteTransaction(ctx, transaction.ID)
+		// Process balance updates through centralized processor
+		if err := s.balanceProcessor.ProcessTransaction(sessionCtx, req.Type, req.Amount, pocketFrom, pocketTo, userPlatformFrom, userPlatformTo); err != nil {
+			return err
+		}
+
+		return s.dailySummaryService.AddTransaction(sessionCtx, summaryEntry(transaction))
+	})
+	if err != nil {
 		return nil, err
 	}
 
-	// Trigger daily summary recalculation if the transaction date is in the past (date has passed)
-	now := time.Now()
-	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
-	if date.Before(today) {
-		go s.dailySummaryService.GenerateDailySummary(context.Background(), userObjID, date)
-	}
+	s.invalidateBalanceSnapshots(ctx, date, pocketFrom, pocketTo, userPlatformFrom, userPlatformTo)
+
+	s.NotifyIncome(transaction)
 
 	return transaction, nil
 }
 
+// OnIncome registers a handler for income transactions. Handlers are registered while wiring the
+// application, before any transaction is created.
+func (s *Service) OnIncome(handler IncomeHandler) {
+	s.incomeHandlers = append(s.incomeHandlers, handler)
+}
+
+// NotifyIncome runs the registered income handlers in the background. It is also called by modules that
+// credit income without going through CreateTransaction, such as payroll.
+func (s *Service) NotifyIncome(tx *Transaction) {
+	if tx.Type != string(TypeIncome) || len(s.incomeHandlers) == 0 {
+		return
+	}
+
+	go func() {
+		for _, handler := range s.incomeHandlers {
+			handler(context.Background(), tx)
+		}
+	}()
+}
+
 func (s *Service) GetTransactionByID(ctx context.Context, userID string, transactionID string) (*Transaction, error) {
 	userObjID, err := primitive.ObjectIDFromHex(userID)
 	if err != nil {
@@ -253,22 +287,23 @@ func (s *Service) DeleteTransaction(ctx context.Context, userID string, transact
 		return errors.New("unauthorized")
 	}
 
-	// Process balance updates (revert)
-	if err := s.balanceProcessor.RevertTransaction(ctx, transaction.Type, transaction.Amount, transaction.PocketFromID, transaction.PocketToID, transaction.UserPlatformFromID, transaction.UserPlatformToID); err != nil {
-		return err
-	}
+	err = s.withTransaction(ctx, func(sessionCtx mongo.SessionContext) error {
+		// Process balance updates (revert)
+		if err := s.balanceProcessor.RevertTransaction(sessionCtx, transaction.Type, transaction.Amount, transaction.PocketFromID, transaction.PocketToID, transaction.UserPlatformFromID, transaction.UserPlatformToID); err != nil {
+			return err
+		}
 
-	err = s.repo.DeleteTransaction(ctx, txObjID)
+		if err := s.repo.DeleteTransaction(sessionCtx, txObjID); err != nil {
+			return err
+		}
+
+		return s.dailySummaryService.RemoveTransaction(sessionCtx, summaryEntry(transaction))
+	})
 	if err != nil {
 		return err
 	}
 
-	// Trigger daily summary recalculation if the transaction date is in the past (date has passed)
-	now := time.Now()
-	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
-	if transaction.Date.Before(today) {
-		go s.dailySummaryService.GenerateDailySummary(context.Background(), transaction.UserID, transaction.Date)
-	}
+	s.invalidateBalanceSnapshots(ctx, transaction.Date, transaction.PocketFromID, transaction.PocketToID, transaction.UserPlatformFromID, transaction.UserPlatformToID)
 
 	return nil
 }
@@ -341,32 +376,6 @@ func (s *Service) UpdateTransaction(ctx context.Context, userID string, transact
 		return nil, err
 	}
 
-	// 3. Revert old balances
-	if err := s.balanceProcessor.RevertTransaction(ctx, oldTx.Type, oldTx.Amount, oldTx.PocketFromID, oldTx.PocketToID, oldTx.UserPlatformFromID, oldTx.UserPlatformToID); err != nil {
-		return nil, err
-	}
-
-	// 4. Validate new ownership and balance sufficiency (after reversion)
-	if err := s.validatePocket(ctx, userObjID, newPocketFrom, newPocketTo, req.Amount); err != nil {
-		// Rollback reversion if validation fails
-		s.balanceProcessor.ProcessTransaction(ctx, oldTx.Type, oldTx.Amount, oldTx.PocketFromID, oldTx.PocketToID, oldTx.UserPlatformFromID, oldTx.UserPlatformToID)
-		return nil, err
-	}
-
-	if err := s.validateUserPlatform(ctx, userObjID, newUserPlatformFrom, newUserPlatformTo, req.Amount); err != nil {
-		// Rollback reversion if validation fails
-		s.balanceProcessor.ProcessTransaction(ctx, oldTx.Type, oldTx.Amount, oldTx.PocketFromID, oldTx.PocketToID, oldTx.UserPlatformFromID, oldTx.UserPlatformToID)
-		return nil, err
-	}
-
-	// 5. Apply new balances
-	if err := s.balanceProcessor.ProcessTransaction(ctx, req.Type, req.Amount, newPocketFrom, newPocketTo, newUserPlatformFrom, newUserPlatformTo); err != nil {
-		// Rollback reversion if apply fails
-		s.balanceProcessor.ProcessTransaction(ctx, oldTx.Type, oldTx.Amount, oldTx.PocketFromID, oldTx.PocketToID, oldTx.UserPlatformFromID, oldTx.UserPlatformToID)
-		return nil, err
-	}
-
-	// 6. Update transaction record
 	updatedTx := &Transaction{
 		ID:                 txObjID,
 		UserID:             userObjID,
@@ -383,38 +392,323 @@ func (s *Service) UpdateTransaction(ctx context.Context, userID string, transact
 		CreatedAt:          oldTx.CreatedAt,
 	}
 
-	if err := s.repo.UpdateTransaction(ctx, txObjID, updatedTx); err != nil {
-		// FATAL: Balance already updated but DB update failed.
-		// In a real system we'd use a transaction if MongoDB supported it across collections easily here.
+	// Balances, the record and the daily summaries of both dates change together or not at all
+	err = s.withTransaction(ctx, func(sessionCtx mongo.SessionContext) error {
+		// 3. Revert old balances
+		if err := s.balanceProcessor.RevertTransaction(sessionCtx, oldTx.Type, oldTx.Amount, oldTx.PocketFromID, oldTx.PocketToID, oldTx.UserPlatformFromID, oldTx.UserPlatformToID); err != nil {
+			return err
+		}
+
+		// 4. Validate new ownership and balance sufficiency (after reversion)
+		if err := s.validatePocket(sessionCtx, userObjID, newPocketFrom, newPocketTo, req.Amount); err != nil {
+			return err
+		}
+
+		if err := s.validateUserPlatform(sessionCtx, userObjID, newUserPlatformFrom, newUserPlatformTo, req.Amount); err != nil {
+			return err
+		}
+
+		// 5. Apply new balances
+		if err := s.balanceProcessor.ProcessTransaction(sessionCtx, req.Type, req.Amount, newPocketFrom, newPocketTo, newUserPlatformFrom, newUserPlatformTo); err != nil {
+			return err
+		}
+
+		// 6. Update transaction record
+		if err := s.repo.UpdateTransaction(sessionCtx, txObjID, updatedTx); err != nil {
+			return err
+		}
+
+		// 7. Move the transaction in the daily summaries, from its old date to its new one
+		if err := s.dailySummaryService.RemoveTransaction(sessionCtx, summaryEntry(oldTx)); err != nil {
+			return err
+		}
+		return s.dailySummaryService.AddTransaction(sessionCtx, summaryEntry(updatedTx))
+	})
+	if err != nil {
 		return nil, err
 	}
 
-	// 7. Trigger daily summary recalculation for both old and new dates if they are in the past
-	loc := time.Local // atau time.LoadLocation("Asia/Jakarta")
+	snapshotDate := oldTx.Date
+	if newDate.Before(snapshotDate) {
+		snapshotDate = newDate
+	}
+	s.invalidateBalanceSnapshots(ctx, snapshotDate,
+		oldTx.PocketFromID, oldTx.PocketToID, oldTx.UserPlatformFromID, oldTx.UserPlatformToID,
+		newPocketFrom, newPocketTo, newUserPlatformFrom, newUserPlatformTo)
 
-	now := time.Now().In(loc)
-	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	return updatedTx, nil
+}
 
-	oldDate := oldTx.Date.In(loc)
-	newDateLoc := newDate.In(loc)
+// RecordTransaction creates a transaction whose balances the caller applies, adding it to the daily summaries
+// like the transactions created here. Given a session context it is part of the caller's database transaction.
+func (s *Service) RecordTransaction(ctx context.Context, tx *Transaction) error {
+	if err := s.repo.CreateTransaction(ctx, tx); err != nil {
+		return err
+	}
+	return s.dailySummaryService.AddTransaction(ctx, summaryEntry(tx))
+}
 
-	// trigger untuk tanggal lama kalau itu di masa lalu
-	if oldDate.Before(today) {
-		go s.dailySummaryService.GenerateDailySummary(context.Background(), userObjID, oldDate)
+// withTransaction runs fn in a database transaction, committing it when fn succeeds
+func (s *Service) withTransaction(ctx context.Context, fn func(sessionCtx mongo.SessionContext) error) error {
+	session, err := s.db.Client().StartSession()
+	if err != nil {
+		return err
 	}
+	defer session.EndSession(ctx)
 
-	// kalau tanggal berubah (beda hari)
-	if oldDate.Year() != newDateLoc.Year() ||
-		oldDate.Month() != newDateLoc.Month() ||
-		oldDate.Day() != newDateLoc.Day() {
+	return mongo.WithSession(ctx, session, func(sessionCtx mongo.SessionContext) error {
+		if err := session.StartTransaction(); err != nil {
+			return err
+		}
 
-		// trigger untuk tanggal baru kalau di masa lalu
-		if newDateLoc.Before(today) {
-			go s.dailySummaryService.GenerateDailySummary(context.Background(), userObjID, newDateLoc)
+		if err := fn(sessionCtx); err != nil {
+			session.AbortTransaction(sessionCtx)
+			return err
 		}
+
+		return session.CommitTransaction(sessionCtx)
+	})
+}
+
+// summaryEntry returns the part of a transaction that daily summaries total
+func summaryEntry(tx *Transaction) *daily_summary.TransactionEntry {
+	return &daily_summary.TransactionEntry{
+		UserID:             tx.UserID,
+		Type:               tx.Type,
+		Amount:             tx.Amount,
+		Date:               tx.Date,
+		CategoryID:         tx.CategoryID,
+		PocketFromID:       tx.PocketFromID,
+		PocketToID:         tx.PocketToID,
+		UserPlatformFromID: tx.UserPlatformFromID,
+		UserPlatformToID:   tx.UserPlatformToID,
 	}
+}
 
-	return updatedTx, nil
+// invalidateBalanceSnapshots drops cached balance history that a transaction dated at date may have changed
+func (s *Service) invalidateBalanceSnapshots(ctx context.Context, date time.Time, entityIDs ...*primitive.ObjectID) {
+	if err := s.balanceSnapshotService.InvalidateFrom(ctx, date, entityIDs...); err != nil {
+		log.Printf("failed to invalidate balance snapshots: %v", err)
+	}
+}
+
+func (s *Service) CreateTemplate(ctx context.Context, userID string, req *dto.CreateTransactionTemplateRequest) (*TransactionTemplate, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, errors.New("invalid user id")
+	}
+
+	if !IsValidTransactionType(req.Type) {
+		return nil, errors.New("invalid transaction type")
+	}
+
+	if req.Amount != nil && *req.Amount <= 0 {
+		return nil, errors.New("amount must be greater than 0")
+	}
+
+	template := &TransactionTemplate{
+		UserID: userObjID,
+		Name:   req.Name,
+		Type:   req.Type,
+		Amount: req.Amount,
+		Note:   stringPtr(req.Note),
+	}
+
+	if template.PocketFromID, err = parseOptionalObjectID(req.PocketFromID, "invalid pocket_from id"); err != nil {
+		return nil, err
+	}
+	if template.PocketToID, err = parseOptionalObjectID(req.PocketToID, "invalid pocket_to id"); err != nil {
+		return nil, err
+	}
+	if template.UserPlatformFromID, err = parseOptionalObjectID(req.UserPlatformFromID, "invalid user_platform_from id"); err != nil {
+		return nil, err
+	}
+	if template.UserPlatformToID, err = parseOptionalObjectID(req.UserPlatformToID, "invalid user_platform_to id"); err != nil {
+		return nil, err
+	}
+	if template.CategoryID, err = parseOptionalObjectID(req.CategoryID, "invalid category id"); err != nil {
+		return nil, err
+	}
+
+	if err := s.validateTemplate(ctx, userObjID, template); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.CreateTemplate(ctx, template); err != nil {
+		return nil, err
+	}
+
+	return template, nil
+}
+
+func (s *Service) GetTemplateByID(ctx context.Context, userID string, templateID string) (*TransactionTemplate, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, errors.New("invalid user id")
+	}
+
+	templateObjID, err := primitive.ObjectIDFromHex(templateID)
+	if err != nil {
+		return nil, errors.New("invalid transaction template id")
+	}
+
+	template, err := s.repo.GetTemplateByID(ctx, templateObjID)
+	if err != nil {
+		return nil, err
+	}
+
+	if template.UserID != userObjID {
+		return nil, errors.New("unauthorized")
+	}
+
+	return template, nil
+}
+
+// ListTemplates returns the user's templates, most used first. A limit of 0 returns all of them.
+func (s *Service) ListTemplates(ctx context.Context, userID string, txType *string, limit int64) ([]*TransactionTemplate, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, errors.New("invalid user id")
+	}
+
+	if txType != nil && *txType != "" && !IsValidTransactionType(*txType) {
+		return nil, errors.New("invalid transaction type")
+	}
+
+	return s.repo.GetTemplatesByUserID(ctx, userObjID, txType, limit)
+}
+
+func (s *Service) UpdateTemplate(ctx context.Context, userID string, templateID string, req *dto.UpdateTransactionTemplateRequest) (*TransactionTemplate, error) {
+	template, err := s.GetTemplateByID(ctx, userID, templateID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != "" {
+		template.Name = req.Name
+	}
+
+	if req.Amount != nil {
+		template.Amount = req.Amount
+	}
+
+	if req.Note != nil {
+		template.Note = stringPtr(*req.Note)
+	}
+
+	if req.PocketFromID != "" {
+		if template.PocketFromID, err = parseOptionalObjectID(req.PocketFromID, "invalid pocket_from id"); err != nil {
+			return nil, err
+		}
+	}
+	if req.PocketToID != "" {
+		if template.PocketToID, err = parseOptionalObjectID(req.PocketToID, "invalid pocket_to id"); err != nil {
+			return nil, err
+		}
+	}
+	if req.UserPlatformFromID != "" {
+		if template.UserPlatformFromID, err = parseOptionalObjectID(req.UserPlatformFromID, "invalid user_platform_from id"); err != nil {
+			return nil, err
+		}
+	}
+	if req.UserPlatformToID != "" {
+		if template.UserPlatformToID, err = parseOptionalObjectID(req.UserPlatformToID, "invalid user_platform_to id"); err != nil {
+			return nil, err
+		}
+	}
+	if req.CategoryID != "" {
+		if template.CategoryID, err = parseOptionalObjectID(req.CategoryID, "invalid category id"); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.validateTemplate(ctx, template.UserID, template); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.UpdateTemplate(ctx, template.ID, template); err != nil {
+		return nil, err
+	}
+
+	return template, nil
+}
+
+func (s *Service) DeleteTemplate(ctx context.Context, userID string, templateID string) error {
+	template, err := s.GetTemplateByID(ctx, userID, templateID)
+	if err != nil {
+		return err
+	}
+
+	return s.repo.DeleteTemplate(ctx, template.ID)
+}
+
+// CreateTransactionFromTemplate replays a saved template through CreateTransaction, so the
+// usual ownership, balance and daily summary rules apply. Request fields override the template.
+func (s *Service) CreateTransactionFromTemplate(ctx context.Context, userID string, templateID string, req *dto.CreateTransactionFromTemplateRequest) (*Transaction, error) {
+	template, err := s.GetTemplateByID(ctx, userID, templateID)
+	if err != nil {
+		return nil, err
+	}
+
+	amount := 0.0
+	if template.Amount != nil {
+		amount = *template.Amount
+	}
+	if req.Amount != nil {
+		amount = *req.Amount
+	}
+	if amount <= 0 {
+		return nil, errors.New("amount is required for this template")
+	}
+
+	date := req.Date
+	if date == "" {
+		date = time.Now().Format(time.RFC3339)
+	}
+
+	note := req.Note
+	if note == "" && template.Note != nil {
+		note = *template.Note
+	}
+
+	txReq := &dto.CreateTransactionRequest{
+		Type:               template.Type,
+		Amount:             amount,
+		PocketFromID:       objectIDHex(template.PocketFromID),
+		PocketToID:         objectIDHex(template.PocketToID),
+		UserPlatformFromID: objectIDHex(template.UserPlatformFromID),
+		UserPlatformToID:   objectIDHex(template.UserPlatformToID),
+		CategoryID:         objectIDHex(template.CategoryID),
+		Note:               note,
+		Date:               date,
+		Ref:                req.Ref,
+	}
+
+	transaction, err := s.CreateTransaction(ctx, userID, txReq)
+	if err != nil {
+		return nil, err
+	}
+
+	// Usage counters only drive ordering, a failure here must not undo the transaction
+	if err := s.repo.IncrementTemplateUsage(ctx, template.ID); err != nil {
+		log.Printf("failed to increment usage for transaction template %s: %v", template.ID.Hex(), err)
+	}
+
+	return transaction, nil
+}
+
+// validateTemplate applies the same shape and ownership rules as a transaction,
+// without the balance check since the amount is only known at execution time.
+func (s *Service) validateTemplate(ctx context.Context, userID primitive.ObjectID, template *TransactionTemplate) error {
+	if err := s.validateTransactionRules(ctx, template.Type, userID, template.PocketFromID, template.PocketToID, template.UserPlatformFromID, template.UserPlatformToID); err != nil {
+		return err
+	}
+
+	if err := s.validatePocket(ctx, userID, template.PocketFromID, template.PocketToID, 0); err != nil {
+		return err
+	}
+
+	return s.validateUserPlatform(ctx, userID, template.UserPlatformFromID, template.UserPlatformToID, 0)
 }
 
 func (s *Service) validateTransactionRules(
@@ -571,3 +865,23 @@ func stringPtr(s string) *string {
 	}
 	return &s
 }
+
+// parseOptionalObjectID converts a hex string into an ObjectID pointer, returning nil for empty input
+func parseOptionalObjectID(hex string, errMsg string) (*primitive.ObjectID, error) {
+	if hex == "" {
+		return nil, nil
+	}
+	id, err := primitive.ObjectIDFromHex(hex)
+	if err != nil {
+		return nil, errors.New(errMsg)
+	}
+	return &id, nil
+}
+
+// objectIDHex returns the hex form of an optional ObjectID, or an empty string when nil
+func objectIDHex(id *primitive.ObjectID) string {
+	if id == nil {
+		return ""
+	}
+	return id.Hex()
+}
@@ -12,19 +12,24 @@ type UpdateUserRequest struct {
 	Phone                 string  `json:"phone" validate:"omitempty,max=20"`
 	TelegramId            string  `json:"telegramId" validate:"omitempty,max=100"`
 	Currency              string  `json:"currency" validate:"omitempty,len=3"`
-	BaseSalary            float64 `json:"baseSalary" validate:"omitempty,min=0"`
-	SalaryCycle           string  `json:"salaryCycle" validate:"omitempty,oneof=monthly weekly biweekly"`
-	SalaryDay             int     `json:"salaryDay" validate:"omitempty,min=1,max=28"`
+	BaseSalary            float64 `json:"baseSalary" validate:"omitempty,min=0"` // deprecated, use income sources
+	SalaryCycle           string  `json:"salaryCycle" validate:"omitempty,oneof=daily weekly monthly"`
+	SalaryDay             int     `json:"salaryDay" validate:"omitempty,min=1,max=31"` // 1=Monday..7=Sunday for weekly
+	SalaryWorkingDaysOnly *bool   `json:"salaryWorkingDaysOnly"`
 	Language              string  `json:"language" validate:"omitempty,len=2"`
 	AutoInputPayroll      *bool   `json:"autoInputPayroll"`
+	ZeroBasedBudgeting    *bool   `json:"zeroBasedBudgeting"`
+	AllocationShortfall   string  `json:"allocationShortfall" validate:"omitempty,oneof=SKIP PARTIAL PROPORTIONAL"`
 	DefaultUserPlatformID string  `json:"defaultUserPlatformId" validate:"omitempty,len=24,hexadecimal"`
+	FinancialMonthStart   *int    `json:"financialMonthStart" validate:"omitempty,min=0,max=31"` // 0 follows the monthly salary day
 }
 
 type CreateUserProfileRequest struct {
-	BaseSalary  float64 `json:"base_salary" validate:"required,min=0"`
-	SalaryCycle string  `json:"salary_cycle" validate:"required,oneof=monthly weekly biweekly"`
-	SalaryDay   int     `json:"salary_day" validate:"required,min=1,max=28"`
-	PayCurrency string  `json:"pay_currency" validate:"omitempty,len=3"`
+	BaseSalary            float64 `json:"base_salary" validate:"required,min=0"`
+	SalaryCycle           string  `json:"salary_cycle" validate:"required,oneof=daily weekly monthly"`
+	SalaryDay             int     `json:"salary_day" validate:"omitempty,min=1,max=31"` // 1=Monday..7=Sunday for weekly, unused for daily
+	SalaryWorkingDaysOnly bool    `json:"salary_working_days_only"`
+	PayCurrency           string  `json:"pay_currency" validate:"omitempty,len=3"`
 }
 
 type CreateRoleRequest struct {
@@ -12,9 +12,13 @@ type UserResponse struct {
 	BaseSalary               float64   `json:"baseSalary"`
 	SalaryCycle              string    `json:"salaryCycle"`
 	SalaryDay                int       `json:"salaryDay"`
+	SalaryWorkingDaysOnly    bool      `json:"salaryWorkingDaysOnly"`
 	Language                 string    `json:"language"`
 	AutoInputPayroll         bool      `json:"autoInputPayroll"`
+	ZeroBasedBudgeting       bool      `json:"zeroBasedBudgeting"`
+	AllocationShortfall      string    `json:"allocationShortfall,omitempty"`
 	DefaultUserPlatformID    *string   `json:"defaultUserPlatformId,omitempty"`
+	FinancialMonthStart      int       `json:"financialMonthStart"`
 	TelegramIntegrationAlert bool      `json:"telegramIntegrationAlert"`
 	IsActive                 bool      `json:"is_active"`
 	CreatedAt                time.Time `json:"created_at"`
@@ -27,6 +31,7 @@ type UserProfileResponse struct {
 	BaseSalary            float64   `json:"base_salary"`
 	SalaryCycle           string    `json:"salary_cycle"`
 	SalaryDay             int       `json:"salary_day"`
+	SalaryWorkingDaysOnly bool      `json:"salary_working_days_only"`
 	PayCurrency           string    `json:"pay_currency"`
 	AutoInputPayroll      bool      `json:"auto_input_payroll"`
 	DefaultUserPlatformID *string   `json:"default_user_platform_id,omitempty"`
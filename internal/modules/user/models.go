@@ -25,13 +25,17 @@ type UserProfile struct {
 	Phone                    string              `bson:"phone" json:"phone"`
 	TelegramId               string              `bson:"telegram_id" json:"telegram_id"`
 	TelegramVerified         bool                `bson:"telegram_verified" json:"telegram_verified"`
-	BaseSalary               float64             `bson:"base_salary" json:"base_salary"`
+	BaseSalary               float64             `bson:"base_salary" json:"base_salary"` // deprecated with the other salary fields, moved into an income source
 	SalaryCycle              string              `bson:"salary_cycle" json:"salary_cycle" enums:"daily,weekly,monthly" default:"monthly"`
-	SalaryDay                int                 `bson:"salary_day" json:"salary_day"`
+	SalaryDay                int                 `bson:"salary_day" json:"salary_day"`                             // day of month for monthly, 1=Monday..7=Sunday for weekly
+	SalaryWorkingDaysOnly    bool                `bson:"salary_working_days_only" json:"salary_working_days_only"` // daily cycle pays Monday to Friday only
 	PayCurrency              string              `bson:"pay_currency" json:"pay_currency" enums:"IDR,USD" default:"IDR"`
 	Lang                     string              `bson:"lang" json:"lang" enums:"id,en" default:"id"`
 	AutoInputPayroll         bool                `bson:"auto_input_payroll" json:"auto_input_payroll"`
+	ZeroBasedBudgeting       bool                `bson:"zero_based_budgeting" json:"zero_based_budgeting"`
+	AllocationShortfall      string              `bson:"allocation_shortfall,omitempty" json:"allocation_shortfall,omitempty" enums:"SKIP,PARTIAL,PROPORTIONAL" default:"SKIP"`
 	DefaultUserPlatformID    *primitive.ObjectID `bson:"default_user_platform_id,omitempty" json:"default_user_platform_id,omitempty"`
+	FinancialMonthStart      int                 `bson:"financial_month_start" json:"financial_month_start,omitempty"` // day of month, 0 follows the monthly salary day
 	IsActive                 bool                `bson:"is_active" json:"is_active"`
 	CreatedAt                time.Time           `bson:"created_at" json:"created_at"`
 	UpdatedAt                time.Time           `bson:"updated_at" json:"updated_at"`
@@ -44,6 +48,48 @@ const (
 	RoleUser  = "user"
 )
 
+// salary cycle constants
+const (
+	SalaryCycleDaily   = "daily"
+	SalaryCycleWeekly  = "weekly"
+	SalaryCycleMonthly = "monthly"
+)
+
+// IsValidSalaryDay reports whether day fits the salary cycle: a weekday 1-7 for weekly, a day of month 1-31
+// for monthly or no cycle, which runs on the last day of shorter months. Daily salaries ignore the day. Cycles no
+// longer supported, such as biweekly, are never valid.
+func IsValidSalaryDay(cycle string, day int) bool {
+	switch cycle {
+	case SalaryCycleDaily:
+		return true
+	case SalaryCycleWeekly:
+		return day >= 1 && day <= 7
+	case "", SalaryCycleMonthly:
+		return day >= 1 && day <= 31
+	default:
+		return false
+	}
+}
+
+// FinancialMonthStartDay returns the day of month the user's financial month starts on: the day they chose, else
+// payDay, the pay day of their monthly income source, else the monthly salary day of a profile not moved to income
+// sources yet, else the 1st for a calendar month
+func (p *UserProfile) FinancialMonthStartDay(payDay int) int {
+	if p != nil && p.FinancialMonthStart > 0 {
+		return p.FinancialMonthStart
+	}
+	if payDay > 0 {
+		return payDay
+	}
+	if p == nil {
+		return 1
+	}
+	if (p.SalaryCycle == "" || p.SalaryCycle == SalaryCycleMonthly) && p.SalaryDay > 0 {
+		return p.SalaryDay
+	}
+	return 1
+}
+
 // currency constants
 const (
 	CurrencyIDR = "IDR"
@@ -21,7 +21,8 @@ func Register(builder *di.Builder) {
 		Name: "userService",
 		Build: func(ctn di.Container) (interface{}, error) {
 			repo := ctn.Get("userRepository").(*Repository)
-			return NewService(repo), nil
+			financialMonth := ctn.Get("incomeSourceRepository").(FinancialMonthResolver)
+			return NewService(repo, financialMonth), nil
 		},
 	})
 
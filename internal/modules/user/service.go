@@ -8,13 +8,21 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// FinancialMonthResolver works out the day a user's financial months start on from their profile and income
+// sources. It is implemented by the income source repository.
+type FinancialMonthResolver interface {
+	GetFinancialMonthStartDay(ctx context.Context, userID primitive.ObjectID) (int, error)
+}
+
 type Service struct {
-	repo *Repository
+	repo           *Repository
+	financialMonth FinancialMonthResolver
 }
 
-func NewService(r *Repository) *Service {
+func NewService(r *Repository, fm FinancialMonthResolver) *Service {
 	return &Service{
-		repo: r,
+		repo:           r,
+		financialMonth: fm,
 	}
 }
 
@@ -58,8 +66,12 @@ func (s *Service) GetUserProfile(ctx context.Context, id string) (*dto.UserRespo
 		resp.BaseSalary = profile.BaseSalary
 		resp.SalaryCycle = profile.SalaryCycle
 		resp.SalaryDay = profile.SalaryDay
+		resp.SalaryWorkingDaysOnly = profile.SalaryWorkingDaysOnly
 		resp.Language = profile.Lang
 		resp.AutoInputPayroll = profile.AutoInputPayroll
+		resp.ZeroBasedBudgeting = profile.ZeroBasedBudgeting
+		resp.AllocationShortfall = profile.AllocationShortfall
+		resp.FinancialMonthStart = s.financialMonthStartDay(ctx, profile)
 		resp.TelegramIntegrationAlert = profile.TelegramIntegrationAlert
 		if profile.DefaultUserPlatformID != nil {
 			id := profile.DefaultUserPlatformID.Hex()
@@ -113,12 +125,24 @@ func (s *Service) UpdateUser(ctx context.Context, id string, req *dto.UpdateUser
 		if req.SalaryDay > 0 {
 			profile.SalaryDay = req.SalaryDay
 		}
+		if req.SalaryWorkingDaysOnly != nil {
+			profile.SalaryWorkingDaysOnly = *req.SalaryWorkingDaysOnly
+		}
+		if !IsValidSalaryDay(profile.SalaryCycle, profile.SalaryDay) {
+			return nil, errors.New("invalid salary day for salary cycle")
+		}
 		if req.Language != "" {
 			profile.Lang = req.Language
 		}
 		if req.AutoInputPayroll != nil {
 			profile.AutoInputPayroll = *req.AutoInputPayroll
 		}
+		if req.ZeroBasedBudgeting != nil {
+			profile.ZeroBasedBudgeting = *req.ZeroBasedBudgeting
+		}
+		if req.AllocationShortfall != "" {
+			profile.AllocationShortfall = req.AllocationShortfall
+		}
 		if req.DefaultUserPlatformID != "" {
 			userPlatformID, err := primitive.ObjectIDFromHex(req.DefaultUserPlatformID)
 			if err != nil {
@@ -126,6 +150,9 @@ func (s *Service) UpdateUser(ctx context.Context, id string, req *dto.UpdateUser
 			}
 			profile.DefaultUserPlatformID = &userPlatformID
 		}
+		if req.FinancialMonthStart != nil {
+			profile.FinancialMonthStart = *req.FinancialMonthStart
+		}
 
 		err = s.repo.UpdateUserProfile(ctx, objID, profile)
 		if err != nil {
@@ -149,8 +176,12 @@ func (s *Service) UpdateUser(ctx context.Context, id string, req *dto.UpdateUser
 		resp.BaseSalary = profile.BaseSalary
 		resp.SalaryCycle = profile.SalaryCycle
 		resp.SalaryDay = profile.SalaryDay
+		resp.SalaryWorkingDaysOnly = profile.SalaryWorkingDaysOnly
 		resp.Language = profile.Lang
 		resp.AutoInputPayroll = profile.AutoInputPayroll
+		resp.ZeroBasedBudgeting = profile.ZeroBasedBudgeting
+		resp.AllocationShortfall = profile.AllocationShortfall
+		resp.FinancialMonthStart = s.financialMonthStartDay(ctx, profile)
 		if profile.DefaultUserPlatformID != nil {
 			id := profile.DefaultUserPlatformID.Hex()
 			resp.DefaultUserPlatformID = &id
@@ -183,11 +214,16 @@ func (s *Service) CreateUserProfile(ctx context.Context, userID string, req *dto
 		return nil, err
 	}
 
+	if !IsValidSalaryDay(req.SalaryCycle, req.SalaryDay) {
+		return nil, errors.New("invalid salary day for salary cycle")
+	}
+
 	profile := &UserProfile{
 		UserID:                   user.ID,
 		BaseSalary:               req.BaseSalary,
 		SalaryCycle:              req.SalaryCycle,
 		SalaryDay:                req.SalaryDay,
+		SalaryWorkingDaysOnly:    req.SalaryWorkingDaysOnly,
 		PayCurrency:              req.PayCurrency,
 		IsActive:                 true,
 		TelegramIntegrationAlert: true,
@@ -230,3 +266,13 @@ func (s *Service) EnableUser(ctx context.Context, userID string) error {
 	user.IsActive = true
 	return s.repo.UpdateUser(ctx, objID, user)
 }
+
+// financialMonthStartDay returns the day the user's financial months start on, following the profile alone when
+// their income sources cannot be read
+func (s *Service) financialMonthStartDay(ctx context.Context, profile *UserProfile) int {
+	day, err := s.financialMonth.GetFinancialMonthStartDay(ctx, profile.UserID)
+	if err != nil {
+		return profile.FinancialMonthStartDay(0)
+	}
+	return day
+}
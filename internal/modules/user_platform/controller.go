@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"github.com/HasanNugroho/coin-be/internal/core/utils"
+	"github.com/HasanNugroho/coin-be/internal/modules/balance_snapshot"
 	"github.com/HasanNugroho/coin-be/internal/modules/platform"
 	"github.com/HasanNugroho/coin-be/internal/modules/user_platform/dto"
 	"github.com/gin-gonic/gin"
@@ -11,14 +12,16 @@ import (
 )
 
 type Controller struct {
-	service      *Service
-	platformRepo *platform.Repository
+	service                *Service
+	platformRepo           *platform.Repository
+	balanceSnapshotService *balance_snapshot.Service
 }
 
-func NewController(s *Service, pr *platform.Repository) *Controller {
+func NewController(s *Service, pr *platform.Repository, bss *balance_snapshot.Service) *Controller {
 	return &Controller{
-		service:      s,
-		platformRepo: pr,
+		service:                s,
+		platformRepo:           pr,
+		balanceSnapshotService: bss,
 	}
 }
 
@@ -351,3 +354,50 @@ func (c *Controller) mapToDropdownResponseList(ctx *gin.Context, userPlatforms [
 	}
 	return responses
 }
+
+// GetUserPlatformBalanceHistory godoc
+// @Summary Get user platform balance history
+// @Description Get the daily closing balances of a user platform within a date range. Defaults to the last 30 days.
+// User Platformsags User Platforms
+// @Accept json
+// @Produce json
+// @Param id path string true "User platform ID"
+// @Param start_date query string false "Start date (YYYY-MM-DD)"
+// @Param end_date query string false "End date (YYYY-MM-DD)"
+// @Success 200 {object} map[string]interface{} "User platform balance history retrieved successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Security BearerAuth
+// @Router /v1/user-platforms/{id}/balance-history [get]
+func (c *Controller) GetUserPlatformBalanceHistory(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		resp := utils.NewErrorResponse(http.StatusUnauthorized, "unauthorized")
+		ctx.JSON(http.StatusUnauthorized, resp)
+		return
+	}
+
+	startDate, err := balance_snapshot.ParseDate(ctx.Query("start_date"))
+	if err != nil {
+		resp := utils.NewErrorResponse(http.StatusBadRequest, err.Error())
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	endDate, err := balance_snapshot.ParseDate(ctx.Query("end_date"))
+	if err != nil {
+		resp := utils.NewErrorResponse(http.StatusBadRequest, err.Error())
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	history, err := c.balanceSnapshotService.GetBalanceHistory(ctx, userID.(string), balance_snapshot.EntityTypeUserPlatform, ctx.Param("id"), startDate, endDate)
+	if err != nil {
+		resp := utils.NewErrorResponse(http.StatusBadRequest, err.Error())
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	resp := utils.NewSuccessResponse("User platform balance history retrieved successfully", history)
+	ctx.JSON(http.StatusOK, resp)
+}
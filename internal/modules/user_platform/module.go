@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/HasanNugroho/coin-be/internal/core/config"
+	"github.com/HasanNugroho/coin-be/internal/modules/balance_snapshot"
 	"github.com/HasanNugroho/coin-be/internal/modules/platform"
 	"github.com/sarulabs/di/v2"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -36,7 +37,8 @@ func Register(builder *di.Builder) {
 		Build: func(ctn di.Container) (interface{}, error) {
 			service := ctn.Get("userPlatformService").(*Service)
 			platformRepo := ctn.Get("platformRepository").(*platform.Repository)
-			return NewController(service, platformRepo), nil
+			bss := ctn.Get("balanceSnapshotService").(*balance_snapshot.Service)
+			return NewController(service, platformRepo, bss), nil
 		},
 	})
 }
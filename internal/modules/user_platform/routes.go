@@ -12,6 +12,7 @@ func RegisterRoutes(r *gin.RouterGroup, controller *Controller) {
 		protected.GET("", controller.ListUserPlatforms)
 		protected.GET("/dropdown", controller.ListUserPlatformsDropdown)
 		protected.GET("/:id", controller.GetUserPlatform)
+		protected.GET("/:id/balance-history", controller.GetUserPlatformBalanceHistory)
 		protected.PUT("/:id", controller.UpdateUserPlatform)
 		protected.DELETE("/:id", controller.DeleteUserPlatform)
 	}
@@ -29,6 +29,14 @@ type Allocation struct {
 	CreatedAt     time.Time          `bson:"created_at"`
 }
 
+type Holiday struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty"`
+	Date      string             `bson:"date"` // YYYY-MM-DD
+	Name      string             `bson:"name"`
+	CreatedAt time.Time          `bson:"created_at"`
+	UpdatedAt time.Time          `bson:"updated_at"`
+}
+
 func getDefaultCategories() []Category {
 	now := time.Now()
 
@@ -174,3 +182,35 @@ func getDefaultAllocations() []Allocation {
 		},
 	}
 }
+
+// getDefaultHolidays returns the Indonesian national holidays of 2026. Admins add later years and adjust dates
+// that depend on the moon sighting through the holiday endpoints.
+func getDefaultHolidays() []Holiday {
+	now := time.Now()
+
+	holidays := []Holiday{
+		{Date: "2026-01-01", Name: "Tahun Baru Masehi"},
+		{Date: "2026-01-16", Name: "Isra Mikraj Nabi Muhammad SAW"},
+		{Date: "2026-02-17", Name: "Tahun Baru Imlek"},
+		{Date: "2026-03-19", Name: "Hari Suci Nyepi"},
+		{Date: "2026-03-20", Name: "Hari Raya Idul Fitri"},
+		{Date: "2026-03-21", Name: "Hari Raya Idul Fitri"},
+		{Date: "2026-04-03", Name: "Wafat Yesus Kristus"},
+		{Date: "2026-04-05", Name: "Kebangkitan Yesus Kristus (Paskah)"},
+		{Date: "2026-05-01", Name: "Hari Buruh Internasional"},
+		{Date: "2026-05-14", Name: "Kenaikan Yesus Kristus"},
+		{Date: "2026-05-27", Name: "Hari Raya Idul Adha"},
+		{Date: "2026-05-31", Name: "Hari Raya Waisak"},
+		{Date: "2026-06-01", Name: "Hari Lahir Pancasila"},
+		{Date: "2026-06-16", Name: "Tahun Baru Islam"},
+		{Date: "2026-08-17", Name: "Hari Kemerdekaan Republik Indonesia"},
+		{Date: "2026-08-25", Name: "Maulid Nabi Muhammad SAW"},
+		{Date: "2026-12-25", Name: "Hari Raya Natal"},
+	}
+
+	for i := range holidays {
+		holidays[i].CreatedAt = now
+		holidays[i].UpdatedAt = now
+	}
+	return holidays
+}
@@ -5,7 +5,9 @@ import (
 	"fmt"
 	"log"
 
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type Seeder struct {
@@ -29,6 +31,10 @@ func (s *Seeder) Seed(ctx context.Context) error {
 		return fmt.Errorf("error seeding allocations: %w", err)
 	}
 
+	if err := s.seedHolidays(ctx); err != nil {
+		return fmt.Errorf("error seeding holidays: %w", err)
+	}
+
 	log.Println("Database seeding completed successfully!")
 	return nil
 }
@@ -94,3 +100,28 @@ func (s *Seeder) seedAllocations(ctx context.Context) error {
 	log.Printf("Inserted %d allocations\n", len(result.InsertedIDs))
 	return nil
 }
+
+func (s *Seeder) seedHolidays(ctx context.Context) error {
+	log.Println("Seeding holidays...")
+
+	collection := s.db.Collection("holidays")
+
+	// Only add dates that have no holiday yet, so holidays edited by admins are kept
+	inserted := 0
+	for _, holiday := range getDefaultHolidays() {
+		result, err := collection.UpdateOne(ctx,
+			bson.M{"date": holiday.Date, "deleted_at": nil},
+			bson.M{"$setOnInsert": holiday},
+			options.Update().SetUpsert(true),
+		)
+		if err != nil {
+			return err
+		}
+		if result.UpsertedCount > 0 {
+			inserted++
+		}
+	}
+
+	log.Printf("Inserted %d holidays\n", inserted)
+	return nil
+}